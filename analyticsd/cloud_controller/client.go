@@ -1,9 +1,11 @@
 package cloud_controller
 
 import (
+	"bytes"
 	"encoding/json"
 	"fmt"
 	"gopkg.in/segmentio/analytics-go.v3"
+	"io"
 	"io/ioutil"
 	"log"
 	"net/http"
@@ -56,6 +58,18 @@ var eventTypes = []string{
 	"audit.route.create",
 }
 
+type ResponseError struct {
+	Method     string
+	Path       string
+	StatusCode int
+	Status     string
+	Body       string
+}
+
+func (e *ResponseError) Error() string {
+	return fmt.Sprintf("%s %s failed: [%s] %s", e.Method, e.Path, e.Status, e.Body)
+}
+
 func New(host string, logger *log.Logger, httpClient *http.Client, analyticsClient analytics.Client, userUUID string, version string) *Client {
 	return &Client{
 		host:            host,
@@ -198,3 +212,57 @@ func (c *Client) Fetch(path string, params url.Values, dest interface{}) error {
 
 	return nil
 }
+
+// Do sends a request with an optional JSON body and unmarshals the response
+// into dest. Unlike Fetch, unsuccessful responses are returned as a *ResponseError.
+func (c *Client) Do(method string, path string, body interface{}, dest interface{}) error {
+	url := c.host + path
+
+	var reader io.Reader
+	if body != nil {
+		contents, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		reader = bytes.NewReader(contents)
+	}
+
+	c.logger.Printf("Making %s request to %q...\n", method, url)
+
+	req, err := http.NewRequest(method, url, reader)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to query %s: %s", c.host, err)
+	}
+	defer resp.Body.Close()
+
+	contents, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+
+	c.logger.Printf("Received status code [%s] from url: %q\n", resp.Status, url)
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return &ResponseError{
+			Method:     method,
+			Path:       path,
+			StatusCode: resp.StatusCode,
+			Status:     resp.Status,
+			Body:       strings.TrimSpace(string(contents)),
+		}
+	}
+
+	if dest == nil || len(contents) == 0 {
+		return nil
+	}
+	return json.Unmarshal(contents, dest)
+}
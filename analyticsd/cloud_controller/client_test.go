@@ -240,4 +240,35 @@ var _ = Describe("Cloud Controller Client", func() {
 			})
 		})
 	})
+
+	Describe("Do", func() {
+		It("sends the body as json and unmarshals the response", func() {
+			server.AppendHandlers(ghttp.CombineHandlers(
+				ghttp.VerifyRequest(http.MethodPost, "/v2/organizations"),
+				ghttp.VerifyJSON(`{"name": "some-org"}`),
+				ghttp.RespondWith(http.StatusCreated, `{"metadata": {"guid": "some-guid"}}`),
+			))
+
+			var result struct {
+				Metadata struct {
+					GUID string
+				}
+			}
+
+			err := client.Do(http.MethodPost, "/v2/organizations", map[string]string{"name": "some-org"}, &result)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(result.Metadata.GUID).To(Equal("some-guid"))
+		})
+
+		It("returns unsuccessful responses as errors", func() {
+			server.AppendHandlers(ghttp.CombineHandlers(
+				ghttp.VerifyRequest(http.MethodGet, "/v2/users/some-guid"),
+				ghttp.RespondWith(http.StatusNotFound, `some-failure-message`),
+			))
+
+			err := client.Do(http.MethodGet, "/v2/users/some-guid", nil, nil)
+			Expect(err).To(MatchError("GET /v2/users/some-guid failed: [404 Not Found] some-failure-message"))
+			Expect(err.(*cloud_controller.ResponseError).StatusCode).To(Equal(http.StatusNotFound))
+		})
+	})
 })
@@ -2,18 +2,30 @@ package bosh
 
 import (
 	"code.cloudfoundry.org/cfdev/config"
+	"context"
 	"io/ioutil"
 	"path/filepath"
 	"strings"
 	"time"
 
 	"code.cloudfoundry.org/cfdev/errors"
+	"code.cloudfoundry.org/cfdev/resource/retry"
 	boshdir "github.com/cloudfoundry/bosh-cli/director"
 	"github.com/onsi/ginkgo"
 )
 
 var VMProgressInterval = 1 * time.Second
 
+// FindDeploymentBackoff spaces out the lookups of a deployment that the
+// director does not know about yet, and bounds how long to look.
+var FindDeploymentBackoff = retry.Backoff{
+	Initial:    250 * time.Millisecond,
+	Max:        5 * time.Second,
+	Multiplier: 2,
+	Jitter:     0.2,
+	MaxElapsed: 10 * time.Second,
+}
+
 type Bosh struct {
 	dir boshdir.Director
 }
@@ -65,25 +77,29 @@ type VMProgress struct {
 	Duration time.Duration
 }
 
-func (b *Bosh) VMProgress(deploymentName string) chan VMProgress {
+// VMProgress reports the progress of the deployment until all of its VMs
+// are running or ctx is done, when the channel is closed.
+func (b *Bosh) VMProgress(ctx context.Context, deploymentName string) chan VMProgress {
 	start := time.Now()
-	var dep boshdir.Deployment
+	ch := make(chan VMProgress, 1)
 
-	for {
-		var err error
-		dep, err = b.dir.FindDeployment(deploymentName)
-		if err == nil {
-			break
-		}
+	dep, err := b.findDeployment(ctx, deploymentName)
+	if err != nil {
+		close(ch)
+		return ch
 	}
 
-	ch := make(chan VMProgress, 1)
 	total := 0
 	go func() {
 		defer ginkgo.GinkgoRecover()
 
 		for {
-			time.Sleep(VMProgressInterval)
+			select {
+			case <-ctx.Done():
+				close(ch)
+				return
+			case <-time.After(VMProgressInterval):
+			}
 
 			vmInfos, err := dep.VMInfos()
 			if err != nil || len(vmInfos) == 0 {
@@ -116,22 +132,28 @@ func (b *Bosh) VMProgress(deploymentName string) chan VMProgress {
 	return ch
 }
 
-func (b *Bosh) GetVMProgress(start time.Time, deploymentName string, isErrand bool) VMProgress {
-	if isErrand {
-		return VMProgress{State: RunningErrand, Duration: time.Now().Sub(start)}
-	}
-
+// findDeployment waits for the deployment to show up, for as long as
+// FindDeploymentBackoff allows.
+func (b *Bosh) findDeployment(ctx context.Context, deploymentName string) (boshdir.Deployment, error) {
 	var dep boshdir.Deployment
-
-	for {
+	err := retry.Do(ctx, retry.Policy{Backoff: FindDeploymentBackoff}, func() error {
 		var err error
 		dep, err = b.dir.FindDeployment(deploymentName)
-		if err == nil {
-			break
-		}
+		return err
+	})
+	return dep, err
+}
+
+func (b *Bosh) GetVMProgress(ctx context.Context, start time.Time, deploymentName string, isErrand bool) VMProgress {
+	if isErrand {
+		return VMProgress{State: RunningErrand, Duration: time.Now().Sub(start)}
 	}
 
-	vmInfos, err := dep.VMInfos()
+	var vmInfos []boshdir.VMInfo
+	dep, err := b.findDeployment(ctx, deploymentName)
+	if err == nil {
+		vmInfos, err = dep.VMInfos()
+	}
 	if err != nil || len(vmInfos) == 0 {
 		rels, err := b.dir.Releases()
 		if err == nil {
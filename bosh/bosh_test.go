@@ -1,10 +1,13 @@
 package bosh_test
 
 import (
+	"context"
 	"errors"
+	"time"
 
 	"code.cloudfoundry.org/cfdev/bosh"
 	"code.cloudfoundry.org/cfdev/bosh/mocks"
+	"code.cloudfoundry.org/cfdev/resource/retry"
 	boshdir "github.com/cloudfoundry/bosh-cli/director"
 	"github.com/golang/mock/gomock"
 	. "github.com/onsi/ginkgo"
@@ -23,6 +26,7 @@ var _ = Describe("Bosh", func() {
 	)
 	BeforeEach(func() {
 		bosh.VMProgressInterval = 0
+		bosh.FindDeploymentBackoff = retry.Backoff{}
 		mockController = gomock.NewController(GinkgoT())
 		mockDir = mocks.NewMockDirector(mockController)
 		mockDep = mocks.NewMockDeployment(mockController)
@@ -45,7 +49,7 @@ var _ = Describe("Bosh", func() {
 				mockDepVMInfos.Return(vmInfos, nil)
 			})
 
-			ch := subject.VMProgress("cf")
+			ch := subject.VMProgress(context.Background(), "cf")
 			var p bosh.VMProgress
 
 			Eventually(ch).Should(Receive(&p))
@@ -77,4 +81,25 @@ var _ = Describe("Bosh", func() {
 			}).Should(Equal([]int{0, 3, 1}))
 		})
 	})
+
+	Describe("GetVMProgress", func() {
+		It("reports uploading releases when the deployment does not show up in time", func() {
+			bosh.FindDeploymentBackoff = retry.Backoff{MaxAttempts: 2}
+			mockDir.EXPECT().FindDeployment("cf").Times(2).Return(nil, errors.New("not found"))
+			mockDir.EXPECT().Releases().Return([]boshdir.Release{nil}, nil)
+
+			p := subject.GetVMProgress(context.Background(), time.Now(), "cf", false)
+			Expect(p.State).To(Equal(bosh.UploadingReleases))
+			Expect(p.Releases).To(Equal(1))
+		})
+	})
+
+	Describe("VMProgress when the context is done", func() {
+		It("closes the channel", func() {
+			ctx, cancel := context.WithCancel(context.Background())
+			cancel()
+
+			Eventually(subject.VMProgress(ctx, "cf")).Should(BeClosed())
+		})
+	})
 })
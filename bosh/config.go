@@ -2,8 +2,10 @@ package bosh
 
 import (
 	"code.cloudfoundry.org/cfdev/config"
+	"fmt"
 	"io/ioutil"
 	"path/filepath"
+	"strconv"
 	"strings"
 
 )
@@ -17,6 +19,28 @@ type Config struct {
 	GatewayHost       string
 	GatewayPrivateKey string
 	GatewayUsername   string
+
+	AllProxy string
+
+	// TunnelPort is the SOCKS5 port of the running cf dev tunnel, or 0.
+	TunnelPort int
+}
+
+const GatewayPort = 22
+
+// TunnelPortFile is where cf dev tunnel records its SOCKS5 port while
+// it runs.
+func TunnelPortFile(cfg config.Config) string {
+	return filepath.Join(cfg.StateDir, "tunnel.port")
+}
+
+// TunnelProxy is a BOSH_ALL_PROXY value that makes the bosh CLI reach
+// the director through the SOCKS5 proxy of the running cf dev tunnel.
+func (c Config) TunnelProxy() (string, error) {
+	if c.TunnelPort == 0 {
+		return "", fmt.Errorf("cf dev tunnel is not running")
+	}
+	return fmt.Sprintf("socks5://127.0.0.1:%d", c.TunnelPort), nil
 }
 
 func FetchConfig(cfg config.Config) (Config, error) {
@@ -27,6 +51,11 @@ func FetchConfig(cfg config.Config) (Config, error) {
 
 	secret := strings.TrimSpace(string(content))
 
+	tunnelPort := 0
+	if content, err := ioutil.ReadFile(TunnelPortFile(cfg)); err == nil {
+		tunnelPort, _ = strconv.Atoi(strings.TrimSpace(string(content)))
+	}
+
 	return Config{
 		AdminUsername:     "admin",
 		AdminPassword:     secret,
@@ -35,6 +64,7 @@ func FetchConfig(cfg config.Config) (Config, error) {
 		GatewayHost:       cfg.BoshDirectorIP,
 		GatewayPrivateKey: filepath.Join(cfg.StateBosh, "jumpbox.key"),
 		GatewayUsername:   "jumpbox",
+		TunnelPort:        tunnelPort,
 	}, nil
 }
 
@@ -0,0 +1,112 @@
+package bosh
+
+import (
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+
+	"code.cloudfoundry.org/cfdev/config"
+	"gopkg.in/yaml.v2"
+)
+
+const (
+	CredhubPort = 8844
+	UAAPort     = 8443
+)
+
+type CredhubConfig struct {
+	Server        string
+	Client        string
+	Secret        string
+	CACertificate string
+}
+
+type UAAConfig struct {
+	URL           string
+	Client        string
+	Secret        string
+	CACertificate string
+}
+
+type certificate struct {
+	CA string `yaml:"ca"`
+}
+
+// creds holds the subset of the director's vars store (creds.yml)
+// needed to talk to its CredHub and UAA.
+type creds struct {
+	CredhubAdminClientSecret string      `yaml:"credhub_admin_client_secret"`
+	UAAAdminClientSecret     string      `yaml:"uaa_admin_client_secret"`
+	CredhubTLS               certificate `yaml:"credhub_tls"`
+	UAASSL                   certificate `yaml:"uaa_ssl"`
+}
+
+func readCreds(cfg config.Config) (creds, error) {
+	var c creds
+	contents, err := ioutil.ReadFile(filepath.Join(cfg.StateBosh, "creds.yml"))
+	if err != nil {
+		return c, err
+	}
+	if err := yaml.Unmarshal(contents, &c); err != nil {
+		return c, fmt.Errorf("failed to parse creds.yml: %s", err)
+	}
+	return c, nil
+}
+
+// writeCA saves the certificates to a file in the bosh state dir, since
+// the credhub and uaa CLIs expect a path rather than a PEM value.
+func writeCA(cfg config.Config, name string, certs ...string) (string, error) {
+	var pem []string
+	for _, cert := range certs {
+		if cert = strings.TrimSpace(cert); cert != "" {
+			pem = append(pem, cert)
+		}
+	}
+	if len(pem) == 0 {
+		return "", fmt.Errorf("creds.yml does not contain a certificate for %s", name)
+	}
+
+	path := filepath.Join(cfg.StateBosh, name)
+	return path, ioutil.WriteFile(path, []byte(strings.Join(pem, "\n")+"\n"), 0600)
+}
+
+func FetchCredhubConfig(cfg config.Config) (CredhubConfig, error) {
+	c, err := readCreds(cfg)
+	if err != nil {
+		return CredhubConfig{}, err
+	}
+
+	// The credhub CLI authenticates against the director's UAA, so it
+	// needs to trust both certificates.
+	caPath, err := writeCA(cfg, "credhub-ca.crt", c.CredhubTLS.CA, c.UAASSL.CA)
+	if err != nil {
+		return CredhubConfig{}, err
+	}
+
+	return CredhubConfig{
+		Server:        fmt.Sprintf("https://%s:%d", cfg.BoshDirectorIP, CredhubPort),
+		Client:        "credhub-admin",
+		Secret:        c.CredhubAdminClientSecret,
+		CACertificate: caPath,
+	}, nil
+}
+
+func FetchUAAConfig(cfg config.Config) (UAAConfig, error) {
+	c, err := readCreds(cfg)
+	if err != nil {
+		return UAAConfig{}, err
+	}
+
+	caPath, err := writeCA(cfg, "uaa-ca.crt", c.UAASSL.CA)
+	if err != nil {
+		return UAAConfig{}, err
+	}
+
+	return UAAConfig{
+		URL:           fmt.Sprintf("https://%s:%d", cfg.BoshDirectorIP, UAAPort),
+		Client:        "uaa_admin",
+		Secret:        c.UAAAdminClientSecret,
+		CACertificate: caPath,
+	}, nil
+}
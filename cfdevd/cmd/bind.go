@@ -21,8 +21,10 @@ const (
 	ERROR_UNKNOWN   = uint8(66)
 )
 
+// BindCommand binds Addr, a *net.TCPAddr or a *net.UDPAddr, on behalf of
+// VPNKit and passes the socket back over the connection.
 type BindCommand struct {
-	Addr *net.TCPAddr
+	Addr net.Addr
 }
 
 func (b *BindCommand) isIPAllowed(ip net.IP) bool {
@@ -45,23 +47,23 @@ func UnmarshalBindCommand(conn io.Reader) (*BindCommand, error) {
 	binary.Read(conn, binary.LittleEndian, ip)
 	binary.Read(conn, binary.LittleEndian, &port)
 	binary.Read(conn, binary.LittleEndian, &isUDP)
+	addrIP := net.IP([]byte{ip[3], ip[2], ip[1], ip[0]})
 	if isUDP {
-		return nil, fmt.Errorf("Unimplemented UDP socket requested")
+		return &BindCommand{
+			Addr: &net.UDPAddr{IP: addrIP, Port: int(port)},
+		}, nil
 	}
 	return &BindCommand{
-		Addr: &net.TCPAddr{
-			IP:   []byte{ip[3], ip[2], ip[1], ip[0]},
-			Port: int(port),
-		},
+		Addr: &net.TCPAddr{IP: addrIP, Port: int(port)},
 	}, nil
 }
 
 func (b *BindCommand) Execute(conn *net.UnixConn) error {
-	fmt.Printf("Executing tcp bind request for %s \n", b.Addr)
+	fmt.Printf("Executing %s bind request for %s \n", b.Addr.Network(), b.Addr)
 
 	msg := make([]byte, 8, 8)
 	var scmsg []byte
-	if b.isIPAllowed(b.Addr.IP) {
+	if b.isIPAllowed(b.ip()) {
 		fmt.Println("Attempting to bind address ", b.Addr)
 		file, err := b.bind()
 		if file != nil {
@@ -78,34 +80,57 @@ func (b *BindCommand) Execute(conn *net.UnixConn) error {
 	return nil
 }
 
+func (b *BindCommand) ip() net.IP {
+	switch addr := b.Addr.(type) {
+	case *net.TCPAddr:
+		return addr.IP
+	case *net.UDPAddr:
+		return addr.IP
+	}
+	return nil
+}
+
 func (b *BindCommand) bind() (*os.File, error) {
-	listener, err := net.ListenTCP("tcp", b.Addr)
-	if err != nil {
-		return nil, err
+	switch addr := b.Addr.(type) {
+	case *net.TCPAddr:
+		listener, err := net.ListenTCP("tcp", addr)
+		if err != nil {
+			return nil, err
+		}
+		defer listener.Close()
+		return listener.File()
+	case *net.UDPAddr:
+		conn, err := net.ListenUDP("udp", addr)
+		if err != nil {
+			return nil, err
+		}
+		defer conn.Close()
+		return conn.File()
 	}
-	defer listener.Close()
-	return listener.File()
+	return nil, fmt.Errorf("unsupported address %s", b.Addr)
 }
 
 func (b *BindCommand) response(file *os.File, err error) ([]byte, []byte) {
 	msg := make([]byte, 8, 8)
 	var scmsg []byte
 	if err != nil {
+		var errno error
 		if opErr, ok := err.(*net.OpError); ok {
 			if sysErr, ok := opErr.Err.(*os.SyscallError); ok {
-				switch sysErr.Err {
-				case syscall.EADDRINUSE:
-					fmt.Println("Failed to Bind: address in use", b.Addr)
-					msg[0] = ERROR_IN_USE
-				case syscall.EADDRNOTAVAIL:
-					fmt.Println("Failed to Bind: address not available", b.Addr)
-					msg[0] = ERROR_NOT_AVAIL
-				default:
-					fmt.Println("Failed to Bind: unknown error", err)
-					msg[0] = ERROR_UNKNOWN
-				}
+				errno = sysErr.Err
 			}
 		}
+		switch errno {
+		case syscall.EADDRINUSE:
+			fmt.Println("Failed to Bind: address in use", b.Addr)
+			msg[0] = ERROR_IN_USE
+		case syscall.EADDRNOTAVAIL:
+			fmt.Println("Failed to Bind: address not available", b.Addr)
+			msg[0] = ERROR_NOT_AVAIL
+		default:
+			fmt.Println("Failed to Bind: unknown error", err)
+			msg[0] = ERROR_UNKNOWN
+		}
 	}
 	if file != nil {
 		scmsg = syscall.UnixRights(int(file.Fd()))
@@ -0,0 +1,97 @@
+// +build darwin
+
+package cmd_test
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+
+	"code.cloudfoundry.org/cfdev/cfdevd/cmd"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("BindCommand", func() {
+	bindMessage := func(ip string, port uint16, isUDP bool) *bytes.Buffer {
+		message := &bytes.Buffer{}
+		b := net.ParseIP(ip).To4()
+		message.Write([]byte{b[3], b[2], b[1], b[0]})
+		binary.Write(message, binary.LittleEndian, port)
+		binary.Write(message, binary.LittleEndian, isUDP)
+		return message
+	}
+
+	Describe("UnmarshalBindCommand", func() {
+		It("reads a tcp address", func() {
+			command, err := cmd.UnmarshalBindCommand(bindMessage("10.144.0.4", 1777, false))
+
+			Expect(err).NotTo(HaveOccurred())
+			Expect(command.Addr).To(Equal(&net.TCPAddr{IP: net.ParseIP("10.144.0.4").To4(), Port: 1777}))
+		})
+
+		It("reads a udp address", func() {
+			command, err := cmd.UnmarshalBindCommand(bindMessage("10.144.0.34", 53, true))
+
+			Expect(err).NotTo(HaveOccurred())
+			Expect(command.Addr).To(Equal(&net.UDPAddr{IP: net.ParseIP("10.144.0.34").To4(), Port: 53}))
+		})
+	})
+
+	Describe("Execute", func() {
+		var (
+			ln        *net.UnixListener
+			socketDir string
+			addr      *net.UnixAddr
+		)
+
+		BeforeEach(func() {
+			var err error
+			socketDir, err = ioutil.TempDir(os.Getenv("TMPDIR"), "socket")
+			Expect(err).NotTo(HaveOccurred())
+			addr = &net.UnixAddr{
+				Name: filepath.Join(socketDir, "some.socket"),
+			}
+			ln, err = net.ListenUnix("unix", addr)
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		AfterEach(func() {
+			Expect(os.RemoveAll(socketDir)).To(Succeed())
+			Expect(ln.Close()).To(Succeed())
+		})
+
+		refuses := func(bind *cmd.BindCommand) {
+			received := make(chan []byte, 1)
+			go func() {
+				defer GinkgoRecover()
+				conn, err := ln.AcceptUnix()
+				Expect(err).NotTo(HaveOccurred())
+				defer conn.Close()
+				msg := make([]byte, 8, 8)
+				oob := make([]byte, 16, 16)
+				_, oobn, _, _, err := conn.ReadMsgUnix(msg, oob)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(oobn).To(BeZero())
+				received <- msg
+			}()
+			conn, err := net.DialUnix("unix", nil, addr)
+			Expect(err).NotTo(HaveOccurred())
+			defer conn.Close()
+
+			Expect(bind.Execute(conn)).To(Succeed())
+			Eventually(received).Should(Receive(Equal([]byte{cmd.ERROR_DENIED, 0, 0, 0, 0, 0, 0, 0})))
+		}
+
+		It("refuses to bind tcp ports on other interfaces", func() {
+			refuses(&cmd.BindCommand{Addr: &net.TCPAddr{IP: net.ParseIP("127.0.0.1"), Port: 1888}})
+		})
+
+		It("refuses to bind udp ports on other interfaces", func() {
+			refuses(&cmd.BindCommand{Addr: &net.UDPAddr{IP: net.ParseIP("127.0.0.1"), Port: 1888}})
+		})
+	})
+})
@@ -167,6 +167,45 @@ var _ = Describe("cfdevd test", func() {
 				Expect(b[0]).To(Equal(uint8(48)))
 			})
 		})
+
+		It("binds udp ports on gorouter ip", func() {
+			Expect(sendHello(conn, "VMN3T", 22, "0123456789012345678901234567890123456789")).To(Succeed())
+			Expect(recvHello(conn)).To(Equal("CFD3V"))
+			Expect(sendBindUDPAddr(conn, "10.144.0.34", 1553)).To(Succeed())
+			pc, _, err := recvBindUDPAddr(conn, "10.144.0.34", 1553)
+			Expect(err).NotTo(HaveOccurred())
+			defer pc.Close()
+
+			msg := "Hello from test"
+			go sendDatagram("10.144.0.34:1553", msg)
+			Expect(readFromPacketConn(pc)).To(Equal(msg))
+		})
+
+		It("refuses to bind udp ports on other interfaces", func() {
+			Expect(sendHello(conn, "VMN3T", 22, "0123456789012345678901234567890123456789")).To(Succeed())
+			Expect(recvHello(conn)).To(Equal("CFD3V"))
+			Expect(sendBindUDPAddr(conn, "127.0.0.1", 1553)).To(Succeed())
+			_, b, _ := recvBindUDPAddr(conn, "127.0.0.1", 1553)
+			Expect(b[0]).To(Equal(uint8(71)))
+		})
+
+		Context("binding to a bound udp port", func() {
+			var prior net.PacketConn
+			BeforeEach(func() {
+				var err error
+				prior, err = net.ListenPacket("udp", "10.144.0.4:1999")
+				Expect(err).NotTo(HaveOccurred())
+			})
+			AfterEach(func() { prior.Close() })
+
+			It("sends an error", func() {
+				Expect(sendHello(conn, "VMN3T", 22, "0123456789012345678901234567890123456789")).To(Succeed())
+				Expect(recvHello(conn)).To(Equal("CFD3V"))
+				Expect(sendBindUDPAddr(conn, "10.144.0.4", 1999)).To(Succeed())
+				_, b, _ := recvBindUDPAddr(conn, "10.144.0.4", 1999)
+				Expect(b[0]).To(Equal(uint8(48)))
+			})
+		})
 	})
 
 	Describe("timesync", func() {
@@ -238,38 +277,66 @@ func recvHello(conn *net.UnixConn) (string, error) {
 }
 
 func sendBindAddr(conn *net.UnixConn, ip string, port uint16) error {
+	return sendBind(conn, ip, port, 0x0)
+}
+
+func sendBindUDPAddr(conn *net.UnixConn, ip string, port uint16) error {
+	return sendBind(conn, ip, port, 0x1)
+}
+
+func sendBind(conn *net.UnixConn, ip string, port uint16, isUDP byte) error {
 	var instruction uint8 = 6
 	conn.Write([]byte{instruction})
 	b := []byte(net.ParseIP(ip).To4())
 	conn.Write(append([]byte{}, b[3], b[2], b[1], b[0]))
 	binary.Write(conn, binary.LittleEndian, port)
-	_, err := conn.Write([]byte{0x0})
+	_, err := conn.Write([]byte{isUDP})
 	return err
 }
 
 func recvBindAddr(conn *net.UnixConn, ip string, port uint16) (net.Listener, []byte, error) {
+	fd, b, err := recvBindFd(conn)
+	if err != nil {
+		return nil, b, err
+	}
+	syscall.Listen(fd, 65536)
+	defer syscall.Close(fd)
+	file := os.NewFile(uintptr(fd), fmt.Sprintf("tcp:%s:%d", ip, port))
+	defer file.Close()
+	ln, err := net.FileListener(file)
+	return ln, b, err
+}
+
+func recvBindUDPAddr(conn *net.UnixConn, ip string, port uint16) (net.PacketConn, []byte, error) {
+	fd, b, err := recvBindFd(conn)
+	if err != nil {
+		return nil, b, err
+	}
+	defer syscall.Close(fd)
+	file := os.NewFile(uintptr(fd), fmt.Sprintf("udp:%s:%d", ip, port))
+	defer file.Close()
+	pc, err := net.FilePacketConn(file)
+	return pc, b, err
+}
+
+func recvBindFd(conn *net.UnixConn) (int, []byte, error) {
 	b := make([]byte, 8, 8)
 	oob := make([]byte, 16, 16)
 	if _, _, _, _, err := conn.ReadMsgUnix(b, oob); err != nil {
-		return nil, b, err
+		return 0, b, err
 	}
 	if b[0] != 0 {
-		return nil, b, fmt.Errorf("Look at b: %d, %+v", b[0], b)
+		return 0, b, fmt.Errorf("Look at b: %d, %+v", b[0], b)
 	}
 	scms, err := syscall.ParseSocketControlMessage(oob)
 	if err != nil {
-		return nil, b, err
+		return 0, b, err
 	}
 	fds, err := syscall.ParseUnixRights(&scms[0])
 	if err != nil {
-		return nil, b, err
+		return 0, b, err
 	}
-	syscall.Listen(fds[0], 65536)
-	defer syscall.Close(fds[0])
-	file := os.NewFile(uintptr(fds[0]), fmt.Sprintf("tcp:%s:%d", ip, port))
-	defer file.Close()
-	ln, err := net.FileListener(file)
-	return ln, b, err
+	return fds[0], b, nil
 }
 
 func sendMessage(address string, mesg string) {
@@ -280,6 +347,23 @@ func sendMessage(address string, mesg string) {
 	wconn.Write([]byte(mesg))
 }
 
+func sendDatagram(address string, mesg string) {
+	defer GinkgoRecover()
+	wconn, err := net.Dial("udp", address)
+	Expect(err).NotTo(HaveOccurred())
+	defer wconn.Close()
+	wconn.Write([]byte(mesg))
+}
+
+func readFromPacketConn(pc net.PacketConn) (string, error) {
+	received := make([]byte, 15, 15)
+	n, _, err := pc.ReadFrom(received)
+	if err != nil {
+		return "", err
+	}
+	return string(received[:n]), nil
+}
+
 func readFromListener(ln net.Listener) (string, error) {
 	conn, err := ln.Accept()
 	if err != nil {
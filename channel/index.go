@@ -0,0 +1,156 @@
+package channel
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+
+	"code.cloudfoundry.org/cfdev/errors"
+	"code.cloudfoundry.org/cfdev/resource"
+	"code.cloudfoundry.org/cfdev/semver"
+	"code.cloudfoundry.org/cfdev/signature"
+)
+
+const (
+	Stable = "stable"
+	Edge   = "edge"
+)
+
+// Index is the remote catalog index. Every channel lists the deps
+// releases published on it and the latest plugin.
+type Index struct {
+	Channels map[string]Channel `json:"channels"`
+}
+
+type Channel struct {
+	Plugin   Plugin    `json:"plugin"`
+	Releases []Release `json:"releases"`
+}
+
+type Plugin struct {
+	Version string            `json:"version"`
+	URLs    map[string]string `json:"urls"`
+}
+
+// Release is a version of the deps. Catalogs are keyed by GOOS.
+type Release struct {
+	Version          string                      `json:"version"`
+	MinPluginVersion string                      `json:"min_plugin_version"`
+	Catalogs         map[string]resource.Catalog `json:"catalogs"`
+}
+
+// Update describes what is newer on a channel than what is installed.
+type Update struct {
+	// Release is the newest release the plugin can use, if it is newer
+	// than the installed deps.
+	Release *Release
+	// Blocked is a release newer than Release that needs a newer plugin.
+	Blocked *Release
+	// Plugin is set when the channel has a newer plugin.
+	Plugin *Plugin
+}
+
+type Client struct {
+	URL      string
+	HttpDo   func(req *http.Request) (*http.Response, error)
+	Verifier *signature.Verifier
+}
+
+// Fetch downloads the index and, when a signing key is embedded,
+// verifies it against the detached signature at URL.sig.
+func (c *Client) Fetch() (Index, error) {
+	if c.URL == "" {
+		return Index{}, errors.SafeWrap(nil, "No catalog index is configured. Please set CFDEV_CATALOG_INDEX")
+	}
+
+	contents, err := c.get(c.URL)
+	if err != nil {
+		return Index{}, errors.SafeWrap(err, "Unable to fetch the catalog index")
+	}
+
+	if c.Verifier.Enabled() {
+		sig, err := c.get(c.URL + ".sig")
+		if err != nil {
+			return Index{}, errors.SafeWrap(err, "Unable to fetch the catalog index signature")
+		}
+		if err := c.Verifier.Verify(contents, strings.TrimSpace(string(sig))); err != nil {
+			return Index{}, errors.SafeWrap(err, "Unable to verify the catalog index")
+		}
+	}
+
+	var index Index
+	if err := json.Unmarshal(contents, &index); err != nil {
+		return Index{}, errors.SafeWrap(err, "Unable to parse the catalog index")
+	}
+	return index, nil
+}
+
+func (c *Client) get(url string) ([]byte, error) {
+	req, err := http.NewRequest("GET", url, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := c.HttpDo(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%s: %s", url, resp.Status)
+	}
+	return ioutil.ReadAll(resp.Body)
+}
+
+// Check compares the installed deps version and plugin version against
+// the releases on channel that have a catalog for goos. An empty
+// installed version cannot be compared, so no release is offered for it.
+func (i Index) Check(channel, goos, installed string, plugin *semver.Version) (Update, error) {
+	ch, ok := i.Channels[channel]
+	if !ok {
+		return Update{}, fmt.Errorf("unknown channel '%s'", channel)
+	}
+
+	var (
+		update  Update
+		current = parse(installed)
+	)
+	for index := range ch.Releases {
+		release := &ch.Releases[index]
+		if _, ok := release.Catalogs[goos]; !ok || installed == "" {
+			continue
+		}
+
+		version := parse(release.Version)
+		if version.Compare(current) <= 0 {
+			continue
+		}
+
+		if parse(release.MinPluginVersion).Compare(plugin) > 0 {
+			if update.Blocked == nil || version.Compare(parse(update.Blocked.Version)) > 0 {
+				update.Blocked = release
+			}
+		} else if update.Release == nil || version.Compare(parse(update.Release.Version)) > 0 {
+			update.Release = release
+		}
+	}
+
+	if update.Blocked != nil && update.Release != nil && parse(update.Blocked.Version).Compare(parse(update.Release.Version)) < 0 {
+		update.Blocked = nil
+	}
+
+	if ch.Plugin.Version != "" && parse(ch.Plugin.Version).Compare(plugin) > 0 {
+		update.Plugin = &ch.Plugin
+	}
+
+	return update, nil
+}
+
+func parse(version string) *semver.Version {
+	v, err := semver.New(strings.TrimPrefix(version, "v"))
+	if err != nil {
+		return &semver.Version{Original: version}
+	}
+	return v
+}
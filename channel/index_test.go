@@ -0,0 +1,167 @@
+package channel_test
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+
+	"code.cloudfoundry.org/cfdev/channel"
+	"code.cloudfoundry.org/cfdev/resource"
+	"code.cloudfoundry.org/cfdev/semver"
+	"code.cloudfoundry.org/cfdev/signature"
+	"golang.org/x/crypto/ed25519"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+const indexJSON = `{
+  "channels": {
+    "stable": {
+      "plugin": {"version": "0.0.18", "urls": {"darwin": "https://example.com/cfdev-darwin"}},
+      "releases": [
+        {"version": "1.1.0", "min_plugin_version": "0.0.10", "catalogs": {"darwin": {"Items": [{"Name": "cfdev-deps.tgz", "URL": "https://example.com/1.1.0.tgz"}]}}},
+        {"version": "1.2.0", "min_plugin_version": "0.0.16", "catalogs": {"darwin": {"Items": [{"Name": "cfdev-deps.tgz", "URL": "https://example.com/1.2.0.tgz"}]}}},
+        {"version": "1.3.0", "min_plugin_version": "0.0.18", "catalogs": {"darwin": {"Items": [{"Name": "cfdev-deps.tgz", "URL": "https://example.com/1.3.0.tgz"}]}}},
+        {"version": "1.4.0", "min_plugin_version": "0.0.10", "catalogs": {"windows": {"Items": []}}}
+      ]
+    }
+  }
+}`
+
+var _ = Describe("Index", func() {
+	var (
+		server *httptest.Server
+		files  map[string]string
+		client *channel.Client
+	)
+
+	BeforeEach(func() {
+		files = map[string]string{"/index.json": indexJSON}
+		server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			contents, ok := files[r.URL.Path]
+			if !ok {
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+			w.Write([]byte(contents))
+		}))
+		client = &channel.Client{URL: server.URL + "/index.json", HttpDo: http.DefaultClient.Do}
+	})
+
+	AfterEach(func() {
+		server.Close()
+	})
+
+	It("fetches and parses the index", func() {
+		index, err := client.Fetch()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(index.Channels["stable"].Releases).To(HaveLen(4))
+		Expect(index.Channels["stable"].Releases[0].Catalogs["darwin"].Items[0].URL).To(Equal("https://example.com/1.1.0.tgz"))
+	})
+
+	It("requires an index url", func() {
+		client.URL = ""
+		_, err := client.Fetch()
+		Expect(err).To(MatchError(ContainSubstring("CFDEV_CATALOG_INDEX")))
+	})
+
+	Context("when a signing key is embedded", func() {
+		var privateKey ed25519.PrivateKey
+
+		BeforeEach(func() {
+			var publicKey ed25519.PublicKey
+			var err error
+			publicKey, privateKey, err = ed25519.GenerateKey(rand.Reader)
+			Expect(err).NotTo(HaveOccurred())
+			client.Verifier, err = signature.New(base64.StdEncoding.EncodeToString(publicKey))
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		It("accepts a signed index", func() {
+			files["/index.json.sig"] = base64.StdEncoding.EncodeToString(ed25519.Sign(privateKey, []byte(indexJSON)))
+			_, err := client.Fetch()
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		It("rejects an index without a valid signature", func() {
+			_, err := client.Fetch()
+			Expect(err).To(MatchError(ContainSubstring("Unable to fetch the catalog index signature")))
+
+			files["/index.json.sig"] = base64.StdEncoding.EncodeToString(ed25519.Sign(privateKey, []byte("{}")))
+			_, err = client.Fetch()
+			Expect(err).To(MatchError(ContainSubstring("signature does not match")))
+		})
+	})
+
+	Describe("Check", func() {
+		var index channel.Index
+
+		BeforeEach(func() {
+			var err error
+			index, err = client.Fetch()
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		It("returns the newest release the plugin supports and what is held back", func() {
+			update, err := index.Check("stable", "darwin", "1.1.0", semver.Must(semver.New("0.0.16")))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(update.Release.Version).To(Equal("1.2.0"))
+			Expect(update.Blocked.Version).To(Equal("1.3.0"))
+			Expect(update.Plugin.Version).To(Equal("0.0.18"))
+		})
+
+		It("returns nothing when up to date", func() {
+			update, err := index.Check("stable", "darwin", "1.3.0", semver.Must(semver.New("0.0.18")))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(update).To(Equal(channel.Update{}))
+		})
+
+		It("offers no release when the installed version is unknown", func() {
+			update, err := index.Check("stable", "darwin", "", semver.Must(semver.New("0.0.16")))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(update.Release).To(BeNil())
+			Expect(update.Blocked).To(BeNil())
+			Expect(update.Plugin.Version).To(Equal("0.0.18"))
+		})
+
+		It("only considers releases for the given OS", func() {
+			update, err := index.Check("stable", "windows", "1.3.0", semver.Must(semver.New("0.0.18")))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(update.Release.Version).To(Equal("1.4.0"))
+		})
+
+		It("rejects unknown channels", func() {
+			_, err := index.Check("some-channel", "darwin", "", semver.Must(semver.New("0.0.18")))
+			Expect(err).To(MatchError("unknown channel 'some-channel'"))
+		})
+	})
+})
+
+var _ = Describe("Installed", func() {
+	It("round trips the adopted release", func() {
+		tmpDir, err := ioutil.TempDir("", "cfdev-channel-")
+		Expect(err).NotTo(HaveOccurred())
+		defer os.RemoveAll(tmpDir)
+		path := filepath.Join(tmpDir, "catalog.json")
+
+		installed, err := channel.ReadInstalled(path)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(installed).To(BeNil())
+
+		release := channel.Installed{
+			Channel: "edge",
+			Version: "1.2.0",
+			Catalog: resource.Catalog{Items: []resource.Item{{Name: "cfdev-deps.tgz"}}},
+		}
+		Expect(channel.WriteInstalled(path, release)).To(Succeed())
+
+		installed, err = channel.ReadInstalled(path)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(*installed).To(Equal(release))
+	})
+})
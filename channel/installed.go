@@ -0,0 +1,50 @@
+package channel
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"code.cloudfoundry.org/cfdev/resource"
+)
+
+// Installed is the release adopted by 'cf dev upgrade'. It replaces the
+// catalog built into the plugin.
+type Installed struct {
+	Channel string           `json:"channel"`
+	Version string           `json:"version"`
+	Catalog resource.Catalog `json:"catalog"`
+}
+
+// ReadInstalled returns nil when no release has been adopted.
+func ReadInstalled(path string) (*Installed, error) {
+	contents, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	var installed Installed
+	if err := json.Unmarshal(contents, &installed); err != nil {
+		return nil, err
+	}
+	return &installed, nil
+}
+
+func WriteInstalled(path string, installed Installed) error {
+	contents, err := json.MarshalIndent(installed, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+
+	tmpPath := path + ".tmp"
+	if err := ioutil.WriteFile(tmpPath, contents, 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmpPath, path)
+}
@@ -42,30 +42,50 @@ func (b *Bosh) Cmd() *cobra.Command {
 			}
 		},
 	}
+	var args EnvArgs
 	envCmd := &cobra.Command{
 		Use: "env",
-		RunE: func(cmd *cobra.Command, args []string) error {
-			return b.Env()
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			return b.Env(args)
 		},
 	}
+	envCmd.Flags().StringVar(&args.Shell, "shell", "", "format of the generated script: bash, zsh, fish, cmd, powershell, dotenv or json")
+	envCmd.Flags().BoolVar(&args.ViaTunnel, "via-tunnel", false, "reach the director through the SOCKS5 proxy of a running cf dev tunnel")
 	cmd.AddCommand(envCmd)
 	return cmd
 }
 
-func (b *Bosh) Env() error {
+type EnvArgs struct {
+	Shell     string
+	ViaTunnel bool
+}
+
+func (b *Bosh) Env(args EnvArgs) error {
 	go func() {
 		<-b.Exit
 		os.Exit(128)
 	}()
 
+	format, err := shell.ParseFormat(args.Shell)
+	if err != nil {
+		return errors.SafeWrap(err, "invalid --shell")
+	}
+
 	config, err := bosh.FetchConfig(b.Config)
 	if err != nil {
 		return errors.SafeWrap(err, "failed to fetch bosh configuration")
 	}
 
+	if args.ViaTunnel {
+		config.AllProxy, err = config.TunnelProxy()
+		if err != nil {
+			return errors.SafeWrap(err, "unable to use --via-tunnel")
+		}
+	}
+
 	b.Analytics.Event(cfanalytics.BOSH_ENV)
 
-	env := shell.Environment{}
+	env := shell.Environment{Format: format}
 	shellScript, err := env.Prepare(config)
 	if err != nil {
 		return errors.SafeWrap(err, "failed to prepare bosh configuration")
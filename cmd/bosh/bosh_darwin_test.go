@@ -36,6 +36,7 @@ var _ = Describe("Bosh", func() {
 
 		cfg := config.Config{
 			StateBosh: tmpDir,
+			StateDir: tmpDir,
 			BoshDirectorIP: "10.0.0.1",
 		}
 
@@ -82,8 +83,45 @@ export BOSH_GW_USER="jumpbox";`,
 					filepath.Join(tmpDir, "jumpbox.key"),
 				))
 
-				Expect(boshCmd.Env()).To(Succeed())
+				Expect(boshCmd.Env(cmd.EnvArgs{})).To(Succeed())
 			})
+
+			It("prints the script for the requested shell", func() {
+				mockAnalyticsClient.EXPECT().Event(cfanalytics.BOSH_ENV)
+				mockUI.EXPECT().Say(fmt.Sprintf(
+					`set -e BOSH_SOME_VAR;
+set -e BOSH_SOME_OTHER_VAR;
+set -gx BOSH_ENVIRONMENT "10.0.0.1";
+set -gx BOSH_CLIENT "admin";
+set -gx BOSH_CLIENT_SECRET "some-bosh-secret";
+set -gx BOSH_CA_CERT "%s";
+set -gx BOSH_GW_HOST "10.0.0.1";
+set -gx BOSH_GW_PRIVATE_KEY "%s";
+set -gx BOSH_GW_USER "jumpbox";`,
+					filepath.Join(tmpDir, "ca.crt"),
+					filepath.Join(tmpDir, "jumpbox.key"),
+				))
+
+				Expect(boshCmd.Env(cmd.EnvArgs{Shell: "fish"})).To(Succeed())
+			})
+		})
+
+		It("points BOSH_ALL_PROXY at the running tunnel", func() {
+			ioutil.WriteFile(filepath.Join(tmpDir, "tunnel.port"), []byte("1081\n"), 0600)
+			mockAnalyticsClient.EXPECT().Event(cfanalytics.BOSH_ENV)
+			mockUI.EXPECT().Say(gomock.Any()).Do(func(message string, args ...interface{}) {
+				Expect(message).To(HaveSuffix(`export BOSH_ALL_PROXY="socks5://127.0.0.1:1081";`))
+			})
+
+			Expect(boshCmd.Env(cmd.EnvArgs{ViaTunnel: true})).To(Succeed())
+		})
+
+		It("requires a running tunnel to go through it", func() {
+			Expect(boshCmd.Env(cmd.EnvArgs{ViaTunnel: true})).To(MatchError("unable to use --via-tunnel: cf dev tunnel is not running"))
+		})
+
+		It("rejects unknown shells", func() {
+			Expect(boshCmd.Env(cmd.EnvArgs{Shell: "tcsh"})).To(MatchError(ContainSubstring("unknown shell 'tcsh'")))
 		})
 	})
 })
@@ -81,7 +81,7 @@ $env:BOSH_GW_USER="jumpbox";`,
 					filepath.Join(tmpDir, "jumpbox.key"),
 				))
 
-				Expect(boshCmd.Env()).To(Succeed())
+				Expect(boshCmd.Env(cmd.EnvArgs{})).To(Succeed())
 			})
 		})
 	})
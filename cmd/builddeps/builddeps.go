@@ -0,0 +1,86 @@
+package builddeps
+
+import (
+	"runtime"
+
+	"code.cloudfoundry.org/cfdev/deps"
+	e "code.cloudfoundry.org/cfdev/errors"
+	"github.com/spf13/cobra"
+)
+
+//go:generate mockgen -package mocks -destination mocks/ui.go code.cloudfoundry.org/cfdev/cmd/builddeps UI
+type UI interface {
+	Say(message string, args ...interface{})
+}
+
+//go:generate mockgen -package mocks -destination mocks/builder.go code.cloudfoundry.org/cfdev/cmd/builddeps Builder
+type Builder interface {
+	Build(src string, opts deps.Options) (deps.Result, error)
+}
+
+type Args struct {
+	Output  string
+	OS      string
+	Version string
+	URL     string
+}
+
+type BuildDeps struct {
+	UI      UI
+	Builder Builder
+}
+
+func (b *BuildDeps) Cmd() *cobra.Command {
+	args := Args{}
+	cmd := &cobra.Command{
+		Use:   "build-deps <dir|spec.yml>",
+		Short: "Assemble a deps tarball from a directory or a spec file",
+		Long: `Assemble a deps tarball from a directory laid out like one, or from a spec file
+mapping paths in the tarball to files and directories on disk:
+
+  files:
+    .: base-deps
+    services/bin/deploy-redis: redis/deploy-redis
+    deployment_config/redis.yml: redis/manifest.yml
+
+The extension of --output picks the compression: .tgz, .tar.zst, .tar.xz or .tar.`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(_ *cobra.Command, params []string) error {
+			if err := b.Execute(params[0], args); err != nil {
+				return e.SafeWrap(err, "cf dev build-deps")
+			}
+			return nil
+		},
+	}
+
+	pf := cmd.PersistentFlags()
+	pf.StringVarP(&args.Output, "output", "o", "cfdev-deps.tgz", "tarball to write")
+	pf.StringVar(&args.OS, "os", runtime.GOOS, "operating system the deps are for (darwin or windows)")
+	pf.StringVar(&args.Version, "version", "", "version of the deps, recorded in metadata.yml")
+	pf.StringVar(&args.URL, "url", "", "where the tarball will be published, for the catalog snippet (default file:// URL of the output)")
+	return cmd
+}
+
+func (b *BuildDeps) Execute(src string, args Args) error {
+	if args.OS != "darwin" && args.OS != "windows" {
+		return e.SafeWrap(nil, "--os must be darwin or windows")
+	}
+
+	b.UI.Say("Building %s from %s...", args.Output, src)
+	result, err := b.Builder.Build(src, deps.Options{
+		Output:  args.Output,
+		GOOS:    args.OS,
+		Version: args.Version,
+		URL:     args.URL,
+	})
+	if err != nil {
+		return e.SafeWrap(err, "Unable to build the deps")
+	}
+
+	for _, warning := range result.Warnings {
+		b.UI.Say("WARNING: %s", warning)
+	}
+	b.UI.Say("Wrote %s (sha256 %s)", result.Path, result.SHA256)
+	b.UI.Say("Catalog snippet: %s", result.CatalogPath)
+	return nil
+}
@@ -0,0 +1,13 @@
+package builddeps_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"testing"
+)
+
+func TestBuildDeps(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Cmd BuildDeps Suite")
+}
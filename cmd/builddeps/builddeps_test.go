@@ -0,0 +1,73 @@
+package builddeps_test
+
+import (
+	"errors"
+
+	"code.cloudfoundry.org/cfdev/cmd/builddeps"
+	"code.cloudfoundry.org/cfdev/cmd/builddeps/mocks"
+	"code.cloudfoundry.org/cfdev/deps"
+	"github.com/golang/mock/gomock"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("BuildDeps", func() {
+	var (
+		mockController *gomock.Controller
+		mockUI         *mocks.MockUI
+		mockBuilder    *mocks.MockBuilder
+		buildCmd       *builddeps.BuildDeps
+		args           builddeps.Args
+	)
+
+	BeforeEach(func() {
+		mockController = gomock.NewController(GinkgoT())
+		mockUI = mocks.NewMockUI(mockController)
+		mockBuilder = mocks.NewMockBuilder(mockController)
+
+		buildCmd = &builddeps.BuildDeps{
+			UI:      mockUI,
+			Builder: mockBuilder,
+		}
+		args = builddeps.Args{Output: "out.tar.zst", OS: "darwin", Version: "v1.2.3", URL: "https://example.com/out.tar.zst"}
+	})
+
+	AfterEach(func() {
+		mockController.Finish()
+	})
+
+	It("builds the tarball and reports where it is", func() {
+		gomock.InOrder(
+			mockUI.EXPECT().Say("Building %s from %s...", "out.tar.zst", "some-dir"),
+			mockBuilder.EXPECT().Build("some-dir", deps.Options{
+				Output:  "out.tar.zst",
+				GOOS:    "darwin",
+				Version: "v1.2.3",
+				URL:     "https://example.com/out.tar.zst",
+			}).Return(deps.Result{
+				Path:        "out.tar.zst",
+				SHA256:      "some-sha",
+				CatalogPath: "out.tar.zst.catalog.json",
+				Warnings:    []string{"some-warning"},
+			}, nil),
+			mockUI.EXPECT().Say("WARNING: %s", "some-warning"),
+			mockUI.EXPECT().Say("Wrote %s (sha256 %s)", "out.tar.zst", "some-sha"),
+			mockUI.EXPECT().Say("Catalog snippet: %s", "out.tar.zst.catalog.json"),
+		)
+
+		Expect(buildCmd.Execute("some-dir", args)).To(Succeed())
+	})
+
+	It("returns the errors of the build", func() {
+		mockUI.EXPECT().Say(gomock.Any(), gomock.Any(), gomock.Any())
+		mockBuilder.EXPECT().Build(gomock.Any(), gomock.Any()).Return(deps.Result{}, errors.New("some-dir is missing disk.qcow2"))
+
+		Expect(buildCmd.Execute("some-dir", args)).To(MatchError("Unable to build the deps: some-dir is missing disk.qcow2"))
+	})
+
+	It("only builds deps for darwin and windows", func() {
+		args.OS = "linux"
+
+		Expect(buildCmd.Execute("some-dir", args)).To(MatchError("--os must be darwin or windows"))
+	})
+})
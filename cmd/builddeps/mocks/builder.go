@@ -0,0 +1,47 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: code.cloudfoundry.org/cfdev/cmd/builddeps (interfaces: Builder)
+
+// Package mocks is a generated GoMock package.
+package mocks
+
+import (
+	deps "code.cloudfoundry.org/cfdev/deps"
+	gomock "github.com/golang/mock/gomock"
+	reflect "reflect"
+)
+
+// MockBuilder is a mock of Builder interface
+type MockBuilder struct {
+	ctrl     *gomock.Controller
+	recorder *MockBuilderMockRecorder
+}
+
+// MockBuilderMockRecorder is the mock recorder for MockBuilder
+type MockBuilderMockRecorder struct {
+	mock *MockBuilder
+}
+
+// NewMockBuilder creates a new mock instance
+func NewMockBuilder(ctrl *gomock.Controller) *MockBuilder {
+	mock := &MockBuilder{ctrl: ctrl}
+	mock.recorder = &MockBuilderMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use
+func (m *MockBuilder) EXPECT() *MockBuilderMockRecorder {
+	return m.recorder
+}
+
+// Build mocks base method
+func (m *MockBuilder) Build(arg0 string, arg1 deps.Options) (deps.Result, error) {
+	ret := m.ctrl.Call(m, "Build", arg0, arg1)
+	ret0, _ := ret[0].(deps.Result)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Build indicates an expected call of Build
+func (mr *MockBuilderMockRecorder) Build(arg0, arg1 interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Build", reflect.TypeOf((*MockBuilder)(nil).Build), arg0, arg1)
+}
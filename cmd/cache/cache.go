@@ -0,0 +1,204 @@
+package cache
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"text/tabwriter"
+
+	"code.cloudfoundry.org/bytefmt"
+	"code.cloudfoundry.org/cfdev/config"
+	e "code.cloudfoundry.org/cfdev/errors"
+	"code.cloudfoundry.org/cfdev/resource"
+	"github.com/spf13/cobra"
+)
+
+//go:generate mockgen -package mocks -destination mocks/ui.go code.cloudfoundry.org/cfdev/cmd/cache UI
+type UI interface {
+	Say(message string, args ...interface{})
+}
+
+//go:generate mockgen -package mocks -destination mocks/inventory.go code.cloudfoundry.org/cfdev/cmd/cache Inventory
+type Inventory interface {
+	List(clog resource.Catalog) ([]resource.Entry, error)
+	Verify(clog resource.Catalog) ([]resource.Entry, error)
+	Prune(clog resource.Catalog, opts resource.PruneOptions) ([]resource.Entry, error)
+}
+
+//go:generate mockgen -package mocks -destination mocks/hypervisor.go code.cloudfoundry.org/cfdev/cmd/cache Hypervisor
+type Hypervisor interface {
+	IsRunning(vmName string) (bool, error)
+}
+
+type Cache struct {
+	UI         UI
+	Config     config.Config
+	Inventory  Inventory
+	Hypervisor Hypervisor
+}
+
+func (c *Cache) Cmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "cache",
+		Short: "Inspect and prune the downloaded assets",
+	}
+
+	listJSON := false
+	listCmd := &cobra.Command{
+		Use:   "list",
+		Short: "List cached assets and their checksum status",
+		Args:  cobra.NoArgs,
+		RunE: func(_ *cobra.Command, _ []string) error {
+			return c.List(listJSON)
+		},
+	}
+	listCmd.Flags().BoolVar(&listJSON, "json", false, "print as json")
+
+	pruneOpts := resource.PruneOptions{}
+	pruneCmd := &cobra.Command{
+		Use:   "prune",
+		Short: "Delete partial downloads and assets the catalog no longer refers to",
+		Args:  cobra.NoArgs,
+		RunE: func(_ *cobra.Command, _ []string) error {
+			return c.Prune(pruneOpts)
+		},
+	}
+	pruneCmd.Flags().BoolVar(&pruneOpts.DryRun, "dry-run", false, "only print what would be deleted")
+	pruneCmd.Flags().BoolVar(&pruneOpts.Extracted, "all", false, "also delete files extracted from the deps tarball (requires CF Dev to be stopped)")
+
+	sharedPath := false
+	pathCmd := &cobra.Command{
+		Use:   "path",
+		Short: "Print the location of the cache",
+		Args:  cobra.NoArgs,
+		RunE: func(_ *cobra.Command, _ []string) error {
+			return c.Path(sharedPath)
+		},
+	}
+	pathCmd.Flags().BoolVar(&sharedPath, "shared", false, "print the shared cache set by CFDEV_SHARED_CACHE")
+
+	cmd.AddCommand(
+		listCmd,
+		&cobra.Command{
+			Use:   "verify",
+			Short: "Recompute the checksums of cached assets",
+			Args:  cobra.NoArgs,
+			RunE: func(_ *cobra.Command, _ []string) error {
+				return c.Verify()
+			},
+		},
+		pruneCmd,
+		pathCmd,
+	)
+	return cmd
+}
+
+func (c *Cache) List(asJSON bool) error {
+	entries, err := c.Inventory.List(c.Config.Dependencies)
+	if err != nil {
+		return e.SafeWrap(err, "failed to list cache")
+	}
+
+	if asJSON {
+		if entries == nil {
+			entries = []resource.Entry{}
+		}
+		contents, err := json.MarshalIndent(entries, "", "  ")
+		if err != nil {
+			return e.SafeWrap(err, "unable to marshal cache entries")
+		}
+		c.UI.Say("%s", string(contents))
+		return nil
+	}
+
+	c.say(entries)
+	return nil
+}
+
+func (c *Cache) Verify() error {
+	entries, err := c.Inventory.Verify(c.Config.Dependencies)
+	if err != nil {
+		return e.SafeWrap(err, "failed to verify cache")
+	}
+	c.say(entries)
+
+	var failed []string
+	for _, entry := range entries {
+		if entry.Status == resource.StatusMismatch {
+			failed = append(failed, entry.Name)
+		}
+	}
+	if len(failed) > 0 {
+		return e.SafeWrap(nil, fmt.Sprintf("checksum mismatch for %s. Please execute 'cf dev download' to fetch them again", strings.Join(failed, ", ")))
+	}
+	return nil
+}
+
+func (c *Cache) Prune(opts resource.PruneOptions) error {
+	if opts.Extracted {
+		running, err := c.Hypervisor.IsRunning("cfdev")
+		if err != nil {
+			return e.SafeWrap(err, "is running")
+		} else if running {
+			return e.SafeWrap(nil, "CF Dev is running. Please execute 'cf dev stop' before pruning extracted files")
+		}
+	}
+
+	pruned, err := c.Inventory.Prune(c.Config.Dependencies, opts)
+	if err != nil {
+		return e.SafeWrap(err, "failed to prune cache")
+	}
+
+	if len(pruned) == 0 {
+		c.UI.Say("Nothing to prune")
+		return nil
+	}
+
+	verb := "Deleted"
+	if opts.DryRun {
+		verb = "Would delete"
+	}
+
+	var total int64
+	for _, entry := range pruned {
+		c.UI.Say("%s %s (%s)", verb, entry.Name, bytefmt.ByteSize(uint64(entry.Size)))
+		total += entry.Size
+	}
+	c.UI.Say("%s %d file(s), %s in total", verb, len(pruned), bytefmt.ByteSize(uint64(total)))
+	return nil
+}
+
+func (c *Cache) Path(shared bool) error {
+	if !shared {
+		c.UI.Say("%s", c.Config.CacheDir)
+		return nil
+	}
+
+	if c.Config.SharedCacheDir == "" {
+		return e.SafeWrap(nil, "no shared cache is configured. Set CFDEV_SHARED_CACHE to use one")
+	}
+	c.UI.Say("%s", c.Config.SharedCacheDir)
+	return nil
+}
+
+func (c *Cache) say(entries []resource.Entry) {
+	if len(entries) == 0 {
+		c.UI.Say("The cache is empty")
+		return
+	}
+
+	var output bytes.Buffer
+	w := tabwriter.NewWriter(&output, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "NAME\tSIZE\tSTATUS\tREFERENCED")
+	for _, entry := range entries {
+		referenced := "no"
+		if entry.Referenced {
+			referenced = "yes"
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", entry.Name, bytefmt.ByteSize(uint64(entry.Size)), entry.Status, referenced)
+	}
+	w.Flush()
+
+	c.UI.Say("%s", strings.TrimRight(output.String(), "\n"))
+}
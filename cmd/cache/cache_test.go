@@ -0,0 +1,144 @@
+package cache_test
+
+import (
+	"fmt"
+
+	"code.cloudfoundry.org/cfdev/cmd/cache"
+	"code.cloudfoundry.org/cfdev/cmd/cache/mocks"
+	"code.cloudfoundry.org/cfdev/config"
+	"code.cloudfoundry.org/cfdev/resource"
+	"github.com/golang/mock/gomock"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Cache", func() {
+	var (
+		mockController *gomock.Controller
+		mockUI         *mocks.MockUI
+		mockInventory  *mocks.MockInventory
+		mockHypervisor *mocks.MockHypervisor
+		cacheCmd       *cache.Cache
+		catalog        resource.Catalog
+	)
+
+	BeforeEach(func() {
+		mockController = gomock.NewController(GinkgoT())
+		mockUI = mocks.NewMockUI(mockController)
+		mockInventory = mocks.NewMockInventory(mockController)
+		mockHypervisor = mocks.NewMockHypervisor(mockController)
+
+		catalog = resource.Catalog{Items: []resource.Item{{Name: "cfdev-deps.tgz", InUse: true}}}
+		cacheCmd = &cache.Cache{
+			UI: mockUI,
+			Config: config.Config{
+				CacheDir:     "some-cache-dir",
+				Dependencies: catalog,
+			},
+			Inventory:  mockInventory,
+			Hypervisor: mockHypervisor,
+		}
+	})
+
+	AfterEach(func() {
+		mockController.Finish()
+	})
+
+	captureOutput := func() *string {
+		var output string
+		mockUI.EXPECT().Say("%s", gomock.Any()).Do(func(message string, args ...interface{}) {
+			output = args[0].(string)
+		})
+		return &output
+	}
+
+	Describe("List", func() {
+		It("prints a table of the cached items", func() {
+			mockInventory.EXPECT().List(catalog).Return([]resource.Entry{
+				{Name: "cfdev-deps.tgz", Size: 3 * 1024 * 1024, Status: resource.StatusVerified, Referenced: true},
+				{Name: "linuxkit", Size: 512, Status: resource.StatusExtracted},
+			}, nil)
+			output := captureOutput()
+
+			Expect(cacheCmd.List(false)).To(Succeed())
+			Expect(*output).To(Equal("" +
+				"NAME            SIZE  STATUS     REFERENCED\n" +
+				"cfdev-deps.tgz  3M    verified   yes\n" +
+				"linuxkit        512B  extracted  no"))
+		})
+
+		It("prints json", func() {
+			mockInventory.EXPECT().List(catalog).Return([]resource.Entry{
+				{Name: "cfdev-deps.tgz", Status: resource.StatusMissing, Referenced: true},
+			}, nil)
+			output := captureOutput()
+
+			Expect(cacheCmd.List(true)).To(Succeed())
+			Expect(*output).To(MatchJSON(`[{"name": "cfdev-deps.tgz", "size": 0, "status": "missing", "referenced": true}]`))
+		})
+	})
+
+	Describe("Verify", func() {
+		It("fails when checksums do not match", func() {
+			mockInventory.EXPECT().Verify(catalog).Return([]resource.Entry{
+				{Name: "cfdev-deps.tgz", Size: 7, Status: resource.StatusMismatch, Referenced: true},
+			}, nil)
+			captureOutput()
+
+			err := cacheCmd.Verify()
+			Expect(err).To(MatchError("checksum mismatch for cfdev-deps.tgz. Please execute 'cf dev download' to fetch them again"))
+		})
+	})
+
+	Describe("Prune", func() {
+		It("reports what was deleted", func() {
+			gomock.InOrder(
+				mockInventory.EXPECT().Prune(catalog, resource.PruneOptions{}).Return([]resource.Entry{
+					{Name: "old-deps.tgz", Size: 2048, Status: resource.StatusStale},
+					{Name: "cfdev-deps.tgz.tmp.abc", Size: 1024, Status: resource.StatusPartial},
+				}, nil),
+				mockUI.EXPECT().Say("%s %s (%s)", "Deleted", "old-deps.tgz", "2K"),
+				mockUI.EXPECT().Say("%s %s (%s)", "Deleted", "cfdev-deps.tgz.tmp.abc", "1K"),
+				mockUI.EXPECT().Say("%s %d file(s), %s in total", "Deleted", 2, "3K"),
+			)
+
+			Expect(cacheCmd.Prune(resource.PruneOptions{})).To(Succeed())
+		})
+
+		It("reports what would be deleted on a dry run", func() {
+			gomock.InOrder(
+				mockInventory.EXPECT().Prune(catalog, resource.PruneOptions{DryRun: true}).Return([]resource.Entry{
+					{Name: "old-deps.tgz", Size: 2048, Status: resource.StatusStale},
+				}, nil),
+				mockUI.EXPECT().Say("%s %s (%s)", "Would delete", "old-deps.tgz", "2K"),
+				mockUI.EXPECT().Say("%s %d file(s), %s in total", "Would delete", 1, "2K"),
+			)
+
+			Expect(cacheCmd.Prune(resource.PruneOptions{DryRun: true})).To(Succeed())
+		})
+
+		It("refuses to delete extracted files while CF Dev is running", func() {
+			mockHypervisor.EXPECT().IsRunning("cfdev").Return(true, nil)
+
+			err := cacheCmd.Prune(resource.PruneOptions{Extracted: true})
+			Expect(err).To(MatchError("CF Dev is running. Please execute 'cf dev stop' before pruning extracted files"))
+		})
+
+		It("surfaces errors", func() {
+			mockInventory.EXPECT().Prune(catalog, resource.PruneOptions{}).Return(nil, fmt.Errorf("some-error"))
+
+			Expect(cacheCmd.Prune(resource.PruneOptions{})).To(MatchError("failed to prune cache: some-error"))
+		})
+	})
+
+	Describe("Path", func() {
+		It("prints the cache dir", func() {
+			mockUI.EXPECT().Say("%s", "some-cache-dir")
+			Expect(cacheCmd.Path(false)).To(Succeed())
+		})
+
+		It("fails when no shared cache is configured", func() {
+			Expect(cacheCmd.Path(true)).To(MatchError("no shared cache is configured. Set CFDEV_SHARED_CACHE to use one"))
+		})
+	})
+})
@@ -0,0 +1,73 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: code.cloudfoundry.org/cfdev/cmd/cache (interfaces: Inventory)
+
+// Package mocks is a generated GoMock package.
+package mocks
+
+import (
+	resource "code.cloudfoundry.org/cfdev/resource"
+	gomock "github.com/golang/mock/gomock"
+	reflect "reflect"
+)
+
+// MockInventory is a mock of Inventory interface
+type MockInventory struct {
+	ctrl     *gomock.Controller
+	recorder *MockInventoryMockRecorder
+}
+
+// MockInventoryMockRecorder is the mock recorder for MockInventory
+type MockInventoryMockRecorder struct {
+	mock *MockInventory
+}
+
+// NewMockInventory creates a new mock instance
+func NewMockInventory(ctrl *gomock.Controller) *MockInventory {
+	mock := &MockInventory{ctrl: ctrl}
+	mock.recorder = &MockInventoryMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use
+func (m *MockInventory) EXPECT() *MockInventoryMockRecorder {
+	return m.recorder
+}
+
+// List mocks base method
+func (m *MockInventory) List(arg0 resource.Catalog) ([]resource.Entry, error) {
+	ret := m.ctrl.Call(m, "List", arg0)
+	ret0, _ := ret[0].([]resource.Entry)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// List indicates an expected call of List
+func (mr *MockInventoryMockRecorder) List(arg0 interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "List", reflect.TypeOf((*MockInventory)(nil).List), arg0)
+}
+
+// Prune mocks base method
+func (m *MockInventory) Prune(arg0 resource.Catalog, arg1 resource.PruneOptions) ([]resource.Entry, error) {
+	ret := m.ctrl.Call(m, "Prune", arg0, arg1)
+	ret0, _ := ret[0].([]resource.Entry)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Prune indicates an expected call of Prune
+func (mr *MockInventoryMockRecorder) Prune(arg0, arg1 interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Prune", reflect.TypeOf((*MockInventory)(nil).Prune), arg0, arg1)
+}
+
+// Verify mocks base method
+func (m *MockInventory) Verify(arg0 resource.Catalog) ([]resource.Entry, error) {
+	ret := m.ctrl.Call(m, "Verify", arg0)
+	ret0, _ := ret[0].([]resource.Entry)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Verify indicates an expected call of Verify
+func (mr *MockInventoryMockRecorder) Verify(arg0 interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Verify", reflect.TypeOf((*MockInventory)(nil).Verify), arg0)
+}
@@ -0,0 +1,96 @@
+package cp
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"code.cloudfoundry.org/cfdev/config"
+	e "code.cloudfoundry.org/cfdev/errors"
+	"code.cloudfoundry.org/cfdev/resource"
+	"code.cloudfoundry.org/cfdev/resource/progress"
+	"code.cloudfoundry.org/cfdev/ssh"
+	"github.com/spf13/cobra"
+)
+
+//go:generate mockgen -package mocks -destination mocks/ui.go code.cloudfoundry.org/cfdev/cmd/cp UI
+type UI interface {
+	Say(message string, args ...interface{})
+	Writer() io.Writer
+}
+
+//go:generate mockgen -package mocks -destination mocks/ssh.go code.cloudfoundry.org/cfdev/cmd/cp SSH
+type SSH interface {
+	Upload(localPath string, remotePath string, address ssh.SSHAddress, privateKey []byte, timeout time.Duration, progress resource.Progress) error
+	Download(remotePath string, localPath string, address ssh.SSHAddress, privateKey []byte, timeout time.Duration, progress resource.Progress) error
+}
+
+const remotePrefix = "cfdev:"
+
+var vmAddress = ssh.SSHAddress{IP: "127.0.0.1", Port: "9992"}
+
+type Copy struct {
+	Exit   chan struct{}
+	UI     UI
+	Config config.Config
+	SSH    SSH
+}
+
+func (c *Copy) Cmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "cp <src> <dst>",
+		Short: "Copy files and directories between the host and the CF Dev VM",
+		Long: `Copy files and directories between the host and the CF Dev VM.
+Prefix the path inside the VM with 'cfdev:', e.g.
+
+  cf dev cp ./my-file cfdev:/var/vcap/data/my-file
+  cf dev cp cfdev:/var/vcap/sys/log ./logs`,
+		Args: cobra.ExactArgs(2),
+		RunE: c.RunE,
+	}
+}
+
+func (c *Copy) RunE(cmd *cobra.Command, args []string) error {
+	go func() {
+		<-c.Exit
+		os.Exit(128)
+	}()
+
+	return c.Execute(args[0], args[1])
+}
+
+func (c *Copy) Execute(src string, dst string) error {
+	srcRemote := strings.HasPrefix(src, remotePrefix)
+	dstRemote := strings.HasPrefix(dst, remotePrefix)
+
+	if srcRemote == dstRemote {
+		return e.SafeWrap(nil, fmt.Sprintf("exactly one of the paths must refer to the VM using the '%s' prefix", remotePrefix))
+	}
+
+	key, err := ioutil.ReadFile(filepath.Join(c.Config.CacheDir, "id_rsa"))
+	if err != nil {
+		return e.SafeWrap(err, "CF Dev does not seem to be running. Please execute 'cf dev start'")
+	}
+
+	p := progress.NewWithRenderer(progress.Detect(c.UI.Writer(), c.Config.ProgressMode))
+
+	if dstRemote {
+		if _, err := os.Stat(src); err != nil {
+			return e.SafeWrap(err, "unable to read source")
+		}
+
+		if err := c.SSH.Upload(src, strings.TrimPrefix(dst, remotePrefix), vmAddress, key, 20*time.Second, p); err != nil {
+			return e.SafeWrap(err, "failed to copy to the VM")
+		}
+		return nil
+	}
+
+	if err := c.SSH.Download(strings.TrimPrefix(src, remotePrefix), dst, vmAddress, key, 20*time.Second, p); err != nil {
+		return e.SafeWrap(err, "failed to copy from the VM")
+	}
+	return nil
+}
@@ -0,0 +1,13 @@
+package cp_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"testing"
+)
+
+func TestCp(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Cp Suite")
+}
@@ -0,0 +1,89 @@
+package cp_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"time"
+
+	"code.cloudfoundry.org/cfdev/cmd/cp"
+	"code.cloudfoundry.org/cfdev/cmd/cp/mocks"
+	"code.cloudfoundry.org/cfdev/config"
+	"code.cloudfoundry.org/cfdev/ssh"
+	"github.com/golang/mock/gomock"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Cp", func() {
+	var (
+		mockController *gomock.Controller
+		mockUI         *mocks.MockUI
+		mockSSH        *mocks.MockSSH
+		cpCmd          *cp.Copy
+		cacheDir       string
+		localFile      string
+		address        = ssh.SSHAddress{IP: "127.0.0.1", Port: "9992"}
+	)
+
+	BeforeEach(func() {
+		mockController = gomock.NewController(GinkgoT())
+		mockUI = mocks.NewMockUI(mockController)
+		mockSSH = mocks.NewMockSSH(mockController)
+
+		var err error
+		cacheDir, err = ioutil.TempDir("", "cfdev-cp-test-")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(ioutil.WriteFile(filepath.Join(cacheDir, "id_rsa"), []byte("some-key"), 0600)).To(Succeed())
+
+		localFile = filepath.Join(cacheDir, "some-file")
+		Expect(ioutil.WriteFile(localFile, []byte("some-content"), 0644)).To(Succeed())
+
+		mockUI.EXPECT().Writer().Return(ioutil.Discard).AnyTimes()
+
+		cpCmd = &cp.Copy{
+			UI:     mockUI,
+			Config: config.Config{CacheDir: cacheDir},
+			SSH:    mockSSH,
+		}
+	})
+
+	AfterEach(func() {
+		mockController.Finish()
+		os.RemoveAll(cacheDir)
+	})
+
+	It("uploads when the destination refers to the VM", func() {
+		mockSSH.EXPECT().Upload(localFile, "/tmp/some-file", address, []byte("some-key"), 20*time.Second, gomock.Any())
+
+		Expect(cpCmd.Execute(localFile, "cfdev:/tmp/some-file")).To(Succeed())
+	})
+
+	It("downloads when the source refers to the VM", func() {
+		mockSSH.EXPECT().Download("/var/vcap/sys/log", "./logs", address, []byte("some-key"), 20*time.Second, gomock.Any())
+
+		Expect(cpCmd.Execute("cfdev:/var/vcap/sys/log", "./logs")).To(Succeed())
+	})
+
+	It("returns an error when neither path refers to the VM", func() {
+		Expect(cpCmd.Execute(localFile, "/tmp/other")).To(MatchError(ContainSubstring("exactly one of the paths")))
+	})
+
+	It("returns an error when both paths refer to the VM", func() {
+		Expect(cpCmd.Execute("cfdev:/a", "cfdev:/b")).To(MatchError(ContainSubstring("exactly one of the paths")))
+	})
+
+	It("returns an error when the local source does not exist", func() {
+		Expect(cpCmd.Execute(filepath.Join(cacheDir, "missing"), "cfdev:/tmp")).To(MatchError(ContainSubstring("unable to read source")))
+	})
+
+	Context("when the VM key is not present", func() {
+		BeforeEach(func() {
+			os.Remove(filepath.Join(cacheDir, "id_rsa"))
+		})
+
+		It("asks the user to start CF Dev", func() {
+			Expect(cpCmd.Execute(localFile, "cfdev:/tmp")).To(MatchError(ContainSubstring("cf dev start")))
+		})
+	})
+})
@@ -0,0 +1,60 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: code.cloudfoundry.org/cfdev/cmd/cp (interfaces: SSH)
+
+// Package mocks is a generated GoMock package.
+package mocks
+
+import (
+	resource "code.cloudfoundry.org/cfdev/resource"
+	ssh "code.cloudfoundry.org/cfdev/ssh"
+	gomock "github.com/golang/mock/gomock"
+	reflect "reflect"
+	time "time"
+)
+
+// MockSSH is a mock of SSH interface
+type MockSSH struct {
+	ctrl     *gomock.Controller
+	recorder *MockSSHMockRecorder
+}
+
+// MockSSHMockRecorder is the mock recorder for MockSSH
+type MockSSHMockRecorder struct {
+	mock *MockSSH
+}
+
+// NewMockSSH creates a new mock instance
+func NewMockSSH(ctrl *gomock.Controller) *MockSSH {
+	mock := &MockSSH{ctrl: ctrl}
+	mock.recorder = &MockSSHMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use
+func (m *MockSSH) EXPECT() *MockSSHMockRecorder {
+	return m.recorder
+}
+
+// Download mocks base method
+func (m *MockSSH) Download(arg0, arg1 string, arg2 ssh.SSHAddress, arg3 []byte, arg4 time.Duration, arg5 resource.Progress) error {
+	ret := m.ctrl.Call(m, "Download", arg0, arg1, arg2, arg3, arg4, arg5)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Download indicates an expected call of Download
+func (mr *MockSSHMockRecorder) Download(arg0, arg1, arg2, arg3, arg4, arg5 interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Download", reflect.TypeOf((*MockSSH)(nil).Download), arg0, arg1, arg2, arg3, arg4, arg5)
+}
+
+// Upload mocks base method
+func (m *MockSSH) Upload(arg0, arg1 string, arg2 ssh.SSHAddress, arg3 []byte, arg4 time.Duration, arg5 resource.Progress) error {
+	ret := m.ctrl.Call(m, "Upload", arg0, arg1, arg2, arg3, arg4, arg5)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Upload indicates an expected call of Upload
+func (mr *MockSSHMockRecorder) Upload(arg0, arg1, arg2, arg3, arg4, arg5 interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Upload", reflect.TypeOf((*MockSSH)(nil).Upload), arg0, arg1, arg2, arg3, arg4, arg5)
+}
@@ -0,0 +1,109 @@
+package credentials
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"text/tabwriter"
+
+	"code.cloudfoundry.org/cfdev/config"
+	"code.cloudfoundry.org/cfdev/credentials"
+	e "code.cloudfoundry.org/cfdev/errors"
+	"github.com/spf13/cobra"
+)
+
+//go:generate mockgen -package mocks -destination mocks/ui.go code.cloudfoundry.org/cfdev/cmd/credentials UI
+type UI interface {
+	Say(message string, args ...interface{})
+}
+
+type Args struct {
+	JSON   bool
+	Get    string
+	Reveal bool
+}
+
+type Credentials struct {
+	UI     UI
+	Config config.Config
+}
+
+func (c *Credentials) Cmd() *cobra.Command {
+	args := Args{}
+	cmd := &cobra.Command{
+		Use:   "credentials",
+		Short: "Show the endpoints and generated credentials of CF Dev",
+		RunE: func(_ *cobra.Command, _ []string) error {
+			return c.Execute(args)
+		},
+	}
+
+	pf := cmd.PersistentFlags()
+	pf.BoolVar(&args.JSON, "json", false, "print as json")
+	pf.StringVar(&args.Get, "get", "", "print only the value of the named credential")
+	pf.BoolVar(&args.Reveal, "reveal", false, "show secret values instead of masking them")
+	return cmd
+}
+
+func (c *Credentials) Execute(args Args) error {
+	creds, err := credentials.Load(c.Config)
+	if err != nil {
+		return e.SafeWrap(err, "failed to read credentials. Please execute 'cf dev start'")
+	}
+
+	if args.Get != "" {
+		credential, err := credentials.Find(creds, args.Get)
+		if err != nil {
+			return e.SafeWrap(err, "failed to get credential")
+		}
+		c.UI.Say("%s", credential.Value)
+		return nil
+	}
+
+	if !args.Reveal {
+		creds = credentials.Masked(creds)
+	}
+	endpoints := credentials.EndpointsFor(c.Config)
+
+	if args.JSON {
+		contents, err := json.MarshalIndent(struct {
+			Endpoints   credentials.Endpoints    `json:"endpoints"`
+			Credentials []credentials.Credential `json:"credentials"`
+		}{endpoints, creds}, "", "  ")
+		if err != nil {
+			return e.SafeWrap(err, "unable to marshal credentials")
+		}
+		c.UI.Say("%s", string(contents))
+		return nil
+	}
+
+	var output bytes.Buffer
+	w := tabwriter.NewWriter(&output, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "Endpoints:")
+	for _, endpoint := range [][2]string{
+		{"api", endpoints.API},
+		{"uaa", endpoints.UAA},
+		{"login", endpoints.Login},
+		{"doppler", endpoints.Doppler},
+		{"director", endpoints.Director},
+		{"director uaa", endpoints.DirectorUAA},
+		{"credhub", endpoints.Credhub},
+		{"jumpbox", endpoints.Jumpbox},
+	} {
+		fmt.Fprintf(w, "  %s:\t%s\n", endpoint[0], endpoint[1])
+	}
+	w.Flush()
+
+	fmt.Fprintln(&output)
+	fmt.Fprintln(&output, "Credentials:")
+	w = tabwriter.NewWriter(&output, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "  NAME\tUSERNAME\tVALUE\tENDPOINT")
+	for _, credential := range creds {
+		fmt.Fprintf(w, "  %s\t%s\t%s\t%s\n", credential.Name, credential.Username, credential.Value, credential.Endpoint)
+	}
+	w.Flush()
+
+	c.UI.Say("%s", strings.TrimRight(output.String(), "\n"))
+	return nil
+}
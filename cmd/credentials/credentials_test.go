@@ -0,0 +1,106 @@
+package credentials_test
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	cmd "code.cloudfoundry.org/cfdev/cmd/credentials"
+	"code.cloudfoundry.org/cfdev/cmd/credentials/mocks"
+	"code.cloudfoundry.org/cfdev/config"
+	"github.com/golang/mock/gomock"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Credentials", func() {
+	var (
+		mockController *gomock.Controller
+		mockUI         *mocks.MockUI
+		tmpDir         string
+		credentialsCmd *cmd.Credentials
+		output         string
+	)
+
+	BeforeEach(func() {
+		mockController = gomock.NewController(GinkgoT())
+		mockUI = mocks.NewMockUI(mockController)
+
+		var err error
+		tmpDir, err = ioutil.TempDir("", "cmd-credentials-test")
+		Expect(err).NotTo(HaveOccurred())
+
+		ioutil.WriteFile(filepath.Join(tmpDir, "secret"), []byte("some-director-secret"), 0600)
+		ioutil.WriteFile(filepath.Join(tmpDir, "creds.yml"), []byte("credhub_admin_client_secret: some-credhub-secret\n"), 0600)
+
+		credentialsCmd = &cmd.Credentials{
+			UI: mockUI,
+			Config: config.Config{
+				StateBosh:      tmpDir,
+				CFDomain:       "dev.cfdev.sh",
+				BoshDirectorIP: "10.144.0.4",
+			},
+		}
+
+		output = ""
+		mockUI.EXPECT().Say("%s", gomock.Any()).Do(func(message string, args ...interface{}) {
+			output = args[0].(string)
+		}).AnyTimes()
+	})
+
+	AfterEach(func() {
+		mockController.Finish()
+		os.RemoveAll(tmpDir)
+	})
+
+	It("lists endpoints and masked credentials", func() {
+		Expect(credentialsCmd.Execute(cmd.Args{})).To(Succeed())
+
+		Expect(output).To(ContainSubstring("  api:           https://api.dev.cfdev.sh\n"))
+		Expect(output).To(MatchRegexp(`(?m)^  credhub-admin\s+credhub-admin\s+\*{8}\s+https://10.144.0.4:8844$`))
+		Expect(output).NotTo(ContainSubstring("some-credhub-secret"))
+	})
+
+	It("shows the values with --reveal", func() {
+		Expect(credentialsCmd.Execute(cmd.Args{Reveal: true})).To(Succeed())
+
+		Expect(output).To(MatchRegexp(`(?m)^  director\s+admin\s+some-director-secret\s+https://10.144.0.4:25555$`))
+	})
+
+	It("prints json with --json", func() {
+		Expect(credentialsCmd.Execute(cmd.Args{JSON: true})).To(Succeed())
+
+		var result struct {
+			Endpoints   map[string]string   `json:"endpoints"`
+			Credentials []map[string]string `json:"credentials"`
+		}
+		Expect(json.Unmarshal([]byte(output), &result)).To(Succeed())
+		Expect(result.Endpoints).To(HaveKeyWithValue("uaa", "https://uaa.dev.cfdev.sh"))
+		Expect(result.Credentials).To(ContainElement(map[string]string{
+			"name":     "credhub-admin",
+			"endpoint": "https://10.144.0.4:8844",
+			"username": "credhub-admin",
+			"value":    "********",
+		}))
+	})
+
+	It("prints a single value with --get", func() {
+		Expect(credentialsCmd.Execute(cmd.Args{Get: "credhub-admin"})).To(Succeed())
+		Expect(output).To(Equal("some-credhub-secret"))
+	})
+
+	It("fails for unknown names", func() {
+		err := credentialsCmd.Execute(cmd.Args{Get: "some-name"})
+		Expect(err).To(MatchError(ContainSubstring("unknown credential 'some-name'")))
+	})
+
+	Context("when CF Dev has not been started", func() {
+		It("returns an error", func() {
+			os.Remove(filepath.Join(tmpDir, "creds.yml"))
+
+			err := credentialsCmd.Execute(cmd.Args{})
+			Expect(err).To(MatchError(ContainSubstring("Please execute 'cf dev start'")))
+		})
+	})
+})
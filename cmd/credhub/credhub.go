@@ -0,0 +1,64 @@
+package credhub
+
+import (
+	"code.cloudfoundry.org/cfdev/bosh"
+	"code.cloudfoundry.org/cfdev/config"
+	"code.cloudfoundry.org/cfdev/errors"
+	"code.cloudfoundry.org/cfdev/shell"
+	"github.com/spf13/cobra"
+)
+
+//go:generate mockgen -package mocks -destination mocks/ui.go code.cloudfoundry.org/cfdev/cmd/credhub UI
+type UI interface {
+	Say(message string, args ...interface{})
+}
+
+type Credhub struct {
+	UI     UI
+	Config config.Config
+}
+
+func (c *Credhub) Cmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use: "credhub",
+		Run: func(cmd *cobra.Command, args []string) {
+			if shell.DefaultFormat() == shell.PowerShell {
+				c.UI.Say(`Usage: cf dev credhub env | Invoke-Expression`)
+			} else {
+				c.UI.Say(`Usage: eval $(cf dev credhub env)`)
+			}
+		},
+	}
+	var shellName string
+	envCmd := &cobra.Command{
+		Use:   "env",
+		Short: "Print environment variables targeting the BOSH Director's CredHub",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return c.Env(shellName)
+		},
+	}
+	envCmd.Flags().StringVar(&shellName, "shell", "", "format of the generated script: bash, zsh, fish, cmd, powershell, dotenv or json")
+	cmd.AddCommand(envCmd)
+	return cmd
+}
+
+func (c *Credhub) Env(shellName string) error {
+	format, err := shell.ParseFormat(shellName)
+	if err != nil {
+		return errors.SafeWrap(err, "invalid --shell")
+	}
+
+	config, err := bosh.FetchCredhubConfig(c.Config)
+	if err != nil {
+		return errors.SafeWrap(err, "failed to fetch credhub configuration")
+	}
+
+	env := shell.Environment{Format: format}
+	shellScript, err := env.PrepareCredhub(config)
+	if err != nil {
+		return errors.SafeWrap(err, "failed to prepare credhub configuration")
+	}
+
+	c.UI.Say(shellScript)
+	return nil
+}
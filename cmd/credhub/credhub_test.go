@@ -0,0 +1,97 @@
+package credhub_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	cmd "code.cloudfoundry.org/cfdev/cmd/credhub"
+	"code.cloudfoundry.org/cfdev/cmd/credhub/mocks"
+	"code.cloudfoundry.org/cfdev/config"
+	"github.com/golang/mock/gomock"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Credhub", func() {
+	var (
+		mockController *gomock.Controller
+		mockUI         *mocks.MockUI
+		tmpDir         string
+		credhubCmd     *cmd.Credhub
+	)
+
+	BeforeEach(func() {
+		mockController = gomock.NewController(GinkgoT())
+		mockUI = mocks.NewMockUI(mockController)
+
+		var err error
+		tmpDir, err = ioutil.TempDir("", "cmd-credhub-test")
+		Expect(err).NotTo(HaveOccurred())
+
+		ioutil.WriteFile(filepath.Join(tmpDir, "creds.yml"), []byte(`---
+credhub_admin_client_secret: some-credhub-secret
+credhub_tls:
+  ca: some-credhub-ca
+uaa_ssl:
+  ca: some-uaa-ca
+`), 0600)
+
+		credhubCmd = &cmd.Credhub{
+			UI: mockUI,
+			Config: config.Config{
+				StateBosh:      tmpDir,
+				BoshDirectorIP: "10.0.0.1",
+			},
+		}
+	})
+
+	AfterEach(func() {
+		mockController.Finish()
+		os.RemoveAll(tmpDir)
+	})
+
+	Describe("Env", func() {
+		It("prints the credhub target and client credentials", func() {
+			os.Unsetenv("CREDHUB_PROXY")
+			caPath := filepath.Join(tmpDir, "credhub-ca.crt")
+			mockUI.EXPECT().Say(`{
+  "CREDHUB_CA_CERT": "` + caPath + `",
+  "CREDHUB_CLIENT": "credhub-admin",
+  "CREDHUB_SECRET": "some-credhub-secret",
+  "CREDHUB_SERVER": "https://10.0.0.1:8844"
+}`)
+
+			Expect(credhubCmd.Env("json")).To(Succeed())
+			Expect(ioutil.ReadFile(caPath)).To(Equal([]byte("some-credhub-ca\nsome-uaa-ca\n")))
+		})
+
+		Context("when the environment has CREDHUB_* env vars set", func() {
+			BeforeEach(func() {
+				os.Setenv("CREDHUB_PROXY", "some-proxy")
+			})
+
+			AfterEach(func() {
+				os.Unsetenv("CREDHUB_PROXY")
+			})
+
+			It("unsets them", func() {
+				var script string
+				mockUI.EXPECT().Say(gomock.Any()).Do(func(message string, args ...interface{}) {
+					script = message
+				})
+
+				Expect(credhubCmd.Env("bash")).To(Succeed())
+				Expect(script).To(ContainSubstring("unset CREDHUB_PROXY;\nexport CREDHUB_SERVER=\"https://10.0.0.1:8844\";"))
+			})
+		})
+
+		Context("when the bosh state is missing", func() {
+			It("returns an error", func() {
+				os.Remove(filepath.Join(tmpDir, "creds.yml"))
+
+				Expect(credhubCmd.Env("")).To(MatchError(ContainSubstring("failed to fetch credhub configuration")))
+			})
+		})
+	})
+})
@@ -1,17 +1,18 @@
 package download
 
 import (
+	"context"
 	"io"
 	"os"
 	"strings"
 	"time"
 
-	"net/http"
-
 	"code.cloudfoundry.org/cfdev/config"
+	"code.cloudfoundry.org/cfdev/env"
 	"code.cloudfoundry.org/cfdev/errors"
 	"code.cloudfoundry.org/cfdev/resource"
 	"code.cloudfoundry.org/cfdev/resource/progress"
+	"code.cloudfoundry.org/cfdev/resource/retry"
 	"github.com/spf13/cobra"
 )
 
@@ -25,6 +26,10 @@ type Env interface {
 	CreateDirs() error
 }
 
+type Args struct {
+	Bundle string
+}
+
 type Download struct {
 	Exit   chan struct{}
 	UI     UI
@@ -33,15 +38,23 @@ type Download struct {
 }
 
 func (d *Download) Cmd() *cobra.Command {
-	return &cobra.Command{
-		Use:  "download",
-		RunE: d.RunE,
+	args := Args{}
+	cmd := &cobra.Command{
+		Use: "download",
+		RunE: func(_ *cobra.Command, _ []string) error {
+			return d.Execute(args)
+		},
 	}
+	cmd.Flags().StringVar(&args.Bundle, "bundle", "", "also package the assets into an offline bundle for 'cf dev import-bundle'")
+	return cmd
 }
 
-func (d *Download) RunE(cmd *cobra.Command, args []string) error {
+func (d *Download) Execute(args Args) error {
 	go func() {
 		<-d.Exit
+		if args.Bundle != "" {
+			os.Remove(args.Bundle + ".tmp")
+		}
 		os.Exit(128)
 	}()
 
@@ -49,24 +62,62 @@ func (d *Download) RunE(cmd *cobra.Command, args []string) error {
 		return errors.SafeWrap(err, "setup for download")
 	}
 
+	ctx := retry.Until(d.Exit)
 	d.UI.Say("Downloading Resources...")
-	return CacheSync(d.Config.Dependencies, d.Config.CacheDir, d.UI.Writer())
+	if err := CacheSync(ctx, d.Config, d.UI.Writer()); err != nil {
+		return err
+	}
+
+	if args.Bundle != "" {
+		return d.writeBundle(ctx, args.Bundle)
+	}
+	return nil
 }
 
-func CacheSync(dependencies resource.Catalog, cacheDir string, writer io.Writer) error {
-	skipVerify := strings.ToLower(os.Getenv("CFDEV_SKIP_ASSET_CHECK"))
+func (d *Download) writeBundle(ctx context.Context, path string) error {
+	d.UI.Say("Writing bundle to %s...", path)
+	tmpPath := path + ".tmp"
+	file, err := os.Create(tmpPath)
+	if err != nil {
+		return errors.SafeWrap(err, "failed to create bundle")
+	}
 
-	cache := resource.Cache{
-		Dir:                   cacheDir,
-		HttpDo:                http.DefaultClient.Do,
-		SkipAssetVerification: skipVerify == "true",
-		Progress:              progress.New(writer),
-		RetryWait:             time.Second,
-		Writer:                writer,
+	err = newCache(ctx, d.Config, d.UI.Writer()).ExportBundle(file, d.Config.Dependencies)
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(tmpPath, path)
 	}
+	if err != nil {
+		os.Remove(tmpPath)
+		return errors.SafeWrap(err, "failed to write bundle")
+	}
+
+	d.UI.Say("Bundle written. Import it with 'cf dev import-bundle %s'", path)
+	return nil
+}
 
-	if err := cache.Sync(dependencies); err != nil {
+func CacheSync(ctx context.Context, cfg config.Config, writer io.Writer) error {
+	if err := newCache(ctx, cfg, writer).Sync(cfg.Dependencies); err != nil {
 		return errors.SafeWrap(err, "Unable to sync assets")
 	}
 	return nil
 }
+
+func newCache(ctx context.Context, cfg config.Config, writer io.Writer) *resource.Cache {
+	skipVerify := strings.ToLower(os.Getenv("CFDEV_SKIP_ASSET_CHECK"))
+
+	return &resource.Cache{
+		Dir:                   cfg.CacheDir,
+		HttpDo:                env.HTTPDo(cfg),
+		SkipAssetVerification: skipVerify == "true",
+		Progress:              progress.NewWithRenderer(progress.Detect(writer, cfg.ProgressMode)),
+		RetryWait:             time.Second,
+		Writer:                writer,
+		Connections:           cfg.DownloadConnections,
+		SharedDir:             cfg.SharedCacheDir,
+		S3:                    cfg.S3,
+		Context:               ctx,
+	}
+}
@@ -0,0 +1,58 @@
+package importbundle
+
+import (
+	"io"
+	"os"
+
+	"code.cloudfoundry.org/cfdev/config"
+	e "code.cloudfoundry.org/cfdev/errors"
+	"code.cloudfoundry.org/cfdev/resource"
+	"github.com/spf13/cobra"
+)
+
+//go:generate mockgen -package mocks -destination mocks/ui.go code.cloudfoundry.org/cfdev/cmd/importbundle UI
+type UI interface {
+	Say(message string, args ...interface{})
+}
+
+//go:generate mockgen -package mocks -destination mocks/bundles.go code.cloudfoundry.org/cfdev/cmd/importbundle Bundles
+type Bundles interface {
+	ImportBundle(r io.Reader, clog resource.Catalog) ([]resource.Item, error)
+}
+
+type ImportBundle struct {
+	UI      UI
+	Config  config.Config
+	Bundles Bundles
+}
+
+func (i *ImportBundle) Cmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "import-bundle <file>",
+		Short: "Import an offline bundle created with 'cf dev download --bundle'",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(_ *cobra.Command, args []string) error {
+			return i.Execute(args[0])
+		},
+	}
+}
+
+func (i *ImportBundle) Execute(path string) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return e.SafeWrap(err, "failed to open bundle")
+	}
+	defer file.Close()
+
+	i.UI.Say("Importing bundle %s...", path)
+	items, err := i.Bundles.ImportBundle(file, i.Config.Dependencies)
+	if err != nil {
+		return e.SafeWrap(err, "failed to import bundle")
+	}
+
+	for _, item := range items {
+		i.UI.Say("  %s", item.Name)
+	}
+	i.UI.Say("Bundle imported. 'cf dev start' will not need to download any assets")
+	return nil
+}
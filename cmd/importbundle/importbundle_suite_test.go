@@ -0,0 +1,13 @@
+package importbundle_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"testing"
+)
+
+func TestImportBundle(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Cmd ImportBundle Suite")
+}
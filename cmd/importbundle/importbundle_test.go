@@ -0,0 +1,76 @@
+package importbundle_test
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"code.cloudfoundry.org/cfdev/cmd/importbundle"
+	"code.cloudfoundry.org/cfdev/cmd/importbundle/mocks"
+	"code.cloudfoundry.org/cfdev/config"
+	"code.cloudfoundry.org/cfdev/resource"
+	"github.com/golang/mock/gomock"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("ImportBundle", func() {
+	var (
+		mockController *gomock.Controller
+		mockUI         *mocks.MockUI
+		mockBundles    *mocks.MockBundles
+		importCmd      *importbundle.ImportBundle
+		catalog        resource.Catalog
+		tmpDir         string
+		bundlePath     string
+	)
+
+	BeforeEach(func() {
+		mockController = gomock.NewController(GinkgoT())
+		mockUI = mocks.NewMockUI(mockController)
+		mockBundles = mocks.NewMockBundles(mockController)
+
+		var err error
+		tmpDir, err = ioutil.TempDir("", "cfdev-cmd-import-bundle-")
+		Expect(err).NotTo(HaveOccurred())
+		bundlePath = filepath.Join(tmpDir, "bundle.tar")
+		Expect(ioutil.WriteFile(bundlePath, []byte("some-bundle"), 0644)).To(Succeed())
+
+		catalog = resource.Catalog{Items: []resource.Item{{Name: "cfdev-deps.tgz", InUse: true}}}
+		importCmd = &importbundle.ImportBundle{
+			UI:      mockUI,
+			Config:  config.Config{Dependencies: catalog},
+			Bundles: mockBundles,
+		}
+	})
+
+	AfterEach(func() {
+		mockController.Finish()
+		os.RemoveAll(tmpDir)
+	})
+
+	It("imports the bundle into the cache", func() {
+		gomock.InOrder(
+			mockUI.EXPECT().Say("Importing bundle %s...", bundlePath),
+			mockBundles.EXPECT().ImportBundle(gomock.Any(), catalog).Return([]resource.Item{{Name: "cfdev-deps.tgz"}, {Name: "cfdevd"}}, nil),
+			mockUI.EXPECT().Say("  %s", "cfdev-deps.tgz"),
+			mockUI.EXPECT().Say("  %s", "cfdevd"),
+			mockUI.EXPECT().Say("Bundle imported. 'cf dev start' will not need to download any assets"),
+		)
+
+		Expect(importCmd.Execute(bundlePath)).To(Succeed())
+	})
+
+	It("surfaces import errors", func() {
+		mockUI.EXPECT().Say("Importing bundle %s...", bundlePath)
+		mockBundles.EXPECT().ImportBundle(gomock.Any(), catalog).Return(nil, fmt.Errorf("not a cf dev bundle"))
+
+		Expect(importCmd.Execute(bundlePath)).To(MatchError("failed to import bundle: not a cf dev bundle"))
+	})
+
+	It("fails when the bundle does not exist", func() {
+		err := importCmd.Execute(filepath.Join(tmpDir, "missing.tar"))
+		Expect(err).To(MatchError(ContainSubstring("failed to open bundle")))
+	})
+})
@@ -0,0 +1,48 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: code.cloudfoundry.org/cfdev/cmd/importbundle (interfaces: Bundles)
+
+// Package mocks is a generated GoMock package.
+package mocks
+
+import (
+	resource "code.cloudfoundry.org/cfdev/resource"
+	gomock "github.com/golang/mock/gomock"
+	io "io"
+	reflect "reflect"
+)
+
+// MockBundles is a mock of Bundles interface
+type MockBundles struct {
+	ctrl     *gomock.Controller
+	recorder *MockBundlesMockRecorder
+}
+
+// MockBundlesMockRecorder is the mock recorder for MockBundles
+type MockBundlesMockRecorder struct {
+	mock *MockBundles
+}
+
+// NewMockBundles creates a new mock instance
+func NewMockBundles(ctrl *gomock.Controller) *MockBundles {
+	mock := &MockBundles{ctrl: ctrl}
+	mock.recorder = &MockBundlesMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use
+func (m *MockBundles) EXPECT() *MockBundlesMockRecorder {
+	return m.recorder
+}
+
+// ImportBundle mocks base method
+func (m *MockBundles) ImportBundle(arg0 io.Reader, arg1 resource.Catalog) ([]resource.Item, error) {
+	ret := m.ctrl.Call(m, "ImportBundle", arg0, arg1)
+	ret0, _ := ret[0].([]resource.Item)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ImportBundle indicates an expected call of ImportBundle
+func (mr *MockBundlesMockRecorder) ImportBundle(arg0, arg1 interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ImportBundle", reflect.TypeOf((*MockBundles)(nil).ImportBundle), arg0, arg1)
+}
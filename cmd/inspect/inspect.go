@@ -0,0 +1,125 @@
+package inspect
+
+import (
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"runtime"
+	"strings"
+
+	"code.cloudfoundry.org/cfdev/config"
+	"code.cloudfoundry.org/cfdev/deps"
+	e "code.cloudfoundry.org/cfdev/errors"
+	"github.com/spf13/cobra"
+)
+
+//go:generate mockgen -package mocks -destination mocks/ui.go code.cloudfoundry.org/cfdev/cmd/inspect UI
+type UI interface {
+	Say(message string, args ...interface{})
+}
+
+//go:generate mockgen -package mocks -destination mocks/inspector.go code.cloudfoundry.org/cfdev/cmd/inspect Inspector
+type Inspector interface {
+	Inspect(src, goos string) (deps.Report, error)
+}
+
+type Args struct {
+	File string
+	JSON bool
+	OS   string
+}
+
+type Inspect struct {
+	UI        UI
+	Config    config.Config
+	Inspector Inspector
+}
+
+func (i *Inspect) Cmd() *cobra.Command {
+	args := Args{}
+	cmd := &cobra.Command{
+		Use:   "inspect",
+		Short: "Validate and describe a deps tarball without starting",
+		Args:  cobra.NoArgs,
+		RunE: func(_ *cobra.Command, _ []string) error {
+			if err := i.Execute(args); err != nil {
+				return e.SafeWrap(err, "cf dev inspect")
+			}
+			return nil
+		},
+	}
+
+	pf := cmd.PersistentFlags()
+	pf.StringVarP(&args.File, "file", "f", "", "path to the deps tarball (default the cached cfdev-deps.tgz)")
+	pf.BoolVar(&args.JSON, "json", false, "print as json")
+	pf.StringVar(&args.OS, "os", runtime.GOOS, "operating system the deps are for (darwin or windows)")
+	return cmd
+}
+
+func (i *Inspect) Execute(args Args) error {
+	if args.OS != "darwin" && args.OS != "windows" {
+		return e.SafeWrap(nil, "--os must be darwin or windows")
+	}
+	if args.File == "" {
+		args.File = filepath.Join(i.Config.CacheDir, "cfdev-deps.tgz")
+	}
+
+	report, err := i.Inspector.Inspect(args.File, args.OS)
+	if err != nil {
+		return e.SafeWrap(err, fmt.Sprintf("Unable to inspect %s", args.File))
+	}
+
+	if args.JSON {
+		contents, err := json.MarshalIndent(report, "", "  ")
+		if err != nil {
+			return e.SafeWrap(err, "unable to marshal the report")
+		}
+		i.UI.Say("%s", string(contents))
+	} else {
+		i.say(report)
+	}
+
+	if len(report.Problems) > 0 {
+		return e.SafeWrap(nil, fmt.Sprintf("%s has problems", args.File))
+	}
+	return nil
+}
+
+func (i *Inspect) say(report deps.Report) {
+	i.UI.Say("File: %s", report.File)
+	i.UI.Say("Schema version: %d (compatibility version %s)", report.SchemaVersion, report.CompatibilityVersion)
+	if report.RequiredPlugin != "" {
+		i.UI.Say("Required plugin: %s", report.RequiredPlugin)
+	}
+	i.UI.Say("Deployment: %s", report.DeploymentName)
+	i.UI.Say("Default memory: %d MB", report.DefaultMemory)
+
+	if len(report.Versions) > 0 {
+		i.UI.Say("Versions:")
+		for _, version := range report.Versions {
+			i.UI.Say("  %s: %s", version.Name, version.Version)
+		}
+	}
+
+	if len(report.Services) > 0 {
+		i.UI.Say("Services:")
+		for _, service := range report.Services {
+			script := service.Script
+			if !service.ScriptPresent {
+				script += " (missing)"
+			}
+			i.UI.Say("  %s: flag %s, deployed by default %t, script %s, deployment %s",
+				service.Name, service.FlagName, service.DefaultDeploy, script, service.Deployment)
+		}
+	}
+
+	if len(report.MissingFiles) > 0 {
+		i.UI.Say("Missing files: %s", strings.Join(report.MissingFiles, ", "))
+	}
+	if len(report.UnknownKeys) > 0 {
+		i.UI.Say("Unknown keys: %s", strings.Join(report.UnknownKeys, ", "))
+	}
+	for _, problem := range report.Problems {
+		i.UI.Say("PROBLEM: %s", problem)
+	}
+}
@@ -0,0 +1,13 @@
+package inspect_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"testing"
+)
+
+func TestInspect(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Cmd Inspect Suite")
+}
@@ -0,0 +1,139 @@
+package inspect_test
+
+import (
+	"errors"
+	"path/filepath"
+
+	"code.cloudfoundry.org/cfdev/cmd/inspect"
+	"code.cloudfoundry.org/cfdev/cmd/inspect/mocks"
+	"code.cloudfoundry.org/cfdev/config"
+	"code.cloudfoundry.org/cfdev/deps"
+	"github.com/golang/mock/gomock"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Inspect", func() {
+	var (
+		mockController *gomock.Controller
+		mockUI         *mocks.MockUI
+		mockInspector  *mocks.MockInspector
+		inspectCmd     *inspect.Inspect
+		args           inspect.Args
+		report         deps.Report
+	)
+
+	BeforeEach(func() {
+		mockController = gomock.NewController(GinkgoT())
+		mockUI = mocks.NewMockUI(mockController)
+		mockInspector = mocks.NewMockInspector(mockController)
+
+		inspectCmd = &inspect.Inspect{
+			UI:        mockUI,
+			Config:    config.Config{CacheDir: "some-cache-dir"},
+			Inspector: mockInspector,
+		}
+		args = inspect.Args{File: "some-deps.tgz", OS: "darwin"}
+		report = deps.Report{
+			File:                 "some-deps.tgz",
+			SchemaVersion:        2,
+			RequiredPlugin:       ">=0.0.18",
+			CompatibilityVersion: "v3",
+			DeploymentName:       "cf",
+			DefaultMemory:        8192,
+			Versions:             []deps.Version{{Name: "cf", Version: "v1"}},
+			Services: []deps.Service{{
+				Name:          "Mysql",
+				FlagName:      "mysql",
+				DefaultDeploy: true,
+				Script:        "bin/deploy-mysql",
+				ScriptPresent: true,
+				Deployment:    "cf-mysql",
+			}},
+			MissingFiles: []string{},
+			UnknownKeys:  []string{"custom_field"},
+			Problems:     []string{},
+		}
+	})
+
+	AfterEach(func() {
+		mockController.Finish()
+	})
+
+	It("describes the deps", func() {
+		gomock.InOrder(
+			mockInspector.EXPECT().Inspect("some-deps.tgz", "darwin").Return(report, nil),
+			mockUI.EXPECT().Say("File: %s", "some-deps.tgz"),
+			mockUI.EXPECT().Say("Schema version: %d (compatibility version %s)", 2, "v3"),
+			mockUI.EXPECT().Say("Required plugin: %s", ">=0.0.18"),
+			mockUI.EXPECT().Say("Deployment: %s", "cf"),
+			mockUI.EXPECT().Say("Default memory: %d MB", 8192),
+			mockUI.EXPECT().Say("Versions:"),
+			mockUI.EXPECT().Say("  %s: %s", "cf", "v1"),
+			mockUI.EXPECT().Say("Services:"),
+			mockUI.EXPECT().Say("  %s: flag %s, deployed by default %t, script %s, deployment %s",
+				"Mysql", "mysql", true, "bin/deploy-mysql", "cf-mysql"),
+			mockUI.EXPECT().Say("Unknown keys: %s", "custom_field"),
+		)
+
+		Expect(inspectCmd.Execute(args)).To(Succeed())
+	})
+
+	It("inspects the cached deps by default", func() {
+		args.File = ""
+		mockInspector.EXPECT().Inspect(filepath.Join("some-cache-dir", "cfdev-deps.tgz"), "darwin").Return(report, nil)
+		mockUI.EXPECT().Say(gomock.Any()).AnyTimes()
+		mockUI.EXPECT().Say(gomock.Any(), gomock.Any()).AnyTimes()
+		mockUI.EXPECT().Say(gomock.Any(), gomock.Any(), gomock.Any()).AnyTimes()
+		mockUI.EXPECT().Say(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).AnyTimes()
+
+		Expect(inspectCmd.Execute(args)).To(Succeed())
+	})
+
+	It("prints the report as json", func() {
+		args.JSON = true
+		mockInspector.EXPECT().Inspect("some-deps.tgz", "darwin").Return(report, nil)
+		mockUI.EXPECT().Say("%s", gomock.Any()).Do(func(_ string, args ...interface{}) {
+			Expect(args[0]).To(ContainSubstring(`"schema_version": 2`))
+			Expect(args[0]).To(ContainSubstring(`"compatibility_version": "v3"`))
+			Expect(args[0]).To(ContainSubstring(`"flag_name": "mysql"`))
+			Expect(args[0]).To(ContainSubstring(`"missing_files": []`))
+		})
+
+		Expect(inspectCmd.Execute(args)).To(Succeed())
+	})
+
+	It("fails when the deps have problems", func() {
+		args.JSON = true
+		report.MissingFiles = []string{"disk.qcow2"}
+		report.Problems = []string{"disk.qcow2 is missing"}
+		mockInspector.EXPECT().Inspect("some-deps.tgz", "darwin").Return(report, nil)
+		mockUI.EXPECT().Say("%s", gomock.Any())
+
+		Expect(inspectCmd.Execute(args)).To(MatchError("some-deps.tgz has problems"))
+	})
+
+	It("lists the problems", func() {
+		report.Problems = []string{"disk.qcow2 is missing"}
+		mockInspector.EXPECT().Inspect("some-deps.tgz", "darwin").Return(report, nil)
+		mockUI.EXPECT().Say("PROBLEM: %s", "disk.qcow2 is missing")
+		mockUI.EXPECT().Say(gomock.Any()).AnyTimes()
+		mockUI.EXPECT().Say(gomock.Any(), gomock.Any()).AnyTimes()
+		mockUI.EXPECT().Say(gomock.Any(), gomock.Any(), gomock.Any()).AnyTimes()
+		mockUI.EXPECT().Say(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).AnyTimes()
+
+		Expect(inspectCmd.Execute(args)).To(MatchError("some-deps.tgz has problems"))
+	})
+
+	It("returns the errors of the inspection", func() {
+		mockInspector.EXPECT().Inspect("some-deps.tgz", "darwin").Return(deps.Report{}, errors.New("some-error"))
+
+		Expect(inspectCmd.Execute(args)).To(MatchError("Unable to inspect some-deps.tgz: some-error"))
+	})
+
+	It("only inspects deps for darwin and windows", func() {
+		args.OS = "linux"
+
+		Expect(inspectCmd.Execute(args)).To(MatchError("--os must be darwin or windows"))
+	})
+})
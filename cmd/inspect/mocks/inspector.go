@@ -0,0 +1,47 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: code.cloudfoundry.org/cfdev/cmd/inspect (interfaces: Inspector)
+
+// Package mocks is a generated GoMock package.
+package mocks
+
+import (
+	deps "code.cloudfoundry.org/cfdev/deps"
+	gomock "github.com/golang/mock/gomock"
+	reflect "reflect"
+)
+
+// MockInspector is a mock of Inspector interface
+type MockInspector struct {
+	ctrl     *gomock.Controller
+	recorder *MockInspectorMockRecorder
+}
+
+// MockInspectorMockRecorder is the mock recorder for MockInspector
+type MockInspectorMockRecorder struct {
+	mock *MockInspector
+}
+
+// NewMockInspector creates a new mock instance
+func NewMockInspector(ctrl *gomock.Controller) *MockInspector {
+	mock := &MockInspector{ctrl: ctrl}
+	mock.recorder = &MockInspectorMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use
+func (m *MockInspector) EXPECT() *MockInspectorMockRecorder {
+	return m.recorder
+}
+
+// Inspect mocks base method
+func (m *MockInspector) Inspect(arg0, arg1 string) (deps.Report, error) {
+	ret := m.ctrl.Call(m, "Inspect", arg0, arg1)
+	ret0, _ := ret[0].(deps.Report)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Inspect indicates an expected call of Inspect
+func (mr *MockInspectorMockRecorder) Inspect(arg0, arg1 interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Inspect", reflect.TypeOf((*MockInspector)(nil).Inspect), arg0, arg1)
+}
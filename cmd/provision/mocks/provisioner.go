@@ -5,6 +5,7 @@
 package mocks
 
 import (
+	docker "code.cloudfoundry.org/cfdev/docker"
 	provision "code.cloudfoundry.org/cfdev/provision"
 	gomock "github.com/golang/mock/gomock"
 	reflect "reflect"
@@ -46,7 +47,7 @@ func (mr *MockProvisionerMockRecorder) DeployBosh() *gomock.Call {
 }
 
 // DeployCloudFoundry mocks base method
-func (m *MockProvisioner) DeployCloudFoundry(arg0 provision.UI, arg1 []string) error {
+func (m *MockProvisioner) DeployCloudFoundry(arg0 provision.UI, arg1 docker.Config) error {
 	ret := m.ctrl.Call(m, "DeployCloudFoundry", arg0, arg1)
 	ret0, _ := ret[0].(error)
 	return ret0
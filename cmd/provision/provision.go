@@ -3,16 +3,17 @@ package provision
 import (
 	"code.cloudfoundry.org/cfdev/cmd/start"
 	"code.cloudfoundry.org/cfdev/config"
+	"code.cloudfoundry.org/cfdev/docker"
 	e "code.cloudfoundry.org/cfdev/errors"
+	"code.cloudfoundry.org/cfdev/health"
+	"code.cloudfoundry.org/cfdev/hooks"
 	"code.cloudfoundry.org/cfdev/metadata"
 	"code.cloudfoundry.org/cfdev/provision"
 	"fmt"
 	"github.com/spf13/cobra"
 	"io"
-	"net/url"
 	"os"
 	"path/filepath"
-	"strings"
 	"text/template"
 )
 
@@ -31,12 +32,20 @@ type MetaDataReader interface {
 type Provisioner interface {
 	Ping() error
 	DeployBosh() error
-	DeployCloudFoundry(provision.UI, []string) error
+	DeployCloudFoundry(provision.UI, docker.Config) error
 	WhiteListServices(string, []provision.Service) ([]provision.Service, error)
 	DeployServices(provision.UI, []provision.Service) error
 }
 
-const compatibilityVersion = "v3"
+//go:generate mockgen -package mocks -destination mocks/hooks.go code.cloudfoundry.org/cfdev/cmd/provision Hooks
+type Hooks interface {
+	Run(event hooks.Event) error
+}
+
+//go:generate mockgen -package mocks -destination mocks/verifier.go code.cloudfoundry.org/cfdev/cmd/provision Verifier
+type Verifier interface {
+	Verify() []health.Result
+}
 
 type Provision struct {
 	Exit           chan struct{}
@@ -44,6 +53,8 @@ type Provision struct {
 	Provisioner    Provisioner
 	MetaDataReader MetaDataReader
 	Config         config.Config
+	Hooks          Hooks
+	Verifier       Verifier
 }
 
 func (c *Provision) Cmd() *cobra.Command {
@@ -70,19 +81,22 @@ func (c *Provision) Execute(args start.Args) error {
 		return e.SafeWrap(err, fmt.Sprintf("something went wrong while reading the assets. Please execute 'cf dev start'"))
 	}
 
-	if metadataConfig.Version != compatibilityVersion {
-		return fmt.Errorf("asset version is incompatible with the current version of the plugin. Please execute 'cf dev start'")
+	if err := metadataConfig.Check(c.Config.CliVersion); err != nil {
+		return e.SafeWrap(err, "asset version is incompatible with the current version of the plugin")
 	}
 
-	registries, err := c.parseDockerRegistriesFlag(args.Registries)
+	registries, err := docker.Load(args.Registries, args.RegistryConfig)
 	if err != nil {
 		return e.SafeWrap(err, "Unable to parse docker registries")
 	}
+	if err := registries.Deployable(metadataConfig.DockerRegistryVars); err != nil {
+		return e.SafeWrap(err, "Unable to use docker registries")
+	}
 
 	return c.provision(metadataConfig, registries, args.DeploySingleService)
 }
 
-func (c *Provision) provision(metadataConfig metadata.Metadata, registries []string, deploySingleService string) error {
+func (c *Provision) provision(metadataConfig metadata.Metadata, registries docker.Config, deploySingleService string) error {
 	err := c.Provisioner.Ping()
 	if err != nil {
 		return e.SafeWrap(err, "VM is not running. Please execute 'cf dev start'")
@@ -93,11 +107,19 @@ func (c *Provision) provision(metadataConfig metadata.Metadata, registries []str
 		return e.SafeWrap(err, "Failed to deploy the BOSH Director")
 	}
 
+	if err := c.Hooks.Run(hooks.PostBosh); err != nil {
+		return err
+	}
+
 	c.UI.Say("Deploying CF...")
 	if err := c.Provisioner.DeployCloudFoundry(c.UI, registries); err != nil {
 		return e.SafeWrap(err, "Failed to deploy the Cloud Foundry")
 	}
 
+	if err := c.Hooks.Run(hooks.PostCF); err != nil {
+		return err
+	}
+
 	services, err := c.Provisioner.WhiteListServices(deploySingleService, metadataConfig.Services)
 	if err != nil {
 		return e.SafeWrap(err, "Failed to whitelist services")
@@ -107,6 +129,19 @@ func (c *Provision) provision(metadataConfig metadata.Metadata, registries []str
 		return e.SafeWrap(err, "Failed to deploy services")
 	}
 
+	c.UI.Say("Verifying CF...")
+	results := c.Verifier.Verify()
+	for _, line := range health.Checklist(results) {
+		c.UI.Say(line)
+	}
+	if err := health.Failed(results); err != nil {
+		return e.SafeWrap(err, "CF is not healthy")
+	}
+
+	if err := c.Hooks.Run(hooks.PostServices); err != nil {
+		return err
+	}
+
 	if metadataConfig.Message != "" {
 		t := template.Must(template.New("message").Parse(metadataConfig.Message))
 		err := t.Execute(c.UI.Writer(), map[string]string{"SYSTEM_DOMAIN": c.Config.CFDomain})
@@ -117,28 +152,3 @@ func (c *Provision) provision(metadataConfig metadata.Metadata, registries []str
 
 	return nil
 }
-
-func (c *Provision) parseDockerRegistriesFlag(flag string) ([]string, error) {
-	if flag == "" {
-		return nil, nil
-	}
-
-	values := strings.Split(flag, ",")
-
-	registries := make([]string, 0, len(values))
-
-	for _, value := range values {
-		// Including the // will cause url.Parse to validate 'value' as a host:port
-		u, err := url.Parse("//" + value)
-
-		if err != nil {
-			// Grab the more succinct error message
-			if urlErr, ok := err.(*url.Error); ok {
-				err = urlErr.Err
-			}
-			return nil, fmt.Errorf("'%v' - %v", value, err)
-		}
-		registries = append(registries, u.Host)
-	}
-	return registries, nil
-}
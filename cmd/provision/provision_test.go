@@ -5,6 +5,9 @@ import (
 	"code.cloudfoundry.org/cfdev/cmd/provision/mocks"
 	"code.cloudfoundry.org/cfdev/cmd/start"
 	"code.cloudfoundry.org/cfdev/config"
+	"code.cloudfoundry.org/cfdev/docker"
+	"code.cloudfoundry.org/cfdev/health"
+	"code.cloudfoundry.org/cfdev/hooks"
 	"code.cloudfoundry.org/cfdev/metadata"
 	prvsion "code.cloudfoundry.org/cfdev/provision"
 	"code.cloudfoundry.org/cli/cf/errors"
@@ -21,6 +24,8 @@ var _ = Describe("Provision", func() {
 		mockUI             *mocks.MockUI
 		mockMetadataReader *mocks.MockMetaDataReader
 		mockProvisioner    *mocks.MockProvisioner
+		mockHooks          *mocks.MockHooks
+		mockVerifier       *mocks.MockVerifier
 		cmd                *provision.Provision
 	)
 
@@ -29,6 +34,8 @@ var _ = Describe("Provision", func() {
 		mockUI = mocks.NewMockUI(mockController)
 		mockProvisioner = mocks.NewMockProvisioner(mockController)
 		mockMetadataReader = mocks.NewMockMetaDataReader(mockController)
+		mockHooks = mocks.NewMockHooks(mockController)
+		mockVerifier = mocks.NewMockVerifier(mockController)
 
 		localExitChan := make(chan struct{}, 3)
 
@@ -37,6 +44,8 @@ var _ = Describe("Provision", func() {
 			UI:             mockUI,
 			Provisioner:    mockProvisioner,
 			MetaDataReader: mockMetadataReader,
+			Hooks:          mockHooks,
+			Verifier:       mockVerifier,
 			Config: config.Config{
 				CacheDir: "some-cache-dir",
 			},
@@ -52,10 +61,16 @@ var _ = Describe("Provision", func() {
 				mockProvisioner.EXPECT().Ping(),
 				mockUI.EXPECT().Say("Deploying the BOSH Director..."),
 				mockProvisioner.EXPECT().DeployBosh(),
+				mockHooks.EXPECT().Run(hooks.PostBosh),
 				mockUI.EXPECT().Say("Deploying CF..."),
-				mockProvisioner.EXPECT().DeployCloudFoundry(mockUI, nil),
+				mockProvisioner.EXPECT().DeployCloudFoundry(mockUI, docker.Config{}),
+				mockHooks.EXPECT().Run(hooks.PostCF),
 				mockProvisioner.EXPECT().WhiteListServices("", nil).Return([]prvsion.Service{}, nil),
 				mockProvisioner.EXPECT().DeployServices(mockUI, []prvsion.Service{}),
+				mockUI.EXPECT().Say("Verifying CF..."),
+				mockVerifier.EXPECT().Verify().Return([]health.Result{{Name: "Cloud Controller API"}}),
+				mockUI.EXPECT().Say("  [OK]   Cloud Controller API"),
+				mockHooks.EXPECT().Run(hooks.PostServices),
 			)
 
 			err := cmd.Execute(start.Args{})
@@ -85,10 +100,21 @@ var _ = Describe("Provision", func() {
 				mockProvisioner.EXPECT().Ping(),
 				mockUI.EXPECT().Say("Deploying the BOSH Director..."),
 				mockProvisioner.EXPECT().DeployBosh(),
+				mockHooks.EXPECT().Run(hooks.PostBosh),
 				mockUI.EXPECT().Say("Deploying CF..."),
-				mockProvisioner.EXPECT().DeployCloudFoundry(mockUI, []string{"domain1.com", "domain2.com"}),
+				mockProvisioner.EXPECT().DeployCloudFoundry(mockUI, docker.Config{
+					Registries: []docker.Registry{
+						{Host: "domain1.com", Insecure: true},
+						{Host: "domain2.com", Insecure: true},
+					},
+				}),
+				mockHooks.EXPECT().Run(hooks.PostCF),
 				mockProvisioner.EXPECT().WhiteListServices("", nil).Return([]prvsion.Service{}, nil),
 				mockProvisioner.EXPECT().DeployServices(mockUI, []prvsion.Service{}),
+				mockUI.EXPECT().Say("Verifying CF..."),
+				mockVerifier.EXPECT().Verify().Return([]health.Result{{Name: "Cloud Controller API"}}),
+				mockUI.EXPECT().Say("  [OK]   Cloud Controller API"),
+				mockHooks.EXPECT().Run(hooks.PostServices),
 			)
 
 			err := cmd.Execute(start.Args{
@@ -98,6 +124,66 @@ var _ = Describe("Provision", func() {
 		})
 	})
 
+	Describe("when the docker registry flags are invalid", func() {
+		It("returns an error before provisioning", func() {
+			mockMetadataReader.EXPECT().Read(filepath.Join("some-cache-dir", "metadata.yml")).Return(metadata.Metadata{
+				Version: "v3",
+			}, nil)
+
+			err := cmd.Execute(start.Args{
+				Registries: "domain1.com:port",
+			})
+			Expect(err).To(MatchError(ContainSubstring("Unable to parse docker registries")))
+		})
+	})
+
+	Describe("when CF is not healthy after deploying", func() {
+		It("prints the checklist and returns an error", func() {
+			gomock.InOrder(
+				mockMetadataReader.EXPECT().Read(filepath.Join("some-cache-dir", "metadata.yml")).Return(metadata.Metadata{
+					Version: "v3",
+					Message: "some-splash-message",
+				}, nil),
+				mockProvisioner.EXPECT().Ping(),
+				mockUI.EXPECT().Say("Deploying the BOSH Director..."),
+				mockProvisioner.EXPECT().DeployBosh(),
+				mockHooks.EXPECT().Run(hooks.PostBosh),
+				mockUI.EXPECT().Say("Deploying CF..."),
+				mockProvisioner.EXPECT().DeployCloudFoundry(mockUI, docker.Config{}),
+				mockHooks.EXPECT().Run(hooks.PostCF),
+				mockProvisioner.EXPECT().WhiteListServices("", nil).Return([]prvsion.Service{}, nil),
+				mockProvisioner.EXPECT().DeployServices(mockUI, []prvsion.Service{}),
+				mockUI.EXPECT().Say("Verifying CF..."),
+				mockVerifier.EXPECT().Verify().Return([]health.Result{
+					{Name: "Cloud Controller API"},
+					{Name: "Gorouter", Err: errors.New("some-error")},
+				}),
+				mockUI.EXPECT().Say("  [OK]   Cloud Controller API"),
+				mockUI.EXPECT().Say("  [FAIL] Gorouter: some-error"),
+			)
+
+			err := cmd.Execute(start.Args{})
+			Expect(err).To(MatchError("CF is not healthy: Gorouter: some-error"))
+		})
+	})
+
+	Describe("when a hook fails", func() {
+		It("stops provisioning and returns the error", func() {
+			gomock.InOrder(
+				mockMetadataReader.EXPECT().Read(filepath.Join("some-cache-dir", "metadata.yml")).Return(metadata.Metadata{
+					Version: "v3",
+				}, nil),
+				mockProvisioner.EXPECT().Ping(),
+				mockUI.EXPECT().Say("Deploying the BOSH Director..."),
+				mockProvisioner.EXPECT().DeployBosh(),
+				mockHooks.EXPECT().Run(hooks.PostBosh).Return(errors.New("some-hook-error")),
+			)
+
+			err := cmd.Execute(start.Args{})
+			Expect(err).To(MatchError("some-hook-error"))
+		})
+	})
+
 	Describe("when the vm is not running", func() {
 		It("return an error", func() {
 			gomock.InOrder(
@@ -4,7 +4,6 @@ import (
 	"code.cloudfoundry.org/cfdev/env"
 	"code.cloudfoundry.org/cfdev/profiler"
 	"io"
-	"net/http"
 	"os"
 	"strings"
 	"time"
@@ -13,16 +12,34 @@ import (
 
 	"code.cloudfoundry.org/cfdev/cfanalytics"
 	cfdevdClient "code.cloudfoundry.org/cfdev/cfdevd/client"
+	"code.cloudfoundry.org/cfdev/channel"
 	b2 "code.cloudfoundry.org/cfdev/cmd/bosh"
+	b20 "code.cloudfoundry.org/cfdev/cmd/builddeps"
+	b17 "code.cloudfoundry.org/cfdev/cmd/cache"
 	b3 "code.cloudfoundry.org/cfdev/cmd/catalog"
+	b9 "code.cloudfoundry.org/cfdev/cmd/cp"
+	b15 "code.cloudfoundry.org/cfdev/cmd/credentials"
+	b12 "code.cloudfoundry.org/cfdev/cmd/credhub"
 	b4 "code.cloudfoundry.org/cfdev/cmd/download"
+	b18 "code.cloudfoundry.org/cfdev/cmd/importbundle"
+	b21 "code.cloudfoundry.org/cfdev/cmd/inspect"
 	b8 "code.cloudfoundry.org/cfdev/cmd/provision"
+	b10 "code.cloudfoundry.org/cfdev/cmd/snapshot"
 	b5 "code.cloudfoundry.org/cfdev/cmd/start"
 	b6 "code.cloudfoundry.org/cfdev/cmd/stop"
+	b16 "code.cloudfoundry.org/cfdev/cmd/target"
 	b7 "code.cloudfoundry.org/cfdev/cmd/telemetry"
+	b14 "code.cloudfoundry.org/cfdev/cmd/tunnel"
+	b13 "code.cloudfoundry.org/cfdev/cmd/uaa"
+	b11 "code.cloudfoundry.org/cfdev/cmd/up"
+	b19 "code.cloudfoundry.org/cfdev/cmd/upgrade"
 	b1 "code.cloudfoundry.org/cfdev/cmd/version"
 	"code.cloudfoundry.org/cfdev/config"
 	"code.cloudfoundry.org/cfdev/daemon"
+	"code.cloudfoundry.org/cfdev/deps"
+	"code.cloudfoundry.org/cfdev/environment"
+	"code.cloudfoundry.org/cfdev/health"
+	"code.cloudfoundry.org/cfdev/hooks"
 	"code.cloudfoundry.org/cfdev/host"
 	"code.cloudfoundry.org/cfdev/hypervisor"
 	"code.cloudfoundry.org/cfdev/metadata"
@@ -30,6 +47,11 @@ import (
 	"code.cloudfoundry.org/cfdev/provision"
 	"code.cloudfoundry.org/cfdev/resource"
 	"code.cloudfoundry.org/cfdev/resource/progress"
+	"code.cloudfoundry.org/cfdev/resource/retry"
+	"code.cloudfoundry.org/cfdev/signature"
+	"code.cloudfoundry.org/cfdev/snapshot"
+	"code.cloudfoundry.org/cfdev/ssh"
+	"code.cloudfoundry.org/cfdev/target"
 	"github.com/spf13/cobra"
 )
 
@@ -58,11 +80,12 @@ type Toggle interface {
 	SetProp(k, v string) error
 }
 
-func NewRoot(exit chan struct{}, ui UI, config config.Config, analyticsClient AnalyticsClient, analyticsToggle Toggle) *cobra.Command {
+func NewRoot(exit chan struct{}, ui UI, config config.Config, analyticsClient AnalyticsClient, analyticsToggle Toggle, connection *target.Connection, handlers ...hooks.Handler) *cobra.Command {
 	root := &cobra.Command{Use: "cf", SilenceUsage: true, SilenceErrors: true}
 	root.PersistentFlags().Bool("help", false, "")
 	root.PersistentFlags().Lookup("help").Hidden = true
 	lctl := daemon.New(config.CFDevHome)
+	ctx := retry.Until(exit)
 
 	usageTemplate := strings.Replace(root.UsageTemplate(), "\n"+`Use "{{.CommandPath}} [command] --help" for more information about a command.`, "", -1)
 	root.SetUsageTemplate(usageTemplate)
@@ -71,15 +94,26 @@ func NewRoot(exit chan struct{}, ui UI, config config.Config, analyticsClient An
 	writer := ui.Writer()
 	cache := &resource.Cache{
 		Dir:                   config.CacheDir,
-		HttpDo:                http.DefaultClient.Do,
+		Context:               ctx,
+		HttpDo:                env.HTTPDo(config),
 		SkipAssetVerification: skipVerify == "true",
-		Progress:              progress.New(writer),
+		Progress:              progress.NewWithRenderer(progress.Detect(writer, config.ProgressMode)),
 		RetryWait:             time.Second,
 		Writer:                writer,
+		Connections:           config.DownloadConnections,
+		SharedDir:             config.SharedCacheDir,
+		S3:                    config.S3,
 	}
 	linuxkit := &hypervisor.LinuxKit{Config: config, DaemonRunner: lctl}
-	vpnkit := &network.VpnKit{Config: config, DaemonRunner: lctl, Label: network.VpnKitLabel}
-	metaDataReader := metadata.New()
+	vpnkit := &network.VpnKit{Config: config, Context: ctx, DaemonRunner: lctl, Label: network.VpnKitLabel}
+	verifier := signature.Must(signature.New(config.SigningKey))
+	metaDataReader := &metadata.Reader{Verifier: verifier}
+	hks := &hooks.Hooks{
+		Config:   config,
+		UI:       ui,
+		Handlers: handlers,
+		Fatal:    strings.ToLower(os.Getenv("CFDEV_HOOKS_FATAL")) == "true",
+	}
 	analyticsD := &cfanalytics.AnalyticsD{
 		Config:       config,
 		DaemonRunner: lctl,
@@ -87,9 +121,54 @@ func NewRoot(exit chan struct{}, ui UI, config config.Config, analyticsClient An
 	provisionCmd := &b8.Provision{
 		Exit:           exit,
 		UI:             ui,
-		Provisioner:    provision.NewController(config),
+		Provisioner:    provision.NewController(ctx, config),
 		MetaDataReader: metaDataReader,
 		Config:         config,
+		Hooks:          hks,
+		Verifier:       health.New(ctx, config),
+	}
+
+	snapshots := snapshot.New(config)
+	targeter := &target.Target{Config: config, Connection: connection}
+	startCmd := &b5.Start{
+		Exit:            exit,
+		LocalExit:       make(chan string, 3),
+		UI:              ui,
+		Config:          config,
+		Cache:           cache,
+		Env:             &env.Env{Config: config, Verifier: verifier},
+		Analytics:       analyticsClient,
+		AnalyticsToggle: analyticsToggle,
+		HostNet: &network.HostNet{
+			CfdevdClient: cfdevdClient.New("CFD3V", config.CFDevDSocketPath),
+		},
+		Host: &host.Host{},
+		CFDevD: &network.CFDevD{
+			ExecutablePath: filepath.Join(config.CacheDir, "cfdevd"),
+			TimeSyncSocket: filepath.Join(config.StateLinuxkit, "00000003.0000f3a4"),
+		},
+		VpnKit:         vpnkit,
+		AnalyticsD:     analyticsD,
+		Hypervisor:     linuxkit,
+		Provisioner:    provision.NewController(ctx, config),
+		Provision:      provisionCmd,
+		MetaDataReader: metaDataReader,
+		Snapshots:      snapshots,
+		Hooks:          hks,
+		Targeter:       targeter,
+		Stop: &b6.Stop{
+			Config:     config,
+			Analytics:  analyticsClient,
+			Hypervisor: linuxkit,
+			HostNet: &network.HostNet{
+				CfdevdClient: cfdevdClient.New("CFD3V", config.CFDevDSocketPath),
+			},
+			Host:         &host.Host{},
+			AnalyticsD:   analyticsD,
+			VpnKit:       vpnkit,
+			CfdevdClient: cfdevdClient.New("CFD3V", config.CFDevDSocketPath),
+		},
+		Profiler: &profiler.SystemProfiler{},
 	}
 
 	dev := &cobra.Command{
@@ -117,49 +196,33 @@ func NewRoot(exit chan struct{}, ui UI, config config.Config, analyticsClient An
 			UI:     ui,
 			Config: config,
 		},
+		&b17.Cache{
+			UI:         ui,
+			Config:     config,
+			Inventory:  cache,
+			Hypervisor: linuxkit,
+		},
 		&b4.Download{
 			Exit:   exit,
 			UI:     ui,
 			Config: config,
-			Env:    &env.Env{Config: config},
+			Env:    &env.Env{Config: config, Verifier: verifier},
 		},
-		&b5.Start{
-			Exit:            exit,
-			LocalExit:       make(chan string, 3),
-			UI:              ui,
-			Config:          config,
-			Cache:           cache,
-			Env:             &env.Env{Config: config},
-			Analytics:       analyticsClient,
-			AnalyticsToggle: analyticsToggle,
-			HostNet: &network.HostNet{
-				CfdevdClient: cfdevdClient.New("CFD3V", config.CFDevDSocketPath),
-			},
-			Host: &host.Host{},
-			CFDevD: &network.CFDevD{
-				ExecutablePath: filepath.Join(config.CacheDir, "cfdevd"),
-				TimeSyncSocket: filepath.Join(config.StateLinuxkit, "00000003.0000f3a4"),
-			},
-			VpnKit:         vpnkit,
-			AnalyticsD:     analyticsD,
-			Hypervisor:     linuxkit,
-			Provisioner:    provision.NewController(config),
-			Provision:      provisionCmd,
-			MetaDataReader: metaDataReader,
-			Stop: &b6.Stop{
-				Config:     config,
-				Analytics:  analyticsClient,
-				Hypervisor: linuxkit,
-				HostNet: &network.HostNet{
-					CfdevdClient: cfdevdClient.New("CFD3V", config.CFDevDSocketPath),
-				},
-				Host:         &host.Host{},
-				AnalyticsD:   analyticsD,
-				VpnKit:       vpnkit,
-				CfdevdClient: cfdevdClient.New("CFD3V", config.CFDevDSocketPath),
-			},
-			Profiler: &profiler.SystemProfiler{},
+		&b18.ImportBundle{
+			UI:      ui,
+			Config:  config,
+			Bundles: cache,
+		},
+		&b20.BuildDeps{
+			UI:      ui,
+			Builder: &deps.Builder{},
 		},
+		&b21.Inspect{
+			UI:        ui,
+			Config:    config,
+			Inspector: &deps.Inspector{Verifier: verifier, PluginVersion: config.CliVersion},
+		},
+		startCmd,
 		&b6.Stop{
 			Config:     config,
 			Analytics:  analyticsClient,
@@ -171,6 +234,7 @@ func NewRoot(exit chan struct{}, ui UI, config config.Config, analyticsClient An
 			AnalyticsD:   analyticsD,
 			VpnKit:       vpnkit,
 			CfdevdClient: cfdevdClient.New("CFD3V", config.CFDevDSocketPath),
+			Hooks:        hks,
 		},
 		&b7.Telemetry{
 			UI:              ui,
@@ -178,7 +242,66 @@ func NewRoot(exit chan struct{}, ui UI, config config.Config, analyticsClient An
 			AnalyticsToggle: analyticsToggle,
 			AnalyticsD:      analyticsD,
 		},
+		&b9.Copy{
+			Exit:   exit,
+			UI:     ui,
+			Config: config,
+			SSH:    &ssh.SSH{Context: ctx, Writer: writer},
+		},
 		provisionCmd,
+		&b10.Snapshot{
+			Exit:       exit,
+			UI:         ui,
+			Config:     config,
+			Snapshots:  snapshots,
+			Hypervisor: linuxkit,
+			Start:      startCmd,
+		},
+		&b11.Up{
+			Exit:           exit,
+			UI:             ui,
+			Config:         config,
+			Hypervisor:     linuxkit,
+			Start:          startCmd,
+			Environment:    &environment.Client{Config: config},
+			MetaDataReader: metaDataReader,
+		},
+		&b12.Credhub{
+			UI:     ui,
+			Config: config,
+		},
+		&b13.UAA{
+			UI:     ui,
+			Config: config,
+		},
+		&b14.Tunnel{
+			Exit:   exit,
+			UI:     ui,
+			Config: config,
+			SSH:    &ssh.SSH{Context: ctx, Writer: writer},
+		},
+		&b15.Credentials{
+			UI:     ui,
+			Config: config,
+		},
+		&b16.Target{
+			UI:       ui,
+			Targeter: targeter,
+		},
+		&b19.Upgrade{
+			UI:     ui,
+			Config: config,
+			Index: &channel.Client{
+				URL:      config.CatalogIndexURL,
+				HttpDo:   cache.HttpDo,
+				Verifier: verifier,
+			},
+			Cache:      cache,
+			Hypervisor: linuxkit,
+			Stop:       startCmd.Stop,
+			Start:      startCmd,
+			Hooks:      hks,
+		},
 	} {
 		dev.AddCommand(cmd.Cmd())
 	}
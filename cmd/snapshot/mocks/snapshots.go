@@ -0,0 +1,123 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: code.cloudfoundry.org/cfdev/cmd/snapshot (interfaces: Snapshots)
+
+// Package mocks is a generated GoMock package.
+package mocks
+
+import (
+	snapshot "code.cloudfoundry.org/cfdev/snapshot"
+	gomock "github.com/golang/mock/gomock"
+	io "io"
+	reflect "reflect"
+)
+
+// MockSnapshots is a mock of Snapshots interface
+type MockSnapshots struct {
+	ctrl     *gomock.Controller
+	recorder *MockSnapshotsMockRecorder
+}
+
+// MockSnapshotsMockRecorder is the mock recorder for MockSnapshots
+type MockSnapshotsMockRecorder struct {
+	mock *MockSnapshots
+}
+
+// NewMockSnapshots creates a new mock instance
+func NewMockSnapshots(ctrl *gomock.Controller) *MockSnapshots {
+	mock := &MockSnapshots{ctrl: ctrl}
+	mock.recorder = &MockSnapshotsMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use
+func (m *MockSnapshots) EXPECT() *MockSnapshotsMockRecorder {
+	return m.recorder
+}
+
+// ArgsPath mocks base method
+func (m *MockSnapshots) ArgsPath(arg0 string) string {
+	ret := m.ctrl.Call(m, "ArgsPath", arg0)
+	ret0, _ := ret[0].(string)
+	return ret0
+}
+
+// ArgsPath indicates an expected call of ArgsPath
+func (mr *MockSnapshotsMockRecorder) ArgsPath(arg0 interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ArgsPath", reflect.TypeOf((*MockSnapshots)(nil).ArgsPath), arg0)
+}
+
+// Delete mocks base method
+func (m *MockSnapshots) Delete(arg0 string) error {
+	ret := m.ctrl.Call(m, "Delete", arg0)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Delete indicates an expected call of Delete
+func (mr *MockSnapshotsMockRecorder) Delete(arg0 interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Delete", reflect.TypeOf((*MockSnapshots)(nil).Delete), arg0)
+}
+
+// Export mocks base method
+func (m *MockSnapshots) Export(arg0 string, arg1 io.Writer) error {
+	ret := m.ctrl.Call(m, "Export", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Export indicates an expected call of Export
+func (mr *MockSnapshotsMockRecorder) Export(arg0, arg1 interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Export", reflect.TypeOf((*MockSnapshots)(nil).Export), arg0, arg1)
+}
+
+// Get mocks base method
+func (m *MockSnapshots) Get(arg0 string) (snapshot.Snapshot, error) {
+	ret := m.ctrl.Call(m, "Get", arg0)
+	ret0, _ := ret[0].(snapshot.Snapshot)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Get indicates an expected call of Get
+func (mr *MockSnapshotsMockRecorder) Get(arg0 interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Get", reflect.TypeOf((*MockSnapshots)(nil).Get), arg0)
+}
+
+// Import mocks base method
+func (m *MockSnapshots) Import(arg0 io.Reader, arg1 string) (snapshot.Snapshot, error) {
+	ret := m.ctrl.Call(m, "Import", arg0, arg1)
+	ret0, _ := ret[0].(snapshot.Snapshot)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Import indicates an expected call of Import
+func (mr *MockSnapshotsMockRecorder) Import(arg0, arg1 interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Import", reflect.TypeOf((*MockSnapshots)(nil).Import), arg0, arg1)
+}
+
+// List mocks base method
+func (m *MockSnapshots) List() ([]snapshot.Snapshot, error) {
+	ret := m.ctrl.Call(m, "List")
+	ret0, _ := ret[0].([]snapshot.Snapshot)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// List indicates an expected call of List
+func (mr *MockSnapshotsMockRecorder) List() *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "List", reflect.TypeOf((*MockSnapshots)(nil).List))
+}
+
+// Save mocks base method
+func (m *MockSnapshots) Save(arg0 string) (snapshot.Snapshot, error) {
+	ret := m.ctrl.Call(m, "Save", arg0)
+	ret0, _ := ret[0].(snapshot.Snapshot)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Save indicates an expected call of Save
+func (mr *MockSnapshotsMockRecorder) Save(arg0 interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Save", reflect.TypeOf((*MockSnapshots)(nil).Save), arg0)
+}
@@ -0,0 +1,46 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: code.cloudfoundry.org/cfdev/cmd/snapshot (interfaces: Start)
+
+// Package mocks is a generated GoMock package.
+package mocks
+
+import (
+	start "code.cloudfoundry.org/cfdev/cmd/start"
+	gomock "github.com/golang/mock/gomock"
+	reflect "reflect"
+)
+
+// MockStart is a mock of Start interface
+type MockStart struct {
+	ctrl     *gomock.Controller
+	recorder *MockStartMockRecorder
+}
+
+// MockStartMockRecorder is the mock recorder for MockStart
+type MockStartMockRecorder struct {
+	mock *MockStart
+}
+
+// NewMockStart creates a new mock instance
+func NewMockStart(ctrl *gomock.Controller) *MockStart {
+	mock := &MockStart{ctrl: ctrl}
+	mock.recorder = &MockStartMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use
+func (m *MockStart) EXPECT() *MockStartMockRecorder {
+	return m.recorder
+}
+
+// Execute mocks base method
+func (m *MockStart) Execute(arg0 start.Args) error {
+	ret := m.ctrl.Call(m, "Execute", arg0)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Execute indicates an expected call of Execute
+func (mr *MockStartMockRecorder) Execute(arg0 interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Execute", reflect.TypeOf((*MockStart)(nil).Execute), arg0)
+}
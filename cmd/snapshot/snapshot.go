@@ -0,0 +1,234 @@
+package snapshot
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"code.cloudfoundry.org/bytefmt"
+	"code.cloudfoundry.org/cfdev/cmd/start"
+	"code.cloudfoundry.org/cfdev/config"
+	e "code.cloudfoundry.org/cfdev/errors"
+	"code.cloudfoundry.org/cfdev/resource"
+	"code.cloudfoundry.org/cfdev/snapshot"
+	"github.com/spf13/cobra"
+)
+
+//go:generate mockgen -package mocks -destination mocks/ui.go code.cloudfoundry.org/cfdev/cmd/snapshot UI
+type UI interface {
+	Say(message string, args ...interface{})
+	Writer() io.Writer
+}
+
+//go:generate mockgen -package mocks -destination mocks/snapshots.go code.cloudfoundry.org/cfdev/cmd/snapshot Snapshots
+type Snapshots interface {
+	Save(name string) (snapshot.Snapshot, error)
+	List() ([]snapshot.Snapshot, error)
+	Get(name string) (snapshot.Snapshot, error)
+	Delete(name string) error
+	Export(name string, w io.Writer) error
+	Import(r io.Reader, name string) (snapshot.Snapshot, error)
+	ArgsPath(name string) string
+}
+
+//go:generate mockgen -package mocks -destination mocks/hypervisor.go code.cloudfoundry.org/cfdev/cmd/snapshot Hypervisor
+type Hypervisor interface {
+	IsRunning(vmName string) (bool, error)
+}
+
+//go:generate mockgen -package mocks -destination mocks/start.go code.cloudfoundry.org/cfdev/cmd/snapshot Start
+type Start interface {
+	Execute(args start.Args) error
+}
+
+type Snapshot struct {
+	Exit       chan struct{}
+	UI         UI
+	Config     config.Config
+	Snapshots  Snapshots
+	Hypervisor Hypervisor
+	Start      Start
+}
+
+func (s *Snapshot) Cmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "snapshot",
+		Short: "Save, restore and share snapshots of a deployed environment",
+	}
+
+	importName := ""
+	importCmd := &cobra.Command{
+		Use:   "import <file>",
+		Short: "Import a snapshot archive created with 'cf dev snapshot export'",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(_ *cobra.Command, args []string) error {
+			return s.Import(args[0], importName)
+		},
+	}
+	importCmd.Flags().StringVar(&importName, "name", "", "name to store the snapshot under (defaults to the exported name)")
+
+	cmd.AddCommand(
+		&cobra.Command{
+			Use:   "save <name>",
+			Short: "Save the stopped environment as a named snapshot",
+			Args:  cobra.ExactArgs(1),
+			RunE: func(_ *cobra.Command, args []string) error {
+				return s.Save(args[0])
+			},
+		},
+		&cobra.Command{
+			Use:   "list",
+			Short: "List saved snapshots",
+			Args:  cobra.NoArgs,
+			RunE: func(_ *cobra.Command, _ []string) error {
+				return s.List()
+			},
+		},
+		&cobra.Command{
+			Use:   "restore <name>",
+			Short: "Start the environment from a snapshot without redeploying",
+			Args:  cobra.ExactArgs(1),
+			RunE: func(_ *cobra.Command, args []string) error {
+				return s.Restore(args[0])
+			},
+		},
+		&cobra.Command{
+			Use:   "delete <name>",
+			Short: "Delete a snapshot",
+			Args:  cobra.ExactArgs(1),
+			RunE: func(_ *cobra.Command, args []string) error {
+				return s.Delete(args[0])
+			},
+		},
+		&cobra.Command{
+			Use:   "export <name> <file>",
+			Short: "Export a snapshot into a single portable archive",
+			Args:  cobra.ExactArgs(2),
+			RunE: func(_ *cobra.Command, args []string) error {
+				return s.Export(args[0], args[1])
+			},
+		},
+		importCmd,
+	)
+
+	return cmd
+}
+
+func (s *Snapshot) Save(name string) error {
+	running, err := s.Hypervisor.IsRunning("cfdev")
+	if err != nil {
+		return e.SafeWrap(err, "is running")
+	} else if running {
+		return e.SafeWrap(nil, "CF Dev is running. Please execute 'cf dev stop' before saving a snapshot")
+	}
+
+	s.UI.Say("Saving snapshot '%s'...", name)
+	snap, err := s.Snapshots.Save(name)
+	if err != nil {
+		return e.SafeWrap(err, "failed to save snapshot")
+	}
+
+	s.UI.Say("Snapshot '%s' saved (%s)", snap.Name, bytefmt.ByteSize(uint64(snap.Size)))
+	return nil
+}
+
+func (s *Snapshot) List() error {
+	snapshots, err := s.Snapshots.List()
+	if err != nil {
+		return e.SafeWrap(err, "failed to list snapshots")
+	}
+
+	if len(snapshots) == 0 {
+		s.UI.Say("No snapshots found")
+		return nil
+	}
+
+	lines := []string{fmt.Sprintf("%-24s %-20s %-10s %s", "NAME", "CREATED", "SIZE", "PLUGIN VERSION")}
+	for _, snap := range snapshots {
+		lines = append(lines, fmt.Sprintf("%-24s %-20s %-10s %s",
+			snap.Name,
+			snap.CreatedAt.Local().Format("2006-01-02 15:04:05"),
+			bytefmt.ByteSize(uint64(snap.Size)),
+			snap.PluginVersion))
+	}
+
+	s.UI.Say(strings.Join(lines, "\n"))
+	return nil
+}
+
+func (s *Snapshot) Restore(name string) error {
+	if _, err := s.Snapshots.Get(name); err != nil {
+		return err
+	}
+
+	args, err := start.ReadArgs(s.Snapshots.ArgsPath(name))
+	if os.IsNotExist(err) {
+		args = start.Args{Cpus: 4}
+	} else if err != nil {
+		return e.SafeWrap(err, "failed to read snapshot start arguments")
+	}
+
+	if args.DepsPath != "" && !resource.IsRemote(args.DepsPath) {
+		if _, err := os.Stat(args.DepsPath); err != nil {
+			return e.SafeWrap(nil, fmt.Sprintf("snapshot '%s' was taken with the deps file %s, which could not be found", name, args.DepsPath))
+		}
+	}
+
+	args.Snapshot = name
+	args.NoProvision = false
+
+	if err := s.Start.Execute(args); err != nil {
+		return e.SafeWrap(err, "cf dev snapshot restore")
+	}
+	return nil
+}
+
+func (s *Snapshot) Delete(name string) error {
+	if err := s.Snapshots.Delete(name); err != nil {
+		return e.SafeWrap(err, "failed to delete snapshot")
+	}
+
+	s.UI.Say("Snapshot '%s' deleted", name)
+	return nil
+}
+
+func (s *Snapshot) Export(name string, path string) error {
+	go func() {
+		<-s.Exit
+		os.Remove(path)
+		os.Exit(128)
+	}()
+
+	f, err := os.Create(path)
+	if err != nil {
+		return e.SafeWrap(err, "failed to create export file")
+	}
+	defer f.Close()
+
+	s.UI.Say("Exporting snapshot '%s' to %s...", name, path)
+	if err := s.Snapshots.Export(name, f); err != nil {
+		f.Close()
+		os.Remove(path)
+		return e.SafeWrap(err, "failed to export snapshot")
+	}
+
+	return nil
+}
+
+func (s *Snapshot) Import(path string, name string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return e.SafeWrap(err, "failed to open snapshot archive")
+	}
+	defer f.Close()
+
+	s.UI.Say("Importing snapshot from %s...", path)
+	snap, err := s.Snapshots.Import(f, name)
+	if err != nil {
+		return e.SafeWrap(err, "failed to import snapshot")
+	}
+
+	s.UI.Say("Snapshot '%s' imported. Run 'cf dev snapshot restore %s' to start from it", snap.Name, snap.Name)
+	return nil
+}
@@ -0,0 +1,119 @@
+package snapshot_test
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"code.cloudfoundry.org/cfdev/cmd/snapshot"
+	"code.cloudfoundry.org/cfdev/cmd/snapshot/mocks"
+	"code.cloudfoundry.org/cfdev/cmd/start"
+	snap "code.cloudfoundry.org/cfdev/snapshot"
+	"github.com/golang/mock/gomock"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Snapshot", func() {
+	var (
+		mockController *gomock.Controller
+		mockUI         *mocks.MockUI
+		mockSnapshots  *mocks.MockSnapshots
+		mockHypervisor *mocks.MockHypervisor
+		mockStart      *mocks.MockStart
+		snapshotCmd    *snapshot.Snapshot
+		tmpDir         string
+	)
+
+	BeforeEach(func() {
+		mockController = gomock.NewController(GinkgoT())
+		mockUI = mocks.NewMockUI(mockController)
+		mockSnapshots = mocks.NewMockSnapshots(mockController)
+		mockHypervisor = mocks.NewMockHypervisor(mockController)
+		mockStart = mocks.NewMockStart(mockController)
+
+		var err error
+		tmpDir, err = ioutil.TempDir("", "cfdev-cmd-snapshot-")
+		Expect(err).NotTo(HaveOccurred())
+
+		snapshotCmd = &snapshot.Snapshot{
+			UI:         mockUI,
+			Snapshots:  mockSnapshots,
+			Hypervisor: mockHypervisor,
+			Start:      mockStart,
+		}
+	})
+
+	AfterEach(func() {
+		mockController.Finish()
+		os.RemoveAll(tmpDir)
+	})
+
+	Describe("Save", func() {
+		It("saves a snapshot of the stopped environment", func() {
+			gomock.InOrder(
+				mockHypervisor.EXPECT().IsRunning("cfdev").Return(false, nil),
+				mockUI.EXPECT().Say("Saving snapshot '%s'...", "some-name"),
+				mockSnapshots.EXPECT().Save("some-name").Return(snap.Snapshot{Name: "some-name", Size: 2048}, nil),
+				mockUI.EXPECT().Say("Snapshot '%s' saved (%s)", "some-name", "2K"),
+			)
+
+			Expect(snapshotCmd.Save("some-name")).To(Succeed())
+		})
+
+		It("refuses to save while the VM is running", func() {
+			mockHypervisor.EXPECT().IsRunning("cfdev").Return(true, nil)
+
+			Expect(snapshotCmd.Save("some-name")).To(MatchError(ContainSubstring("cf dev stop")))
+		})
+	})
+
+	Describe("Restore", func() {
+		var argsPath string
+
+		BeforeEach(func() {
+			argsPath = filepath.Join(tmpDir, "start-args.json")
+			Expect(start.WriteArgs(argsPath, start.Args{Cpus: 6, Mem: 8192, DeploySingleService: "mysql"})).To(Succeed())
+		})
+
+		It("starts the environment with the saved arguments", func() {
+			mockSnapshots.EXPECT().Get("some-name").Return(snap.Snapshot{Name: "some-name"}, nil)
+			mockSnapshots.EXPECT().ArgsPath("some-name").Return(argsPath)
+			mockStart.EXPECT().Execute(start.Args{
+				Cpus:                6,
+				Mem:                 8192,
+				DeploySingleService: "mysql",
+				Snapshot:            "some-name",
+			})
+
+			Expect(snapshotCmd.Restore("some-name")).To(Succeed())
+		})
+
+		It("returns an error when the snapshot does not exist", func() {
+			mockSnapshots.EXPECT().Get("some-name").Return(snap.Snapshot{}, fmt.Errorf("snapshot 'some-name' does not exist"))
+
+			Expect(snapshotCmd.Restore("some-name")).To(MatchError("snapshot 'some-name' does not exist"))
+		})
+	})
+
+	Describe("List", func() {
+		It("reports when there are no snapshots", func() {
+			mockSnapshots.EXPECT().List().Return(nil, nil)
+			mockUI.EXPECT().Say("No snapshots found")
+
+			Expect(snapshotCmd.List()).To(Succeed())
+		})
+	})
+
+	Describe("Export", func() {
+		It("removes the partial archive on failure", func() {
+			path := filepath.Join(tmpDir, "export.tgz")
+			mockUI.EXPECT().Say("Exporting snapshot '%s' to %s...", "some-name", path)
+			mockSnapshots.EXPECT().Export("some-name", gomock.Any()).Return(fmt.Errorf("some-error"))
+
+			Expect(snapshotCmd.Export("some-name", path)).To(MatchError(ContainSubstring("some-error")))
+			Expect(path).NotTo(BeAnExistingFile())
+		})
+	})
+})
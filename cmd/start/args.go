@@ -0,0 +1,44 @@
+package start
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"code.cloudfoundry.org/cfdev/config"
+)
+
+const argsFile = "start-args.json"
+
+func ArgsPath(cfg config.Config) string {
+	return filepath.Join(cfg.StateDir, argsFile)
+}
+
+func WriteArgs(path string, args Args) error {
+	args.Snapshot = ""
+
+	contents, err := json.MarshalIndent(args, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+
+	return ioutil.WriteFile(path, contents, 0644)
+}
+
+func ReadArgs(path string) (Args, error) {
+	contents, err := ioutil.ReadFile(path)
+	if err != nil {
+		return Args{}, err
+	}
+
+	var args Args
+	if err := json.Unmarshal(contents, &args); err != nil {
+		return Args{}, err
+	}
+	return args, nil
+}
@@ -33,6 +33,19 @@ func (m *MockCache) EXPECT() *MockCacheMockRecorder {
 	return m.recorder
 }
 
+// ReadURL mocks base method
+func (m *MockCache) ReadURL(arg0 string) ([]byte, error) {
+	ret := m.ctrl.Call(m, "ReadURL", arg0)
+	ret0, _ := ret[0].([]byte)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ReadURL indicates an expected call of ReadURL
+func (mr *MockCacheMockRecorder) ReadURL(arg0 interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReadURL", reflect.TypeOf((*MockCache)(nil).ReadURL), arg0)
+}
+
 // Sync mocks base method
 func (m *MockCache) Sync(arg0 resource.Catalog) error {
 	ret := m.ctrl.Call(m, "Sync", arg0)
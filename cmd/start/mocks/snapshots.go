@@ -0,0 +1,45 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: code.cloudfoundry.org/cfdev/cmd/start (interfaces: Snapshots)
+
+// Package mocks is a generated GoMock package.
+package mocks
+
+import (
+	gomock "github.com/golang/mock/gomock"
+	reflect "reflect"
+)
+
+// MockSnapshots is a mock of Snapshots interface
+type MockSnapshots struct {
+	ctrl     *gomock.Controller
+	recorder *MockSnapshotsMockRecorder
+}
+
+// MockSnapshotsMockRecorder is the mock recorder for MockSnapshots
+type MockSnapshotsMockRecorder struct {
+	mock *MockSnapshots
+}
+
+// NewMockSnapshots creates a new mock instance
+func NewMockSnapshots(ctrl *gomock.Controller) *MockSnapshots {
+	mock := &MockSnapshots{ctrl: ctrl}
+	mock.recorder = &MockSnapshotsMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use
+func (m *MockSnapshots) EXPECT() *MockSnapshotsMockRecorder {
+	return m.recorder
+}
+
+// Restore mocks base method
+func (m *MockSnapshots) Restore(arg0 string) error {
+	ret := m.ctrl.Call(m, "Restore", arg0)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Restore indicates an expected call of Restore
+func (mr *MockSnapshotsMockRecorder) Restore(arg0 interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Restore", reflect.TypeOf((*MockSnapshots)(nil).Restore), arg0)
+}
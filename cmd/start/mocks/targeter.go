@@ -0,0 +1,46 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: code.cloudfoundry.org/cfdev/cmd/start (interfaces: Targeter)
+
+// Package mocks is a generated GoMock package.
+package mocks
+
+import (
+	target "code.cloudfoundry.org/cfdev/target"
+	gomock "github.com/golang/mock/gomock"
+	reflect "reflect"
+)
+
+// MockTargeter is a mock of Targeter interface
+type MockTargeter struct {
+	ctrl     *gomock.Controller
+	recorder *MockTargeterMockRecorder
+}
+
+// MockTargeterMockRecorder is the mock recorder for MockTargeter
+type MockTargeterMockRecorder struct {
+	mock *MockTargeter
+}
+
+// NewMockTargeter creates a new mock instance
+func NewMockTargeter(ctrl *gomock.Controller) *MockTargeter {
+	mock := &MockTargeter{ctrl: ctrl}
+	mock.recorder = &MockTargeterMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use
+func (m *MockTargeter) EXPECT() *MockTargeterMockRecorder {
+	return m.recorder
+}
+
+// Login mocks base method
+func (m *MockTargeter) Login(arg0 target.Options) error {
+	ret := m.ctrl.Call(m, "Login", arg0)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Login indicates an expected call of Login
+func (mr *MockTargeterMockRecorder) Login(arg0 interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Login", reflect.TypeOf((*MockTargeter)(nil).Login), arg0)
+}
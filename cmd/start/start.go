@@ -1,18 +1,26 @@
 package start
 
 import (
+	"context"
+	"encoding/hex"
 	"io"
 	"time"
 
 	"code.cloudfoundry.org/cfdev/metadata"
 
 	"code.cloudfoundry.org/cfdev/config"
+	"code.cloudfoundry.org/cfdev/credentials"
+	"code.cloudfoundry.org/cfdev/docker"
 	e "code.cloudfoundry.org/cfdev/errors"
+	"code.cloudfoundry.org/cfdev/hooks"
 	"code.cloudfoundry.org/cfdev/provision"
 	"code.cloudfoundry.org/cfdev/resource"
+	"code.cloudfoundry.org/cfdev/resource/retry"
+	"code.cloudfoundry.org/cfdev/target"
 	"fmt"
 	"github.com/spf13/cobra"
 	"os"
+	"path"
 	"strings"
 
 	"code.cloudfoundry.org/cfdev/cfanalytics"
@@ -59,6 +67,7 @@ type Host interface {
 //go:generate mockgen -package mocks -destination mocks/cache.go code.cloudfoundry.org/cfdev/cmd/start Cache
 type Cache interface {
 	Sync(resource.Catalog) error
+	ReadURL(url string) ([]byte, error)
 }
 
 //go:generate mockgen -package mocks -destination mocks/cfdevd.go code.cloudfoundry.org/cfdev/cmd/start CFDevD
@@ -114,13 +123,33 @@ type Env interface {
 	SetupState() error
 }
 
+//go:generate mockgen -package mocks -destination mocks/snapshots.go code.cloudfoundry.org/cfdev/cmd/start Snapshots
+type Snapshots interface {
+	Restore(name string) error
+}
+
+//go:generate mockgen -package mocks -destination mocks/targeter.go code.cloudfoundry.org/cfdev/cmd/start Targeter
+type Targeter interface {
+	Login(opts target.Options) error
+}
+
+//go:generate mockgen -package mocks -destination mocks/hooks.go code.cloudfoundry.org/cfdev/cmd/start Hooks
+type Hooks interface {
+	Run(event hooks.Event) error
+}
+
 type Args struct {
 	Registries          string
+	RegistryConfig      string
 	DeploySingleService string
 	DepsPath            string
+	DepsSHA256          string
 	NoProvision         bool
 	Cpus                int
 	Mem                 int
+	Snapshot            string
+	Login               string
+	CFHome              string
 }
 
 type Start struct {
@@ -143,9 +172,11 @@ type Start struct {
 	Provision       Provision
 	Env             Env
 	Profiler        SystemProfiler
+	Snapshots       Snapshots
+	Hooks           Hooks
+	Targeter        Targeter
 }
 
-const compatibilityVersion = "v3"
 const defaultMemory = 4192
 
 func (s *Start) Cmd() *cobra.Command {
@@ -161,17 +192,28 @@ func (s *Start) Cmd() *cobra.Command {
 	}
 
 	pf := cmd.PersistentFlags()
-	pf.StringVarP(&args.DepsPath, "file", "f", "", "path to .dev file containing bosh & cf bits")
+	pf.StringVarP(&args.DepsPath, "file", "f", "", "path or url to .dev file containing bosh & cf bits")
+	pf.StringVar(&args.DepsSHA256, "file-sha256", "", "sha256 of a remote --file (defaults to the contents of <url>.sha256)")
 	pf.StringVarP(&args.Registries, "registries", "r", "", "docker registries that skip ssl validation - ie. host:port,host2:port2")
+	pf.StringVar(&args.RegistryConfig, "registry-config", "", "path to a yml file configuring docker registry ca certs, credentials and mirrors")
 	pf.IntVarP(&args.Cpus, "cpus", "c", 4, "cpus to allocate to vm")
 	pf.IntVarP(&args.Mem, "memory", "m", 0, "memory to allocate to vm in MB")
 	pf.BoolVarP(&args.NoProvision, "no-provision", "n", false, "start vm but do not provision")
 	pf.StringVarP(&args.DeploySingleService, "white-listed-services", "s", "", "list of supported services to deploy")
+	pf.StringVar(&args.Login, "login", "", "target the cf CLI at CF Dev and log in as admin or user once started")
+	pf.Lookup("login").NoOptDefVal = "admin"
+	pf.StringVar(&args.CFHome, "cf-home", "", "CF_HOME to log in with when using --login")
 
 	pf.MarkHidden("no-provision")
 	return cmd
 }
 
+// SetDependencies replaces the catalog that Execute downloads, for
+// commands that change it after the config has been loaded.
+func (s *Start) SetDependencies(catalog resource.Catalog) {
+	s.Config.Dependencies = catalog
+}
+
 func (s *Start) Execute(args Args) error {
 	go func() {
 		select {
@@ -187,7 +229,17 @@ func (s *Start) Execute(args Args) error {
 
 	depsFileName := "cf"
 	*s.Config.DepsFile = filepath.Join(s.Config.CacheDir, "cfdev-deps.tgz")
-	if args.DepsPath != "" {
+	if resource.IsRemote(args.DepsPath) {
+		depsFileName = path.Base(args.DepsPath)
+		item, err := s.remoteDeps(args)
+		if err != nil {
+			return err
+		}
+		*s.Config.DepsFile = filepath.Join(s.Config.CacheDir, item.Name)
+
+		s.Config.Dependencies.Remove("cfdev-deps.tgz")
+		s.Config.Dependencies.Items = append(s.Config.Dependencies.Items, item)
+	} else if args.DepsPath != "" {
 		depsFileName = filepath.Base(args.DepsPath)
 		var err error
 		*s.Config.DepsFile, err = filepath.Abs(args.DepsPath)
@@ -201,6 +253,25 @@ func (s *Start) Execute(args Args) error {
 		s.Config.Dependencies.Remove("cfdev-deps.tgz")
 	}
 
+	if args.RegistryConfig != "" {
+		var err error
+		args.RegistryConfig, err = filepath.Abs(args.RegistryConfig)
+		if err != nil {
+			return e.SafeWrap(err, "determining absolute path to registry config")
+		}
+	}
+
+	registries, err := docker.Load(args.Registries, args.RegistryConfig)
+	if err != nil {
+		return e.SafeWrap(err, "Unable to parse docker registries")
+	}
+
+	if args.Login != "" {
+		if _, err := target.Password(args.Login); err != nil {
+			return e.SafeWrap(err, "invalid --login")
+		}
+	}
+
 	s.AnalyticsToggle.SetProp("type", depsFileName)
 
 	aMem, err := s.Profiler.GetAvailableMemory()
@@ -222,7 +293,11 @@ func (s *Start) Execute(args Args) error {
 	} else if running {
 		s.UI.Say("CF Dev is already running...")
 		s.Analytics.Event(cfanalytics.START_END, map[string]interface{}{"alreadyrunning": true})
-		return nil
+		return s.login(args)
+	}
+
+	if err := s.Hooks.Run(hooks.PreStart); err != nil {
+		return err
 	}
 
 	if err := s.Stop.RunE(nil, nil); err != nil {
@@ -233,6 +308,10 @@ func (s *Start) Execute(args Args) error {
 		return e.SafeWrap(err, "setting up cfdev home dir")
 	}
 
+	if err := WriteArgs(ArgsPath(s.Config), args); err != nil {
+		return e.SafeWrap(err, "saving start arguments")
+	}
+
 	if cfdevd := s.Config.Dependencies.Lookup("cfdevd"); cfdevd != nil {
 		s.UI.Say("Downloading Network Helper...")
 		if err := s.Cache.Sync(resource.Catalog{
@@ -261,12 +340,22 @@ func (s *Start) Execute(args Args) error {
 		return e.SafeWrap(err, "Unable to setup directories")
 	}
 
+	if args.Snapshot != "" {
+		s.UI.Say("Restoring Snapshot...")
+		if err := s.Snapshots.Restore(args.Snapshot); err != nil {
+			return e.SafeWrap(err, "Unable to restore snapshot")
+		}
+	}
+
 	metaData, err := s.MetaDataReader.Read(filepath.Join(s.Config.CacheDir, "metadata.yml"))
 	if err != nil {
 		return e.SafeWrap(err, fmt.Sprintf("%s is not compatible with CF Dev. Please use a compatible file.", depsFileName))
 	}
-	if metaData.Version != compatibilityVersion {
-		return fmt.Errorf("%s is not compatible with CF Dev. Please use a compatible file", depsFileName)
+	if err := metaData.Check(s.Config.CliVersion); err != nil {
+		return e.SafeWrap(err, fmt.Sprintf("%s is not compatible with CF Dev", depsFileName))
+	}
+	if err := registries.Deployable(metaData.DockerRegistryVars); err != nil {
+		return e.SafeWrap(err, "Unable to use docker registries")
 	}
 
 	s.Analytics.PromptOptInIfNeeded(metaData.AnalyticsMessage)
@@ -308,7 +397,7 @@ func (s *Start) Execute(args Args) error {
 	}
 
 	s.UI.Say("Waiting for the VM...")
-	err = s.waitForVM()
+	err = s.waitForVM(retry.Until(s.Exit))
 	if err != nil {
 		return e.SafeWrap(err, "Timed out waiting for the VM")
 	}
@@ -318,7 +407,17 @@ func (s *Start) Execute(args Args) error {
 		return nil
 	}
 
-	if err := s.Provision.Execute(args); err != nil {
+	if args.Snapshot != "" {
+		s.UI.Say("VM was restored from snapshot '%s' and will not be provisioned.", args.Snapshot)
+	} else if err := s.Provision.Execute(args); err != nil {
+		return err
+	}
+
+	if err := credentials.WriteEndpoints(s.Config); err != nil {
+		return e.SafeWrap(err, "writing endpoints")
+	}
+
+	if err := s.login(args); err != nil {
 		return err
 	}
 
@@ -331,19 +430,36 @@ func (s *Start) Execute(args Args) error {
 	return nil
 }
 
-func (s *Start) waitForVM() error {
-	timeout := 120
-	var err error
-	for i := 0; i < timeout; i++ {
-		err = s.Provisioner.Ping()
-		if err == nil {
-			return nil
-		}
+func (s *Start) login(args Args) error {
+	if args.Login == "" {
+		return nil
+	}
 
-		time.Sleep(1 * time.Second)
+	s.UI.Say("Logging in as %s...", args.Login)
+	if err := s.Targeter.Login(target.Options{
+		User:   args.Login,
+		Org:    target.DefaultOrg,
+		Space:  target.DefaultSpace,
+		CFHome: args.CFHome,
+	}); err != nil {
+		return e.SafeWrap(err, "logging in")
 	}
+	return nil
+}
 
-	return err
+func (s *Start) waitForVM(ctx context.Context) error {
+	return retry.Do(ctx, retry.Policy{
+		Backoff: retry.Backoff{
+			Initial:    250 * time.Millisecond,
+			Max:        5 * time.Second,
+			Multiplier: 2,
+			Jitter:     0.2,
+			MaxElapsed: 2 * time.Minute,
+		},
+		Notify: func(attempt retry.Attempt) {
+			s.UI.Say("  The VM is not ready yet (attempt %d): %s", attempt.Number, attempt.Err)
+		},
+	}, s.Provisioner.Ping)
 }
 
 func (s *Start) isServiceSupported(service string, services []provision.Service) bool {
@@ -416,3 +532,30 @@ func (s *Start) allocateMemory(metaData metadata.Metadata, requestedMem int) (in
 
 	return 0, nil
 }
+
+// remoteDeps describes a deps file given by url as a catalog item, named
+// after its checksum so that it is cached like any other asset.
+func (s *Start) remoteDeps(args Args) (resource.Item, error) {
+	sum := args.DepsSHA256
+	if sum == "" {
+		contents, err := s.Cache.ReadURL(args.DepsPath + ".sha256")
+		if err != nil {
+			return resource.Item{}, e.SafeWrap(err, fmt.Sprintf("Unable to fetch the checksum of %s. Please pass it with --file-sha256", args.DepsPath))
+		}
+		if fields := strings.Fields(string(contents)); len(fields) > 0 {
+			sum = fields[0]
+		}
+	}
+
+	sum = strings.ToLower(sum)
+	if _, err := hex.DecodeString(sum); err != nil || len(sum) != 64 {
+		return resource.Item{}, fmt.Errorf("'%s' is not a valid sha256 for %s", sum, args.DepsPath)
+	}
+
+	return resource.Item{
+		URL:    args.DepsPath,
+		Name:   fmt.Sprintf("cfdev-deps-%s.tgz", sum),
+		SHA256: sum,
+		InUse:  true,
+	}, nil
+}
@@ -1,6 +1,7 @@
 package start_test
 
 import (
+	"errors"
 	"runtime"
 
 	mdata "code.cloudfoundry.org/cfdev/metadata"
@@ -15,9 +16,12 @@ import (
 	"code.cloudfoundry.org/cfdev/cmd/start"
 	"code.cloudfoundry.org/cfdev/cmd/start/mocks"
 	"code.cloudfoundry.org/cfdev/config"
+	"code.cloudfoundry.org/cfdev/credentials"
+	"code.cloudfoundry.org/cfdev/hooks"
 	"code.cloudfoundry.org/cfdev/hypervisor"
 	"code.cloudfoundry.org/cfdev/provision"
 	"code.cloudfoundry.org/cfdev/resource"
+	"code.cloudfoundry.org/cfdev/target"
 	"github.com/golang/mock/gomock"
 )
 
@@ -41,6 +45,9 @@ var _ = Describe("Start", func() {
 		mockMetadataReader  *mocks.MockMetaDataReader
 		mockEnv             *mocks.MockEnv
 		mockStop            *mocks.MockStop
+		mockSnapshots       *mocks.MockSnapshots
+		mockHooks           *mocks.MockHooks
+		mockTargeter        *mocks.MockTargeter
 
 		startCmd      start.Start
 		exitChan      chan struct{}
@@ -87,6 +94,9 @@ var _ = Describe("Start", func() {
 		mockMetadataReader = mocks.NewMockMetaDataReader(mockController)
 		mockEnv = mocks.NewMockEnv(mockController)
 		mockStop = mocks.NewMockStop(mockController)
+		mockSnapshots = mocks.NewMockSnapshots(mockController)
+		mockHooks = mocks.NewMockHooks(mockController)
+		mockTargeter = mocks.NewMockTargeter(mockController)
 
 		localExitChan = make(chan string, 3)
 		tmpDir, err = ioutil.TempDir("", "start-test-home")
@@ -130,6 +140,9 @@ var _ = Describe("Start", func() {
 			Env:             mockEnv,
 			Stop:            mockStop,
 			Profiler:        mockSystemProfiler,
+			Snapshots:       mockSnapshots,
+			Hooks:           mockHooks,
+			Targeter:        mockTargeter,
 		}
 
 		metadata = mdata.Metadata{
@@ -161,6 +174,7 @@ var _ = Describe("Start", func() {
 
 					mockHost.EXPECT().CheckRequirements(),
 					mockHypervisor.EXPECT().IsRunning("cfdev").Return(false, nil),
+					mockHooks.EXPECT().Run(hooks.PreStart),
 					mockStop.EXPECT().RunE(nil, nil),
 					mockEnv.EXPECT().CreateDirs(),
 
@@ -206,6 +220,9 @@ var _ = Describe("Start", func() {
 					Cpus: 7,
 					Mem:  0,
 				})).To(Succeed())
+
+				Expect(start.ReadArgs(start.ArgsPath(startCmd.Config))).To(Equal(start.Args{Cpus: 7}))
+				Expect(credentials.EndpointsPath(startCmd.Config)).To(BeAnExistingFile())
 			})
 
 			It("starts the vm with analytics toggled off", func() {
@@ -220,6 +237,7 @@ var _ = Describe("Start", func() {
 					mockSystemProfiler.EXPECT().GetTotalMemory().Return(uint64(222), nil),
 					mockHost.EXPECT().CheckRequirements(),
 					mockHypervisor.EXPECT().IsRunning("cfdev").Return(false, nil),
+					mockHooks.EXPECT().Run(hooks.PreStart),
 					mockStop.EXPECT().RunE(nil, nil),
 					mockEnv.EXPECT().CreateDirs(),
 
@@ -288,6 +306,7 @@ var _ = Describe("Start", func() {
 						mockSystemProfiler.EXPECT().GetTotalMemory().Return(uint64(222), nil),
 						mockHost.EXPECT().CheckRequirements(),
 						mockHypervisor.EXPECT().IsRunning("cfdev").Return(false, nil),
+						mockHooks.EXPECT().Run(hooks.PreStart),
 						mockStop.EXPECT().RunE(nil, nil),
 						mockEnv.EXPECT().CreateDirs(),
 
@@ -355,6 +374,7 @@ var _ = Describe("Start", func() {
 						mockSystemProfiler.EXPECT().GetTotalMemory().Return(uint64(222), nil),
 						mockHost.EXPECT().CheckRequirements(),
 						mockHypervisor.EXPECT().IsRunning("cfdev").Return(false, nil),
+						mockHooks.EXPECT().Run(hooks.PreStart),
 						mockStop.EXPECT().RunE(nil, nil),
 						mockEnv.EXPECT().CreateDirs(),
 
@@ -421,6 +441,7 @@ var _ = Describe("Start", func() {
 						mockSystemProfiler.EXPECT().GetTotalMemory().Return(uint64(222), nil),
 						mockHost.EXPECT().CheckRequirements(),
 						mockHypervisor.EXPECT().IsRunning("cfdev").Return(false, nil),
+						mockHooks.EXPECT().Run(hooks.PreStart),
 						mockStop.EXPECT().RunE(nil, nil),
 						mockEnv.EXPECT().CreateDirs(),
 
@@ -486,6 +507,7 @@ var _ = Describe("Start", func() {
 						mockSystemProfiler.EXPECT().GetTotalMemory().Return(uint64(222), nil),
 						mockHost.EXPECT().CheckRequirements(),
 						mockHypervisor.EXPECT().IsRunning("cfdev").Return(false, nil),
+						mockHooks.EXPECT().Run(hooks.PreStart),
 						mockStop.EXPECT().RunE(nil, nil),
 						mockEnv.EXPECT().CreateDirs(),
 
@@ -532,6 +554,67 @@ var _ = Describe("Start", func() {
 						NoProvision: true,
 					})).To(Succeed())
 				})
+
+				It("reports the attempts while waiting for the VM", func() {
+					if runtime.GOOS == "darwin" {
+						mockUI.EXPECT().Say("Installing cfdevd network helper...")
+						mockCFDevD.EXPECT().Install()
+					}
+					notReady := errors.New("some-ping-error")
+
+					gomock.InOrder(
+						mockToggle.EXPECT().SetProp("type", "cf"),
+						mockSystemProfiler.EXPECT().GetAvailableMemory().Return(uint64(111), nil),
+						mockSystemProfiler.EXPECT().GetTotalMemory().Return(uint64(222), nil),
+						mockHost.EXPECT().CheckRequirements(),
+						mockHypervisor.EXPECT().IsRunning("cfdev").Return(false, nil),
+						mockHooks.EXPECT().Run(hooks.PreStart),
+						mockStop.EXPECT().RunE(nil, nil),
+						mockEnv.EXPECT().CreateDirs(),
+
+						mockHostNet.EXPECT().AddLoopbackAliases("some-bosh-director-ip", "some-cf-router-ip"),
+						mockUI.EXPECT().Say("Downloading Resources..."),
+						mockCache.EXPECT().Sync(resource.Catalog{
+							Items: []resource.Item{
+								{Name: "some-item"},
+								{Name: "cfdev-deps.tgz"},
+							},
+						}),
+						mockUI.EXPECT().Say("Setting State..."),
+						mockEnv.EXPECT().SetupState(),
+						mockMetadataReader.EXPECT().Read(filepath.Join(cacheDir, "metadata.yml")).Return(metadata, nil),
+
+						mockAnalyticsClient.EXPECT().PromptOptInIfNeeded(""),
+						mockAnalyticsClient.EXPECT().Event(cfanalytics.START_BEGIN, map[string]interface{}{
+							"total memory":     uint64(222),
+							"available memory": uint64(111),
+						}),
+						mockSystemProfiler.EXPECT().GetAvailableMemory().Return(uint64(10000), nil),
+						mockUI.EXPECT().Say("Creating the VM..."),
+						mockHypervisor.EXPECT().CreateVM(hypervisor.VM{
+							Name:     "cfdev",
+							CPUs:     7,
+							MemoryMB: 8765,
+						}),
+						mockUI.EXPECT().Say("Starting VPNKit..."),
+						mockVpnKit.EXPECT().Start(),
+						mockVpnKit.EXPECT().Watch(localExitChan),
+						mockUI.EXPECT().Say("Starting the VM..."),
+						mockHypervisor.EXPECT().Start("cfdev"),
+						mockUI.EXPECT().Say("Waiting for the VM..."),
+						mockProvisioner.EXPECT().Ping().Return(notReady),
+						mockUI.EXPECT().Say("  The VM is not ready yet (attempt %d): %s", 1, notReady),
+						mockProvisioner.EXPECT().Ping(),
+					)
+
+					//no provision message message
+					mockUI.EXPECT().Say(gomock.Any())
+
+					Expect(startCmd.Execute(start.Args{
+						Cpus:        7,
+						NoProvision: true,
+					})).To(Succeed())
+				})
 			})
 
 			Context("and the requested memory > base memory", func() {
@@ -549,6 +632,7 @@ var _ = Describe("Start", func() {
 								mockSystemProfiler.EXPECT().GetTotalMemory().Return(uint64(16000), nil),
 								mockHost.EXPECT().CheckRequirements(),
 								mockHypervisor.EXPECT().IsRunning("cfdev").Return(false, nil),
+								mockHooks.EXPECT().Run(hooks.PreStart),
 								mockStop.EXPECT().RunE(nil, nil),
 								mockEnv.EXPECT().CreateDirs(),
 
@@ -611,6 +695,7 @@ var _ = Describe("Start", func() {
 							mockSystemProfiler.EXPECT().GetTotalMemory().Return(uint64(9500), nil),
 							mockHost.EXPECT().CheckRequirements(),
 							mockHypervisor.EXPECT().IsRunning("cfdev").Return(false, nil),
+							mockHooks.EXPECT().Run(hooks.PreStart),
 							mockStop.EXPECT().RunE(nil, nil),
 							mockEnv.EXPECT().CreateDirs(),
 
@@ -676,6 +761,7 @@ var _ = Describe("Start", func() {
 							mockSystemProfiler.EXPECT().GetTotalMemory().Return(uint64(16000), nil),
 							mockHost.EXPECT().CheckRequirements(),
 							mockHypervisor.EXPECT().IsRunning("cfdev").Return(false, nil),
+							mockHooks.EXPECT().Run(hooks.PreStart),
 							mockStop.EXPECT().RunE(nil, nil),
 							mockEnv.EXPECT().CreateDirs(),
 
@@ -743,6 +829,7 @@ var _ = Describe("Start", func() {
 							mockSystemProfiler.EXPECT().GetTotalMemory().Return(uint64(5500), nil),
 							mockHost.EXPECT().CheckRequirements(),
 							mockHypervisor.EXPECT().IsRunning("cfdev").Return(false, nil),
+							mockHooks.EXPECT().Run(hooks.PreStart),
 							mockStop.EXPECT().RunE(nil, nil),
 							mockEnv.EXPECT().CreateDirs(),
 
@@ -809,6 +896,7 @@ var _ = Describe("Start", func() {
 						mockSystemProfiler.EXPECT().GetTotalMemory().Return(uint64(222), nil),
 						mockHost.EXPECT().CheckRequirements(),
 						mockHypervisor.EXPECT().IsRunning("cfdev").Return(false, nil),
+						mockHooks.EXPECT().Run(hooks.PreStart),
 						mockStop.EXPECT().RunE(nil, nil),
 						mockEnv.EXPECT().CreateDirs(),
 
@@ -872,6 +960,7 @@ var _ = Describe("Start", func() {
 						mockSystemProfiler.EXPECT().GetTotalMemory().Return(uint64(222), nil),
 						mockHost.EXPECT().CheckRequirements(),
 						mockHypervisor.EXPECT().IsRunning("cfdev").Return(false, nil),
+						mockHooks.EXPECT().Run(hooks.PreStart),
 						mockStop.EXPECT().RunE(nil, nil),
 						mockEnv.EXPECT().CreateDirs(),
 
@@ -935,6 +1024,7 @@ var _ = Describe("Start", func() {
 						mockSystemProfiler.EXPECT().GetTotalMemory().Return(uint64(222), nil),
 						mockHost.EXPECT().CheckRequirements(),
 						mockHypervisor.EXPECT().IsRunning("cfdev").Return(false, nil),
+						mockHooks.EXPECT().Run(hooks.PreStart),
 						mockStop.EXPECT().RunE(nil, nil),
 						mockEnv.EXPECT().CreateDirs(),
 
@@ -983,6 +1073,7 @@ var _ = Describe("Start", func() {
 					mockSystemProfiler.EXPECT().GetTotalMemory().Return(uint64(222), nil),
 					mockHost.EXPECT().CheckRequirements(),
 					mockHypervisor.EXPECT().IsRunning("cfdev").Return(false, nil),
+					mockHooks.EXPECT().Run(hooks.PreStart),
 					mockStop.EXPECT().RunE(nil, nil),
 					mockEnv.EXPECT().CreateDirs(),
 
@@ -1003,7 +1094,7 @@ var _ = Describe("Start", func() {
 					Cpus:     7,
 					Mem:      6666,
 					DepsPath: tarballFile,
-				})).To(MatchError("custom.tgz is not compatible with CF Dev. Please use a compatible file"))
+				})).To(MatchError("custom.tgz is not compatible with CF Dev: the deps are too new for this version of the cf dev plugin (compatibility version v100). Please upgrade the plugin"))
 			})
 		})
 
@@ -1023,6 +1114,7 @@ var _ = Describe("Start", func() {
 					mockSystemProfiler.EXPECT().GetTotalMemory().Return(uint64(222), nil),
 					mockHost.EXPECT().CheckRequirements(),
 					mockHypervisor.EXPECT().IsRunning("cfdev").Return(false, nil),
+					mockHooks.EXPECT().Run(hooks.PreStart),
 					mockStop.EXPECT().RunE(nil, nil),
 					mockEnv.EXPECT().CreateDirs(),
 
@@ -1073,6 +1165,271 @@ var _ = Describe("Start", func() {
 			})
 		})
 
+		Context("when the -f flag is provided with a url", func() {
+			It("downloads the tarball into the cache under its checksum instead of cfdev-deps", func() {
+				customTarball := "https://example.com/custom.tgz"
+
+				if runtime.GOOS == "darwin" {
+					mockUI.EXPECT().Say("Installing cfdevd network helper...")
+					mockCFDevD.EXPECT().Install()
+				}
+
+				gomock.InOrder(
+					mockCache.EXPECT().ReadURL("https://example.com/custom.tgz.sha256").Return([]byte("aaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaa  custom.tgz\n"), nil),
+					mockToggle.EXPECT().SetProp("type", "custom.tgz"),
+					mockSystemProfiler.EXPECT().GetAvailableMemory().Return(uint64(111), nil),
+					mockSystemProfiler.EXPECT().GetTotalMemory().Return(uint64(222), nil),
+					mockHost.EXPECT().CheckRequirements(),
+					mockHypervisor.EXPECT().IsRunning("cfdev").Return(false, nil),
+					mockHooks.EXPECT().Run(hooks.PreStart),
+					mockStop.EXPECT().RunE(nil, nil),
+					mockEnv.EXPECT().CreateDirs(),
+
+					mockHostNet.EXPECT().AddLoopbackAliases("some-bosh-director-ip", "some-cf-router-ip"),
+					mockUI.EXPECT().Say("Downloading Resources..."),
+					mockCache.EXPECT().Sync(resource.Catalog{
+						Items: []resource.Item{
+							{Name: "some-item"},
+							{
+								Name:   "cfdev-deps-aaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaa.tgz",
+								URL:    "https://example.com/custom.tgz",
+								SHA256: "aaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaa",
+								InUse:  true,
+							},
+						},
+					}),
+					mockUI.EXPECT().Say("Setting State..."),
+					mockEnv.EXPECT().SetupState(),
+					mockMetadataReader.EXPECT().Read(filepath.Join(cacheDir, "metadata.yml")).Return(metadata, nil),
+
+					mockAnalyticsClient.EXPECT().PromptOptInIfNeeded(""),
+					mockAnalyticsClient.EXPECT().Event(cfanalytics.START_BEGIN, map[string]interface{}{
+						"total memory":     uint64(222),
+						"available memory": uint64(111),
+					}),
+					mockSystemProfiler.EXPECT().GetAvailableMemory().Return(uint64(10000), nil),
+					mockUI.EXPECT().Say("WARNING: It is recommended that you run CF Dev with at least 8765 MB of RAM."),
+					mockUI.EXPECT().Say("Creating the VM..."),
+					mockHypervisor.EXPECT().CreateVM(hypervisor.VM{
+						Name:     "cfdev",
+						CPUs:     7,
+						MemoryMB: 6666,
+					}),
+					mockUI.EXPECT().Say("Starting VPNKit..."),
+					mockVpnKit.EXPECT().Start(),
+					mockVpnKit.EXPECT().Watch(localExitChan),
+					mockUI.EXPECT().Say("Starting the VM..."),
+					mockHypervisor.EXPECT().Start("cfdev"),
+					mockUI.EXPECT().Say("Waiting for the VM..."),
+					mockProvisioner.EXPECT().Ping(),
+					mockProvision.EXPECT().Execute(start.Args{Cpus: 7, Mem: 6666, DepsPath: customTarball}),
+
+					mockToggle.EXPECT().Enabled().Return(true),
+					mockAnalyticsD.EXPECT().Start(),
+					mockAnalyticsClient.EXPECT().Event(cfanalytics.START_END),
+				)
+
+				Expect(startCmd.Execute(start.Args{
+					Cpus:     7,
+					Mem:      6666,
+					DepsPath: customTarball,
+				})).To(Succeed())
+				Expect(*startCmd.Config.DepsFile).To(Equal(filepath.Join(cacheDir, "cfdev-deps-aaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaa.tgz")))
+			})
+
+			It("rejects an invalid --file-sha256 without fetching the checksum", func() {
+				mockCache.EXPECT().ReadURL(gomock.Any()).Times(0)
+
+				err := startCmd.Execute(start.Args{
+					DepsPath:   "https://example.com/custom.tgz",
+					DepsSHA256: "not-a-sha",
+				})
+				Expect(err).To(MatchError("'not-a-sha' is not a valid sha256 for https://example.com/custom.tgz"))
+			})
+
+			It("asks for --file-sha256 when the checksum cannot be fetched", func() {
+				mockCache.EXPECT().ReadURL("https://example.com/custom.tgz.sha256").Return(nil, errors.New("some-error"))
+
+				err := startCmd.Execute(start.Args{DepsPath: "https://example.com/custom.tgz"})
+				Expect(err).To(MatchError(ContainSubstring("Please pass it with --file-sha256")))
+			})
+		})
+
+		Context("when the docker registry configuration is invalid", func() {
+			It("returns an error before starting anything", func() {
+				registryConfig := filepath.Join(tmpDir, "registries.yml")
+				ioutil.WriteFile(registryConfig, []byte("registries:\n- host: a.com\n  username: some-user\n"), 0644)
+
+				Expect(startCmd.Execute(start.Args{
+					Cpus:           7,
+					RegistryConfig: registryConfig,
+				})).To(MatchError(ContainSubstring("both a username and a password are required")))
+			})
+
+			It("refuses settings that the deps cannot apply before creating the VM", func() {
+				if runtime.GOOS == "darwin" {
+					mockUI.EXPECT().Say("Installing cfdevd network helper...")
+					mockCFDevD.EXPECT().Install()
+				}
+				registryConfig := filepath.Join(tmpDir, "registries.yml")
+				ioutil.WriteFile(registryConfig, []byte("registries:\n- host: a.com\n  mirrors: [https://mirror.example.com]\n"), 0644)
+
+				gomock.InOrder(
+					mockToggle.EXPECT().SetProp("type", "cf"),
+					mockSystemProfiler.EXPECT().GetAvailableMemory().Return(uint64(111), nil),
+					mockSystemProfiler.EXPECT().GetTotalMemory().Return(uint64(222), nil),
+					mockHost.EXPECT().CheckRequirements(),
+					mockHypervisor.EXPECT().IsRunning("cfdev").Return(false, nil),
+					mockHooks.EXPECT().Run(hooks.PreStart),
+					mockStop.EXPECT().RunE(nil, nil),
+					mockEnv.EXPECT().CreateDirs(),
+					mockHostNet.EXPECT().AddLoopbackAliases("some-bosh-director-ip", "some-cf-router-ip"),
+					mockUI.EXPECT().Say("Downloading Resources..."),
+					mockCache.EXPECT().Sync(gomock.Any()),
+					mockUI.EXPECT().Say("Setting State..."),
+					mockEnv.EXPECT().SetupState(),
+					mockMetadataReader.EXPECT().Read(filepath.Join(cacheDir, "metadata.yml")).Return(metadata, nil),
+				)
+
+				Expect(startCmd.Execute(start.Args{
+					Cpus:           7,
+					RegistryConfig: registryConfig,
+				})).To(MatchError(ContainSubstring("Unable to use docker registries: 'a.com' - ca_cert, username, password and mirrors need deps that declare docker_registry_vars")))
+			})
+		})
+
+		Context("when the pre-start hook fails", func() {
+			It("returns the error before stopping or creating anything", func() {
+				gomock.InOrder(
+					mockToggle.EXPECT().SetProp("type", "cf"),
+					mockSystemProfiler.EXPECT().GetAvailableMemory().Return(uint64(111), nil),
+					mockSystemProfiler.EXPECT().GetTotalMemory().Return(uint64(222), nil),
+					mockHost.EXPECT().CheckRequirements(),
+					mockHypervisor.EXPECT().IsRunning("cfdev").Return(false, nil),
+					mockHooks.EXPECT().Run(hooks.PreStart).Return(errors.New("some-hook-error")),
+				)
+
+				Expect(startCmd.Execute(start.Args{Cpus: 7})).To(MatchError("some-hook-error"))
+			})
+		})
+
+		Context("when a snapshot is being restored", func() {
+			It("restores the snapshot state and does not provision", func() {
+				if runtime.GOOS == "darwin" {
+					mockUI.EXPECT().Say("Installing cfdevd network helper...")
+					mockCFDevD.EXPECT().Install()
+				}
+
+				gomock.InOrder(
+					mockToggle.EXPECT().SetProp("type", "cf"),
+					mockSystemProfiler.EXPECT().GetAvailableMemory().Return(uint64(111), nil),
+					mockSystemProfiler.EXPECT().GetTotalMemory().Return(uint64(222), nil),
+					mockHost.EXPECT().CheckRequirements(),
+					mockHypervisor.EXPECT().IsRunning("cfdev").Return(false, nil),
+					mockHooks.EXPECT().Run(hooks.PreStart),
+					mockStop.EXPECT().RunE(nil, nil),
+					mockEnv.EXPECT().CreateDirs(),
+
+					mockHostNet.EXPECT().AddLoopbackAliases("some-bosh-director-ip", "some-cf-router-ip"),
+					mockUI.EXPECT().Say("Downloading Resources..."),
+					mockCache.EXPECT().Sync(gomock.Any()),
+					mockUI.EXPECT().Say("Setting State..."),
+					mockEnv.EXPECT().SetupState(),
+					mockUI.EXPECT().Say("Restoring Snapshot..."),
+					mockSnapshots.EXPECT().Restore("some-snapshot"),
+					mockMetadataReader.EXPECT().Read(filepath.Join(cacheDir, "metadata.yml")).Return(metadata, nil),
+
+					mockAnalyticsClient.EXPECT().PromptOptInIfNeeded(""),
+					mockAnalyticsClient.EXPECT().Event(cfanalytics.START_BEGIN, gomock.Any()),
+					mockSystemProfiler.EXPECT().GetAvailableMemory().Return(uint64(10000), nil),
+					mockUI.EXPECT().Say("Creating the VM..."),
+					mockHypervisor.EXPECT().CreateVM(hypervisor.VM{
+						Name:     "cfdev",
+						CPUs:     6,
+						MemoryMB: 8765,
+					}),
+					mockUI.EXPECT().Say("Starting VPNKit..."),
+					mockVpnKit.EXPECT().Start(),
+					mockVpnKit.EXPECT().Watch(localExitChan),
+					mockUI.EXPECT().Say("Starting the VM..."),
+					mockHypervisor.EXPECT().Start("cfdev"),
+					mockUI.EXPECT().Say("Waiting for the VM..."),
+					mockProvisioner.EXPECT().Ping(),
+					mockUI.EXPECT().Say("VM was restored from snapshot '%s' and will not be provisioned.", "some-snapshot"),
+
+					mockToggle.EXPECT().Enabled().Return(false),
+					mockAnalyticsClient.EXPECT().Event(cfanalytics.START_END),
+				)
+
+				Expect(startCmd.Execute(start.Args{
+					Cpus:     6,
+					Snapshot: "some-snapshot",
+				})).To(Succeed())
+			})
+		})
+
+		Context("when --login is given", func() {
+			It("logs the cf CLI in once CF Dev is provisioned", func() {
+				if runtime.GOOS == "darwin" {
+					mockUI.EXPECT().Say("Installing cfdevd network helper...")
+					mockCFDevD.EXPECT().Install()
+				}
+
+				gomock.InOrder(
+					mockToggle.EXPECT().SetProp("type", "cf"),
+					mockSystemProfiler.EXPECT().GetAvailableMemory().Return(uint64(111), nil),
+					mockSystemProfiler.EXPECT().GetTotalMemory().Return(uint64(222), nil),
+					mockHost.EXPECT().CheckRequirements(),
+					mockHypervisor.EXPECT().IsRunning("cfdev").Return(false, nil),
+					mockHooks.EXPECT().Run(hooks.PreStart),
+					mockStop.EXPECT().RunE(nil, nil),
+					mockEnv.EXPECT().CreateDirs(),
+
+					mockHostNet.EXPECT().AddLoopbackAliases("some-bosh-director-ip", "some-cf-router-ip"),
+					mockUI.EXPECT().Say("Downloading Resources..."),
+					mockCache.EXPECT().Sync(gomock.Any()),
+					mockUI.EXPECT().Say("Setting State..."),
+					mockEnv.EXPECT().SetupState(),
+					mockMetadataReader.EXPECT().Read(filepath.Join(cacheDir, "metadata.yml")).Return(metadata, nil),
+
+					mockAnalyticsClient.EXPECT().PromptOptInIfNeeded(""),
+					mockAnalyticsClient.EXPECT().Event(cfanalytics.START_BEGIN, gomock.Any()),
+					mockSystemProfiler.EXPECT().GetAvailableMemory().Return(uint64(10000), nil),
+					mockUI.EXPECT().Say("Creating the VM..."),
+					mockHypervisor.EXPECT().CreateVM(gomock.Any()),
+					mockUI.EXPECT().Say("Starting VPNKit..."),
+					mockVpnKit.EXPECT().Start(),
+					mockVpnKit.EXPECT().Watch(localExitChan),
+					mockUI.EXPECT().Say("Starting the VM..."),
+					mockHypervisor.EXPECT().Start("cfdev"),
+					mockUI.EXPECT().Say("Waiting for the VM..."),
+					mockProvisioner.EXPECT().Ping(),
+					mockProvision.EXPECT().Execute(start.Args{Cpus: 6, Login: "user", CFHome: "some-cf-home"}),
+					mockUI.EXPECT().Say("Logging in as %s...", "user"),
+					mockTargeter.EXPECT().Login(target.Options{
+						User:   "user",
+						Org:    "cfdev-org",
+						Space:  "cfdev-space",
+						CFHome: "some-cf-home",
+					}),
+
+					mockToggle.EXPECT().Enabled().Return(false),
+					mockAnalyticsClient.EXPECT().Event(cfanalytics.START_END),
+				)
+
+				Expect(startCmd.Execute(start.Args{
+					Cpus:   6,
+					Login:  "user",
+					CFHome: "some-cf-home",
+				})).To(Succeed())
+			})
+
+			It("rejects unknown users before starting", func() {
+				err := startCmd.Execute(start.Args{Login: "root"})
+				Expect(err).To(MatchError("invalid --login: unknown user 'root', expected admin or user"))
+			})
+		})
+
 		Context("when linuxkit is already running", func() {
 			It("says cf dev is already running", func() {
 				gomock.InOrder(
@@ -1087,6 +1444,27 @@ var _ = Describe("Start", func() {
 
 				Expect(startCmd.Execute(start.Args{})).To(Succeed())
 			})
+
+			It("still logs in with --login", func() {
+				gomock.InOrder(
+					mockToggle.EXPECT().SetProp("type", "cf"),
+					mockSystemProfiler.EXPECT().GetAvailableMemory().Return(uint64(111), nil),
+					mockSystemProfiler.EXPECT().GetTotalMemory().Return(uint64(222), nil),
+					mockHost.EXPECT().CheckRequirements(),
+					mockHypervisor.EXPECT().IsRunning("cfdev").Return(true, nil),
+					mockUI.EXPECT().Say("CF Dev is already running..."),
+					mockAnalyticsClient.EXPECT().Event(cfanalytics.START_END, map[string]interface{}{"alreadyrunning": true}),
+					mockUI.EXPECT().Say("Logging in as %s...", "user"),
+					mockTargeter.EXPECT().Login(target.Options{
+						User:   "user",
+						Org:    target.DefaultOrg,
+						Space:  target.DefaultSpace,
+						CFHome: "/some/cf-home",
+					}),
+				)
+
+				Expect(startCmd.Execute(start.Args{Login: "user", CFHome: "/some/cf-home"})).To(Succeed())
+			})
 		})
 	})
 })
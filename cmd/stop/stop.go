@@ -6,6 +6,7 @@ import (
 	"code.cloudfoundry.org/cfdev/cfanalytics"
 	"code.cloudfoundry.org/cfdev/config"
 	"code.cloudfoundry.org/cfdev/errors"
+	"code.cloudfoundry.org/cfdev/hooks"
 	"github.com/spf13/cobra"
 )
 
@@ -52,6 +53,11 @@ type AnalyticsD interface {
 	Destroy() error
 }
 
+//go:generate mockgen -package mocks -destination mocks/hooks.go code.cloudfoundry.org/cfdev/cmd/stop Hooks
+type Hooks interface {
+	Run(event hooks.Event) error
+}
+
 type Stop struct {
 	Hypervisor   Hypervisor
 	VpnKit       VpnKit
@@ -61,12 +67,18 @@ type Stop struct {
 	HostNet      HostNet
 	AnalyticsD   AnalyticsD
 	Host         Host
+	Hooks        Hooks
 }
 
 func (s *Stop) Cmd() *cobra.Command {
 	return &cobra.Command{
-		Use:  "stop",
-		RunE: s.RunE,
+		Use: "stop",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := s.Hooks.Run(hooks.PreStop); err != nil {
+				return err
+			}
+			return s.RunE(cmd, args)
+		},
 	}
 }
 
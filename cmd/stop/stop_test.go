@@ -11,6 +11,7 @@ import (
 	"code.cloudfoundry.org/cfdev/cmd/stop"
 	"code.cloudfoundry.org/cfdev/cmd/stop/mocks"
 	"code.cloudfoundry.org/cfdev/config"
+	"code.cloudfoundry.org/cfdev/hooks"
 	"github.com/golang/mock/gomock"
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
@@ -28,6 +29,7 @@ var _ = Describe("Stop", func() {
 		mockHypervisor   *mocks.MockHypervisor
 		mockAnalyticsD   *mocks.MockAnalyticsD
 		mockVpnkit       *mocks.MockVpnKit
+		mockHooks        *mocks.MockHooks
 		mockController   *gomock.Controller
 		stateDir         string
 		err              error
@@ -51,6 +53,7 @@ var _ = Describe("Stop", func() {
 		mockAnalyticsD = mocks.NewMockAnalyticsD(mockController)
 		mockHypervisor = mocks.NewMockHypervisor(mockController)
 		mockVpnkit = mocks.NewMockVpnKit(mockController)
+		mockHooks = mocks.NewMockHooks(mockController)
 
 		subject := &stop.Stop{
 			Hypervisor:   mockHypervisor,
@@ -61,6 +64,7 @@ var _ = Describe("Stop", func() {
 			AnalyticsD:   mockAnalyticsD,
 			HostNet:      mockHostNet,
 			Host:         mockHost,
+			Hooks:        mockHooks,
 		}
 		stopCmd = subject.Cmd()
 		stopCmd.SetArgs([]string{})
@@ -73,6 +77,7 @@ var _ = Describe("Stop", func() {
 	})
 
 	It("destroys the VM, uninstalls vpnkit, analyticsd, and cfdevd, tears down aliases, and sends analytics event", func() {
+		mockHooks.EXPECT().Run(hooks.PreStop)
 		mockAnalytics.EXPECT().Event(cfanalytics.STOP)
 		mockHost.EXPECT().CheckRequirements()
 		mockAnalyticsD.EXPECT().Stop()
@@ -90,8 +95,17 @@ var _ = Describe("Stop", func() {
 		Expect(stopCmd.Execute()).To(Succeed())
 	})
 
+	Context("when the pre-stop hook fails", func() {
+		It("returns the error without stopping anything", func() {
+			mockHooks.EXPECT().Run(hooks.PreStop).Return(errors.New("some-hook-error"))
+
+			Expect(stopCmd.Execute()).To(MatchError("some-hook-error"))
+		})
+	})
+
 	Context("stopping the VM fails", func() {
 		It("stops the others and returns VM error", func() {
+			mockHooks.EXPECT().Run(hooks.PreStop)
 			mockAnalytics.EXPECT().Event(cfanalytics.STOP)
 			mockHost.EXPECT().CheckRequirements()
 			mockAnalyticsD.EXPECT().Stop()
@@ -112,6 +126,7 @@ var _ = Describe("Stop", func() {
 
 	Context("destroying the VM fails", func() {
 		It("stops the others and returns VM error", func() {
+			mockHooks.EXPECT().Run(hooks.PreStop)
 			mockAnalytics.EXPECT().Event(cfanalytics.STOP)
 			mockHost.EXPECT().CheckRequirements()
 			mockAnalyticsD.EXPECT().Stop()
@@ -132,6 +147,7 @@ var _ = Describe("Stop", func() {
 
 	Context("stopping vpnkit fails", func() {
 		It("stops the others and returns vpnkit error", func() {
+			mockHooks.EXPECT().Run(hooks.PreStop)
 			mockAnalytics.EXPECT().Event(cfanalytics.STOP)
 			mockHost.EXPECT().CheckRequirements()
 			mockAnalyticsD.EXPECT().Stop()
@@ -152,6 +168,7 @@ var _ = Describe("Stop", func() {
 
 	Context("destroying vpnkit fails", func() {
 		It("stops the others and returns vpnkit error", func() {
+			mockHooks.EXPECT().Run(hooks.PreStop)
 			mockAnalytics.EXPECT().Event(cfanalytics.STOP)
 			mockHost.EXPECT().CheckRequirements()
 			mockAnalyticsD.EXPECT().Stop()
@@ -172,6 +189,7 @@ var _ = Describe("Stop", func() {
 
 	Context("stopping analyticsd fails", func() {
 		It("stops the others and returns analyticsd error", func() {
+			mockHooks.EXPECT().Run(hooks.PreStop)
 			mockAnalytics.EXPECT().Event(cfanalytics.STOP)
 			mockHost.EXPECT().CheckRequirements()
 			mockAnalyticsD.EXPECT().Stop().Return(errors.New("test"))
@@ -192,6 +210,7 @@ var _ = Describe("Stop", func() {
 
 	Context("destroying analyticsd fails", func() {
 		It("stops the others and returns analyticsd error", func() {
+			mockHooks.EXPECT().Run(hooks.PreStop)
 			mockAnalytics.EXPECT().Event(cfanalytics.STOP)
 			mockHost.EXPECT().CheckRequirements()
 			mockAnalyticsD.EXPECT().Stop()
@@ -212,6 +231,7 @@ var _ = Describe("Stop", func() {
 
 	Context("removing aliases fails", func() {
 		It("stops the others and returns alias error", func() {
+			mockHooks.EXPECT().Run(hooks.PreStop)
 			mockAnalytics.EXPECT().Event(cfanalytics.STOP)
 			mockHost.EXPECT().CheckRequirements()
 			mockAnalyticsD.EXPECT().Stop()
@@ -0,0 +1,61 @@
+package target
+
+import (
+	e "code.cloudfoundry.org/cfdev/errors"
+	"code.cloudfoundry.org/cfdev/target"
+	"github.com/spf13/cobra"
+)
+
+//go:generate mockgen -package mocks -destination mocks/ui.go code.cloudfoundry.org/cfdev/cmd/target UI
+type UI interface {
+	Say(message string, args ...interface{})
+}
+
+//go:generate mockgen -package mocks -destination mocks/targeter.go code.cloudfoundry.org/cfdev/cmd/target Targeter
+type Targeter interface {
+	Login(opts target.Options) error
+}
+
+type Target struct {
+	UI       UI
+	Targeter Targeter
+}
+
+func (t *Target) Cmd() *cobra.Command {
+	opts := target.Options{}
+	cmd := &cobra.Command{
+		Use:   "target",
+		Short: "Point the cf CLI at CF Dev and log in",
+		RunE: func(_ *cobra.Command, _ []string) error {
+			return t.Execute(opts)
+		},
+	}
+
+	pf := cmd.PersistentFlags()
+	pf.StringVarP(&opts.User, "user", "u", "admin", "user to log in as: admin or user")
+	pf.StringVarP(&opts.Org, "org", "o", target.DefaultOrg, "org to target")
+	pf.StringVarP(&opts.Space, "space", "s", target.DefaultSpace, "space to target")
+	pf.BoolVar(&opts.Create, "create", false, "create the org and space if they do not exist")
+	pf.StringVar(&opts.CFHome, "cf-home", "", "use this CF_HOME instead of the current cf CLI configuration")
+	return cmd
+}
+
+func (t *Target) Execute(opts target.Options) error {
+	t.UI.Say("Logging in to CF Dev as %s...", opts.User)
+	if err := t.Targeter.Login(opts); err != nil {
+		return e.SafeWrap(err, "failed to log in")
+	}
+
+	if opts.Org != "" {
+		if opts.Space != "" {
+			t.UI.Say("Targeted org %s and space %s", opts.Org, opts.Space)
+		} else {
+			t.UI.Say("Targeted org %s", opts.Org)
+		}
+	}
+
+	if opts.CFHome != "" {
+		t.UI.Say("Set CF_HOME=%s to use this target", opts.CFHome)
+	}
+	return nil
+}
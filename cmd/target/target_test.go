@@ -0,0 +1,67 @@
+package target_test
+
+import (
+	"errors"
+
+	cmd "code.cloudfoundry.org/cfdev/cmd/target"
+	"code.cloudfoundry.org/cfdev/cmd/target/mocks"
+	"code.cloudfoundry.org/cfdev/target"
+	"github.com/golang/mock/gomock"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Target", func() {
+	var (
+		mockController *gomock.Controller
+		mockUI         *mocks.MockUI
+		mockTargeter   *mocks.MockTargeter
+		targetCmd      *cmd.Target
+	)
+
+	BeforeEach(func() {
+		mockController = gomock.NewController(GinkgoT())
+		mockUI = mocks.NewMockUI(mockController)
+		mockTargeter = mocks.NewMockTargeter(mockController)
+
+		targetCmd = &cmd.Target{
+			UI:       mockUI,
+			Targeter: mockTargeter,
+		}
+	})
+
+	AfterEach(func() {
+		mockController.Finish()
+	})
+
+	It("logs in and reports the target", func() {
+		opts := target.Options{User: "admin", Org: "some-org", Space: "some-space"}
+		gomock.InOrder(
+			mockUI.EXPECT().Say("Logging in to CF Dev as %s...", "admin"),
+			mockTargeter.EXPECT().Login(opts),
+			mockUI.EXPECT().Say("Targeted org %s and space %s", "some-org", "some-space"),
+		)
+
+		Expect(targetCmd.Execute(opts)).To(Succeed())
+	})
+
+	It("explains how to use an isolated CF_HOME", func() {
+		opts := target.Options{User: "user", CFHome: "some-cf-home"}
+		gomock.InOrder(
+			mockUI.EXPECT().Say("Logging in to CF Dev as %s...", "user"),
+			mockTargeter.EXPECT().Login(opts),
+			mockUI.EXPECT().Say("Set CF_HOME=%s to use this target", "some-cf-home"),
+		)
+
+		Expect(targetCmd.Execute(opts)).To(Succeed())
+	})
+
+	Context("when logging in fails", func() {
+		It("returns an error", func() {
+			mockUI.EXPECT().Say("Logging in to CF Dev as %s...", "admin")
+			mockTargeter.EXPECT().Login(gomock.Any()).Return(errors.New("some-error"))
+
+			Expect(targetCmd.Execute(target.Options{User: "admin"})).To(MatchError("failed to log in: some-error"))
+		})
+	})
+})
@@ -0,0 +1,48 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: code.cloudfoundry.org/cfdev/cmd/tunnel (interfaces: SSH)
+
+// Package mocks is a generated GoMock package.
+package mocks
+
+import (
+	ssh "code.cloudfoundry.org/cfdev/ssh"
+	gomock "github.com/golang/mock/gomock"
+	reflect "reflect"
+	time "time"
+)
+
+// MockSSH is a mock of SSH interface
+type MockSSH struct {
+	ctrl     *gomock.Controller
+	recorder *MockSSHMockRecorder
+}
+
+// MockSSHMockRecorder is the mock recorder for MockSSH
+type MockSSHMockRecorder struct {
+	mock *MockSSH
+}
+
+// NewMockSSH creates a new mock instance
+func NewMockSSH(ctrl *gomock.Controller) *MockSSH {
+	mock := &MockSSH{ctrl: ctrl}
+	mock.recorder = &MockSSHMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use
+func (m *MockSSH) EXPECT() *MockSSHMockRecorder {
+	return m.recorder
+}
+
+// Tunnel mocks base method
+func (m *MockSSH) Tunnel(arg0 []ssh.Hop, arg1 time.Duration) (*ssh.Tunnel, error) {
+	ret := m.ctrl.Call(m, "Tunnel", arg0, arg1)
+	ret0, _ := ret[0].(*ssh.Tunnel)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Tunnel indicates an expected call of Tunnel
+func (mr *MockSSHMockRecorder) Tunnel(arg0, arg1 interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Tunnel", reflect.TypeOf((*MockSSH)(nil).Tunnel), arg0, arg1)
+}
@@ -0,0 +1,154 @@
+package tunnel
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+
+	"code.cloudfoundry.org/cfdev/bosh"
+	"code.cloudfoundry.org/cfdev/config"
+	e "code.cloudfoundry.org/cfdev/errors"
+	"code.cloudfoundry.org/cfdev/ssh"
+	"github.com/spf13/cobra"
+)
+
+//go:generate mockgen -package mocks -destination mocks/ui.go code.cloudfoundry.org/cfdev/cmd/tunnel UI
+type UI interface {
+	Say(message string, args ...interface{})
+}
+
+//go:generate mockgen -package mocks -destination mocks/ssh.go code.cloudfoundry.org/cfdev/cmd/tunnel SSH
+type SSH interface {
+	Tunnel(hops []ssh.Hop, timeout time.Duration) (*ssh.Tunnel, error)
+}
+
+type Args struct {
+	Port     int
+	NoSocks  bool
+	Forwards []string
+}
+
+type Tunnel struct {
+	Exit   chan struct{}
+	UI     UI
+	Config config.Config
+	SSH    SSH
+}
+
+const DefaultPort = 1080
+
+// vmAddress is the ssh port of the VM, which is forwarded to the host
+// without the loopback aliases.
+var vmAddress = ssh.SSHAddress{IP: "127.0.0.1", Port: "9992"}
+
+func (t *Tunnel) Cmd() *cobra.Command {
+	args := Args{}
+	cmd := &cobra.Command{
+		Use:   "tunnel",
+		Short: "Proxy connections into CF Dev through the BOSH jumpbox",
+		Long: `Runs a local SOCKS5 proxy, plus any -L style port forwards, over ssh to the
+BOSH jumpbox so that the director, CredHub and internal service IPs are
+reachable without host networking changes. The jumpbox is reached through
+the ssh port that the VM forwards to 127.0.0.1. Runs until interrupted.`,
+		RunE: func(_ *cobra.Command, _ []string) error {
+			return t.Execute(args)
+		},
+	}
+
+	pf := cmd.PersistentFlags()
+	pf.IntVarP(&args.Port, "port", "p", DefaultPort, "local port for the SOCKS5 proxy")
+	pf.BoolVar(&args.NoSocks, "no-socks", false, "only run the port forwards")
+	pf.StringArrayVarP(&args.Forwards, "local-forward", "L", nil, "forward [bind_address:]port:host:hostport")
+	return cmd
+}
+
+func (t *Tunnel) Execute(args Args) error {
+	var forwards []ssh.Forward
+	for _, spec := range args.Forwards {
+		forward, err := ssh.ParseForward(spec)
+		if err != nil {
+			return e.SafeWrap(err, "invalid port forward")
+		}
+		forwards = append(forwards, forward)
+	}
+
+	if args.NoSocks && len(forwards) == 0 {
+		return e.SafeWrap(nil, "nothing to do: --no-socks requires at least one -L forward")
+	}
+
+	boshConfig, err := bosh.FetchConfig(t.Config)
+	if err != nil {
+		return e.SafeWrap(err, "failed to fetch bosh configuration")
+	}
+
+	privateKey, err := ioutil.ReadFile(boshConfig.GatewayPrivateKey)
+	if err != nil {
+		return e.SafeWrap(err, "failed to read the jumpbox key")
+	}
+
+	vmKey, err := ioutil.ReadFile(filepath.Join(t.Config.CacheDir, "id_rsa"))
+	if err != nil {
+		return e.SafeWrap(err, "failed to read the vm key")
+	}
+
+	tunnel, err := t.SSH.Tunnel([]ssh.Hop{
+		{Address: vmAddress, User: "root", PrivateKey: vmKey},
+		{
+			Address:    ssh.SSHAddress{IP: boshConfig.GatewayHost, Port: strconv.Itoa(bosh.GatewayPort)},
+			User:       boshConfig.GatewayUsername,
+			PrivateKey: privateKey,
+		},
+	}, 30*time.Second)
+	if err != nil {
+		return e.SafeWrap(err, "failed to connect to the jumpbox")
+	}
+	defer tunnel.Close()
+
+	errs := make(chan error, len(forwards)+1)
+
+	if !args.NoSocks {
+		address := net.JoinHostPort("127.0.0.1", strconv.Itoa(args.Port))
+		listener, err := net.Listen("tcp", address)
+		if err != nil {
+			return e.SafeWrap(err, "failed to start the SOCKS5 proxy")
+		}
+		defer listener.Close()
+
+		portFile := bosh.TunnelPortFile(t.Config)
+		port := listener.Addr().(*net.TCPAddr).Port
+		if err := ioutil.WriteFile(portFile, []byte(strconv.Itoa(port)), 0600); err != nil {
+			return e.SafeWrap(err, "failed to record the SOCKS5 proxy port")
+		}
+		defer os.Remove(portFile)
+
+		go func() { errs <- tunnel.ServeSOCKS5(listener) }()
+		t.UI.Say("SOCKS5 proxy listening on %s", listener.Addr())
+		t.UI.Say("  e.g. export https_proxy=socks5://%s", listener.Addr())
+		t.UI.Say("  or eval \"$(cf dev bosh env --via-tunnel)\"")
+	}
+
+	for _, forward := range forwards {
+		listener, err := net.Listen("tcp", forward.LocalAddress)
+		if err != nil {
+			return e.SafeWrap(err, fmt.Sprintf("failed to listen on %s", forward.LocalAddress))
+		}
+		defer listener.Close()
+
+		remote := forward.RemoteAddress
+		go func() { errs <- tunnel.Forward(listener, remote) }()
+		t.UI.Say("Forwarding %s -> %s", forward.LocalAddress, forward.RemoteAddress)
+	}
+
+	t.UI.Say("Press Ctrl-C to stop the tunnel")
+
+	select {
+	case <-t.Exit:
+		return nil
+	case err := <-errs:
+		return e.SafeWrap(err, "tunnel closed")
+	}
+}
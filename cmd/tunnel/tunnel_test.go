@@ -0,0 +1,96 @@
+package tunnel_test
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"time"
+
+	cmd "code.cloudfoundry.org/cfdev/cmd/tunnel"
+	"code.cloudfoundry.org/cfdev/cmd/tunnel/mocks"
+	"code.cloudfoundry.org/cfdev/config"
+	"code.cloudfoundry.org/cfdev/ssh"
+	"github.com/golang/mock/gomock"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Tunnel", func() {
+	var (
+		mockController *gomock.Controller
+		mockUI         *mocks.MockUI
+		mockSSH        *mocks.MockSSH
+		tmpDir         string
+		tunnelCmd      *cmd.Tunnel
+	)
+
+	BeforeEach(func() {
+		mockController = gomock.NewController(GinkgoT())
+		mockUI = mocks.NewMockUI(mockController)
+		mockSSH = mocks.NewMockSSH(mockController)
+
+		var err error
+		tmpDir, err = ioutil.TempDir("", "cmd-tunnel-test")
+		Expect(err).NotTo(HaveOccurred())
+
+		ioutil.WriteFile(filepath.Join(tmpDir, "secret"), []byte("some-bosh-secret"), 0600)
+		ioutil.WriteFile(filepath.Join(tmpDir, "jumpbox.key"), []byte("some-private-key"), 0600)
+		ioutil.WriteFile(filepath.Join(tmpDir, "id_rsa"), []byte("some-vm-key"), 0600)
+
+		tunnelCmd = &cmd.Tunnel{
+			Exit: make(chan struct{}),
+			UI:   mockUI,
+			SSH:  mockSSH,
+			Config: config.Config{
+				StateBosh:      tmpDir,
+				StateDir:       tmpDir,
+				CacheDir:       tmpDir,
+				BoshDirectorIP: "10.0.0.1",
+			},
+		}
+	})
+
+	AfterEach(func() {
+		mockController.Finish()
+		os.RemoveAll(tmpDir)
+	})
+
+	It("connects to the jumpbox through the forwarded vm ssh port", func() {
+		mockSSH.EXPECT().Tunnel([]ssh.Hop{
+			{Address: ssh.SSHAddress{IP: "127.0.0.1", Port: "9992"}, User: "root", PrivateKey: []byte("some-vm-key")},
+			{Address: ssh.SSHAddress{IP: "10.0.0.1", Port: "22"}, User: "jumpbox", PrivateKey: []byte("some-private-key")},
+		}, 30*time.Second).Return(nil, errors.New("some-error"))
+
+		err := tunnelCmd.Execute(cmd.Args{Port: cmd.DefaultPort})
+		Expect(err).To(MatchError("failed to connect to the jumpbox: some-error"))
+	})
+
+	It("rejects malformed port forwards before connecting", func() {
+		err := tunnelCmd.Execute(cmd.Args{Forwards: []string{"8080"}})
+		Expect(err).To(MatchError(ContainSubstring("invalid port forward")))
+	})
+
+	It("requires a forward when the SOCKS5 proxy is disabled", func() {
+		err := tunnelCmd.Execute(cmd.Args{NoSocks: true})
+		Expect(err).To(MatchError("nothing to do: --no-socks requires at least one -L forward"))
+	})
+
+	Context("when the jumpbox key is missing", func() {
+		It("returns an error", func() {
+			os.Remove(filepath.Join(tmpDir, "jumpbox.key"))
+
+			err := tunnelCmd.Execute(cmd.Args{Port: cmd.DefaultPort})
+			Expect(err).To(MatchError(ContainSubstring("failed to read the jumpbox key")))
+		})
+	})
+
+	Context("when the vm key is missing", func() {
+		It("returns an error", func() {
+			os.Remove(filepath.Join(tmpDir, "id_rsa"))
+
+			err := tunnelCmd.Execute(cmd.Args{Port: cmd.DefaultPort})
+			Expect(err).To(MatchError(ContainSubstring("failed to read the vm key")))
+		})
+	})
+})
@@ -0,0 +1,64 @@
+package uaa
+
+import (
+	"code.cloudfoundry.org/cfdev/bosh"
+	"code.cloudfoundry.org/cfdev/config"
+	"code.cloudfoundry.org/cfdev/errors"
+	"code.cloudfoundry.org/cfdev/shell"
+	"github.com/spf13/cobra"
+)
+
+//go:generate mockgen -package mocks -destination mocks/ui.go code.cloudfoundry.org/cfdev/cmd/uaa UI
+type UI interface {
+	Say(message string, args ...interface{})
+}
+
+type UAA struct {
+	UI     UI
+	Config config.Config
+}
+
+func (u *UAA) Cmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use: "uaa",
+		Run: func(cmd *cobra.Command, args []string) {
+			if shell.DefaultFormat() == shell.PowerShell {
+				u.UI.Say(`Usage: cf dev uaa env | Invoke-Expression`)
+			} else {
+				u.UI.Say(`Usage: eval $(cf dev uaa env)`)
+			}
+		},
+	}
+	var shellName string
+	envCmd := &cobra.Command{
+		Use:   "env",
+		Short: "Print environment variables targeting the BOSH Director's UAA",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return u.Env(shellName)
+		},
+	}
+	envCmd.Flags().StringVar(&shellName, "shell", "", "format of the generated script: bash, zsh, fish, cmd, powershell, dotenv or json")
+	cmd.AddCommand(envCmd)
+	return cmd
+}
+
+func (u *UAA) Env(shellName string) error {
+	format, err := shell.ParseFormat(shellName)
+	if err != nil {
+		return errors.SafeWrap(err, "invalid --shell")
+	}
+
+	config, err := bosh.FetchUAAConfig(u.Config)
+	if err != nil {
+		return errors.SafeWrap(err, "failed to fetch uaa configuration")
+	}
+
+	env := shell.Environment{Format: format}
+	shellScript, err := env.PrepareUAA(config)
+	if err != nil {
+		return errors.SafeWrap(err, "failed to prepare uaa configuration")
+	}
+
+	u.UI.Say(shellScript)
+	return nil
+}
@@ -0,0 +1,72 @@
+package uaa_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	cmd "code.cloudfoundry.org/cfdev/cmd/uaa"
+	"code.cloudfoundry.org/cfdev/cmd/uaa/mocks"
+	"code.cloudfoundry.org/cfdev/config"
+	"github.com/golang/mock/gomock"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("UAA", func() {
+	var (
+		mockController *gomock.Controller
+		mockUI         *mocks.MockUI
+		tmpDir         string
+		uaaCmd         *cmd.UAA
+	)
+
+	BeforeEach(func() {
+		mockController = gomock.NewController(GinkgoT())
+		mockUI = mocks.NewMockUI(mockController)
+
+		var err error
+		tmpDir, err = ioutil.TempDir("", "cmd-uaa-test")
+		Expect(err).NotTo(HaveOccurred())
+
+		ioutil.WriteFile(filepath.Join(tmpDir, "creds.yml"), []byte(`---
+uaa_admin_client_secret: some-uaa-secret
+uaa_ssl:
+  ca: some-uaa-ca
+`), 0600)
+
+		uaaCmd = &cmd.UAA{
+			UI: mockUI,
+			Config: config.Config{
+				StateBosh:      tmpDir,
+				BoshDirectorIP: "10.0.0.1",
+			},
+		}
+	})
+
+	AfterEach(func() {
+		mockController.Finish()
+		os.RemoveAll(tmpDir)
+	})
+
+	Describe("Env", func() {
+		It("prints the uaa target and client credentials", func() {
+			caPath := filepath.Join(tmpDir, "uaa-ca.crt")
+			mockUI.EXPECT().Say(`UAA_URL="https://10.0.0.1:8443"
+UAA_CLIENT="uaa_admin"
+UAA_CLIENT_SECRET="some-uaa-secret"
+UAA_CA_CERT="` + caPath + `"`)
+
+			Expect(uaaCmd.Env("dotenv")).To(Succeed())
+			Expect(ioutil.ReadFile(caPath)).To(Equal([]byte("some-uaa-ca\n")))
+		})
+
+		Context("when creds.yml has no uaa certificate", func() {
+			It("returns an error", func() {
+				ioutil.WriteFile(filepath.Join(tmpDir, "creds.yml"), []byte("uaa_admin_client_secret: s\n"), 0600)
+
+				Expect(uaaCmd.Env("")).To(MatchError(ContainSubstring("creds.yml does not contain a certificate for uaa-ca.crt")))
+			})
+		})
+	})
+})
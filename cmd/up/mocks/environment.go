@@ -0,0 +1,47 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: code.cloudfoundry.org/cfdev/cmd/up (interfaces: Environment)
+
+// Package mocks is a generated GoMock package.
+package mocks
+
+import (
+	environment "code.cloudfoundry.org/cfdev/environment"
+	gomock "github.com/golang/mock/gomock"
+	reflect "reflect"
+)
+
+// MockEnvironment is a mock of Environment interface
+type MockEnvironment struct {
+	ctrl     *gomock.Controller
+	recorder *MockEnvironmentMockRecorder
+}
+
+// MockEnvironmentMockRecorder is the mock recorder for MockEnvironment
+type MockEnvironmentMockRecorder struct {
+	mock *MockEnvironment
+}
+
+// NewMockEnvironment creates a new mock instance
+func NewMockEnvironment(ctrl *gomock.Controller) *MockEnvironment {
+	mock := &MockEnvironment{ctrl: ctrl}
+	mock.recorder = &MockEnvironmentMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use
+func (m *MockEnvironment) EXPECT() *MockEnvironmentMockRecorder {
+	return m.recorder
+}
+
+// Converge mocks base method
+func (m *MockEnvironment) Converge(arg0 environment.Spec, arg1 bool) ([]environment.Change, error) {
+	ret := m.ctrl.Call(m, "Converge", arg0, arg1)
+	ret0, _ := ret[0].([]environment.Change)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Converge indicates an expected call of Converge
+func (mr *MockEnvironmentMockRecorder) Converge(arg0, arg1 interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Converge", reflect.TypeOf((*MockEnvironment)(nil).Converge), arg0, arg1)
+}
@@ -0,0 +1,46 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: code.cloudfoundry.org/cfdev/cmd/up (interfaces: Hypervisor)
+
+// Package mocks is a generated GoMock package.
+package mocks
+
+import (
+	gomock "github.com/golang/mock/gomock"
+	reflect "reflect"
+)
+
+// MockHypervisor is a mock of Hypervisor interface
+type MockHypervisor struct {
+	ctrl     *gomock.Controller
+	recorder *MockHypervisorMockRecorder
+}
+
+// MockHypervisorMockRecorder is the mock recorder for MockHypervisor
+type MockHypervisorMockRecorder struct {
+	mock *MockHypervisor
+}
+
+// NewMockHypervisor creates a new mock instance
+func NewMockHypervisor(ctrl *gomock.Controller) *MockHypervisor {
+	mock := &MockHypervisor{ctrl: ctrl}
+	mock.recorder = &MockHypervisorMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use
+func (m *MockHypervisor) EXPECT() *MockHypervisorMockRecorder {
+	return m.recorder
+}
+
+// IsRunning mocks base method
+func (m *MockHypervisor) IsRunning(arg0 string) (bool, error) {
+	ret := m.ctrl.Call(m, "IsRunning", arg0)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// IsRunning indicates an expected call of IsRunning
+func (mr *MockHypervisorMockRecorder) IsRunning(arg0 interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "IsRunning", reflect.TypeOf((*MockHypervisor)(nil).IsRunning), arg0)
+}
@@ -0,0 +1,176 @@
+package up
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"code.cloudfoundry.org/cfdev/cmd/start"
+	"code.cloudfoundry.org/cfdev/config"
+	"code.cloudfoundry.org/cfdev/environment"
+	e "code.cloudfoundry.org/cfdev/errors"
+	"code.cloudfoundry.org/cfdev/metadata"
+	"code.cloudfoundry.org/cfdev/provision"
+	"github.com/spf13/cobra"
+)
+
+//go:generate mockgen -package mocks -destination mocks/ui.go code.cloudfoundry.org/cfdev/cmd/up UI
+type UI interface {
+	Say(message string, args ...interface{})
+	Writer() io.Writer
+}
+
+//go:generate mockgen -package mocks -destination mocks/hypervisor.go code.cloudfoundry.org/cfdev/cmd/up Hypervisor
+type Hypervisor interface {
+	IsRunning(vmName string) (bool, error)
+}
+
+//go:generate mockgen -package mocks -destination mocks/start.go code.cloudfoundry.org/cfdev/cmd/up Start
+type Start interface {
+	Execute(args start.Args) error
+}
+
+//go:generate mockgen -package mocks -destination mocks/environment.go code.cloudfoundry.org/cfdev/cmd/up Environment
+type Environment interface {
+	Converge(spec environment.Spec, dryRun bool) ([]environment.Change, error)
+}
+
+//go:generate mockgen -package mocks -destination mocks/metadata_reader.go code.cloudfoundry.org/cfdev/cmd/up MetaDataReader
+type MetaDataReader interface {
+	Read(path string) (metadata.Metadata, error)
+}
+
+type Args struct {
+	File   string
+	DryRun bool
+}
+
+type Up struct {
+	Exit           chan struct{}
+	UI             UI
+	Config         config.Config
+	Hypervisor     Hypervisor
+	Start          Start
+	Environment    Environment
+	MetaDataReader MetaDataReader
+}
+
+const defaultCpus = 4
+
+func (u *Up) Cmd() *cobra.Command {
+	args := Args{}
+	cmd := &cobra.Command{
+		Use:   "up",
+		Short: "Start CF Dev if needed and converge it to an environment file",
+		RunE: func(_ *cobra.Command, _ []string) error {
+			if err := u.Execute(args); err != nil {
+				return e.SafeWrap(err, "cf dev up")
+			}
+			return nil
+		},
+	}
+
+	pf := cmd.PersistentFlags()
+	pf.StringVarP(&args.File, "file", "f", "cfdev.yml", "path to the environment file")
+	pf.BoolVar(&args.DryRun, "dry-run", false, "show what would change without changing anything")
+	return cmd
+}
+
+func (u *Up) Execute(args Args) error {
+	spec, err := environment.Load(args.File)
+	if err != nil {
+		return e.SafeWrap(err, "Unable to read environment file")
+	}
+
+	running, err := u.Hypervisor.IsRunning("cfdev")
+	if err != nil {
+		return e.SafeWrap(err, "is running")
+	}
+
+	if running {
+		u.UI.Say("CF Dev is already running...")
+		if err := u.warnUndeployedServices(args.File, spec.Start.Services); err != nil {
+			return err
+		}
+	} else if args.DryRun {
+		return e.SafeWrap(nil, "CF Dev is not running. Please execute 'cf dev start' before using --dry-run")
+	} else {
+		cpus := spec.Start.Cpus
+		if cpus == 0 {
+			cpus = defaultCpus
+		}
+
+		if err := u.Start.Execute(start.Args{
+			DepsPath:            spec.Start.File,
+			Cpus:                cpus,
+			Mem:                 spec.Start.Memory,
+			DeploySingleService: strings.Join(spec.Start.Services, ","),
+		}); err != nil {
+			return err
+		}
+	}
+
+	u.UI.Say("Converging environment...")
+	changes, err := u.Environment.Converge(spec, args.DryRun)
+	for _, change := range changes {
+		u.UI.Say("  %s", change)
+	}
+	if err != nil {
+		return e.SafeWrap(err, "Unable to converge environment")
+	}
+
+	if len(changes) == 0 {
+		u.UI.Say("Environment is up to date.")
+	}
+	return nil
+}
+
+// warnUndeployedServices points out the services the environment file
+// lists that the running CF Dev was started without, as they are only
+// deployed by start.
+func (u *Up) warnUndeployedServices(file string, services []string) error {
+	if len(services) == 0 {
+		return nil
+	}
+
+	startArgs, err := start.ReadArgs(start.ArgsPath(u.Config))
+	if err != nil && !os.IsNotExist(err) {
+		return e.SafeWrap(err, "Unable to read the start arguments")
+	}
+
+	metaData, err := u.MetaDataReader.Read(filepath.Join(u.Config.CacheDir, "metadata.yml"))
+	if err != nil {
+		return e.SafeWrap(err, "Unable to read the deps metadata")
+	}
+
+	controller := &provision.Controller{}
+	deployed, err := controller.WhiteListServices(startArgs.DeploySingleService, metaData.Services)
+	if err != nil {
+		return err
+	}
+	wanted, err := controller.WhiteListServices(strings.Join(services, ","), metaData.Services)
+	if err != nil {
+		return err
+	}
+
+	var missing []string
+	for _, service := range wanted {
+		if !hasService(deployed, service.Name) {
+			missing = append(missing, service.Flagname)
+		}
+	}
+	if len(missing) > 0 {
+		u.UI.Say("WARNING: %s lists services that are not deployed: %s. Run 'cf dev stop' and 'cf dev up' to deploy them", file, strings.Join(missing, ", "))
+	}
+	return nil
+}
+
+func hasService(services []provision.Service, name string) bool {
+	for _, service := range services {
+		if service.Name == name {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,157 @@
+package up_test
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"code.cloudfoundry.org/cfdev/cmd/start"
+	"code.cloudfoundry.org/cfdev/cmd/up"
+	"code.cloudfoundry.org/cfdev/cmd/up/mocks"
+	"code.cloudfoundry.org/cfdev/config"
+	"code.cloudfoundry.org/cfdev/environment"
+	"code.cloudfoundry.org/cfdev/metadata"
+	"code.cloudfoundry.org/cfdev/provision"
+	"github.com/golang/mock/gomock"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Up", func() {
+	var (
+		mockController  *gomock.Controller
+		mockUI          *mocks.MockUI
+		mockHypervisor  *mocks.MockHypervisor
+		mockStart       *mocks.MockStart
+		mockEnvironment *mocks.MockEnvironment
+		mockMetaData    *mocks.MockMetaDataReader
+		subject         *up.Up
+		tmpDir          string
+		specPath        string
+	)
+
+	BeforeEach(func() {
+		mockController = gomock.NewController(GinkgoT())
+		mockUI = mocks.NewMockUI(mockController)
+		mockHypervisor = mocks.NewMockHypervisor(mockController)
+		mockStart = mocks.NewMockStart(mockController)
+		mockEnvironment = mocks.NewMockEnvironment(mockController)
+		mockMetaData = mocks.NewMockMetaDataReader(mockController)
+
+		var err error
+		tmpDir, err = ioutil.TempDir("", "cfdev-up-")
+		Expect(err).NotTo(HaveOccurred())
+
+		subject = &up.Up{
+			UI:             mockUI,
+			Config:         config.Config{StateDir: tmpDir, CacheDir: tmpDir},
+			Hypervisor:     mockHypervisor,
+			Start:          mockStart,
+			Environment:    mockEnvironment,
+			MetaDataReader: mockMetaData,
+		}
+		specPath = filepath.Join(tmpDir, "cfdev.yml")
+		Expect(ioutil.WriteFile(specPath, []byte(`---
+start:
+  memory: 8192
+  services: [mysql, redis]
+orgs:
+- name: some-org
+`), 0644)).To(Succeed())
+	})
+
+	AfterEach(func() {
+		mockController.Finish()
+		os.RemoveAll(tmpDir)
+	})
+
+	Context("when cf dev is not running", func() {
+		It("starts it with the listed services and converges the environment", func() {
+			gomock.InOrder(
+				mockHypervisor.EXPECT().IsRunning("cfdev").Return(false, nil),
+				mockStart.EXPECT().Execute(start.Args{
+					Cpus:                4,
+					Mem:                 8192,
+					DeploySingleService: "mysql,redis",
+				}),
+				mockUI.EXPECT().Say("Converging environment..."),
+				mockEnvironment.EXPECT().Converge(gomock.Any(), false).Return([]environment.Change{
+					{Action: environment.Created, Kind: "org", Name: "some-org"},
+				}, nil),
+				mockUI.EXPECT().Say("  %s", environment.Change{Action: environment.Created, Kind: "org", Name: "some-org"}),
+			)
+
+			Expect(subject.Execute(up.Args{File: specPath})).To(Succeed())
+		})
+
+		It("refuses to do a dry run", func() {
+			mockHypervisor.EXPECT().IsRunning("cfdev").Return(false, nil)
+
+			Expect(subject.Execute(up.Args{File: specPath, DryRun: true})).To(MatchError(ContainSubstring("CF Dev is not running")))
+		})
+	})
+
+	Context("when cf dev is already running", func() {
+		BeforeEach(func() {
+			Expect(start.WriteArgs(start.ArgsPath(subject.Config), start.Args{DeploySingleService: "mysql,redis"})).To(Succeed())
+			mockMetaData.EXPECT().Read(filepath.Join(tmpDir, "metadata.yml")).Return(metadata.Metadata{
+				Services: []provision.Service{
+					{Name: "mysql", Flagname: "mysql", DefaultDeploy: true},
+					{Name: "redis", Flagname: "redis"},
+					{Name: "rabbitmq", Flagname: "rabbitmq"},
+				},
+			}, nil).AnyTimes()
+		})
+
+		It("warns about listed services it was started without", func() {
+			Expect(ioutil.WriteFile(specPath, []byte("start:\n  services: [mysql, rabbitmq]\n"), 0644)).To(Succeed())
+			Expect(start.WriteArgs(start.ArgsPath(subject.Config), start.Args{})).To(Succeed())
+			gomock.InOrder(
+				mockHypervisor.EXPECT().IsRunning("cfdev").Return(true, nil),
+				mockUI.EXPECT().Say("CF Dev is already running..."),
+				mockUI.EXPECT().Say("WARNING: %s lists services that are not deployed: %s. Run 'cf dev stop' and 'cf dev up' to deploy them", specPath, "rabbitmq"),
+				mockUI.EXPECT().Say("Converging environment..."),
+				mockEnvironment.EXPECT().Converge(gomock.Any(), false),
+				mockUI.EXPECT().Say("Environment is up to date."),
+			)
+
+			Expect(subject.Execute(up.Args{File: specPath})).To(Succeed())
+		})
+
+		It("reuses it and reports when nothing changed", func() {
+			gomock.InOrder(
+				mockHypervisor.EXPECT().IsRunning("cfdev").Return(true, nil),
+				mockUI.EXPECT().Say("CF Dev is already running..."),
+				mockUI.EXPECT().Say("Converging environment..."),
+				mockEnvironment.EXPECT().Converge(gomock.Any(), true).Do(func(spec environment.Spec, _ bool) {
+					Expect(spec.Orgs[0].Name).To(Equal("some-org"))
+				}),
+				mockUI.EXPECT().Say("Environment is up to date."),
+			)
+
+			Expect(subject.Execute(up.Args{File: specPath, DryRun: true})).To(Succeed())
+		})
+
+		It("reports the changes made before a failure", func() {
+			change := environment.Change{Action: environment.Created, Kind: "quota", Name: "small"}
+			gomock.InOrder(
+				mockHypervisor.EXPECT().IsRunning("cfdev").Return(true, nil),
+				mockUI.EXPECT().Say("CF Dev is already running..."),
+				mockUI.EXPECT().Say("Converging environment..."),
+				mockEnvironment.EXPECT().Converge(gomock.Any(), false).Return([]environment.Change{change}, errors.New("some-error")),
+				mockUI.EXPECT().Say("  %s", change),
+			)
+
+			Expect(subject.Execute(up.Args{File: specPath})).To(MatchError("Unable to converge environment: some-error"))
+		})
+	})
+
+	Context("when the environment file is invalid", func() {
+		It("returns an error without starting anything", func() {
+			Expect(ioutil.WriteFile(specPath, []byte("orgs:\n- name: o\n  managers: [bob]\n"), 0644)).To(Succeed())
+
+			Expect(subject.Execute(up.Args{File: specPath})).To(MatchError(ContainSubstring("user 'bob' is not defined")))
+		})
+	})
+})
@@ -0,0 +1,46 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: code.cloudfoundry.org/cfdev/cmd/upgrade (interfaces: Hooks)
+
+// Package mocks is a generated GoMock package.
+package mocks
+
+import (
+	hooks "code.cloudfoundry.org/cfdev/hooks"
+	gomock "github.com/golang/mock/gomock"
+	reflect "reflect"
+)
+
+// MockHooks is a mock of Hooks interface
+type MockHooks struct {
+	ctrl     *gomock.Controller
+	recorder *MockHooksMockRecorder
+}
+
+// MockHooksMockRecorder is the mock recorder for MockHooks
+type MockHooksMockRecorder struct {
+	mock *MockHooks
+}
+
+// NewMockHooks creates a new mock instance
+func NewMockHooks(ctrl *gomock.Controller) *MockHooks {
+	mock := &MockHooks{ctrl: ctrl}
+	mock.recorder = &MockHooksMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use
+func (m *MockHooks) EXPECT() *MockHooksMockRecorder {
+	return m.recorder
+}
+
+// Run mocks base method
+func (m *MockHooks) Run(arg0 hooks.Event) error {
+	ret := m.ctrl.Call(m, "Run", arg0)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Run indicates an expected call of Run
+func (mr *MockHooksMockRecorder) Run(arg0 interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Run", reflect.TypeOf((*MockHooks)(nil).Run), arg0)
+}
@@ -0,0 +1,47 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: code.cloudfoundry.org/cfdev/cmd/upgrade (interfaces: Index)
+
+// Package mocks is a generated GoMock package.
+package mocks
+
+import (
+	channel "code.cloudfoundry.org/cfdev/channel"
+	gomock "github.com/golang/mock/gomock"
+	reflect "reflect"
+)
+
+// MockIndex is a mock of Index interface
+type MockIndex struct {
+	ctrl     *gomock.Controller
+	recorder *MockIndexMockRecorder
+}
+
+// MockIndexMockRecorder is the mock recorder for MockIndex
+type MockIndexMockRecorder struct {
+	mock *MockIndex
+}
+
+// NewMockIndex creates a new mock instance
+func NewMockIndex(ctrl *gomock.Controller) *MockIndex {
+	mock := &MockIndex{ctrl: ctrl}
+	mock.recorder = &MockIndexMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use
+func (m *MockIndex) EXPECT() *MockIndexMockRecorder {
+	return m.recorder
+}
+
+// Fetch mocks base method
+func (m *MockIndex) Fetch() (channel.Index, error) {
+	ret := m.ctrl.Call(m, "Fetch")
+	ret0, _ := ret[0].(channel.Index)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Fetch indicates an expected call of Fetch
+func (mr *MockIndexMockRecorder) Fetch() *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Fetch", reflect.TypeOf((*MockIndex)(nil).Fetch))
+}
@@ -0,0 +1,57 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: code.cloudfoundry.org/cfdev/cmd/upgrade (interfaces: Start)
+
+// Package mocks is a generated GoMock package.
+package mocks
+
+import (
+	start "code.cloudfoundry.org/cfdev/cmd/start"
+	resource "code.cloudfoundry.org/cfdev/resource"
+	gomock "github.com/golang/mock/gomock"
+	reflect "reflect"
+)
+
+// MockStart is a mock of Start interface
+type MockStart struct {
+	ctrl     *gomock.Controller
+	recorder *MockStartMockRecorder
+}
+
+// MockStartMockRecorder is the mock recorder for MockStart
+type MockStartMockRecorder struct {
+	mock *MockStart
+}
+
+// NewMockStart creates a new mock instance
+func NewMockStart(ctrl *gomock.Controller) *MockStart {
+	mock := &MockStart{ctrl: ctrl}
+	mock.recorder = &MockStartMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use
+func (m *MockStart) EXPECT() *MockStartMockRecorder {
+	return m.recorder
+}
+
+// Execute mocks base method
+func (m *MockStart) Execute(arg0 start.Args) error {
+	ret := m.ctrl.Call(m, "Execute", arg0)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Execute indicates an expected call of Execute
+func (mr *MockStartMockRecorder) Execute(arg0 interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Execute", reflect.TypeOf((*MockStart)(nil).Execute), arg0)
+}
+
+// SetDependencies mocks base method
+func (m *MockStart) SetDependencies(arg0 resource.Catalog) {
+	m.ctrl.Call(m, "SetDependencies", arg0)
+}
+
+// SetDependencies indicates an expected call of SetDependencies
+func (mr *MockStartMockRecorder) SetDependencies(arg0 interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetDependencies", reflect.TypeOf((*MockStart)(nil).SetDependencies), arg0)
+}
@@ -0,0 +1,194 @@
+package upgrade
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"runtime"
+
+	"code.cloudfoundry.org/cfdev/channel"
+	"code.cloudfoundry.org/cfdev/cmd/start"
+	"code.cloudfoundry.org/cfdev/config"
+	e "code.cloudfoundry.org/cfdev/errors"
+	"code.cloudfoundry.org/cfdev/hooks"
+	"code.cloudfoundry.org/cfdev/resource"
+	"github.com/spf13/cobra"
+)
+
+//go:generate mockgen -package mocks -destination mocks/ui.go code.cloudfoundry.org/cfdev/cmd/upgrade UI
+type UI interface {
+	Say(message string, args ...interface{})
+	Writer() io.Writer
+}
+
+//go:generate mockgen -package mocks -destination mocks/index.go code.cloudfoundry.org/cfdev/cmd/upgrade Index
+type Index interface {
+	Fetch() (channel.Index, error)
+}
+
+//go:generate mockgen -package mocks -destination mocks/cache.go code.cloudfoundry.org/cfdev/cmd/upgrade Cache
+type Cache interface {
+	Sync(resource.Catalog) error
+}
+
+//go:generate mockgen -package mocks -destination mocks/hypervisor.go code.cloudfoundry.org/cfdev/cmd/upgrade Hypervisor
+type Hypervisor interface {
+	IsRunning(vmName string) (bool, error)
+}
+
+//go:generate mockgen -package mocks -destination mocks/stop.go code.cloudfoundry.org/cfdev/cmd/upgrade Stop
+type Stop interface {
+	RunE(cmd *cobra.Command, args []string) error
+}
+
+//go:generate mockgen -package mocks -destination mocks/hooks.go code.cloudfoundry.org/cfdev/cmd/upgrade Hooks
+type Hooks interface {
+	Run(event hooks.Event) error
+}
+
+//go:generate mockgen -package mocks -destination mocks/start.go code.cloudfoundry.org/cfdev/cmd/upgrade Start
+type Start interface {
+	SetDependencies(catalog resource.Catalog)
+	Execute(args start.Args) error
+}
+
+type Args struct {
+	Check   bool
+	Channel string
+}
+
+type Upgrade struct {
+	UI         UI
+	Config     config.Config
+	Index      Index
+	Cache      Cache
+	Hypervisor Hypervisor
+	Stop       Stop
+	Start      Start
+	Hooks      Hooks
+}
+
+func (u *Upgrade) Cmd() *cobra.Command {
+	args := Args{}
+	cmd := &cobra.Command{
+		Use:   "upgrade",
+		Short: "Download newer deps from the release channel and reprovision CF Dev with them",
+		RunE: func(_ *cobra.Command, _ []string) error {
+			if err := u.Execute(args); err != nil {
+				return e.SafeWrap(err, "cf dev upgrade")
+			}
+			return nil
+		},
+	}
+
+	pf := cmd.PersistentFlags()
+	pf.BoolVar(&args.Check, "check", false, "only report available deps and plugin updates")
+	pf.StringVar(&args.Channel, "channel", "", fmt.Sprintf("release channel to follow, e.g. %s or %s (default %s)", channel.Stable, channel.Edge, u.Config.Channel))
+	return cmd
+}
+
+func (u *Upgrade) Execute(args Args) error {
+	if args.Channel == "" {
+		args.Channel = u.Config.Channel
+	}
+
+	index, err := u.Index.Fetch()
+	if err != nil {
+		return err
+	}
+
+	update, err := index.Check(args.Channel, runtime.GOOS, u.Config.DepsVersion, u.Config.CliVersion)
+	if err != nil {
+		return e.SafeWrap(err, "Unable to check for updates")
+	}
+
+	if args.Check {
+		u.report(args.Channel, update)
+		return nil
+	}
+
+	if u.Config.DepsVersion == "" {
+		u.UI.Say("The version of the built in deps is unknown, so they cannot be upgraded. Please install a released plugin")
+		return nil
+	}
+
+	if update.Release == nil {
+		u.UI.Say("CF Dev deps are up to date (%s)", u.Config.DepsVersion)
+		u.reportBlocked(update)
+		return nil
+	}
+
+	running, err := u.Hypervisor.IsRunning("cfdev")
+	if err != nil {
+		return e.SafeWrap(err, "is running")
+	}
+
+	startArgs, err := start.ReadArgs(start.ArgsPath(u.Config))
+	if os.IsNotExist(err) {
+		startArgs = start.Args{Cpus: 4}
+	} else if err != nil {
+		return e.SafeWrap(err, "failed to read start arguments")
+	}
+
+	if running && startArgs.DepsPath != "" {
+		return e.SafeWrap(nil, fmt.Sprintf("CF Dev is running the deps file %s. Please execute 'cf dev stop' before upgrading", startArgs.DepsPath))
+	}
+
+	catalog := update.Release.Catalogs[runtime.GOOS]
+	u.UI.Say("Downloading deps %s...", update.Release.Version)
+	if err := u.Cache.Sync(catalog); err != nil {
+		return e.SafeWrap(err, "Unable to download the new deps")
+	}
+
+	if err := channel.WriteInstalled(u.Config.InstalledCatalogPath, channel.Installed{
+		Channel: args.Channel,
+		Version: update.Release.Version,
+		Catalog: catalog,
+	}); err != nil {
+		return e.SafeWrap(err, "Unable to save the new catalog")
+	}
+
+	if !running {
+		u.UI.Say("Deps %s are installed. 'cf dev start' will use them", update.Release.Version)
+		return nil
+	}
+
+	u.UI.Say("Reprovisioning CF Dev with deps %s...", update.Release.Version)
+	if err := u.Hooks.Run(hooks.PreStop); err != nil {
+		return err
+	}
+	if err := u.Stop.RunE(nil, nil); err != nil {
+		return e.SafeWrap(err, "stopping cfdev")
+	}
+
+	startArgs.Snapshot = ""
+	startArgs.NoProvision = false
+	u.Start.SetDependencies(catalog)
+	return u.Start.Execute(startArgs)
+}
+
+func (u *Upgrade) report(channelName string, update channel.Update) {
+	u.UI.Say("Channel: %s", channelName)
+
+	if u.Config.DepsVersion == "" {
+		u.UI.Say("Deps:    %s (version unknown)", "built in")
+	} else if update.Release != nil {
+		u.UI.Say("Deps:    %s installed, %s available", u.Config.DepsVersion, update.Release.Version)
+	} else {
+		u.UI.Say("Deps:    %s (up to date)", u.Config.DepsVersion)
+	}
+
+	if update.Plugin != nil {
+		u.UI.Say("Plugin:  %s installed, %s available. Run 'cf install-plugin %s' to upgrade", u.Config.CliVersion.Original, update.Plugin.Version, update.Plugin.URLs[runtime.GOOS])
+	} else {
+		u.UI.Say("Plugin:  %s (up to date)", u.Config.CliVersion.Original)
+	}
+
+	u.reportBlocked(update)
+}
+
+func (u *Upgrade) reportBlocked(update channel.Update) {
+	if update.Blocked != nil {
+		u.UI.Say("Deps %s need plugin %s or later", update.Blocked.Version, update.Blocked.MinPluginVersion)
+	}
+}
@@ -0,0 +1,200 @@
+package upgrade_test
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"runtime"
+
+	"code.cloudfoundry.org/cfdev/channel"
+	"code.cloudfoundry.org/cfdev/cmd/start"
+	"code.cloudfoundry.org/cfdev/cmd/upgrade"
+	"code.cloudfoundry.org/cfdev/cmd/upgrade/mocks"
+	"code.cloudfoundry.org/cfdev/config"
+	"code.cloudfoundry.org/cfdev/hooks"
+	"code.cloudfoundry.org/cfdev/resource"
+	"code.cloudfoundry.org/cfdev/semver"
+	"github.com/golang/mock/gomock"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Upgrade", func() {
+	var (
+		mockController *gomock.Controller
+		mockUI         *mocks.MockUI
+		mockIndex      *mocks.MockIndex
+		mockCache      *mocks.MockCache
+		mockHypervisor *mocks.MockHypervisor
+		mockStop       *mocks.MockStop
+		mockStart      *mocks.MockStart
+		mockHooks      *mocks.MockHooks
+		subject        *upgrade.Upgrade
+		tmpDir         string
+		catalog        resource.Catalog
+		index          channel.Index
+		output         []string
+	)
+
+	BeforeEach(func() {
+		mockController = gomock.NewController(GinkgoT())
+		mockUI = mocks.NewMockUI(mockController)
+		mockIndex = mocks.NewMockIndex(mockController)
+		mockCache = mocks.NewMockCache(mockController)
+		mockHypervisor = mocks.NewMockHypervisor(mockController)
+		mockStop = mocks.NewMockStop(mockController)
+		mockStart = mocks.NewMockStart(mockController)
+		mockHooks = mocks.NewMockHooks(mockController)
+
+		var err error
+		tmpDir, err = ioutil.TempDir("", "cfdev-upgrade-")
+		Expect(err).NotTo(HaveOccurred())
+
+		subject = &upgrade.Upgrade{
+			UI: mockUI,
+			Config: config.Config{
+				StateDir:             filepath.Join(tmpDir, "state"),
+				InstalledCatalogPath: filepath.Join(tmpDir, "catalog.json"),
+				Channel:              channel.Stable,
+				DepsVersion:          "1.1.0",
+				CliVersion:           semver.Must(semver.New("0.0.16")),
+			},
+			Index:      mockIndex,
+			Cache:      mockCache,
+			Hypervisor: mockHypervisor,
+			Stop:       mockStop,
+			Start:      mockStart,
+			Hooks:      mockHooks,
+		}
+
+		catalog = resource.Catalog{Items: []resource.Item{{Name: "cfdev-deps.tgz", URL: "https://example.com/1.2.0.tgz"}}}
+		index = channel.Index{Channels: map[string]channel.Channel{
+			channel.Stable: {
+				Plugin: channel.Plugin{Version: "0.0.16"},
+				Releases: []channel.Release{
+					{Version: "1.2.0", Catalogs: map[string]resource.Catalog{runtime.GOOS: catalog}},
+				},
+			},
+		}}
+		mockIndex.EXPECT().Fetch().Return(index, nil).AnyTimes()
+
+		output = nil
+		mockUI.EXPECT().Say(gomock.Any(), gomock.Any()).Do(func(message string, args ...interface{}) {
+			output = append(output, fmt.Sprintf(message, args...))
+		}).AnyTimes()
+	})
+
+	AfterEach(func() {
+		mockController.Finish()
+		os.RemoveAll(tmpDir)
+	})
+
+	Context("with --check", func() {
+		It("reports available updates without changing anything", func() {
+			Expect(subject.Execute(upgrade.Args{Check: true})).To(Succeed())
+			Expect(output).To(Equal([]string{
+				"Channel: stable",
+				"Deps:    1.1.0 installed, 1.2.0 available",
+				"Plugin:  0.0.16 (up to date)",
+			}))
+
+			_, err := os.Stat(filepath.Join(tmpDir, "catalog.json"))
+			Expect(os.IsNotExist(err)).To(BeTrue())
+		})
+
+		It("does not offer deps when the built in version is unknown", func() {
+			subject.Config.DepsVersion = ""
+
+			Expect(subject.Execute(upgrade.Args{Check: true})).To(Succeed())
+			Expect(output).To(Equal([]string{
+				"Channel: stable",
+				"Deps:    built in (version unknown)",
+				"Plugin:  0.0.16 (up to date)",
+			}))
+		})
+	})
+
+	It("does not upgrade built in deps of an unknown version", func() {
+		subject.Config.DepsVersion = ""
+
+		Expect(subject.Execute(upgrade.Args{})).To(Succeed())
+		Expect(output).To(Equal([]string{"The version of the built in deps is unknown, so they cannot be upgraded. Please install a released plugin"}))
+
+		_, err := os.Stat(filepath.Join(tmpDir, "catalog.json"))
+		Expect(os.IsNotExist(err)).To(BeTrue())
+	})
+
+	It("does nothing when the deps are up to date", func() {
+		subject.Config.DepsVersion = "1.2.0"
+
+		Expect(subject.Execute(upgrade.Args{})).To(Succeed())
+		Expect(output).To(Equal([]string{"CF Dev deps are up to date (1.2.0)"}))
+	})
+
+	Context("when CF Dev is not running", func() {
+		It("downloads and adopts the new deps", func() {
+			gomock.InOrder(
+				mockHypervisor.EXPECT().IsRunning("cfdev").Return(false, nil),
+				mockCache.EXPECT().Sync(catalog),
+			)
+
+			Expect(subject.Execute(upgrade.Args{})).To(Succeed())
+
+			installed, err := channel.ReadInstalled(filepath.Join(tmpDir, "catalog.json"))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(*installed).To(Equal(channel.Installed{Channel: "stable", Version: "1.2.0", Catalog: catalog}))
+			Expect(output).To(ContainElement("Deps 1.2.0 are installed. 'cf dev start' will use them"))
+		})
+
+		It("does not adopt deps that fail to download", func() {
+			mockHypervisor.EXPECT().IsRunning("cfdev").Return(false, nil)
+			mockCache.EXPECT().Sync(catalog).Return(errors.New("some-error"))
+
+			Expect(subject.Execute(upgrade.Args{})).To(MatchError(ContainSubstring("some-error")))
+			_, err := os.Stat(filepath.Join(tmpDir, "catalog.json"))
+			Expect(os.IsNotExist(err)).To(BeTrue())
+		})
+	})
+
+	Context("when CF Dev is running", func() {
+		BeforeEach(func() {
+			Expect(start.WriteArgs(start.ArgsPath(subject.Config), start.Args{
+				Cpus:                6,
+				Mem:                 8192,
+				DeploySingleService: "mysql",
+			})).To(Succeed())
+		})
+
+		It("reprovisions it with the same start arguments", func() {
+			gomock.InOrder(
+				mockHypervisor.EXPECT().IsRunning("cfdev").Return(true, nil),
+				mockCache.EXPECT().Sync(catalog),
+				mockHooks.EXPECT().Run(hooks.PreStop),
+				mockStop.EXPECT().RunE(nil, nil),
+				mockStart.EXPECT().SetDependencies(catalog),
+				mockStart.EXPECT().Execute(start.Args{Cpus: 6, Mem: 8192, DeploySingleService: "mysql"}),
+			)
+
+			Expect(subject.Execute(upgrade.Args{})).To(Succeed())
+		})
+
+		It("does not stop CF Dev when a pre-stop hook fails", func() {
+			gomock.InOrder(
+				mockHypervisor.EXPECT().IsRunning("cfdev").Return(true, nil),
+				mockCache.EXPECT().Sync(catalog),
+				mockHooks.EXPECT().Run(hooks.PreStop).Return(errors.New("some-hook-error")),
+			)
+
+			Expect(subject.Execute(upgrade.Args{})).To(MatchError("some-hook-error"))
+		})
+
+		It("refuses when it was started with a deps file", func() {
+			Expect(start.WriteArgs(start.ArgsPath(subject.Config), start.Args{DepsPath: "/some/deps.tgz"})).To(Succeed())
+			mockHypervisor.EXPECT().IsRunning("cfdev").Return(true, nil)
+
+			Expect(subject.Execute(upgrade.Args{})).To(MatchError(ContainSubstring("CF Dev is running the deps file /some/deps.tgz")))
+		})
+	})
+})
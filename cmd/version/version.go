@@ -50,6 +50,11 @@ func (v *Version) Execute(pathTarball string) {
 				Dst:           tmpDir,
 				FlattenFolder: true,
 			},
+			{
+				Include:       "metadata.yml.sig",
+				Dst:           tmpDir,
+				FlattenFolder: true,
+			},
 		})
 
 		if !exists(filepath.Join(tmpDir, "metadata.yml")) {
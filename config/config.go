@@ -2,30 +2,49 @@ package config
 
 import (
 	"encoding/json"
+	"fmt"
+	"net/url"
 	"os"
 	"path/filepath"
 	"sort"
 	"strconv"
+	"strings"
+	"time"
 
+	"code.cloudfoundry.org/bytefmt"
+	"code.cloudfoundry.org/cfdev/channel"
 	"code.cloudfoundry.org/cfdev/errors"
 
 	"code.cloudfoundry.org/cfdev/resource"
+	"code.cloudfoundry.org/cfdev/resource/progress"
 	"code.cloudfoundry.org/cfdev/semver"
+	"code.cloudfoundry.org/cfdev/signature"
 	"runtime"
 )
 
 var (
-	cfdepsUrl  string
-	cfdepsMd5  string
-	cfdepsSize string
+	cfdepsUrl    string
+	cfdepsMd5    string
+	cfdepsSha256 string
+	cfdepsSize   string
+	// cfdepsVersion is the release of the built in deps on the
+	// catalog index at catalogIndexUrl.
+	cfdepsVersion   string
+	catalogIndexUrl string
 
-	cfdevdUrl  string
-	cfdevdMd5  string
-	cfdevdSize string
+	cfdevdUrl    string
+	cfdevdMd5    string
+	cfdevdSha256 string
+	cfdevdSize   string
 
-	analyticsdUrl  string
-	analyticsdMd5  string
-	analyticsdSize string
+	analyticsdUrl    string
+	analyticsdMd5    string
+	analyticsdSha256 string
+	analyticsdSize   string
+
+	// signingKey is the base64 encoded ed25519 public key used to verify
+	// catalogs, metadata and binaries. Development builds leave it empty.
+	signingKey string
 
 	analyticsKey     string
 	testAnalyticsKey string
@@ -52,12 +71,67 @@ type Config struct {
 	AnalyticsKey           string
 	ServicesDir            string
 	CFDomain               string
+	SigningKey             string
+	DownloadConnections    int
+	SharedCacheDir         string
+	S3                     resource.S3Config
+	DownloadClient         DownloadClient
+	ProgressMode           string
+	CatalogIndexURL        string
+	Channel                string
+	DepsVersion            string
+	InstalledCatalogPath   string
+}
+
+// DownloadClient configures the http client assets are downloaded with.
+// Without a Proxy, downloads use the same proxy variables as the VM.
+type DownloadClient struct {
+	Proxy          string
+	CABundle       string
+	ConnectTimeout time.Duration
+	ReadTimeout    time.Duration
+	// RateLimit caps the combined download speed in bytes per second.
+	RateLimit uint64
 }
 
 func NewConfig() (Config, error) {
 	cfdevHome := getCfdevHome()
 
-	catalog, err := catalog()
+	installedCatalogPath := filepath.Join(cfdevHome, "catalog.json")
+	installed, err := channel.ReadInstalled(installedCatalogPath)
+	if err != nil {
+		return Config{}, errors.SafeWrap(err, fmt.Sprintf("Unable to read %s", installedCatalogPath))
+	}
+
+	catalog, err := catalog(installed)
+	if err != nil {
+		return Config{}, err
+	}
+
+	depsVersion, depsChannel := cfdepsVersion, channel.Stable
+	if installed != nil {
+		depsVersion, depsChannel = installed.Version, installed.Channel
+	}
+	if value := os.Getenv("CFDEV_CHANNEL"); value != "" {
+		depsChannel = value
+	}
+
+	catalogIndexURL := catalogIndexUrl
+	if value := os.Getenv("CFDEV_CATALOG_INDEX"); value != "" {
+		catalogIndexURL = value
+	}
+
+	connections, err := downloadConnections()
+	if err != nil {
+		return Config{}, err
+	}
+
+	downloadClient, err := newDownloadClient()
+	if err != nil {
+		return Config{}, err
+	}
+
+	progressMode, err := progressMode()
 	if err != nil {
 		return Config{}, err
 	}
@@ -86,13 +160,103 @@ func NewConfig() (Config, error) {
 		Dependencies:           catalog,
 		CFDevDSocketPath:       filepath.Join("/var", "tmp", "cfdevd.socket"),
 		CFDevDInstallationPath: filepath.Join("/Library", "PrivilegedHelperTools", "org.cloudfoundry.cfdevd"),
-		CliVersion:             semver.Must(semver.New(cliVersion)),
+		CliVersion:             pluginVersion(),
 		AnalyticsKey:           analytixKey,
 		ServicesDir:            filepath.Join(cfdevHome, "services"),
 		CFDomain:               "dev.cfdev.sh",
+		SigningKey:             signingKey,
+		DownloadConnections:    connections,
+		SharedCacheDir:         os.Getenv("CFDEV_SHARED_CACHE"),
+		S3:                     s3Config(),
+		DownloadClient:         downloadClient,
+		ProgressMode:           progressMode,
+		CatalogIndexURL:        catalogIndexURL,
+		Channel:                depsChannel,
+		DepsVersion:            depsVersion,
+		InstalledCatalogPath:   installedCatalogPath,
 	}, nil
 }
 
+// pluginVersion is cliVersion, or an unversioned development build when it
+// cannot be parsed, so that a bad version does not stop every command.
+func pluginVersion() *semver.Version {
+	v, err := semver.NewLenient(cliVersion)
+	if err != nil {
+		return &semver.Version{}
+	}
+	return v
+}
+
+func progressMode() (string, error) {
+	mode := strings.ToLower(os.Getenv("CFDEV_PROGRESS"))
+	switch mode {
+	case "", progress.ModeInteractive, progress.ModePlain, progress.ModeSilent:
+		return mode, nil
+	}
+	return "", errors.SafeWrap(fmt.Errorf("'%s' is not one of interactive, plain or silent", mode), "Unable to parse CFDEV_PROGRESS env variable")
+}
+
+func newDownloadClient() (DownloadClient, error) {
+	client := DownloadClient{
+		Proxy:          os.Getenv("CFDEV_DOWNLOAD_PROXY"),
+		CABundle:       os.Getenv("CFDEV_CA_BUNDLE"),
+		ConnectTimeout: 30 * time.Second,
+		ReadTimeout:    2 * time.Minute,
+	}
+
+	if client.Proxy != "" {
+		if _, err := url.Parse(client.Proxy); err != nil {
+			return DownloadClient{}, errors.SafeWrap(err, "Unable to parse CFDEV_DOWNLOAD_PROXY env variable")
+		}
+	}
+
+	for name, timeout := range map[string]*time.Duration{
+		"CFDEV_DOWNLOAD_CONNECT_TIMEOUT": &client.ConnectTimeout,
+		"CFDEV_DOWNLOAD_READ_TIMEOUT":    &client.ReadTimeout,
+	} {
+		if value := os.Getenv(name); value != "" {
+			duration, err := time.ParseDuration(value)
+			if err != nil || duration < 0 {
+				return DownloadClient{}, errors.SafeWrap(fmt.Errorf("'%s' is not a duration such as 30s", value), fmt.Sprintf("Unable to parse %s env variable", name))
+			}
+			*timeout = duration
+		}
+	}
+
+	if value := os.Getenv("CFDEV_DOWNLOAD_RATE_LIMIT"); value != "" {
+		rate, err := bytefmt.ToBytes(value)
+		if err != nil {
+			return DownloadClient{}, errors.SafeWrap(fmt.Errorf("'%s' is not a size such as 10M", value), "Unable to parse CFDEV_DOWNLOAD_RATE_LIMIT env variable")
+		}
+		client.RateLimit = rate
+	}
+
+	return client, nil
+}
+
+func s3Config() resource.S3Config {
+	region := os.Getenv("CFDEV_S3_REGION")
+	if region == "" {
+		region = resource.DefaultS3Region
+	}
+	return resource.S3Config{
+		Endpoint:        os.Getenv("CFDEV_S3_ENDPOINT"),
+		Region:          region,
+		AccessKeyID:     getenv("CFDEV_S3_ACCESS_KEY_ID", "AWS_ACCESS_KEY_ID"),
+		SecretAccessKey: getenv("CFDEV_S3_SECRET_ACCESS_KEY", "AWS_SECRET_ACCESS_KEY"),
+		Insecure:        strings.ToLower(os.Getenv("CFDEV_S3_INSECURE")) == "true",
+	}
+}
+
+func getenv(names ...string) string {
+	for _, name := range names {
+		if value := os.Getenv(name); value != "" {
+			return value
+		}
+	}
+	return ""
+}
+
 func aToUint64(a string) uint64 {
 	i, err := strconv.ParseUint(a, 10, 64)
 	if err != nil {
@@ -101,10 +265,27 @@ func aToUint64(a string) uint64 {
 	return i
 }
 
-func catalog() (resource.Catalog, error) {
+func downloadConnections() (int, error) {
+	value := os.Getenv("CFDEV_DOWNLOAD_CONNECTIONS")
+	if value == "" {
+		return resource.DefaultConnections, nil
+	}
+
+	connections, err := strconv.Atoi(value)
+	if err != nil || connections < 1 {
+		return 0, errors.SafeWrap(fmt.Errorf("'%s' is not a positive number", value), "Unable to parse CFDEV_DOWNLOAD_CONNECTIONS env variable")
+	}
+	return connections, nil
+}
+
+func catalog(installed *channel.Installed) (resource.Catalog, error) {
 	override := os.Getenv("CFDEV_CATALOG")
 
 	if override != "" {
+		if err := verifyCatalog(override); err != nil {
+			return resource.Catalog{}, err
+		}
+
 		var c resource.Catalog
 		if err := json.Unmarshal([]byte(override), &c); err != nil {
 			return resource.Catalog{}, errors.SafeWrap(err, "Unable to parse CFDEV_CATALOG env variable")
@@ -112,14 +293,19 @@ func catalog() (resource.Catalog, error) {
 		return c, nil
 	}
 
+	if installed != nil {
+		return installed.Catalog, nil
+	}
+
 	catalog := resource.Catalog{
 		Items: []resource.Item{
 			{
-				URL:   cfdepsUrl,
-				Name:  "cfdev-deps.tgz",
-				MD5:   cfdepsMd5,
-				Size:  aToUint64(cfdepsSize),
-				InUse: true,
+				URL:    cfdepsUrl,
+				Name:   "cfdev-deps.tgz",
+				MD5:    cfdepsMd5,
+				SHA256: cfdepsSha256,
+				Size:   aToUint64(cfdepsSize),
+				InUse:  true,
 			},
 		},
 	}
@@ -127,27 +313,30 @@ func catalog() (resource.Catalog, error) {
 	if runtime.GOOS != "windows" {
 		catalog.Items = append(catalog.Items,
 			resource.Item{
-				URL:   analyticsdUrl,
-				Name:  "analyticsd",
-				MD5:   analyticsdMd5,
-				Size:  aToUint64(analyticsdSize),
-				InUse: true,
+				URL:    analyticsdUrl,
+				Name:   "analyticsd",
+				MD5:    analyticsdMd5,
+				SHA256: analyticsdSha256,
+				Size:   aToUint64(analyticsdSize),
+				InUse:  true,
 			},
 			resource.Item{
-				URL:   cfdevdUrl,
-				Name:  "cfdevd",
-				MD5:   cfdevdMd5,
-				Size:  aToUint64(cfdevdSize),
-				InUse: true,
+				URL:    cfdevdUrl,
+				Name:   "cfdevd",
+				MD5:    cfdevdMd5,
+				SHA256: cfdevdSha256,
+				Size:   aToUint64(cfdevdSize),
+				InUse:  true,
 			})
 	} else {
 		catalog.Items = append(catalog.Items,
 			resource.Item{
-				URL:   analyticsdUrl,
-				Name:  "analyticsd.exe",
-				MD5:   analyticsdMd5,
-				Size:  aToUint64(analyticsdSize),
-				InUse: true,
+				URL:    analyticsdUrl,
+				Name:   "analyticsd.exe",
+				MD5:    analyticsdMd5,
+				SHA256: analyticsdSha256,
+				Size:   aToUint64(analyticsdSize),
+				InUse:  true,
 			})
 	}
 
@@ -157,6 +346,27 @@ func catalog() (resource.Catalog, error) {
 	return catalog, nil
 }
 
+// verifyCatalog checks a CFDEV_CATALOG override against the detached
+// signature in CFDEV_CATALOG_SIGNATURE when a signing key is embedded.
+func verifyCatalog(override string) error {
+	verifier, err := signature.New(signingKey)
+	if err != nil {
+		return err
+	}
+	if !verifier.Enabled() {
+		return nil
+	}
+
+	sig := os.Getenv("CFDEV_CATALOG_SIGNATURE")
+	if sig == "" {
+		return errors.SafeWrap(nil, "CFDEV_CATALOG_SIGNATURE must be set when overriding the catalog")
+	}
+	if err := verifier.Verify([]byte(override), sig); err != nil {
+		return errors.SafeWrap(err, "Unable to verify CFDEV_CATALOG env variable")
+	}
+	return nil
+}
+
 func getCfdevHome() string {
 	cfdevHome := os.Getenv("CFDEV_HOME")
 	if cfdevHome != "" {
@@ -0,0 +1,131 @@
+package credentials
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"code.cloudfoundry.org/cfdev/bosh"
+	"code.cloudfoundry.org/cfdev/config"
+	"gopkg.in/yaml.v2"
+)
+
+type Endpoints struct {
+	Domain      string `json:"domain"`
+	API         string `json:"api"`
+	UAA         string `json:"uaa"`
+	Login       string `json:"login"`
+	Doppler     string `json:"doppler"`
+	Director    string `json:"director"`
+	DirectorUAA string `json:"director_uaa"`
+	Credhub     string `json:"credhub"`
+	Jumpbox     string `json:"jumpbox"`
+}
+
+type Credential struct {
+	Name     string `json:"name"`
+	Endpoint string `json:"endpoint"`
+	Username string `json:"username"`
+	Value    string `json:"value"`
+}
+
+const Mask = "********"
+
+func EndpointsFor(cfg config.Config) Endpoints {
+	return Endpoints{
+		Domain:      cfg.CFDomain,
+		API:         "https://api." + cfg.CFDomain,
+		UAA:         "https://uaa." + cfg.CFDomain,
+		Login:       "https://login." + cfg.CFDomain,
+		Doppler:     "wss://doppler." + cfg.CFDomain + ":443",
+		Director:    fmt.Sprintf("https://%s:25555", cfg.BoshDirectorIP),
+		DirectorUAA: fmt.Sprintf("https://%s:%d", cfg.BoshDirectorIP, bosh.UAAPort),
+		Credhub:     fmt.Sprintf("https://%s:%d", cfg.BoshDirectorIP, bosh.CredhubPort),
+		Jumpbox:     fmt.Sprintf("%s:%d", cfg.BoshDirectorIP, bosh.GatewayPort),
+	}
+}
+
+func EndpointsPath(cfg config.Config) string {
+	return filepath.Join(cfg.StateDir, "endpoints.json")
+}
+
+// WriteEndpoints records the endpoints of the running CF Dev for other
+// tools. It contains no secrets.
+func WriteEndpoints(cfg config.Config) error {
+	contents, err := json.MarshalIndent(EndpointsFor(cfg), "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(cfg.StateDir, 0755); err != nil {
+		return err
+	}
+	return ioutil.WriteFile(EndpointsPath(cfg), contents, 0644)
+}
+
+// Load reads the well-known credentials from the director's vars store
+// (creds.yml) and secret file. Entries missing from the vars store are
+// left out.
+func Load(cfg config.Config) ([]Credential, error) {
+	contents, err := ioutil.ReadFile(filepath.Join(cfg.StateBosh, "creds.yml"))
+	if err != nil {
+		return nil, err
+	}
+
+	var vars map[string]interface{}
+	if err := yaml.Unmarshal(contents, &vars); err != nil {
+		return nil, fmt.Errorf("failed to parse creds.yml: %s", err)
+	}
+
+	secret, err := ioutil.ReadFile(filepath.Join(cfg.StateBosh, "secret"))
+	if err != nil {
+		return nil, err
+	}
+
+	str := func(key string) string {
+		value, _ := vars[key].(string)
+		return value
+	}
+
+	endpoints := EndpointsFor(cfg)
+	all := []Credential{
+		{Name: "cf-admin", Endpoint: endpoints.API, Username: "admin", Value: "admin"},
+		{Name: "cf-user", Endpoint: endpoints.API, Username: "user", Value: "pass"},
+		{Name: "director", Endpoint: endpoints.Director, Username: "admin", Value: strings.TrimSpace(string(secret))},
+		{Name: "director-health-monitor", Endpoint: endpoints.Director, Username: "hm", Value: str("hm_password")},
+		{Name: "director-uaa-admin", Endpoint: endpoints.DirectorUAA, Username: "uaa_admin", Value: str("uaa_admin_client_secret")},
+		{Name: "credhub-admin", Endpoint: endpoints.Credhub, Username: "credhub-admin", Value: str("credhub_admin_client_secret")},
+		{Name: "credhub-cli", Endpoint: endpoints.Credhub, Username: "credhub_cli_user", Value: str("credhub_cli_user_password")},
+		{Name: "jumpbox", Endpoint: endpoints.Jumpbox, Username: "jumpbox", Value: filepath.Join(cfg.StateBosh, "jumpbox.key")},
+	}
+
+	var credentials []Credential
+	for _, credential := range all {
+		if credential.Value != "" {
+			credentials = append(credentials, credential)
+		}
+	}
+	return credentials, nil
+}
+
+func Find(credentials []Credential, name string) (Credential, error) {
+	var names []string
+	for _, credential := range credentials {
+		if credential.Name == name {
+			return credential, nil
+		}
+		names = append(names, credential.Name)
+	}
+	return Credential{}, fmt.Errorf("unknown credential '%s', expected one of: %s", name, strings.Join(names, ", "))
+}
+
+func Masked(credentials []Credential) []Credential {
+	var masked []Credential
+	for _, credential := range credentials {
+		credential.Value = Mask
+		masked = append(masked, credential)
+	}
+	return masked
+}
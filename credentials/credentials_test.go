@@ -0,0 +1,89 @@
+package credentials_test
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"code.cloudfoundry.org/cfdev/config"
+	"code.cloudfoundry.org/cfdev/credentials"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Credentials", func() {
+	var (
+		tmpDir string
+		cfg    config.Config
+	)
+
+	BeforeEach(func() {
+		var err error
+		tmpDir, err = ioutil.TempDir("", "cfdev-credentials-")
+		Expect(err).NotTo(HaveOccurred())
+
+		cfg = config.Config{
+			StateDir:       filepath.Join(tmpDir, "state"),
+			StateBosh:      tmpDir,
+			CFDomain:       "dev.cfdev.sh",
+			BoshDirectorIP: "10.144.0.4",
+		}
+
+		Expect(ioutil.WriteFile(filepath.Join(tmpDir, "secret"), []byte("some-director-secret\n"), 0600)).To(Succeed())
+		Expect(ioutil.WriteFile(filepath.Join(tmpDir, "creds.yml"), []byte(`---
+credhub_admin_client_secret: some-credhub-secret
+uaa_admin_client_secret: some-uaa-secret
+credhub_tls:
+  ca: some-ca
+`), 0600)).To(Succeed())
+	})
+
+	AfterEach(func() {
+		os.RemoveAll(tmpDir)
+	})
+
+	Describe("Load", func() {
+		It("returns the well-known credentials present in the vars store", func() {
+			creds, err := credentials.Load(cfg)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(creds).To(Equal([]credentials.Credential{
+				{Name: "cf-admin", Endpoint: "https://api.dev.cfdev.sh", Username: "admin", Value: "admin"},
+				{Name: "cf-user", Endpoint: "https://api.dev.cfdev.sh", Username: "user", Value: "pass"},
+				{Name: "director", Endpoint: "https://10.144.0.4:25555", Username: "admin", Value: "some-director-secret"},
+				{Name: "director-uaa-admin", Endpoint: "https://10.144.0.4:8443", Username: "uaa_admin", Value: "some-uaa-secret"},
+				{Name: "credhub-admin", Endpoint: "https://10.144.0.4:8844", Username: "credhub-admin", Value: "some-credhub-secret"},
+				{Name: "jumpbox", Endpoint: "10.144.0.4:22", Username: "jumpbox", Value: filepath.Join(tmpDir, "jumpbox.key")},
+			}))
+		})
+
+		It("fails when the vars store is missing", func() {
+			os.Remove(filepath.Join(tmpDir, "creds.yml"))
+			_, err := credentials.Load(cfg)
+			Expect(err).To(HaveOccurred())
+		})
+	})
+
+	Describe("Find", func() {
+		It("lists the known names when the credential does not exist", func() {
+			creds := []credentials.Credential{{Name: "a"}, {Name: "b"}}
+			_, err := credentials.Find(creds, "c")
+			Expect(err).To(MatchError("unknown credential 'c', expected one of: a, b"))
+		})
+	})
+
+	Describe("WriteEndpoints", func() {
+		It("writes endpoints.json to the state dir", func() {
+			Expect(credentials.WriteEndpoints(cfg)).To(Succeed())
+
+			contents, err := ioutil.ReadFile(filepath.Join(tmpDir, "state", "endpoints.json"))
+			Expect(err).NotTo(HaveOccurred())
+
+			var endpoints map[string]string
+			Expect(json.Unmarshal(contents, &endpoints)).To(Succeed())
+			Expect(endpoints).To(HaveKeyWithValue("api", "https://api.dev.cfdev.sh"))
+			Expect(endpoints).To(HaveKeyWithValue("doppler", "wss://doppler.dev.cfdev.sh:443"))
+			Expect(endpoints).To(HaveKeyWithValue("credhub", "https://10.144.0.4:8844"))
+		})
+	})
+})
@@ -0,0 +1,425 @@
+package deps
+
+import (
+	"archive/tar"
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"code.cloudfoundry.org/cfdev/metadata"
+	"code.cloudfoundry.org/cfdev/resource"
+	"gopkg.in/yaml.v2"
+)
+
+// VersionName is the entry of the metadata versions that Build fills in.
+const VersionName = "cfdev-deps"
+
+// Spec assembles a tarball from several places. Files maps paths in the
+// tarball to files or directories on disk, relative to the spec.
+type Spec struct {
+	Files map[string]string `yaml:"files"`
+}
+
+type Options struct {
+	// Output is the tarball to write. Its extension picks the compression.
+	Output  string
+	GOOS    string
+	Version string
+	// URL is where the tarball will be published, for the catalog snippet.
+	URL string
+}
+
+type Result struct {
+	Path        string
+	SHA256      string
+	Size        uint64
+	CatalogPath string
+	Warnings    []string
+}
+
+type Builder struct{}
+
+// Build lays out the files of src, a directory or a spec file, as a deps
+// tarball for opts.GOOS. It writes the tarball's sha256 to <output>.sha256
+// and a catalog referring to it to <output>.catalog.json.
+func (b *Builder) Build(src string, opts Options) (Result, error) {
+	files, err := collect(src)
+	if err != nil {
+		return Result{}, err
+	}
+
+	var missing []string
+	for _, name := range requiredFiles(opts.GOOS) {
+		if _, ok := files[name]; !ok {
+			missing = append(missing, name)
+		}
+	}
+	if len(missing) > 0 {
+		return Result{}, fmt.Errorf("%s is missing %s", src, strings.Join(missing, ", "))
+	}
+
+	result := Result{Path: opts.Output}
+	generated := map[string][]byte{}
+
+	contents, changed, err := fillMetadata(files, opts)
+	if err != nil {
+		return Result{}, err
+	}
+	if changed {
+		generated[MetadataFile] = contents
+		if _, ok := files[MetadataFile+".sig"]; ok {
+			delete(files, MetadataFile+".sig")
+			result.Warnings = append(result.Warnings, "metadata.yml.sig was left out as versions were filled in. Sign the new metadata.yml before publishing the tarball")
+		}
+	}
+
+	if digests, err := binaryDigests(files); err != nil {
+		return Result{}, err
+	} else if digests != nil {
+		generated[DigestsFile] = digests
+	}
+
+	if err := write(opts.Output, files, generated, &result); err != nil {
+		return Result{}, err
+	}
+
+	if err := ioutil.WriteFile(opts.Output+".sha256", []byte(fmt.Sprintf("%s  %s\n", result.SHA256, filepath.Base(opts.Output))), 0644); err != nil {
+		return Result{}, err
+	}
+
+	url := opts.URL
+	if url == "" {
+		abs, err := filepath.Abs(opts.Output)
+		if err != nil {
+			return Result{}, err
+		}
+		url = "file://" + filepath.ToSlash(abs)
+	}
+	catalog, err := json.MarshalIndent(resource.Catalog{Items: []resource.Item{{
+		URL:    url,
+		Name:   "cfdev-deps.tgz",
+		SHA256: result.SHA256,
+		Size:   result.Size,
+		InUse:  true,
+	}}}, "", "  ")
+	if err != nil {
+		return Result{}, err
+	}
+	result.CatalogPath = opts.Output + ".catalog.json"
+	return result, ioutil.WriteFile(result.CatalogPath, catalog, 0644)
+}
+
+// collect maps the paths in the tarball to the files on disk.
+func collect(src string) (map[string]string, error) {
+	fi, err := os.Stat(src)
+	if err != nil {
+		return nil, err
+	}
+
+	files := map[string]string{}
+	if fi.IsDir() {
+		return files, walk(src, "", files)
+	}
+
+	contents, err := ioutil.ReadFile(src)
+	if err != nil {
+		return nil, err
+	}
+	var spec Spec
+	if err := yaml.Unmarshal(contents, &spec); err != nil {
+		return nil, fmt.Errorf("%s is not a directory or a valid spec file: %s", src, err)
+	}
+	if len(spec.Files) == 0 {
+		return nil, fmt.Errorf("%s lists no files", src)
+	}
+
+	for name, from := range spec.Files {
+		name = path.Clean(filepath.ToSlash(name))
+		if path.IsAbs(name) || name == ".." || strings.HasPrefix(name, "../") {
+			return nil, fmt.Errorf("%s: '%s' is not a path within the tarball", src, name)
+		}
+		if !filepath.IsAbs(from) {
+			from = filepath.Join(filepath.Dir(src), from)
+		}
+		if name == "." {
+			name = ""
+		}
+		if err := walk(from, name, files); err != nil {
+			return nil, err
+		}
+	}
+	return files, nil
+}
+
+func walk(root, prefix string, files map[string]string) error {
+	return filepath.Walk(root, func(file string, fi os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if fi.IsDir() {
+			return nil
+		}
+		if !fi.Mode().IsRegular() {
+			return fmt.Errorf("%s is not a regular file", file)
+		}
+
+		rel, err := filepath.Rel(root, file)
+		if err != nil {
+			return err
+		}
+		files[path.Join(prefix, filepath.ToSlash(rel))] = file
+		return nil
+	})
+}
+
+// fillMetadata validates metadata.yml and fills in the schema, compatibility
+// and deps versions, keeping the fields it does not know.
+func fillMetadata(files map[string]string, opts Options) ([]byte, bool, error) {
+	contents, err := ioutil.ReadFile(files[MetadataFile])
+	if err != nil {
+		return nil, false, err
+	}
+
+	var doc yaml.MapSlice
+	if err := yaml.Unmarshal(contents, &doc); err != nil {
+		return nil, false, fmt.Errorf("%s is invalid: %s", MetadataFile, err)
+	}
+
+	// Metadata without any version is taken to be of the current schema.
+	// compatibility_version stays for plugins that predate schema_version.
+	changed := false
+	if !hasKey(doc, "schema_version") && !hasKey(doc, "compatibility_version") {
+		doc = set(doc, "compatibility_version", metadata.CompatibilityVersion)
+		changed = true
+	}
+	filled, err := yaml.Marshal(doc)
+	if err != nil {
+		return nil, false, err
+	}
+
+	m, err := metadata.Parse(filled)
+	if err != nil {
+		return nil, false, fmt.Errorf("%s is invalid: %s", MetadataFile, err)
+	}
+	if err := m.Check(nil); err != nil {
+		return nil, false, fmt.Errorf("%s: %s", MetadataFile, err)
+	}
+	has := func(name string) bool {
+		_, ok := files[name]
+		return ok
+	}
+	if problems := metadataProblems(m, has, opts.GOOS); len(problems) > 0 {
+		return nil, false, errors.New(problems[0])
+	}
+
+	if !hasKey(doc, "schema_version") {
+		doc = set(doc, "schema_version", m.SchemaVersion)
+		changed = true
+	}
+
+	if opts.Version != "" {
+		versions := []metadata.Version{{Name: VersionName, Value: opts.Version}}
+		for _, version := range m.Versions {
+			if version.Name == VersionName {
+				changed = changed || version.Value != opts.Version
+			} else {
+				versions = append(versions, version)
+			}
+		}
+		if len(versions) > len(m.Versions) {
+			changed = true
+		}
+		doc = set(doc, "versions", versions)
+	}
+
+	if !changed {
+		return contents, false, nil
+	}
+	contents, err = yaml.Marshal(doc)
+	return contents, true, err
+}
+
+// metadataProblems checks the fields that CF Dev needs, other than the
+// versions. has reports whether the tarball has a file.
+func metadataProblems(m metadata.Metadata, has func(name string) bool, goos string) []string {
+	var problems []string
+	if m.DeploymentName == "" {
+		problems = append(problems, fmt.Sprintf("%s has no deployment_name", MetadataFile))
+	}
+	if m.DefaultMemory <= 0 {
+		problems = append(problems, fmt.Sprintf("%s has no default_memory", MetadataFile))
+	}
+
+	flags := map[string]bool{}
+	for _, service := range m.Services {
+		if service.Name == "" || service.Flagname == "" || service.Script == "" {
+			problems = append(problems, fmt.Sprintf("%s: services need a name, flag_name and script", MetadataFile))
+			continue
+		}
+		if flags[service.Flagname] {
+			problems = append(problems, fmt.Sprintf("%s: more than one service has the flag_name %s", MetadataFile, service.Flagname))
+		}
+		flags[service.Flagname] = true
+
+		if !has(serviceScript(goos, service.Script)) {
+			problems = append(problems, fmt.Sprintf("%s: the script of service %s is missing from %s", MetadataFile, service.Name, serviceScript(goos, service.Script)))
+		}
+	}
+	return problems
+}
+
+func hasKey(doc yaml.MapSlice, key string) bool {
+	for _, item := range doc {
+		if item.Key == key {
+			return true
+		}
+	}
+	return false
+}
+
+func set(doc yaml.MapSlice, key string, value interface{}) yaml.MapSlice {
+	for index := range doc {
+		if doc[index].Key == key {
+			doc[index].Value = value
+			return doc
+		}
+	}
+	return append(doc, yaml.MapItem{Key: key, Value: value})
+}
+
+// binaryDigests checks the binaries against SHA256SUMS, or returns a new
+// SHA256SUMS when there is none.
+func binaryDigests(files map[string]string) ([]byte, error) {
+	var binaries []string
+	for name := range files {
+		if path.Dir(name) == BinariesDir && !strings.HasPrefix(path.Base(name), "SHA256SUMS") {
+			binaries = append(binaries, name)
+		}
+	}
+	sort.Strings(binaries)
+
+	manifest, ok := files[DigestsFile]
+	if !ok {
+		var contents strings.Builder
+		for _, name := range binaries {
+			sum, err := resource.SHA256(files[name])
+			if err != nil {
+				return nil, err
+			}
+			fmt.Fprintf(&contents, "%s  %s\n", sum, path.Base(name))
+		}
+		return []byte(contents.String()), nil
+	}
+
+	digests, err := resource.ReadDigests(manifest)
+	if err != nil {
+		return nil, err
+	}
+	for _, name := range binaries {
+		expected, ok := digests[path.Base(name)]
+		if !ok {
+			return nil, fmt.Errorf("%s has no digest in %s", name, DigestsFile)
+		}
+		actual, err := resource.SHA256(files[name])
+		if err != nil {
+			return nil, err
+		}
+		if actual != expected {
+			return nil, fmt.Errorf("%s does not match %s: %s != %s", name, DigestsFile, actual, expected)
+		}
+	}
+	return nil, nil
+}
+
+func write(output string, files map[string]string, generated map[string][]byte, result *Result) error {
+	var names []string
+	for name := range files {
+		if _, ok := generated[name]; !ok {
+			names = append(names, name)
+		}
+	}
+	for name := range generated {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	tmpPath := output + ".tmp"
+	file, err := os.Create(tmpPath)
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmpPath)
+	defer file.Close()
+
+	hash := sha256.New()
+	compressed, err := resource.NewArchiveWriter(io.MultiWriter(file, hash), output)
+	if err != nil {
+		return err
+	}
+	tw := tar.NewWriter(compressed)
+
+	for _, name := range names {
+		if contents, ok := generated[name]; ok {
+			header := &tar.Header{Name: name, Mode: 0644, Size: int64(len(contents)), ModTime: time.Now(), Typeflag: tar.TypeReg}
+			if err := tw.WriteHeader(header); err != nil {
+				return err
+			}
+			if _, err := tw.Write(contents); err != nil {
+				return err
+			}
+			continue
+		}
+
+		if err := addFile(tw, name, files[name]); err != nil {
+			return err
+		}
+	}
+
+	if err := tw.Close(); err != nil {
+		return err
+	}
+	if err := compressed.Close(); err != nil {
+		return err
+	}
+	fi, err := file.Stat()
+	if err != nil {
+		return err
+	}
+	if err := file.Close(); err != nil {
+		return err
+	}
+
+	result.SHA256 = fmt.Sprintf("%x", hash.Sum(nil))
+	result.Size = uint64(fi.Size())
+	return os.Rename(tmpPath, output)
+}
+
+func addFile(tw *tar.Writer, name, src string) error {
+	file, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	fi, err := file.Stat()
+	if err != nil {
+		return err
+	}
+
+	// Extraction refuses setuid files, so only the permissions are kept.
+	header := &tar.Header{Name: name, Mode: int64(fi.Mode().Perm()), Size: fi.Size(), ModTime: fi.ModTime(), Typeflag: tar.TypeReg}
+	if err := tw.WriteHeader(header); err != nil {
+		return err
+	}
+	_, err = io.Copy(tw, file)
+	return err
+}
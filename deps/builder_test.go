@@ -0,0 +1,169 @@
+package deps_test
+
+import (
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"code.cloudfoundry.org/cfdev/deps"
+	"code.cloudfoundry.org/cfdev/metadata"
+	"code.cloudfoundry.org/cfdev/resource"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Builder", func() {
+	var (
+		dir     string
+		src     string
+		opts    deps.Options
+		builder *deps.Builder
+	)
+
+	write := func(name, contents string) {
+		path := filepath.Join(src, filepath.FromSlash(name))
+		Expect(os.MkdirAll(filepath.Dir(path), 0755)).To(Succeed())
+		Expect(ioutil.WriteFile(path, []byte(contents), 0644)).To(Succeed())
+	}
+
+	extract := func(name string) string {
+		out := filepath.Join(dir, "extracted")
+		Expect(os.MkdirAll(out, 0755)).To(Succeed())
+		Expect(resource.Untar(opts.Output, []resource.TarOpts{{Include: filepath.Base(name), IncludeFolder: filepath.Dir(name), Dst: out}})).To(Succeed())
+		contents, err := ioutil.ReadFile(filepath.Join(out, filepath.FromSlash(name)))
+		Expect(err).NotTo(HaveOccurred())
+		return string(contents)
+	}
+
+	BeforeEach(func() {
+		var err error
+		dir, err = ioutil.TempDir("", "cfdev-deps-")
+		Expect(err).NotTo(HaveOccurred())
+		src = filepath.Join(dir, "src")
+
+		for _, name := range []string{"state.json", "creds.yml", "secret", "jumpbox.key", "ca.crt", "id_rsa", "disk.qcow2"} {
+			write(name, "some-"+name)
+		}
+		for _, name := range []string{"linuxkit", "hyperkit", "vpnkit", "qcow-tool", "UEFI.fd", "cfdev-efi-v2.iso"} {
+			write("binaries/"+name, "some-"+name)
+		}
+		write("services/bin/deploy-mysql", "some-script")
+		write("deployment_config/metadata.yml", `---
+deployment_name: cf
+default_memory: 8192
+custom_field: kept
+services:
+- name: Mysql
+  flag_name: mysql
+  script: bin/deploy-mysql
+versions:
+- name: some-release
+  version: v1
+`)
+
+		opts = deps.Options{Output: filepath.Join(dir, "cfdev-deps.tar.zst"), GOOS: "darwin", Version: "v42"}
+		builder = &deps.Builder{}
+	})
+
+	AfterEach(func() {
+		os.RemoveAll(dir)
+	})
+
+	It("writes the tarball with its checksum and a catalog snippet", func() {
+		result, err := builder.Build(src, opts)
+		Expect(err).NotTo(HaveOccurred())
+
+		sum, err := resource.SHA256(opts.Output)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(result.SHA256).To(Equal(sum))
+		Expect(ioutil.ReadFile(opts.Output + ".sha256")).To(Equal([]byte(fmt.Sprintf("%s  cfdev-deps.tar.zst\n", sum))))
+
+		contents, err := ioutil.ReadFile(result.CatalogPath)
+		Expect(err).NotTo(HaveOccurred())
+		var catalog resource.Catalog
+		Expect(json.Unmarshal(contents, &catalog)).To(Succeed())
+		Expect(catalog.Items).To(HaveLen(1))
+		Expect(catalog.Items[0].Name).To(Equal("cfdev-deps.tgz"))
+		Expect(catalog.Items[0].URL).To(Equal("file://" + filepath.ToSlash(opts.Output)))
+		Expect(catalog.Items[0].SHA256).To(Equal(sum))
+		Expect(catalog.Items[0].Size).To(Equal(result.Size))
+
+		Expect(extract("binaries/linuxkit")).To(Equal("some-linuxkit"))
+		Expect(extract("binaries/SHA256SUMS")).To(ContainSubstring("  linuxkit\n"))
+	})
+
+	It("fills in the compatibility and deps versions", func() {
+		_, err := builder.Build(src, opts)
+		Expect(err).NotTo(HaveOccurred())
+
+		path := filepath.Join(dir, "metadata.yml")
+		Expect(ioutil.WriteFile(path, []byte(extract("deployment_config/metadata.yml")), 0644)).To(Succeed())
+		m, err := metadata.New().Read(path)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(m.Version).To(Equal(metadata.CompatibilityVersion))
+		Expect(m.SchemaVersion).To(Equal(metadata.SchemaVersion))
+		Expect(ioutil.ReadFile(path)).To(ContainSubstring("schema_version: 2"))
+		Expect(m.Versions).To(Equal([]metadata.Version{{Name: "cfdev-deps", Value: "v42"}, {Name: "some-release", Value: "v1"}}))
+		Expect(ioutil.ReadFile(path)).To(ContainSubstring("custom_field: kept"))
+	})
+
+	It("assembles the tarball from a spec file", func() {
+		write("redis/deploy-redis", "some-redis-script")
+		spec := filepath.Join(src, "spec.yml")
+		Expect(ioutil.WriteFile(spec, []byte(`files:
+  .: .
+  services/bin/deploy-redis: redis/deploy-redis
+`), 0644)).To(Succeed())
+
+		_, err := builder.Build(spec, opts)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(extract("services/bin/deploy-redis")).To(Equal("some-redis-script"))
+	})
+
+	It("lists the files that are missing", func() {
+		Expect(os.Remove(filepath.Join(src, "disk.qcow2"))).To(Succeed())
+		Expect(os.Remove(filepath.Join(src, "binaries", "vpnkit"))).To(Succeed())
+
+		_, err := builder.Build(src, opts)
+		Expect(err).To(MatchError(src + " is missing disk.qcow2, binaries/vpnkit"))
+	})
+
+	It("rejects metadata for another version of CF Dev", func() {
+		write("deployment_config/metadata.yml", "compatibility_version: v1\ndeployment_name: cf\ndefault_memory: 8192\n")
+
+		_, err := builder.Build(src, opts)
+		Expect(err).To(MatchError("deployment_config/metadata.yml: the deps are too old for this version of the cf dev plugin (compatibility version v1, but at least v3 is needed). Please use newer deps"))
+	})
+
+	It("rejects an invalid required plugin range", func() {
+		write("deployment_config/metadata.yml", "schema_version: 2\nrequired_plugin: '>=a'\ndeployment_name: cf\ndefault_memory: 8192\n")
+
+		_, err := builder.Build(src, opts)
+		Expect(err).To(MatchError(ContainSubstring("invalid required_plugin")))
+	})
+
+	It("rejects services without their script", func() {
+		Expect(os.Remove(filepath.Join(src, "services", "bin", "deploy-mysql"))).To(Succeed())
+
+		_, err := builder.Build(src, opts)
+		Expect(err).To(MatchError("deployment_config/metadata.yml: the script of service Mysql is missing from services/bin/deploy-mysql"))
+	})
+
+	It("rejects binaries that do not match SHA256SUMS", func() {
+		manifest := ""
+		for _, name := range []string{"hyperkit", "vpnkit", "qcow-tool", "UEFI.fd", "cfdev-efi-v2.iso"} {
+			manifest += fmt.Sprintf("%x  %s\n", sha256.Sum256([]byte("some-"+name)), name)
+		}
+		write("binaries/SHA256SUMS", manifest)
+
+		_, err := builder.Build(src, opts)
+		Expect(err).To(MatchError("binaries/linuxkit has no digest in binaries/SHA256SUMS"))
+
+		write("binaries/SHA256SUMS", manifest+"0000  linuxkit\n")
+		_, err = builder.Build(src, opts)
+		Expect(err).To(MatchError(ContainSubstring("binaries/linuxkit does not match binaries/SHA256SUMS")))
+	})
+})
@@ -0,0 +1,13 @@
+package deps_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"testing"
+)
+
+func TestDeps(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Deps Suite")
+}
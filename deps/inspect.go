@@ -0,0 +1,189 @@
+package deps
+
+import (
+	"fmt"
+	"path"
+	"sort"
+
+	"code.cloudfoundry.org/cfdev/metadata"
+	"code.cloudfoundry.org/cfdev/resource"
+	"code.cloudfoundry.org/cfdev/semver"
+	"code.cloudfoundry.org/cfdev/signature"
+	"gopkg.in/yaml.v2"
+)
+
+var (
+	metadataKeys = map[string]bool{
+		"schema_version":        true,
+		"required_plugin":       true,
+		"compatibility_version": true,
+		"splash_message":        true,
+		"deployment_name":       true,
+		"analytics_message":     true,
+		"default_memory":        true,
+		"services":              true,
+		"versions":              true,
+		"docker_registry_vars":  true,
+	}
+	serviceKeys = map[string]bool{
+		"name":           true,
+		"flag_name":      true,
+		"default_deploy": true,
+		"handle":         true,
+		"script":         true,
+		"deployment":     true,
+		"errand":         true,
+	}
+)
+
+// Report describes a deps tarball. Problems lists what would stop CF Dev
+// from starting with it.
+type Report struct {
+	File                 string    `json:"file"`
+	SchemaVersion        int       `json:"schema_version"`
+	RequiredPlugin       string    `json:"required_plugin"`
+	CompatibilityVersion string    `json:"compatibility_version"`
+	DeploymentName       string    `json:"deployment_name"`
+	DefaultMemory        int       `json:"default_memory"`
+	Versions             []Version `json:"versions"`
+	Services             []Service `json:"services"`
+	MissingFiles         []string  `json:"missing_files"`
+	UnknownKeys          []string  `json:"unknown_keys"`
+	Problems             []string  `json:"problems"`
+}
+
+type Version struct {
+	Name    string `json:"name"`
+	Version string `json:"version"`
+}
+
+type Service struct {
+	Name          string `json:"name"`
+	FlagName      string `json:"flag_name"`
+	DefaultDeploy bool   `json:"default_deploy"`
+	Script        string `json:"script"`
+	ScriptPresent bool   `json:"script_present"`
+	Deployment    string `json:"deployment"`
+}
+
+type Inspector struct {
+	Verifier      *signature.Verifier
+	PluginVersion *semver.Version
+}
+
+// Inspect reads the metadata of the tarball at src through its table of
+// contents, without extracting it.
+func (i *Inspector) Inspect(src, goos string) (Report, error) {
+	report := Report{
+		File:         src,
+		Versions:     []Version{},
+		Services:     []Service{},
+		MissingFiles: []string{},
+		UnknownKeys:  []string{},
+		Problems:     []string{},
+	}
+
+	toc, err := resource.ReadTOC(src)
+	if err != nil {
+		return report, err
+	}
+
+	// Tarballs list their entries with or without a leading ./
+	names := map[string]string{}
+	for _, entry := range toc.Entries {
+		names[path.Clean(entry.Name)] = entry.Name
+	}
+	has := func(name string) bool {
+		_, ok := names[name]
+		return ok
+	}
+
+	for _, name := range requiredFiles(goos) {
+		if !has(name) {
+			report.MissingFiles = append(report.MissingFiles, name)
+			report.Problems = append(report.Problems, fmt.Sprintf("%s is missing", name))
+		}
+	}
+	if !has(MetadataFile) {
+		return report, nil
+	}
+
+	contents, err := resource.ReadEntry(src, toc, names[MetadataFile])
+	if err != nil {
+		return report, err
+	}
+
+	if i.Verifier.Enabled() {
+		if !has(MetadataFile + ".sig") {
+			report.Problems = append(report.Problems, fmt.Sprintf("%s is not signed", MetadataFile))
+		} else {
+			sig, err := resource.ReadEntry(src, toc, names[MetadataFile+".sig"])
+			if err != nil {
+				return report, err
+			}
+			if err := i.Verifier.Verify(contents, string(sig)); err != nil {
+				report.Problems = append(report.Problems, fmt.Sprintf("%s: %s", MetadataFile, err))
+			}
+		}
+	}
+
+	m, err := metadata.Parse(contents)
+	if err != nil {
+		report.Problems = append(report.Problems, fmt.Sprintf("%s is invalid: %s", MetadataFile, err))
+		return report, nil
+	}
+	var doc yaml.MapSlice
+	yaml.Unmarshal(contents, &doc)
+
+	report.SchemaVersion = m.SchemaVersion
+	report.RequiredPlugin = m.RequiredPlugin
+	report.CompatibilityVersion = m.Version
+	report.DeploymentName = m.DeploymentName
+	report.DefaultMemory = m.DefaultMemory
+	for _, version := range m.Versions {
+		report.Versions = append(report.Versions, Version{Name: version.Name, Version: version.Value})
+	}
+	for _, service := range m.Services {
+		report.Services = append(report.Services, Service{
+			Name:          service.Name,
+			FlagName:      service.Flagname,
+			DefaultDeploy: service.DefaultDeploy,
+			Script:        service.Script,
+			ScriptPresent: service.Script != "" && has(serviceScript(goos, service.Script)),
+			Deployment:    service.Deployment,
+		})
+	}
+	report.UnknownKeys = unknownKeys(doc)
+
+	if err := m.Check(i.PluginVersion); err != nil {
+		report.Problems = append(report.Problems, fmt.Sprintf("%s: %s", MetadataFile, err))
+	}
+	report.Problems = append(report.Problems, metadataProblems(m, has, goos)...)
+	return report, nil
+}
+
+func unknownKeys(doc yaml.MapSlice) []string {
+	unknown := []string{}
+	for _, item := range doc {
+		key := fmt.Sprint(item.Key)
+		if !metadataKeys[key] {
+			unknown = append(unknown, key)
+			continue
+		}
+		if key != "services" {
+			continue
+		}
+
+		services, _ := item.Value.([]interface{})
+		for index, service := range services {
+			fields, _ := service.(yaml.MapSlice)
+			for _, field := range fields {
+				if name := fmt.Sprint(field.Key); !serviceKeys[name] {
+					unknown = append(unknown, fmt.Sprintf("services[%d].%s", index, name))
+				}
+			}
+		}
+	}
+	sort.Strings(unknown)
+	return unknown
+}
@@ -0,0 +1,234 @@
+package deps_test
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"crypto/rand"
+	"encoding/base64"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"code.cloudfoundry.org/cfdev/deps"
+	"code.cloudfoundry.org/cfdev/semver"
+	"code.cloudfoundry.org/cfdev/signature"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"golang.org/x/crypto/ed25519"
+)
+
+var _ = Describe("Inspector", func() {
+	var (
+		dir       string
+		tarball   string
+		files     map[string]string
+		inspector *deps.Inspector
+	)
+
+	write := func() {
+		file, err := os.Create(tarball)
+		Expect(err).NotTo(HaveOccurred())
+		defer file.Close()
+		gw := gzip.NewWriter(file)
+		tw := tar.NewWriter(gw)
+		for name, contents := range files {
+			Expect(tw.WriteHeader(&tar.Header{Name: name, Mode: 0644, Size: int64(len(contents)), Typeflag: tar.TypeReg})).To(Succeed())
+			_, err := tw.Write([]byte(contents))
+			Expect(err).NotTo(HaveOccurred())
+		}
+		Expect(tw.Close()).To(Succeed())
+		Expect(gw.Close()).To(Succeed())
+	}
+
+	BeforeEach(func() {
+		var err error
+		dir, err = ioutil.TempDir("", "cfdev-inspect-")
+		Expect(err).NotTo(HaveOccurred())
+		tarball = filepath.Join(dir, "cfdev-deps.tgz")
+
+		files = map[string]string{}
+		for _, name := range []string{"state.json", "creds.yml", "secret", "jumpbox.key", "ca.crt", "id_rsa", "disk.qcow2"} {
+			files["./"+name] = "some-" + name
+		}
+		for _, name := range []string{"linuxkit", "hyperkit", "vpnkit", "qcow-tool", "UEFI.fd", "cfdev-efi-v2.iso"} {
+			files["./binaries/"+name] = "some-" + name
+		}
+		files["./services/bin/deploy-mysql"] = "some-script"
+		files["./deployment_config/metadata.yml"] = `---
+compatibility_version: v3
+deployment_name: cf
+default_memory: 8192
+custom_field: unknown
+services:
+- name: Mysql
+  flag_name: mysql
+  default_deploy: true
+  script: bin/deploy-mysql
+  deployment: cf-mysql
+  typo: unknown
+- name: RabbitMQ
+  flag_name: rabbitmq
+  script: bin/deploy-rabbitmq
+  deployment: cf-rabbitmq
+versions:
+- name: cf
+  version: v1
+`
+
+		inspector = &deps.Inspector{}
+	})
+
+	AfterEach(func() {
+		os.RemoveAll(dir)
+	})
+
+	It("describes the deps", func() {
+		files["./deployment_config/metadata.yml"] = `---
+compatibility_version: v3
+deployment_name: cf
+default_memory: 8192
+services:
+- name: Mysql
+  flag_name: mysql
+  default_deploy: true
+  script: bin/deploy-mysql
+  deployment: cf-mysql
+versions:
+- name: cf
+  version: v1
+`
+		write()
+
+		report, err := inspector.Inspect(tarball, "darwin")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(report).To(Equal(deps.Report{
+			File:                 tarball,
+			SchemaVersion:        2,
+			CompatibilityVersion: "v3",
+			DeploymentName:       "cf",
+			DefaultMemory:        8192,
+			Versions:             []deps.Version{{Name: "cf", Version: "v1"}},
+			Services: []deps.Service{{
+				Name:          "Mysql",
+				FlagName:      "mysql",
+				DefaultDeploy: true,
+				Script:        "bin/deploy-mysql",
+				ScriptPresent: true,
+				Deployment:    "cf-mysql",
+			}},
+			MissingFiles: []string{},
+			UnknownKeys:  []string{},
+			Problems:     []string{},
+		}))
+	})
+
+	It("reports unknown keys and missing scripts", func() {
+		write()
+
+		report, err := inspector.Inspect(tarball, "darwin")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(report.UnknownKeys).To(Equal([]string{"custom_field", "services[0].typo"}))
+		Expect(report.Services).To(HaveLen(2))
+		Expect(report.Services[1].ScriptPresent).To(BeFalse())
+		Expect(report.Problems).To(ConsistOf(ContainSubstring("the script of service RabbitMQ is missing")))
+	})
+
+	It("reports the required files that are missing", func() {
+		delete(files, "./binaries/hyperkit")
+		delete(files, "./id_rsa")
+		write()
+
+		report, err := inspector.Inspect(tarball, "darwin")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(report.MissingFiles).To(Equal([]string{"id_rsa", "binaries/hyperkit"}))
+		Expect(report.Problems).To(ContainElement("binaries/hyperkit is missing"))
+	})
+
+	It("checks the files for the given operating system", func() {
+		write()
+
+		report, err := inspector.Inspect(tarball, "windows")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(report.MissingFiles).To(Equal([]string{"disk.vhdx", "binaries/vpnkit.exe"}))
+		Expect(report.Services[0].ScriptPresent).To(BeFalse())
+	})
+
+	It("reports an incompatible compatibility version", func() {
+		files["./deployment_config/metadata.yml"] = "compatibility_version: v2\ndeployment_name: cf\ndefault_memory: 8192\n"
+		write()
+
+		report, err := inspector.Inspect(tarball, "darwin")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(report.CompatibilityVersion).To(Equal("v2"))
+		Expect(report.Problems).To(Equal([]string{"deployment_config/metadata.yml: the deps are too old for this version of the cf dev plugin (compatibility version v2, but at least v3 is needed). Please use newer deps"}))
+	})
+
+	It("reports deps that need another plugin version", func() {
+		files["./deployment_config/metadata.yml"] = "schema_version: 2\nrequired_plugin: '>=1.2 <2'\ndeployment_name: cf\ndefault_memory: 8192\n"
+		write()
+		inspector.PluginVersion = semver.Must(semver.New("1.1.0"))
+
+		report, err := inspector.Inspect(tarball, "darwin")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(report.SchemaVersion).To(Equal(2))
+		Expect(report.RequiredPlugin).To(Equal(">=1.2 <2"))
+		Expect(report.Problems).To(Equal([]string{"deployment_config/metadata.yml: the deps need a cf dev plugin version >=1.2 <2, but this is 1.1.0"}))
+	})
+
+	It("stops at the missing files when there is no metadata", func() {
+		delete(files, "./deployment_config/metadata.yml")
+		write()
+
+		report, err := inspector.Inspect(tarball, "darwin")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(report.MissingFiles).To(Equal([]string{"deployment_config/metadata.yml"}))
+		Expect(report.DeploymentName).To(BeEmpty())
+	})
+
+	It("returns an error when the file is not a tarball", func() {
+		Expect(ioutil.WriteFile(tarball, []byte("not a tarball"), 0644)).To(Succeed())
+
+		_, err := inspector.Inspect(tarball, "darwin")
+		Expect(err).To(HaveOccurred())
+	})
+
+	Context("when signatures are checked", func() {
+		var privateKey ed25519.PrivateKey
+
+		BeforeEach(func() {
+			publicKey, key, err := ed25519.GenerateKey(rand.Reader)
+			Expect(err).NotTo(HaveOccurred())
+			privateKey = key
+			inspector.Verifier, err = signature.New(base64.StdEncoding.EncodeToString(publicKey))
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		It("accepts signed metadata", func() {
+			contents := files["./deployment_config/metadata.yml"]
+			files["./deployment_config/metadata.yml.sig"] = base64.StdEncoding.EncodeToString(ed25519.Sign(privateKey, []byte(contents)))
+			write()
+
+			report, err := inspector.Inspect(tarball, "darwin")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(report.Problems).NotTo(ContainElement(ContainSubstring("signature")))
+			Expect(report.Problems).NotTo(ContainElement(ContainSubstring("not signed")))
+		})
+
+		It("reports unsigned metadata", func() {
+			write()
+
+			report, err := inspector.Inspect(tarball, "darwin")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(report.Problems).To(ContainElement("deployment_config/metadata.yml is not signed"))
+		})
+
+		It("reports a signature that does not match", func() {
+			files["./deployment_config/metadata.yml.sig"] = base64.StdEncoding.EncodeToString(ed25519.Sign(privateKey, []byte("other")))
+			write()
+
+			report, err := inspector.Inspect(tarball, "darwin")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(report.Problems).To(ContainElement("deployment_config/metadata.yml: signature does not match"))
+		})
+	})
+})
@@ -0,0 +1,59 @@
+package deps
+
+// The layout of a deps tarball, as Env.SetupState extracts it:
+//
+//	state.json, creds.yml, secret,     the BOSH director state, extracted
+//	jumpbox.key, ca.crt, ca.yml        to $CFDEV_HOME/state/bosh
+//	id_rsa                             the key to the VM, extracted to the cache
+//	disk.qcow2 or disk.vhdx            the VM disk
+//	binaries/                          the programs run on the host, with an
+//	                                   optional SHA256SUMS(.sig) manifest,
+//	                                   flattened into the cache
+//	deployment_config/                 metadata.yml(.sig) and the manifests,
+//	                                   flattened into the cache
+//	services/                          the scripts deploying the services
+//	                                   listed in metadata.yml, extracted to
+//	                                   $CFDEV_HOME/services
+//
+// deploy-cf gets the insecure registries in DOCKER_REGISTRIES. When
+// metadata.yml declares docker_registry_vars, it also has to apply the
+// BOSH vars file in DOCKER_REGISTRIES_VARS_FILE to the CF deployment.
+const (
+	BinariesDir         = "binaries"
+	DeploymentConfigDir = "deployment_config"
+	ServicesDir         = "services"
+
+	MetadataFile = DeploymentConfigDir + "/metadata.yml"
+	DigestsFile  = BinariesDir + "/SHA256SUMS"
+)
+
+var stateFiles = []string{"state.json", "creds.yml", "secret", "jumpbox.key", "ca.crt", "id_rsa"}
+
+// requiredFiles lists the files that a tarball for goos must have.
+func requiredFiles(goos string) []string {
+	if goos == "windows" {
+		return append(stateFiles,
+			"disk.vhdx",
+			BinariesDir+"/vpnkit.exe",
+			BinariesDir+"/cfdev-efi-v2.iso",
+			MetadataFile)
+	}
+
+	return append(stateFiles,
+		"disk.qcow2",
+		BinariesDir+"/linuxkit",
+		BinariesDir+"/hyperkit",
+		BinariesDir+"/vpnkit",
+		BinariesDir+"/qcow-tool",
+		BinariesDir+"/UEFI.fd",
+		BinariesDir+"/cfdev-efi-v2.iso",
+		MetadataFile)
+}
+
+// serviceScript is the path of a service's deploy script in the tarball.
+func serviceScript(goos, script string) string {
+	if goos == "windows" {
+		return ServicesDir + "/" + script + ".ps1"
+	}
+	return ServicesDir + "/" + script
+}
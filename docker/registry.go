@@ -0,0 +1,208 @@
+package docker
+
+import (
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+	"io/ioutil"
+	"net/url"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+)
+
+type Registry struct {
+	Host     string   `yaml:"host"`
+	Insecure bool     `yaml:"insecure"`
+	CACert   string   `yaml:"ca_cert"`
+	Username string   `yaml:"username"`
+	Password string   `yaml:"password"`
+	Mirrors  []string `yaml:"mirrors"`
+}
+
+type Config struct {
+	Registries []Registry `yaml:"registries"`
+}
+
+// Load reads the registry config file at path (if any) and merges in the
+// comma separated list of insecure registries given via the --registries flag.
+func Load(insecureFlag string, path string) (Config, error) {
+	var config Config
+
+	if path != "" {
+		contents, err := ioutil.ReadFile(path)
+		if err != nil {
+			return Config{}, err
+		}
+
+		if err := yaml.UnmarshalStrict(contents, &config); err != nil {
+			return Config{}, fmt.Errorf("'%s' - %v", path, err)
+		}
+	}
+
+	if insecureFlag != "" {
+		for _, value := range strings.Split(insecureFlag, ",") {
+			config.add(Registry{Host: value, Insecure: true})
+		}
+	}
+
+	return config, config.Validate()
+}
+
+func (c *Config) add(registry Registry) {
+	for i := range c.Registries {
+		if c.Registries[i].Host == registry.Host {
+			c.Registries[i].Insecure = c.Registries[i].Insecure || registry.Insecure
+			return
+		}
+	}
+	c.Registries = append(c.Registries, registry)
+}
+
+func (c Config) Validate() error {
+	seen := map[string]bool{}
+
+	for _, registry := range c.Registries {
+		if err := registry.Validate(); err != nil {
+			return err
+		}
+
+		if seen[registry.Host] {
+			return fmt.Errorf("'%v' - registry is configured more than once", registry.Host)
+		}
+		seen[registry.Host] = true
+	}
+
+	return nil
+}
+
+func (r Registry) Validate() error {
+	if r.Host == "" {
+		return fmt.Errorf("registry host must not be empty")
+	}
+
+	// Including the // will cause url.Parse to validate 'host' as a host:port
+	u, err := url.Parse("//" + r.Host)
+	if err != nil {
+		// Grab the more succinct error message
+		if urlErr, ok := err.(*url.Error); ok {
+			err = urlErr.Err
+		}
+		return fmt.Errorf("'%v' - %v", r.Host, err)
+	}
+	if u.Host != r.Host {
+		return fmt.Errorf("'%v' - registry host must be of the form host[:port]", r.Host)
+	}
+
+	if (r.Username == "") != (r.Password == "") {
+		return fmt.Errorf("'%v' - both a username and a password are required", r.Host)
+	}
+
+	for _, mirror := range r.Mirrors {
+		u, err := url.Parse(mirror)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return fmt.Errorf("'%v' - mirror '%v' is not a valid http(s) url", r.Host, mirror)
+		}
+	}
+
+	if r.CACert != "" {
+		if _, err := r.readCACert(); err != nil {
+			return fmt.Errorf("'%v' - %v", r.Host, err)
+		}
+	}
+
+	return nil
+}
+
+func (r Registry) readCACert() (string, error) {
+	contents, err := ioutil.ReadFile(r.CACert)
+	if err != nil {
+		return "", fmt.Errorf("unable to read ca cert: %v", err)
+	}
+
+	block, _ := pem.Decode(contents)
+	if block == nil || block.Type != "CERTIFICATE" {
+		return "", fmt.Errorf("ca cert '%v' does not contain a PEM encoded certificate", r.CACert)
+	}
+
+	if _, err := x509.ParseCertificate(block.Bytes); err != nil {
+		return "", fmt.Errorf("ca cert '%v' is invalid: %v", r.CACert, err)
+	}
+
+	return string(contents), nil
+}
+
+func (c Config) InsecureHosts() []string {
+	var hosts []string
+	for _, registry := range c.Registries {
+		if registry.Insecure {
+			hosts = append(hosts, registry.Host)
+		}
+	}
+	return hosts
+}
+
+type renderedRegistry struct {
+	Host     string   `yaml:"host"`
+	Insecure bool     `yaml:"insecure"`
+	CACert   string   `yaml:"ca_cert,omitempty"`
+	Username string   `yaml:"username,omitempty"`
+	Password string   `yaml:"password,omitempty"`
+	Mirrors  []string `yaml:"mirrors,omitempty"`
+}
+
+type renderedVars struct {
+	InsecureRegistries []string           `yaml:"insecure_docker_registries"`
+	Registries         []renderedRegistry `yaml:"docker_registries"`
+}
+
+// Render produces the BOSH vars file that deploy-cf applies to the CF
+// deployment. CA certs are inlined since the paths only exist on the host.
+func (c Config) Render() ([]byte, error) {
+	vars := renderedVars{
+		InsecureRegistries: c.InsecureHosts(),
+		Registries:         []renderedRegistry{},
+	}
+	if vars.InsecureRegistries == nil {
+		vars.InsecureRegistries = []string{}
+	}
+
+	for _, registry := range c.Registries {
+		rendered := renderedRegistry{
+			Host:     registry.Host,
+			Insecure: registry.Insecure,
+			Username: registry.Username,
+			Password: registry.Password,
+			Mirrors:  registry.Mirrors,
+		}
+
+		if registry.CACert != "" {
+			cert, err := registry.readCACert()
+			if err != nil {
+				return nil, fmt.Errorf("'%v' - %v", registry.Host, err)
+			}
+			rendered.CACert = cert
+		}
+
+		vars.Registries = append(vars.Registries, rendered)
+	}
+
+	return yaml.Marshal(vars)
+}
+
+// Deployable returns an error for settings that the deps cannot apply.
+// Deps whose deploy-cf does not read the rendered vars file only
+// configure insecure registries, so ca certs, credentials and mirrors
+// would be dropped.
+func (c Config) Deployable(rendersVars bool) error {
+	if rendersVars {
+		return nil
+	}
+
+	for _, registry := range c.Registries {
+		if registry.CACert != "" || registry.Username != "" || len(registry.Mirrors) > 0 {
+			return fmt.Errorf("'%v' - ca_cert, username, password and mirrors need deps that declare docker_registry_vars; these deps only configure insecure registries", registry.Host)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,203 @@
+package docker_test
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io/ioutil"
+	"math/big"
+	"os"
+	"path/filepath"
+	"time"
+
+	"code.cloudfoundry.org/cfdev/docker"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"gopkg.in/yaml.v2"
+)
+
+func generateCert() []byte {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	Expect(err).NotTo(HaveOccurred())
+
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "some-ca"},
+		NotBefore:    time.Now(),
+		NotAfter:     time.Now().Add(time.Hour),
+		IsCA:         true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	Expect(err).NotTo(HaveOccurred())
+
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+}
+
+var _ = Describe("Registry Config", func() {
+	var (
+		tmpDir     string
+		configPath string
+	)
+
+	BeforeEach(func() {
+		var err error
+		tmpDir, err = ioutil.TempDir("", "cfdev-docker-")
+		Expect(err).NotTo(HaveOccurred())
+		configPath = filepath.Join(tmpDir, "registries.yml")
+	})
+
+	AfterEach(func() {
+		os.RemoveAll(tmpDir)
+	})
+
+	Context("when only the --registries flag is given", func() {
+		It("treats every host as insecure", func() {
+			config, err := docker.Load("domain1.com,domain2.com:5000", "")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(config.Registries).To(Equal([]docker.Registry{
+				{Host: "domain1.com", Insecure: true},
+				{Host: "domain2.com:5000", Insecure: true},
+			}))
+			Expect(config.InsecureHosts()).To(Equal([]string{"domain1.com", "domain2.com:5000"}))
+		})
+
+		It("returns an error for a malformed host", func() {
+			_, err := docker.Load("domain1.com:port", "")
+			Expect(err).To(MatchError(ContainSubstring("'domain1.com:port'")))
+		})
+
+		It("returns an error for a url", func() {
+			_, err := docker.Load("https://domain1.com", "")
+			Expect(err).To(HaveOccurred())
+		})
+	})
+
+	Context("when nothing is given", func() {
+		It("returns an empty config", func() {
+			config, err := docker.Load("", "")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(config.Registries).To(BeEmpty())
+		})
+	})
+
+	Context("when a config file is given", func() {
+		var caPath string
+
+		BeforeEach(func() {
+			caPath = filepath.Join(tmpDir, "ca.pem")
+			Expect(ioutil.WriteFile(caPath, generateCert(), 0600)).To(Succeed())
+
+			Expect(ioutil.WriteFile(configPath, []byte(`---
+registries:
+- host: registry.example.com:5000
+  ca_cert: `+caPath+`
+  username: some-user
+  password: some-password
+- host: docker.io
+  mirrors:
+  - https://mirror.example.com
+`), 0600)).To(Succeed())
+		})
+
+		It("loads the registries and merges the insecure flag", func() {
+			config, err := docker.Load("docker.io,other.example.com", configPath)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(config.Registries).To(Equal([]docker.Registry{
+				{
+					Host:     "registry.example.com:5000",
+					CACert:   caPath,
+					Username: "some-user",
+					Password: "some-password",
+				},
+				{
+					Host:     "docker.io",
+					Insecure: true,
+					Mirrors:  []string{"https://mirror.example.com"},
+				},
+				{
+					Host:     "other.example.com",
+					Insecure: true,
+				},
+			}))
+		})
+
+		It("renders the registries as bosh vars with the ca cert inlined", func() {
+			config, err := docker.Load("", configPath)
+			Expect(err).NotTo(HaveOccurred())
+
+			contents, err := config.Render()
+			Expect(err).NotTo(HaveOccurred())
+
+			var vars struct {
+				Insecure   []string                 `yaml:"insecure_docker_registries"`
+				Registries []map[string]interface{} `yaml:"docker_registries"`
+			}
+			Expect(yaml.Unmarshal(contents, &vars)).To(Succeed())
+
+			ca, err := ioutil.ReadFile(caPath)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(vars.Insecure).To(BeEmpty())
+			Expect(vars.Registries).To(HaveLen(2))
+			Expect(vars.Registries[0]["host"]).To(Equal("registry.example.com:5000"))
+			Expect(vars.Registries[0]["ca_cert"]).To(Equal(string(ca)))
+			Expect(vars.Registries[0]["username"]).To(Equal("some-user"))
+			Expect(vars.Registries[0]["password"]).To(Equal("some-password"))
+			Expect(vars.Registries[1]["mirrors"]).To(ConsistOf("https://mirror.example.com"))
+		})
+
+		It("refuses settings that deps without the vars file cannot apply", func() {
+			config, err := docker.Load("", configPath)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(config.Deployable(true)).To(Succeed())
+			Expect(config.Deployable(false)).To(MatchError("'registry.example.com:5000' - ca_cert, username, password and mirrors need deps that declare docker_registry_vars; these deps only configure insecure registries"))
+
+			config, err = docker.Load("docker.io", "")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(config.Deployable(false)).To(Succeed())
+		})
+	})
+
+	Context("when the config file is invalid", func() {
+		It("rejects unknown keys", func() {
+			Expect(ioutil.WriteFile(configPath, []byte("registries:\n- host: a.com\n  pasword: typo\n"), 0600)).To(Succeed())
+			_, err := docker.Load("", configPath)
+			Expect(err).To(MatchError(ContainSubstring("pasword")))
+		})
+
+		It("rejects a username without a password", func() {
+			Expect(ioutil.WriteFile(configPath, []byte("registries:\n- host: a.com\n  username: some-user\n"), 0600)).To(Succeed())
+			_, err := docker.Load("", configPath)
+			Expect(err).To(MatchError("'a.com' - both a username and a password are required"))
+		})
+
+		It("rejects a mirror that is not a url", func() {
+			Expect(ioutil.WriteFile(configPath, []byte("registries:\n- host: a.com\n  mirrors: [mirror.com]\n"), 0600)).To(Succeed())
+			_, err := docker.Load("", configPath)
+			Expect(err).To(MatchError("'a.com' - mirror 'mirror.com' is not a valid http(s) url"))
+		})
+
+		It("rejects a ca cert that is not PEM encoded", func() {
+			caPath := filepath.Join(tmpDir, "ca.pem")
+			Expect(ioutil.WriteFile(caPath, []byte("not-a-cert"), 0600)).To(Succeed())
+			Expect(ioutil.WriteFile(configPath, []byte("registries:\n- host: a.com\n  ca_cert: "+caPath+"\n"), 0600)).To(Succeed())
+			_, err := docker.Load("", configPath)
+			Expect(err).To(MatchError(ContainSubstring("does not contain a PEM encoded certificate")))
+		})
+
+		It("rejects a missing ca cert", func() {
+			Expect(ioutil.WriteFile(configPath, []byte("registries:\n- host: a.com\n  ca_cert: /does/not/exist\n"), 0600)).To(Succeed())
+			_, err := docker.Load("", configPath)
+			Expect(err).To(MatchError(ContainSubstring("unable to read ca cert")))
+		})
+
+		It("rejects duplicate registries", func() {
+			Expect(ioutil.WriteFile(configPath, []byte("registries:\n- host: a.com\n- host: a.com\n"), 0600)).To(Succeed())
+			_, err := docker.Load("", configPath)
+			Expect(err).To(MatchError("'a.com' - registry is configured more than once"))
+		})
+	})
+})
@@ -0,0 +1,148 @@
+package env
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+
+	"code.cloudfoundry.org/cfdev/config"
+	"code.cloudfoundry.org/cfdev/errors"
+	"golang.org/x/net/http/httpproxy"
+)
+
+// NewHTTPClient builds the client assets are downloaded with from the
+// proxy, CA bundle, timeout and rate limit settings in cfg.
+func NewHTTPClient(cfg config.Config) (*http.Client, error) {
+	settings := cfg.DownloadClient
+
+	proxyConfig := BuildProxyConfig(cfg.BoshDirectorIP, cfg.CFRouterIP, cfg.HostIP)
+	if settings.Proxy != "" {
+		proxyConfig.Http = settings.Proxy
+		proxyConfig.Https = settings.Proxy
+	}
+	proxy := (&httpproxy.Config{
+		HTTPProxy:  proxyConfig.Http,
+		HTTPSProxy: proxyConfig.Https,
+		NoProxy:    proxyConfig.NoProxy,
+	}).ProxyFunc()
+
+	dialer := &net.Dialer{Timeout: settings.ConnectTimeout, KeepAlive: 30 * time.Second}
+	var limit *limiter
+	if settings.RateLimit > 0 {
+		limit = &limiter{rate: settings.RateLimit}
+	}
+
+	transport := &http.Transport{
+		Proxy: func(req *http.Request) (*url.URL, error) {
+			return proxy(req.URL)
+		},
+		DialContext: func(ctx context.Context, network, addr string) (net.Conn, error) {
+			c, err := dialer.DialContext(ctx, network, addr)
+			if err != nil {
+				return nil, err
+			}
+			return &conn{Conn: c, readTimeout: settings.ReadTimeout, limiter: limit}, nil
+		},
+		TLSHandshakeTimeout:   settings.ConnectTimeout,
+		ResponseHeaderTimeout: settings.ReadTimeout,
+		MaxIdleConns:          10,
+		IdleConnTimeout:       90 * time.Second,
+	}
+
+	if settings.CABundle != "" {
+		pool, err := certPool(settings.CABundle)
+		if err != nil {
+			return nil, errors.SafeWrap(err, "Unable to load CFDEV_CA_BUNDLE")
+		}
+		transport.TLSClientConfig = &tls.Config{RootCAs: pool}
+	}
+
+	return &http.Client{Transport: transport}, nil
+}
+
+// HTTPDo returns the Do function of NewHTTPClient for resource.Cache. A
+// misconfigured client fails the first download rather than every command.
+func HTTPDo(cfg config.Config) func(*http.Request) (*http.Response, error) {
+	client, err := NewHTTPClient(cfg)
+	if err != nil {
+		return func(*http.Request) (*http.Response, error) {
+			return nil, err
+		}
+	}
+	return client.Do
+}
+
+func certPool(path string) (*x509.CertPool, error) {
+	contents, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	pool, err := x509.SystemCertPool()
+	if err != nil || pool == nil {
+		pool = x509.NewCertPool()
+	}
+	if !pool.AppendCertsFromPEM(contents) {
+		return nil, fmt.Errorf("no certificates found in %s", path)
+	}
+	return pool, nil
+}
+
+// conn fails reads that stall for longer than readTimeout and throttles
+// reads through a limiter shared by all connections.
+type conn struct {
+	net.Conn
+	readTimeout time.Duration
+	limiter     *limiter
+}
+
+func (c *conn) Read(b []byte) (int, error) {
+	if c.readTimeout > 0 {
+		c.Conn.SetReadDeadline(time.Now().Add(c.readTimeout))
+	}
+	if c.limiter != nil && uint64(len(b)) > c.limiter.burst() {
+		b = b[:c.limiter.burst()]
+	}
+	n, err := c.Conn.Read(b)
+	c.limiter.wait(n)
+	return n, err
+}
+
+type limiter struct {
+	mutex sync.Mutex
+	rate  uint64
+	next  time.Time
+}
+
+// burst keeps single reads to a tenth of a second worth of data so that
+// the rate stays smooth.
+func (l *limiter) burst() uint64 {
+	if l.rate < 10*1024 {
+		return 1024
+	}
+	return l.rate / 10
+}
+
+func (l *limiter) wait(n int) {
+	if l == nil || n <= 0 {
+		return
+	}
+
+	l.mutex.Lock()
+	now := time.Now()
+	if l.next.Before(now) {
+		l.next = now
+	}
+	l.next = l.next.Add(time.Duration(float64(n) / float64(l.rate) * float64(time.Second)))
+	delay := l.next.Sub(now)
+	l.mutex.Unlock()
+
+	time.Sleep(delay)
+}
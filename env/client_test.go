@@ -0,0 +1,168 @@
+package env_test
+
+import (
+	"encoding/base64"
+	"encoding/pem"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"code.cloudfoundry.org/cfdev/config"
+	"code.cloudfoundry.org/cfdev/env"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("NewHTTPClient", func() {
+	var (
+		tmpDir string
+		cfg    config.Config
+	)
+
+	BeforeEach(func() {
+		var err error
+		tmpDir, err = ioutil.TempDir("", "cfdev-client-")
+		Expect(err).NotTo(HaveOccurred())
+		cfg = config.Config{DownloadClient: config.DownloadClient{ConnectTimeout: time.Second}}
+	})
+
+	AfterEach(func() {
+		os.RemoveAll(tmpDir)
+	})
+
+	get := func(url string) (string, error) {
+		client, err := env.NewHTTPClient(cfg)
+		Expect(err).NotTo(HaveOccurred())
+		resp, err := client.Get(url)
+		if err != nil {
+			return "", err
+		}
+		defer resp.Body.Close()
+		body, err := ioutil.ReadAll(resp.Body)
+		return string(body), err
+	}
+
+	Context("when a proxy is configured", func() {
+		var (
+			proxy     *httptest.Server
+			requested string
+			auth      string
+		)
+
+		BeforeEach(func() {
+			proxy = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				requested = r.URL.String()
+				auth = r.Header.Get("Proxy-Authorization")
+				w.Write([]byte("proxied"))
+			}))
+		})
+
+		AfterEach(func() {
+			proxy.Close()
+			os.Unsetenv("HTTP_PROXY")
+		})
+
+		It("sends downloads through the proxy with its credentials", func() {
+			cfg.DownloadClient.Proxy = strings.Replace(proxy.URL, "http://", "http://some-user:some-password@", 1)
+
+			Expect(get("http://assets.example.com/some-asset")).To(Equal("proxied"))
+			Expect(requested).To(Equal("http://assets.example.com/some-asset"))
+			Expect(auth).To(Equal("Basic " + base64.StdEncoding.EncodeToString([]byte("some-user:some-password"))))
+		})
+
+		It("defaults to the proxy the VM uses", func() {
+			os.Setenv("HTTP_PROXY", proxy.URL)
+
+			Expect(get("http://assets.example.com/some-asset")).To(Equal("proxied"))
+		})
+	})
+
+	Context("when a CA bundle is configured", func() {
+		var server *httptest.Server
+
+		BeforeEach(func() {
+			server = httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Write([]byte("content"))
+			}))
+			bundle := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw})
+			cfg.DownloadClient.CABundle = filepath.Join(tmpDir, "ca.pem")
+			Expect(ioutil.WriteFile(cfg.DownloadClient.CABundle, bundle, 0600)).To(Succeed())
+		})
+
+		AfterEach(func() {
+			server.Close()
+		})
+
+		It("trusts certificates signed by it", func() {
+			Expect(get(server.URL)).To(Equal("content"))
+		})
+
+		It("rejects them without it", func() {
+			cfg.DownloadClient.CABundle = ""
+
+			_, err := get(server.URL)
+			Expect(err).To(MatchError(ContainSubstring("certificate")))
+		})
+
+		It("fails downloads when the bundle has no certificates", func() {
+			Expect(ioutil.WriteFile(cfg.DownloadClient.CABundle, []byte("garbage"), 0600)).To(Succeed())
+
+			req, err := http.NewRequest("GET", server.URL, nil)
+			Expect(err).NotTo(HaveOccurred())
+			_, err = env.HTTPDo(cfg)(req)
+			Expect(err).To(MatchError(ContainSubstring("Unable to load CFDEV_CA_BUNDLE")))
+		})
+	})
+
+	Context("when the server stalls", func() {
+		var server *httptest.Server
+
+		BeforeEach(func() {
+			server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Write([]byte("some-"))
+				w.(http.Flusher).Flush()
+				time.Sleep(500 * time.Millisecond)
+				w.Write([]byte("content"))
+			}))
+		})
+
+		AfterEach(func() {
+			server.Close()
+		})
+
+		It("times out reads", func() {
+			cfg.DownloadClient.ReadTimeout = 50 * time.Millisecond
+
+			_, err := get(server.URL)
+			Expect(err).To(MatchError(ContainSubstring("timeout")))
+		})
+	})
+
+	Context("when a rate limit is configured", func() {
+		var server *httptest.Server
+
+		BeforeEach(func() {
+			server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Write(make([]byte, 64*1024))
+			}))
+		})
+
+		AfterEach(func() {
+			server.Close()
+		})
+
+		It("throttles downloads", func() {
+			cfg.DownloadClient.RateLimit = 128 * 1024
+
+			start := time.Now()
+			body, err := get(server.URL)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(body).To(HaveLen(64 * 1024))
+			Expect(time.Since(start)).To(BeNumerically(">", 400*time.Millisecond))
+		})
+	})
+})
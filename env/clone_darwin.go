@@ -0,0 +1,37 @@
+package env
+
+import (
+	"unsafe"
+
+	"golang.org/x/sys/unix"
+)
+
+const atFDCWD = -2
+
+// clone makes a copy-on-write clone of src at dst, which only works on
+// APFS. The clone shares the blocks of src until the VM writes to it.
+func clone(src, dst string) error {
+	srcPtr, err := unix.BytePtrFromString(src)
+	if err != nil {
+		return err
+	}
+	dstPtr, err := unix.BytePtrFromString(dst)
+	if err != nil {
+		return err
+	}
+
+	fd := atFDCWD
+	_, _, errno := unix.Syscall6(
+		unix.SYS_CLONEFILEAT,
+		uintptr(fd),
+		uintptr(unsafe.Pointer(srcPtr)),
+		uintptr(fd),
+		uintptr(unsafe.Pointer(dstPtr)),
+		0,
+		0,
+	)
+	if errno != 0 {
+		return errno
+	}
+	return nil
+}
@@ -0,0 +1,9 @@
+// +build !darwin
+
+package env
+
+import "errors"
+
+func clone(src, dst string) error {
+	return errors.New("cloning files is not supported")
+}
@@ -3,12 +3,15 @@ package env
 import (
 	"code.cloudfoundry.org/cfdev/resource"
 	"fmt"
+	"io"
 	"os"
+	"path/filepath"
 	"runtime"
 	"strings"
 
 	"code.cloudfoundry.org/cfdev/config"
 	"code.cloudfoundry.org/cfdev/errors"
+	"code.cloudfoundry.org/cfdev/signature"
 )
 
 type ProxyConfig struct {
@@ -55,7 +58,8 @@ func BuildProxyConfig(boshDirectorIP string, cfRouterIP string, hostIP string) P
 }
 
 type Env struct {
-	Config config.Config
+	Config   config.Config
+	Verifier *signature.Verifier
 }
 
 func (e *Env) CreateDirs() error {
@@ -143,22 +147,101 @@ func (e *Env) SetupState() error {
 		},
 	}
 
+	// The disk is extracted to the cache and cloned or copied from there,
+	// as the VM writes to its copy. The cached one is only extracted again
+	// when the deps change. On APFS the clone shares its blocks with the
+	// cached disk, elsewhere the disk is kept on disk twice.
+	disk := "disk.qcow2"
 	if runtime.GOOS == "windows" {
-		thingsToUntar = append(thingsToUntar, resource.TarOpts{
-			Include: "disk.vhdx",
-			Dst:     e.Config.StateLinuxkit,
-		})
-	} else {
-		thingsToUntar = append(thingsToUntar, resource.TarOpts{
-			Include: "disk.qcow2",
-			Dst:     e.Config.StateLinuxkit,
-		})
+		disk = "disk.vhdx"
 	}
+	thingsToUntar = append(thingsToUntar, resource.TarOpts{
+		Include: disk,
+		Dst:     e.Config.CacheDir,
+	})
+
+	// A manifest left behind by an older tarball must not be mistaken
+	// for the new one's.
+	manifest := filepath.Join(e.Config.CacheDir, "SHA256SUMS")
+	os.Remove(manifest)
+	os.Remove(manifest + ".sig")
 
 	err := resource.Untar(*e.Config.DepsFile, thingsToUntar)
 	if err != nil {
 		return errors.SafeWrap(err, "failed to untar the desired parts of the tarball")
 	}
 
+	if err := cloneOrCopy(filepath.Join(e.Config.CacheDir, disk), filepath.Join(e.Config.StateLinuxkit, disk)); err != nil {
+		return errors.SafeWrap(err, "failed to copy the disk image")
+	}
+
+	if err := e.verifyBinaries(manifest); err != nil {
+		return errors.SafeWrap(err, "failed to verify binaries")
+	}
+
 	return nil
 }
+
+// verifyBinaries checks the binaries that are run as root or as daemons
+// against the SHA256SUMS manifest shipped with them in the tarball. The
+// manifest is only required when a signing key is embedded.
+func (e *Env) verifyBinaries(manifest string) error {
+	if exists, err := fileExists(manifest); err != nil {
+		return err
+	} else if !exists && !e.Verifier.Enabled() {
+		return nil
+	}
+
+	if err := e.Verifier.VerifyFile(manifest); err != nil {
+		return err
+	}
+
+	digests, err := resource.ReadDigests(manifest)
+	if err != nil {
+		return err
+	}
+
+	binaries := []string{"linuxkit", "hyperkit", "vpnkit", "qcow-tool"}
+	if runtime.GOOS == "windows" {
+		binaries = []string{"vpnkit.exe"}
+	}
+	return resource.VerifyDigests(e.Config.CacheDir, digests, binaries...)
+}
+
+// cloneOrCopy falls back to copying src when the file system cannot
+// clone it.
+func cloneOrCopy(src, dst string) error {
+	if err := os.Remove(dst); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	if err := clone(src, dst); err == nil {
+		return nil
+	}
+	return copyFile(src, dst)
+}
+
+func copyFile(src, dst string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	out, err := os.OpenFile(dst, os.O_TRUNC|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
+
+func fileExists(path string) (bool, error) {
+	_, err := os.Stat(path)
+	if os.IsNotExist(err) {
+		return false, nil
+	}
+	return err == nil, err
+}
@@ -6,6 +6,10 @@ import (
 	. "github.com/onsi/gomega"
 	"runtime"
 
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
 	"os"
 
 	"io/ioutil"
@@ -13,6 +17,8 @@ import (
 
 	"code.cloudfoundry.org/cfdev/config"
 	"code.cloudfoundry.org/cfdev/env"
+	"code.cloudfoundry.org/cfdev/signature"
+	"golang.org/x/crypto/ed25519"
 )
 
 var _ = Describe("env", func() {
@@ -176,6 +182,21 @@ var _ = Describe("env", func() {
 				Expect(err).ToNot(HaveOccurred())
 			})
 
+			It("fails when the tarball has no disk image", func() {
+				tmpDir, err := ioutil.TempDir(os.TempDir(), "tmp-tar")
+				Expect(err).ToNot(HaveOccurred())
+				defer os.RemoveAll(tmpDir)
+				Expect(ioutil.WriteFile(filepath.Join(tmpDir, "state.json"), []byte("state"), 0600)).To(Succeed())
+
+				tarDst, err := os.Create(*conf.DepsFile)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(resource.Tar(tmpDir, tarDst)).To(Succeed())
+				Expect(tarDst.Close()).To(Succeed())
+
+				Expect(subject.CreateDirs()).To(Succeed())
+				Expect(subject.SetupState()).To(MatchError(ContainSubstring("failed to copy the disk image")))
+			})
+
 			It("overwrites the qcow disk with a new one", func() {
 				var fPath string
 
@@ -267,6 +288,74 @@ var _ = Describe("env", func() {
 			})
 		})
 
+		Context("when the tarball contains a digest manifest", func() {
+			var (
+				binary     string
+				manifest   string
+				privateKey ed25519.PrivateKey
+				makeTar    func()
+			)
+
+			BeforeEach(func() {
+				binary = "linuxkit"
+				if runtime.GOOS == "windows" {
+					binary = "vpnkit.exe"
+				}
+				manifest = fmt.Sprintf("%x  %s\n", sha256.Sum256([]byte("some-binary")), binary)
+
+				publicKey, key, err := ed25519.GenerateKey(rand.Reader)
+				Expect(err).NotTo(HaveOccurred())
+				privateKey = key
+				subject.Verifier, err = signature.New(base64.StdEncoding.EncodeToString(publicKey))
+				Expect(err).NotTo(HaveOccurred())
+
+				makeTar = func() {
+					tmpDir, err := ioutil.TempDir(os.TempDir(), "tmp-tar")
+					Expect(err).ToNot(HaveOccurred())
+					defer os.RemoveAll(tmpDir)
+
+					binaryPath := filepath.Join(tmpDir, "binaries")
+					Expect(os.MkdirAll(binaryPath, 0755)).To(Succeed())
+					Expect(ioutil.WriteFile(filepath.Join(binaryPath, binary), []byte("some-binary"), 0755)).To(Succeed())
+					Expect(ioutil.WriteFile(filepath.Join(binaryPath, "SHA256SUMS"), []byte(manifest), 0644)).To(Succeed())
+					sig := base64.StdEncoding.EncodeToString(ed25519.Sign(privateKey, []byte(manifest)))
+					Expect(ioutil.WriteFile(filepath.Join(binaryPath, "SHA256SUMS.sig"), []byte(sig), 0644)).To(Succeed())
+					Expect(ioutil.WriteFile(filepath.Join(tmpDir, "disk.qcow2"), []byte("tmp-disk"), 0600)).To(Succeed())
+					Expect(ioutil.WriteFile(filepath.Join(tmpDir, "disk.vhdx"), []byte("tmp-disk"), 0600)).To(Succeed())
+
+					tarDst, err := os.Create(*conf.DepsFile)
+					Expect(err).ToNot(HaveOccurred())
+					defer tarDst.Close()
+					Expect(resource.Tar(tmpDir, tarDst)).To(Succeed())
+				}
+			})
+
+			It("accepts binaries matching the signed manifest", func() {
+				makeTar()
+
+				Expect(subject.CreateDirs()).To(Succeed())
+				Expect(subject.SetupState()).To(Succeed())
+			})
+
+			It("rejects binaries that do not match the manifest", func() {
+				manifest = fmt.Sprintf("%x  %s\n", sha256.Sum256([]byte("other-binary")), binary)
+				makeTar()
+
+				Expect(subject.CreateDirs()).To(Succeed())
+				err := subject.SetupState()
+				Expect(err).To(MatchError(ContainSubstring("failed to verify binaries")))
+			})
+
+			It("rejects manifests with an invalid signature", func() {
+				_, privateKey, _ = ed25519.GenerateKey(rand.Reader)
+				makeTar()
+
+				Expect(subject.CreateDirs()).To(Succeed())
+				err := subject.SetupState()
+				Expect(err).To(MatchError(ContainSubstring("signature does not match")))
+			})
+		})
+
 		Context("when home dir cannot be created", func() {
 			BeforeEach(func() {
 				ioutil.WriteFile(homeDir, []byte{}, 0400)
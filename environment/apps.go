@@ -0,0 +1,95 @@
+package environment
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+)
+
+func (c *Converger) app(app App) error {
+	key := app.Org + "/" + app.Space + "/" + app.Name
+	digest, err := sourceDigest(app)
+	if err != nil {
+		return fmt.Errorf("reading app '%s': %s", app.Name, err)
+	}
+
+	if c.Pushed[key] == digest {
+		exists, err := c.appExists(app)
+		if err != nil {
+			return err
+		}
+		if exists {
+			return nil
+		}
+	}
+
+	c.record(Updated, "app", app.Name, "push to "+app.Org+"/"+app.Space)
+	if c.DryRun {
+		return nil
+	}
+	if err := c.Pusher.Push(app); err != nil {
+		return fmt.Errorf("pushing app '%s': %s", app.Name, err)
+	}
+
+	if c.Pushed == nil {
+		c.Pushed = map[string]string{}
+	}
+	c.Pushed[key] = digest
+	return nil
+}
+
+func (c *Converger) appExists(app App) (bool, error) {
+	orgGUID, err := c.findByName("/v2/organizations", app.Org, nil)
+	if err != nil || orgGUID == "" {
+		return false, err
+	}
+	spaceGUID, err := c.findByName("/v2/organizations/"+orgGUID+"/spaces", app.Space, nil)
+	if err != nil || spaceGUID == "" {
+		return false, err
+	}
+	guid, err := c.findByName("/v2/spaces/"+spaceGUID+"/apps", app.Name, nil)
+	return guid != "", err
+}
+
+// sourceDigest hashes the names, modes and contents of the files at the
+// path of app, which may be a directory or an archive, and its manifest.
+func sourceDigest(app App) (string, error) {
+	hash := sha256.New()
+	err := filepath.Walk(app.Path, func(path string, fi os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(app.Path, path)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(hash, "%s\x00%o\x00", filepath.ToSlash(rel), fi.Mode())
+		if !fi.Mode().IsRegular() {
+			return nil
+		}
+		return hashFile(hash, path)
+	})
+	if err != nil {
+		return "", err
+	}
+
+	if app.Manifest != "" {
+		fmt.Fprintf(hash, "manifest\x00")
+		if err := hashFile(hash, app.Manifest); err != nil {
+			return "", err
+		}
+	}
+	return fmt.Sprintf("sha256:%x", hash.Sum(nil)), nil
+}
+
+func hashFile(w io.Writer, path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	_, err = io.Copy(w, f)
+	return err
+}
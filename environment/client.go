@@ -0,0 +1,151 @@
+package environment
+
+import (
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"time"
+
+	"code.cloudfoundry.org/cfdev/analyticsd/cloud_controller"
+	"code.cloudfoundry.org/cfdev/config"
+	"golang.org/x/oauth2"
+)
+
+const (
+	adminUsername = "admin"
+	adminPassword = "admin"
+)
+
+// Client converges a running CF Dev environment as the admin user.
+type Client struct {
+	Config config.Config
+}
+
+func (c *Client) Converge(spec Spec, dryRun bool) ([]Change, error) {
+	httpClient, err := c.login()
+	if err != nil {
+		return nil, err
+	}
+
+	logger := log.New(ioutil.Discard, "", log.LstdFlags)
+	converger := &Converger{
+		CC:     cloud_controller.New("https://api."+c.Config.CFDomain, logger, httpClient, nil, "", ""),
+		UAA:    cloud_controller.New("https://uaa."+c.Config.CFDomain, logger, httpClient, nil, "", ""),
+		Pusher: &CFPusher{Config: c.Config},
+		DryRun: dryRun,
+		Pushed: readPushed(c.pushedPath()),
+	}
+
+	changes, err := converger.Converge(spec)
+	if dryRun {
+		return changes, err
+	}
+	// Apps pushed before a failure are recorded too.
+	if writeErr := writePushed(c.pushedPath(), converger.Pushed); err == nil && writeErr != nil {
+		err = fmt.Errorf("failed to record the pushed apps: %s", writeErr)
+	}
+	return changes, err
+}
+
+func (c *Client) pushedPath() string {
+	return filepath.Join(c.Config.StateDir, "up-apps.json")
+}
+
+// readPushed returns no digests when none were recorded, so that every
+// app is pushed.
+func readPushed(path string) map[string]string {
+	pushed := map[string]string{}
+	contents, err := ioutil.ReadFile(path)
+	if err != nil {
+		return pushed
+	}
+	json.Unmarshal(contents, &pushed)
+	return pushed
+}
+
+func writePushed(path string, pushed map[string]string) error {
+	contents, err := json.Marshal(pushed)
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(path, contents, 0600)
+}
+
+func (c *Client) login() (*http.Client, error) {
+	httpClient := &http.Client{
+		Timeout: 30 * time.Second,
+		Transport: &http.Transport{
+			Proxy: http.ProxyFromEnvironment,
+			TLSClientConfig: &tls.Config{
+				InsecureSkipVerify: true,
+			},
+		},
+	}
+
+	ctx := context.WithValue(context.Background(), oauth2.HTTPClient, httpClient)
+	cfg := &oauth2.Config{
+		ClientID: "cf",
+		Endpoint: oauth2.Endpoint{TokenURL: "https://uaa." + c.Config.CFDomain + "/oauth/token"},
+	}
+
+	token, err := cfg.PasswordCredentialsToken(ctx, adminUsername, adminPassword)
+	if err != nil {
+		return nil, fmt.Errorf("failed to log in to uaa: %s", err)
+	}
+
+	return cfg.Client(ctx, token), nil
+}
+
+// CFPusher pushes apps with the cf CLI using an isolated CF_HOME so the
+// user's own targeting is left untouched.
+type CFPusher struct {
+	Config config.Config
+}
+
+func (p *CFPusher) Push(app App) error {
+	home, err := ioutil.TempDir("", "cfdev-up-")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(home)
+
+	logFile, err := os.OpenFile(filepath.Join(p.Config.LogDir, "up-"+app.Name+".log"), os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0600)
+	if err != nil {
+		return err
+	}
+	defer logFile.Close()
+
+	push := []string{"push", app.Name, "-p", app.Path}
+	if app.Manifest != "" {
+		push = append(push, "-f", app.Manifest)
+	}
+
+	for _, args := range [][]string{
+		{"api", "https://api." + p.Config.CFDomain, "--skip-ssl-validation"},
+		{"auth", adminUsername, adminPassword},
+		{"target", "-o", app.Org, "-s", app.Space},
+		push,
+	} {
+		if err := p.cf(home, logFile, args...); err != nil {
+			return fmt.Errorf("cf %s failed (see %s): %s", args[0], logFile.Name(), err)
+		}
+	}
+
+	return nil
+}
+
+func (p *CFPusher) cf(home string, output io.Writer, args ...string) error {
+	cmd := exec.Command("cf", args...)
+	cmd.Env = append(os.Environ(), "CF_HOME="+home)
+	cmd.Stdout = output
+	cmd.Stderr = output
+	return cmd.Run()
+}
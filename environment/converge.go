@@ -0,0 +1,576 @@
+package environment
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"reflect"
+	"sort"
+
+	"code.cloudfoundry.org/cfdev/analyticsd/cloud_controller"
+)
+
+type CloudController interface {
+	Do(method string, path string, body interface{}, dest interface{}) error
+}
+
+//go:generate mockgen -package mocks -destination mocks/pusher.go code.cloudfoundry.org/cfdev/environment Pusher
+type Pusher interface {
+	Push(app App) error
+}
+
+type Change struct {
+	Action string
+	Kind   string
+	Name   string
+	Detail string
+}
+
+func (c Change) String() string {
+	if c.Detail == "" {
+		return fmt.Sprintf("%s %s %s", c.Action, c.Kind, c.Name)
+	}
+	return fmt.Sprintf("%s %s %s (%s)", c.Action, c.Kind, c.Name, c.Detail)
+}
+
+const (
+	Created = "+"
+	Updated = "~"
+)
+
+// Converger drives the Cloud Controller (and UAA for users) towards a Spec.
+// Convergence is additive: things not mentioned in the spec are left alone.
+type Converger struct {
+	CC     CloudController
+	UAA    CloudController
+	Pusher Pusher
+	DryRun bool
+	// Pushed is the source digest of every app pushed before, keyed by
+	// org/space/name. Apps are only pushed again when their source has
+	// changed or they no longer exist.
+	Pushed map[string]string
+
+	changes []Change
+	users   map[string]string
+}
+
+type resource struct {
+	Metadata struct {
+		GUID string `json:"guid"`
+	} `json:"metadata"`
+	Entity json.RawMessage `json:"entity"`
+}
+
+type resourceList struct {
+	Resources []resource `json:"resources"`
+	NextURL   string     `json:"next_url"`
+}
+
+type quotaEntity struct {
+	Name                    string `json:"name"`
+	NonBasicServicesAllowed bool   `json:"non_basic_services_allowed"`
+	TotalServices           int    `json:"total_services"`
+	TotalRoutes             int    `json:"total_routes"`
+	MemoryLimit             int    `json:"memory_limit"`
+	InstanceMemoryLimit     int    `json:"instance_memory_limit"`
+	AppInstanceLimit        int    `json:"app_instance_limit"`
+}
+
+func (c *Converger) Converge(spec Spec) ([]Change, error) {
+	c.changes = nil
+	c.users = map[string]string{}
+
+	quotas := map[string]string{}
+	for _, quota := range spec.Quotas {
+		guid, err := c.quota(quota)
+		if err != nil {
+			return c.changes, err
+		}
+		quotas[quota.Name] = guid
+	}
+
+	for _, user := range spec.Users {
+		if err := c.user(user); err != nil {
+			return c.changes, err
+		}
+	}
+
+	for _, org := range spec.Orgs {
+		if err := c.org(org, quotas); err != nil {
+			return c.changes, err
+		}
+	}
+
+	if err := c.featureFlags(spec.FeatureFlags); err != nil {
+		return c.changes, err
+	}
+
+	if err := c.environmentGroup("running", spec.EnvironmentVariableGroups.Running); err != nil {
+		return c.changes, err
+	}
+	if err := c.environmentGroup("staging", spec.EnvironmentVariableGroups.Staging); err != nil {
+		return c.changes, err
+	}
+
+	for _, group := range spec.SecurityGroups {
+		if err := c.securityGroup(group); err != nil {
+			return c.changes, err
+		}
+	}
+
+	for _, app := range spec.Apps {
+		if err := c.app(app); err != nil {
+			return c.changes, err
+		}
+	}
+
+	return c.changes, nil
+}
+
+func (c *Converger) record(action, kind, name, detail string) {
+	c.changes = append(c.changes, Change{Action: action, Kind: kind, Name: name, Detail: detail})
+}
+
+func (c *Converger) findByName(path string, name string, entity interface{}) (string, error) {
+	var list resourceList
+	if err := c.CC.Do(http.MethodGet, path+"?q="+url.QueryEscape("name:"+name), nil, &list); err != nil {
+		return "", err
+	}
+
+	for _, r := range list.Resources {
+		var named struct {
+			Name string `json:"name"`
+		}
+		if err := json.Unmarshal(r.Entity, &named); err != nil {
+			return "", err
+		}
+		if named.Name != name {
+			continue
+		}
+		if entity != nil {
+			if err := json.Unmarshal(r.Entity, entity); err != nil {
+				return "", err
+			}
+		}
+		return r.Metadata.GUID, nil
+	}
+	return "", nil
+}
+
+// listAll reads every page of a list, following next_url.
+func (c *Converger) listAll(path string) ([]resource, error) {
+	var resources []resource
+	for path != "" {
+		var list resourceList
+		if err := c.CC.Do(http.MethodGet, path, nil, &list); err != nil {
+			return nil, err
+		}
+		resources = append(resources, list.Resources...)
+		path = list.NextURL
+	}
+	return resources, nil
+}
+
+func (c *Converger) create(path string, body interface{}) (string, error) {
+	if c.DryRun {
+		return "", nil
+	}
+
+	var created resource
+	if err := c.CC.Do(http.MethodPost, path, body, &created); err != nil {
+		return "", err
+	}
+	return created.Metadata.GUID, nil
+}
+
+func (c *Converger) update(path string, body interface{}) error {
+	if c.DryRun {
+		return nil
+	}
+	return c.CC.Do(http.MethodPut, path, body, nil)
+}
+
+func unlimited(limit *int) int {
+	if limit == nil {
+		return -1
+	}
+	return *limit
+}
+
+func (c *Converger) quota(quota Quota) (string, error) {
+	desired := quotaEntity{
+		Name:                    quota.Name,
+		NonBasicServicesAllowed: quota.NonBasicServicesAllowed,
+		TotalServices:           unlimited(quota.TotalServices),
+		TotalRoutes:             unlimited(quota.TotalRoutes),
+		MemoryLimit:             quota.MemoryLimit,
+		InstanceMemoryLimit:     unlimited(quota.InstanceMemoryLimit),
+		AppInstanceLimit:        unlimited(quota.AppInstanceLimit),
+	}
+
+	var actual quotaEntity
+	guid, err := c.findByName("/v2/quota_definitions", quota.Name, &actual)
+	if err != nil {
+		return "", err
+	}
+
+	if guid == "" {
+		c.record(Created, "quota", quota.Name, "")
+		return c.create("/v2/quota_definitions", desired)
+	}
+
+	if actual != desired {
+		c.record(Updated, "quota", quota.Name, "")
+		return guid, c.update("/v2/quota_definitions/"+guid, desired)
+	}
+
+	return guid, nil
+}
+
+func (c *Converger) userGUID(name string) (string, error) {
+	if guid, ok := c.users[name]; ok {
+		return guid, nil
+	}
+
+	var result struct {
+		Resources []struct {
+			ID string `json:"id"`
+		} `json:"resources"`
+	}
+	filter := url.QueryEscape(fmt.Sprintf(`userName eq "%s"`, name))
+	if err := c.UAA.Do(http.MethodGet, "/Users?filter="+filter, nil, &result); err != nil {
+		return "", err
+	}
+
+	if len(result.Resources) == 0 {
+		return "", nil
+	}
+
+	c.users[name] = result.Resources[0].ID
+	return result.Resources[0].ID, nil
+}
+
+func (c *Converger) user(user User) error {
+	guid, err := c.userGUID(user.Name)
+	if err != nil {
+		return err
+	}
+
+	if guid == "" {
+		c.record(Created, "user", user.Name, "")
+		if c.DryRun {
+			c.users[user.Name] = ""
+			return nil
+		}
+
+		var created struct {
+			ID string `json:"id"`
+		}
+		if err := c.UAA.Do(http.MethodPost, "/Users", map[string]interface{}{
+			"userName": user.Name,
+			"password": user.Password,
+			"emails":   []map[string]string{{"value": user.Name}},
+		}, &created); err != nil {
+			return err
+		}
+		guid = created.ID
+		c.users[user.Name] = guid
+	}
+
+	err = c.CC.Do(http.MethodGet, "/v2/users/"+guid, nil, nil)
+	if respErr, ok := err.(*cloud_controller.ResponseError); ok && respErr.StatusCode == http.StatusNotFound {
+		_, err = c.create("/v2/users", map[string]string{"guid": guid})
+	}
+	return err
+}
+
+func (c *Converger) quotaGUID(name string, quotas map[string]string) (string, error) {
+	if guid, ok := quotas[name]; ok {
+		return guid, nil
+	}
+	return c.findByName("/v2/quota_definitions", name, nil)
+}
+
+func (c *Converger) org(org Org, quotas map[string]string) error {
+	var actual struct {
+		QuotaGUID string `json:"quota_definition_guid"`
+	}
+	guid, err := c.findByName("/v2/organizations", org.Name, &actual)
+	if err != nil {
+		return err
+	}
+
+	quotaGUID := ""
+	if org.Quota != "" {
+		if quotaGUID, err = c.quotaGUID(org.Quota, quotas); err != nil {
+			return err
+		}
+	}
+
+	if guid == "" {
+		c.record(Created, "org", org.Name, "")
+		body := map[string]string{"name": org.Name}
+		if quotaGUID != "" {
+			body["quota_definition_guid"] = quotaGUID
+		}
+		if guid, err = c.create("/v2/organizations", body); err != nil {
+			return err
+		}
+	} else if org.Quota != "" && quotaGUID != actual.QuotaGUID {
+		c.record(Updated, "org", org.Name, "quota "+org.Quota)
+		if err := c.update("/v2/organizations/"+guid, map[string]string{"quota_definition_guid": quotaGUID}); err != nil {
+			return err
+		}
+	}
+
+	members := map[string]bool{}
+	for _, list := range [][]string{org.Managers, org.Auditors, org.BillingManagers} {
+		for _, name := range list {
+			members[name] = true
+		}
+	}
+	for _, space := range org.Spaces {
+		for _, list := range [][]string{space.Developers, space.Managers, space.Auditors} {
+			for _, name := range list {
+				members[name] = true
+			}
+		}
+	}
+
+	roles := []struct {
+		role  string
+		users []string
+	}{
+		{"users", sortedKeys(members)},
+		{"managers", org.Managers},
+		{"auditors", org.Auditors},
+		{"billing_managers", org.BillingManagers},
+	}
+	for _, r := range roles {
+		if err := c.roles("/v2/organizations/"+guid, guid, r.role, r.users, "org "+org.Name); err != nil {
+			return err
+		}
+	}
+
+	for _, space := range org.Spaces {
+		if err := c.space(guid, org.Name, space); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (c *Converger) space(orgGUID string, orgName string, space Space) error {
+	guid := ""
+	if orgGUID != "" {
+		var err error
+		if guid, err = c.findByName("/v2/organizations/"+orgGUID+"/spaces", space.Name, nil); err != nil {
+			return err
+		}
+	}
+
+	name := orgName + "/" + space.Name
+	if guid == "" {
+		c.record(Created, "space", name, "")
+		var err error
+		if guid, err = c.create("/v2/spaces", map[string]string{"name": space.Name, "organization_guid": orgGUID}); err != nil {
+			return err
+		}
+	}
+
+	roles := []struct {
+		role  string
+		users []string
+	}{
+		{"developers", space.Developers},
+		{"managers", space.Managers},
+		{"auditors", space.Auditors},
+	}
+	for _, r := range roles {
+		if err := c.roles("/v2/spaces/"+guid, guid, r.role, r.users, "space "+name); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (c *Converger) roles(path string, guid string, role string, users []string, target string) error {
+	if len(users) == 0 {
+		return nil
+	}
+
+	existing := map[string]bool{}
+	if guid != "" {
+		resources, err := c.listAll(path + "/" + role + "?results-per-page=100")
+		if err != nil {
+			return err
+		}
+		for _, r := range resources {
+			var user struct {
+				Username string `json:"username"`
+			}
+			if err := json.Unmarshal(r.Entity, &user); err != nil {
+				return err
+			}
+			existing[user.Username] = true
+		}
+	}
+
+	for _, user := range users {
+		if existing[user] {
+			continue
+		}
+
+		c.record(Created, "role", target, fmt.Sprintf("%s %s", role, user))
+		if c.DryRun {
+			continue
+		}
+
+		userGUID, err := c.userGUID(user)
+		if err != nil {
+			return err
+		}
+		if userGUID == "" {
+			return fmt.Errorf("user '%s' does not exist", user)
+		}
+		if err := c.CC.Do(http.MethodPut, path+"/"+role+"/"+userGUID, nil, nil); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (c *Converger) featureFlags(flags map[string]bool) error {
+	for _, name := range sortedKeys(flags) {
+		var actual struct {
+			Enabled bool `json:"enabled"`
+		}
+		if err := c.CC.Do(http.MethodGet, "/v2/config/feature_flags/"+name, nil, &actual); err != nil {
+			return err
+		}
+
+		if actual.Enabled == flags[name] {
+			continue
+		}
+
+		c.record(Updated, "feature flag", name, fmt.Sprintf("%t -> %t", actual.Enabled, flags[name]))
+		if err := c.update("/v2/config/feature_flags/"+name, map[string]bool{"enabled": flags[name]}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (c *Converger) environmentGroup(group string, desired map[string]string) error {
+	if desired == nil {
+		return nil
+	}
+
+	path := "/v2/config/environment_variable_groups/" + group
+
+	var actual map[string]interface{}
+	if err := c.CC.Do(http.MethodGet, path, nil, &actual); err != nil {
+		return err
+	}
+
+	same := len(actual) == len(desired)
+	for k, v := range desired {
+		if a, ok := actual[k]; !ok || fmt.Sprint(a) != v {
+			same = false
+		}
+	}
+	if same {
+		return nil
+	}
+
+	c.record(Updated, "environment variable group", group, "")
+	return c.update(path, desired)
+}
+
+func (c *Converger) securityGroup(group SecurityGroup) error {
+	rules, err := normalize(group.Rules)
+	if err != nil {
+		return err
+	}
+
+	var actual struct {
+		Rules interface{} `json:"rules"`
+	}
+	guid, err := c.findByName("/v2/security_groups", group.Name, &actual)
+	if err != nil {
+		return err
+	}
+
+	if guid == "" {
+		c.record(Created, "security group", group.Name, "")
+		if guid, err = c.create("/v2/security_groups", map[string]interface{}{"name": group.Name, "rules": rules}); err != nil {
+			return err
+		}
+	} else if !reflect.DeepEqual(actual.Rules, rules) {
+		c.record(Updated, "security group", group.Name, "rules")
+		if err := c.update("/v2/security_groups/"+guid, map[string]interface{}{"rules": rules}); err != nil {
+			return err
+		}
+	}
+
+	if group.Running {
+		if err := c.bindSecurityGroup("running", guid, group.Name); err != nil {
+			return err
+		}
+	}
+	if group.Staging {
+		if err := c.bindSecurityGroup("staging", guid, group.Name); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (c *Converger) bindSecurityGroup(lifecycle string, guid string, name string) error {
+	path := "/v2/config/" + lifecycle + "_security_groups"
+
+	if guid != "" {
+		resources, err := c.listAll(path)
+		if err != nil {
+			return err
+		}
+		for _, r := range resources {
+			if r.Metadata.GUID == guid {
+				return nil
+			}
+		}
+	}
+
+	c.record(Created, "security group binding", name, lifecycle)
+	return c.update(path+"/"+guid, nil)
+}
+
+// normalize round trips the rules through json so they can be compared
+// with what the Cloud Controller returns.
+func normalize(rules []map[string]interface{}) (interface{}, error) {
+	if rules == nil {
+		rules = []map[string]interface{}{}
+	}
+
+	contents, err := json.Marshal(rules)
+	if err != nil {
+		return nil, err
+	}
+
+	var normalized interface{}
+	return normalized, json.Unmarshal(contents, &normalized)
+}
+
+func sortedKeys(m interface{}) []string {
+	var keys []string
+	for _, k := range reflect.ValueOf(m).MapKeys() {
+		keys = append(keys, k.String())
+	}
+	sort.Strings(keys)
+	return keys
+}
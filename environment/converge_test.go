@@ -0,0 +1,229 @@
+package environment_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"code.cloudfoundry.org/cfdev/environment"
+	"code.cloudfoundry.org/cfdev/environment/mocks"
+	"github.com/golang/mock/gomock"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Converger", func() {
+	var (
+		mockController *gomock.Controller
+		mockPusher     *mocks.MockPusher
+		appDir         string
+		fake           *fakeCloudFoundry
+		converger      *environment.Converger
+		spec           environment.Spec
+	)
+
+	strings := func(changes []environment.Change) []string {
+		var result []string
+		for _, change := range changes {
+			result = append(result, change.String())
+		}
+		return result
+	}
+
+	BeforeEach(func() {
+		mockController = gomock.NewController(GinkgoT())
+		mockPusher = mocks.NewMockPusher(mockController)
+
+		var err error
+		appDir, err = ioutil.TempDir("", "cfdev-app-")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(ioutil.WriteFile(filepath.Join(appDir, "index.html"), []byte("hello"), 0644)).To(Succeed())
+
+		fake = newFakeCloudFoundry()
+		converger = &environment.Converger{
+			CC:     fakeCC{fake},
+			UAA:    fakeUAA{fake},
+			Pusher: mockPusher,
+		}
+
+		routes := 10
+		spec = environment.Spec{
+			Quotas: []environment.Quota{
+				{Name: "small", MemoryLimit: 2048, TotalRoutes: &routes},
+			},
+			Users: []environment.User{
+				{Name: "alice", Password: "some-password"},
+			},
+			Orgs: []environment.Org{
+				{
+					Name:     "some-org",
+					Quota:    "small",
+					Managers: []string{"alice"},
+					Spaces: []environment.Space{
+						{Name: "some-space", Developers: []string{"alice", "admin"}},
+					},
+				},
+			},
+			FeatureFlags: map[string]bool{"diego_docker": true},
+			EnvironmentVariableGroups: environment.EnvironmentGroups{
+				Running: map[string]string{"SOME_KEY": "some-value"},
+			},
+			SecurityGroups: []environment.SecurityGroup{
+				{
+					Name:    "open",
+					Rules:   []map[string]interface{}{{"protocol": "all", "destination": "0.0.0.0/0"}},
+					Running: true,
+				},
+			},
+		}
+	})
+
+	AfterEach(func() {
+		mockController.Finish()
+		os.RemoveAll(appDir)
+	})
+
+	It("creates everything that is missing and reports the changes", func() {
+		changes, err := converger.Converge(spec)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(strings(changes)).To(Equal([]string{
+			"+ quota small",
+			"+ user alice",
+			"+ org some-org",
+			"+ role org some-org (users admin)",
+			"+ role org some-org (users alice)",
+			"+ role org some-org (managers alice)",
+			"+ space some-org/some-space",
+			"+ role space some-org/some-space (developers alice)",
+			"+ role space some-org/some-space (developers admin)",
+			"~ feature flag diego_docker (false -> true)",
+			"~ environment variable group running",
+			"+ security group open",
+			"+ security group binding open (running)",
+		}))
+
+		Expect(fake.collections["/v2/quota_definitions"]["guid-1"]).To(HaveKeyWithValue("total_routes", float64(10)))
+		Expect(fake.collections["/v2/quota_definitions"]["guid-1"]).To(HaveKeyWithValue("app_instance_limit", float64(-1)))
+		Expect(fake.collections["/v2/organizations"]["guid-3"]).To(HaveKeyWithValue("quota_definition_guid", "guid-1"))
+		Expect(fake.collections["/v2/users"]).To(HaveKey("guid-2"))
+		Expect(fake.config["/v2/config/environment_variable_groups/running"]).To(Equal(map[string]interface{}{"SOME_KEY": "some-value"}))
+	})
+
+	It("is idempotent", func() {
+		_, err := converger.Converge(spec)
+		Expect(err).NotTo(HaveOccurred())
+		mutations := len(fake.mutations)
+
+		changes, err := converger.Converge(spec)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(changes).To(BeEmpty())
+		Expect(fake.mutations).To(HaveLen(mutations))
+	})
+
+	It("follows next_url through paged roles and bindings", func() {
+		fake.pageSize = 1
+		_, err := converger.Converge(spec)
+		Expect(err).NotTo(HaveOccurred())
+		mutations := len(fake.mutations)
+
+		changes, err := converger.Converge(spec)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(changes).To(BeEmpty())
+		Expect(fake.mutations).To(HaveLen(mutations))
+	})
+
+	It("updates things that have drifted", func() {
+		_, err := converger.Converge(spec)
+		Expect(err).NotTo(HaveOccurred())
+
+		spec.Quotas[0].MemoryLimit = 4096
+		spec.FeatureFlags["diego_docker"] = false
+		spec.SecurityGroups[0].Rules[0]["ports"] = "443"
+
+		changes, err := converger.Converge(spec)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(strings(changes)).To(Equal([]string{
+			"~ quota small",
+			"~ feature flag diego_docker (true -> false)",
+			"~ security group open (rules)",
+		}))
+		Expect(fake.collections["/v2/quota_definitions"]["guid-1"]).To(HaveKeyWithValue("memory_limit", float64(4096)))
+	})
+
+	Context("when doing a dry run", func() {
+		It("reports the changes without making them", func() {
+			converger.DryRun = true
+			spec.Apps = []environment.App{{Name: "some-app", Path: appDir, Org: "some-org", Space: "some-space"}}
+
+			changes, err := converger.Converge(spec)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(strings(changes)).To(ContainElement("+ org some-org"))
+			Expect(strings(changes)).To(ContainElement("+ role space some-org/some-space (developers alice)"))
+			Expect(strings(changes)).To(ContainElement("~ app some-app (push to some-org/some-space)"))
+			Expect(fake.mutations).To(BeEmpty())
+		})
+	})
+
+	Context("when apps are listed", func() {
+		var app environment.App
+
+		// push stands in for cf push by adding the app to its space.
+		push := func(app environment.App) {
+			for guid, space := range fake.collection("/v2/spaces") {
+				if space["name"] == app.Space {
+					fake.collection("/v2/spaces/" + guid + "/apps")["app-guid"] = map[string]interface{}{"name": app.Name}
+				}
+			}
+		}
+
+		BeforeEach(func() {
+			app = environment.App{Name: "some-app", Path: appDir, Org: "some-org", Space: "some-space"}
+			spec.Apps = []environment.App{app}
+		})
+
+		It("pushes them", func() {
+			mockPusher.EXPECT().Push(app)
+
+			changes, err := converger.Converge(spec)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(strings(changes)).To(ContainElement("~ app some-app (push to some-org/some-space)"))
+			Expect(converger.Pushed).To(HaveKey("some-org/some-space/some-app"))
+		})
+
+		Context("when they were pushed before", func() {
+			BeforeEach(func() {
+				mockPusher.EXPECT().Push(app).Do(push)
+				_, err := converger.Converge(spec)
+				Expect(err).NotTo(HaveOccurred())
+			})
+
+			It("does not push them again", func() {
+				changes, err := converger.Converge(spec)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(changes).To(BeEmpty())
+			})
+
+			It("pushes them again once their source changes", func() {
+				Expect(ioutil.WriteFile(filepath.Join(appDir, "index.html"), []byte("new"), 0644)).To(Succeed())
+				mockPusher.EXPECT().Push(app)
+
+				changes, err := converger.Converge(spec)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(strings(changes)).To(Equal([]string{"~ app some-app (push to some-org/some-space)"}))
+			})
+
+			It("pushes them again when they no longer exist", func() {
+				for path := range fake.collections {
+					if filepath.Base(path) == "apps" {
+						delete(fake.collections, path)
+					}
+				}
+				mockPusher.EXPECT().Push(app)
+
+				changes, err := converger.Converge(spec)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(strings(changes)).To(Equal([]string{"~ app some-app (push to some-org/some-space)"}))
+			})
+		})
+	})
+})
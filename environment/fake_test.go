@@ -0,0 +1,226 @@
+package environment_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+
+	"code.cloudfoundry.org/cfdev/analyticsd/cloud_controller"
+)
+
+// fakeCloudFoundry is a tiny in-memory stand-in for the parts of the
+// Cloud Controller and UAA APIs that the converger talks to.
+type fakeCloudFoundry struct {
+	collections map[string]map[string]map[string]interface{}
+	roles       map[string][]string
+	config      map[string]interface{}
+	uaaUsers    map[string]string
+	nextGUID    int
+	mutations   []string
+	// pageSize splits role and security group binding lists into pages
+	// linked by next_url when set.
+	pageSize int
+}
+
+func newFakeCloudFoundry() *fakeCloudFoundry {
+	return &fakeCloudFoundry{
+		collections: map[string]map[string]map[string]interface{}{},
+		roles:       map[string][]string{},
+		config: map[string]interface{}{
+			"/v2/config/feature_flags/diego_docker":          map[string]interface{}{"name": "diego_docker", "enabled": false},
+			"/v2/config/environment_variable_groups/running": map[string]interface{}{},
+			"/v2/config/environment_variable_groups/staging": map[string]interface{}{},
+		},
+		uaaUsers: map[string]string{"admin": "admin-guid"},
+	}
+}
+
+func (f *fakeCloudFoundry) guid() string {
+	f.nextGUID++
+	return fmt.Sprintf("guid-%d", f.nextGUID)
+}
+
+func (f *fakeCloudFoundry) username(guid string) string {
+	for name, g := range f.uaaUsers {
+		if g == guid {
+			return name
+		}
+	}
+	return ""
+}
+
+func respond(src interface{}, dest interface{}) error {
+	if dest == nil {
+		return nil
+	}
+	contents, err := json.Marshal(src)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(contents, dest)
+}
+
+func roundTrip(body interface{}) map[string]interface{} {
+	var result map[string]interface{}
+	respond(body, &result)
+	return result
+}
+
+func notFound(method, path string) error {
+	return &cloud_controller.ResponseError{Method: method, Path: path, StatusCode: http.StatusNotFound, Status: "404 Not Found"}
+}
+
+type fakeCC struct{ *fakeCloudFoundry }
+type fakeUAA struct{ *fakeCloudFoundry }
+
+func (f fakeUAA) Do(method string, path string, body interface{}, dest interface{}) error {
+	u, _ := url.Parse(path)
+	switch method {
+	case http.MethodGet:
+		name := strings.TrimSuffix(strings.TrimPrefix(u.Query().Get("filter"), `userName eq "`), `"`)
+		resources := []map[string]string{}
+		if guid, ok := f.uaaUsers[name]; ok {
+			resources = append(resources, map[string]string{"id": guid})
+		}
+		return respond(map[string]interface{}{"resources": resources}, dest)
+	case http.MethodPost:
+		name := roundTrip(body)["userName"].(string)
+		f.uaaUsers[name] = f.guid()
+		f.mutations = append(f.mutations, "POST /Users "+name)
+		return respond(map[string]string{"id": f.uaaUsers[name]}, dest)
+	}
+	return fmt.Errorf("unexpected uaa request %s %s", method, path)
+}
+
+func (f fakeCC) Do(method string, path string, body interface{}, dest interface{}) error {
+	u, _ := url.Parse(path)
+	p := u.Path
+
+	if method != http.MethodGet {
+		f.mutations = append(f.mutations, method+" "+p)
+	}
+
+	if value, ok := f.config[p]; ok {
+		if method == http.MethodPut {
+			f.config[p] = roundTrip(body)
+			return nil
+		}
+		return respond(value, dest)
+	}
+
+	for _, lifecycle := range []string{"running", "staging"} {
+		bindings := "/v2/config/" + lifecycle + "_security_groups"
+		if p == bindings && method == http.MethodGet {
+			return respond(f.page(u, f.list(f.roles[bindings], nil)), dest)
+		}
+		if strings.HasPrefix(p, bindings+"/") && method == http.MethodPut {
+			f.roles[bindings] = append(f.roles[bindings], strings.TrimPrefix(p, bindings+"/"))
+			return nil
+		}
+	}
+
+	parts := strings.Split(strings.TrimPrefix(p, "/"), "/")
+	switch {
+	case p == "/v2/users" && method == http.MethodPost:
+		f.collection("/v2/users")[roundTrip(body)["guid"].(string)] = map[string]interface{}{}
+		return nil
+	case len(parts) == 3 && parts[1] == "users" && method == http.MethodGet:
+		if _, ok := f.collection("/v2/users")[parts[2]]; !ok {
+			return notFound(method, path)
+		}
+		return nil
+	case len(parts) == 4 && parts[3] == "spaces" && method == http.MethodGet:
+		return f.query("/v2/organizations/"+parts[2]+"/spaces", u, dest)
+	case len(parts) == 4 && parts[3] == "apps" && method == http.MethodGet:
+		return f.query(p, u, dest)
+	case len(parts) == 4 && method == http.MethodGet:
+		return respond(f.page(u, f.list(f.roles[p], func(guid string) map[string]interface{} {
+			return map[string]interface{}{"username": f.username(guid)}
+		})), dest)
+	case len(parts) == 5 && method == http.MethodPut:
+		key := "/" + strings.Join(parts[:4], "/")
+		f.roles[key] = append(f.roles[key], parts[4])
+		return nil
+	case len(parts) == 2 && method == http.MethodGet:
+		return f.query(p, u, dest)
+	case len(parts) == 2 && method == http.MethodPost:
+		guid := f.guid()
+		entity := roundTrip(body)
+		f.collection(p)[guid] = entity
+		if p == "/v2/spaces" {
+			f.collection("/v2/organizations/" + entity["organization_guid"].(string) + "/spaces")[guid] = entity
+		}
+		return respond(map[string]interface{}{"metadata": map[string]string{"guid": guid}}, dest)
+	case len(parts) == 3 && method == http.MethodPut:
+		entity := f.collection("/" + parts[0] + "/" + parts[1])[parts[2]]
+		for k, v := range roundTrip(body) {
+			entity[k] = v
+		}
+		return nil
+	}
+
+	return fmt.Errorf("unexpected cc request %s %s", method, path)
+}
+
+func (f *fakeCloudFoundry) collection(path string) map[string]map[string]interface{} {
+	if f.collections[path] == nil {
+		f.collections[path] = map[string]map[string]interface{}{}
+	}
+	return f.collections[path]
+}
+
+func (f *fakeCloudFoundry) query(path string, u *url.URL, dest interface{}) error {
+	name := strings.TrimPrefix(u.Query().Get("q"), "name:")
+
+	var guids []string
+	for guid, entity := range f.collection(path) {
+		if entity["name"] == name {
+			guids = append(guids, guid)
+		}
+	}
+
+	return respond(f.list(guids, func(guid string) map[string]interface{} {
+		return f.collection(path)[guid]
+	}), dest)
+}
+
+func (f *fakeCloudFoundry) list(guids []string, entity func(string) map[string]interface{}) map[string]interface{} {
+	sort.Strings(guids)
+	resources := []map[string]interface{}{}
+	for _, guid := range guids {
+		r := map[string]interface{}{"metadata": map[string]string{"guid": guid}}
+		if entity != nil {
+			r["entity"] = entity(guid)
+		}
+		resources = append(resources, r)
+	}
+	return map[string]interface{}{"resources": resources}
+}
+
+func (f *fakeCloudFoundry) page(u *url.URL, list map[string]interface{}) map[string]interface{} {
+	if f.pageSize == 0 {
+		return list
+	}
+
+	resources := list["resources"].([]map[string]interface{})
+	page, _ := strconv.Atoi(u.Query().Get("page"))
+	if page == 0 {
+		page = 1
+	}
+	start := (page - 1) * f.pageSize
+	if start > len(resources) {
+		start = len(resources)
+	}
+	end := start + f.pageSize
+	if end < len(resources) {
+		list["next_url"] = fmt.Sprintf("%s?page=%d", u.Path, page+1)
+	} else {
+		end = len(resources)
+	}
+	list["resources"] = resources[start:end]
+	return list
+}
@@ -0,0 +1,189 @@
+package environment
+
+import (
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+)
+
+type Spec struct {
+	Start                     StartSpec         `yaml:"start"`
+	Quotas                    []Quota           `yaml:"quotas"`
+	Users                     []User            `yaml:"users"`
+	Orgs                      []Org             `yaml:"orgs"`
+	FeatureFlags              map[string]bool   `yaml:"feature_flags"`
+	EnvironmentVariableGroups EnvironmentGroups `yaml:"environment_variable_groups"`
+	SecurityGroups            []SecurityGroup   `yaml:"security_groups"`
+	Apps                      []App             `yaml:"apps"`
+}
+
+type StartSpec struct {
+	File     string   `yaml:"file"`
+	Cpus     int      `yaml:"cpus"`
+	Memory   int      `yaml:"memory"`
+	Services []string `yaml:"services"`
+}
+
+// Quota limits that are omitted are unlimited.
+type Quota struct {
+	Name                    string `yaml:"name"`
+	MemoryLimit             int    `yaml:"memory"`
+	InstanceMemoryLimit     *int   `yaml:"instance_memory"`
+	TotalRoutes             *int   `yaml:"routes"`
+	TotalServices           *int   `yaml:"services"`
+	AppInstanceLimit        *int   `yaml:"app_instances"`
+	NonBasicServicesAllowed bool   `yaml:"paid_services"`
+}
+
+type User struct {
+	Name     string `yaml:"name"`
+	Password string `yaml:"password"`
+}
+
+type Org struct {
+	Name            string   `yaml:"name"`
+	Quota           string   `yaml:"quota"`
+	Managers        []string `yaml:"managers"`
+	Auditors        []string `yaml:"auditors"`
+	BillingManagers []string `yaml:"billing_managers"`
+	Spaces          []Space  `yaml:"spaces"`
+}
+
+type Space struct {
+	Name       string   `yaml:"name"`
+	Developers []string `yaml:"developers"`
+	Managers   []string `yaml:"managers"`
+	Auditors   []string `yaml:"auditors"`
+}
+
+type EnvironmentGroups struct {
+	Running map[string]string `yaml:"running"`
+	Staging map[string]string `yaml:"staging"`
+}
+
+type SecurityGroup struct {
+	Name    string                   `yaml:"name"`
+	Rules   []map[string]interface{} `yaml:"rules"`
+	Running bool                     `yaml:"running"`
+	Staging bool                     `yaml:"staging"`
+}
+
+type App struct {
+	Name     string `yaml:"name"`
+	Path     string `yaml:"path"`
+	Manifest string `yaml:"manifest"`
+	Org      string `yaml:"org"`
+	Space    string `yaml:"space"`
+}
+
+// Load reads and validates the environment file at path. Relative app
+// and deps paths are resolved against the directory of the file.
+func Load(path string) (Spec, error) {
+	contents, err := ioutil.ReadFile(path)
+	if err != nil {
+		return Spec{}, err
+	}
+
+	var spec Spec
+	if err := yaml.UnmarshalStrict(contents, &spec); err != nil {
+		return Spec{}, fmt.Errorf("'%s' - %v", path, err)
+	}
+
+	dir, err := filepath.Abs(filepath.Dir(path))
+	if err != nil {
+		return Spec{}, err
+	}
+
+	spec.Start.File = resolve(dir, spec.Start.File)
+	for i := range spec.Apps {
+		spec.Apps[i].Path = resolve(dir, spec.Apps[i].Path)
+		spec.Apps[i].Manifest = resolve(dir, spec.Apps[i].Manifest)
+	}
+
+	return spec, spec.Validate()
+}
+
+func resolve(dir string, path string) string {
+	if path == "" || filepath.IsAbs(path) || strings.Contains(path, "://") {
+		return path
+	}
+	return filepath.Join(dir, path)
+}
+
+func (s Spec) Validate() error {
+	quotas := map[string]bool{}
+	for _, quota := range s.Quotas {
+		if quota.Name == "" {
+			return fmt.Errorf("quotas must have a name")
+		}
+		if quota.MemoryLimit <= 0 {
+			return fmt.Errorf("quota '%s' must have a memory limit", quota.Name)
+		}
+		if quotas[quota.Name] {
+			return fmt.Errorf("quota '%s' is defined more than once", quota.Name)
+		}
+		quotas[quota.Name] = true
+	}
+
+	users := map[string]bool{}
+	for _, user := range s.Users {
+		if user.Name == "" || user.Password == "" {
+			return fmt.Errorf("users must have a name and a password")
+		}
+		users[user.Name] = true
+	}
+
+	knownUser := func(names ...[]string) error {
+		for _, list := range names {
+			for _, name := range list {
+				if !users[name] && name != "admin" {
+					return fmt.Errorf("user '%s' is not defined", name)
+				}
+			}
+		}
+		return nil
+	}
+
+	spaces := map[string]bool{}
+	for _, org := range s.Orgs {
+		if org.Name == "" {
+			return fmt.Errorf("orgs must have a name")
+		}
+		if org.Quota != "" && !quotas[org.Quota] && org.Quota != "default" {
+			return fmt.Errorf("org '%s' refers to undefined quota '%s'", org.Name, org.Quota)
+		}
+		if err := knownUser(org.Managers, org.Auditors, org.BillingManagers); err != nil {
+			return fmt.Errorf("org '%s': %s", org.Name, err)
+		}
+
+		for _, space := range org.Spaces {
+			if space.Name == "" {
+				return fmt.Errorf("spaces in org '%s' must have a name", org.Name)
+			}
+			if err := knownUser(space.Developers, space.Managers, space.Auditors); err != nil {
+				return fmt.Errorf("space '%s/%s': %s", org.Name, space.Name, err)
+			}
+			spaces[org.Name+"/"+space.Name] = true
+		}
+	}
+
+	for _, group := range s.SecurityGroups {
+		if group.Name == "" {
+			return fmt.Errorf("security groups must have a name")
+		}
+	}
+
+	for _, app := range s.Apps {
+		if app.Name == "" || app.Path == "" {
+			return fmt.Errorf("apps must have a name and a path")
+		}
+		if !spaces[app.Org+"/"+app.Space] {
+			return fmt.Errorf("app '%s' must target a space defined in the file", app.Name)
+		}
+	}
+
+	return nil
+}
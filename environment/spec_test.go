@@ -0,0 +1,96 @@
+package environment_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"code.cloudfoundry.org/cfdev/environment"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Spec", func() {
+	var (
+		tmpDir   string
+		specPath string
+	)
+
+	BeforeEach(func() {
+		var err error
+		tmpDir, err = ioutil.TempDir("", "cfdev-environment-")
+		Expect(err).NotTo(HaveOccurred())
+		specPath = filepath.Join(tmpDir, "cfdev.yml")
+	})
+
+	AfterEach(func() {
+		os.RemoveAll(tmpDir)
+	})
+
+	It("loads the file and resolves relative paths", func() {
+		Expect(ioutil.WriteFile(specPath, []byte(`---
+start:
+  cpus: 6
+  services: [mysql, redis]
+quotas:
+- name: small
+  memory: 2048
+users:
+- name: alice
+  password: some-password
+orgs:
+- name: some-org
+  quota: small
+  spaces:
+  - name: some-space
+    developers: [alice]
+feature_flags:
+  diego_docker: true
+apps:
+- name: some-app
+  path: apps/some-app
+  org: some-org
+  space: some-space
+`), 0644)).To(Succeed())
+
+		spec, err := environment.Load(specPath)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(spec.Start.Cpus).To(Equal(6))
+		Expect(spec.Start.Services).To(Equal([]string{"mysql", "redis"}))
+		Expect(spec.Quotas[0].TotalRoutes).To(BeNil())
+		Expect(spec.FeatureFlags).To(Equal(map[string]bool{"diego_docker": true}))
+		Expect(spec.Apps[0].Path).To(Equal(filepath.Join(tmpDir, "apps", "some-app")))
+	})
+
+	It("leaves a deps file url as is", func() {
+		Expect(ioutil.WriteFile(specPath, []byte("start:\n  file: https://example.com/cfdev-deps.tgz\n"), 0644)).To(Succeed())
+
+		spec, err := environment.Load(specPath)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(spec.Start.File).To(Equal("https://example.com/cfdev-deps.tgz"))
+	})
+
+	It("rejects unknown keys", func() {
+		Expect(ioutil.WriteFile(specPath, []byte("orgz: []\n"), 0644)).To(Succeed())
+		_, err := environment.Load(specPath)
+		Expect(err).To(MatchError(ContainSubstring("orgz")))
+	})
+
+	It("rejects references to undefined users", func() {
+		Expect(ioutil.WriteFile(specPath, []byte("orgs:\n- name: some-org\n  managers: [bob]\n"), 0644)).To(Succeed())
+		_, err := environment.Load(specPath)
+		Expect(err).To(MatchError("org 'some-org': user 'bob' is not defined"))
+	})
+
+	It("rejects references to undefined quotas", func() {
+		Expect(ioutil.WriteFile(specPath, []byte("orgs:\n- name: some-org\n  quota: huge\n"), 0644)).To(Succeed())
+		_, err := environment.Load(specPath)
+		Expect(err).To(MatchError("org 'some-org' refers to undefined quota 'huge'"))
+	})
+
+	It("rejects apps that target unknown spaces", func() {
+		Expect(ioutil.WriteFile(specPath, []byte("apps:\n- name: some-app\n  path: .\n  org: o\n  space: s\n"), 0644)).To(Succeed())
+		_, err := environment.Load(specPath)
+		Expect(err).To(MatchError("app 'some-app' must target a space defined in the file"))
+	})
+})
@@ -4,6 +4,10 @@ $pkg="code.cloudfoundry.org/cfdev/config"
 $cfdepsUrl="C:\Users\pivotal\.cfdev\cache\cfdev-deps.tgz"
 $cfAnalyticsdUrl="$PWD\analytix.exe"
 
+# The release of the deps above on the catalog index 'cf dev upgrade' checks.
+$cfdepsVersion="$env:CFDEPS_VERSION"
+$catalogIndexUrl="$env:CFDEV_CATALOG_INDEX"
+
 $date=(Get-Date -Format FileDate)
 
 go build -ldflags `
@@ -15,11 +19,15 @@ go build -ldflags `
 go build -ldflags `
    "-X $pkg.analyticsdUrl=$cfAnalyticsdUrl
     -X $pkg.analyticsdMd5=$((Get-FileHash $cfAnalyticsdUrl -Algorithm MD5).Hash.ToLower())
+    -X $pkg.analyticsdSha256=$((Get-FileHash $cfAnalyticsdUrl -Algorithm SHA256).Hash.ToLower())
     -X $pkg.analyticsdSize=$((Get-Item $cfAnalyticsdUrl).length)
 
     -X $pkg.cfdepsUrl=$cfdepsUrl
     -X $pkg.cfdepsMd5=$((Get-FileHash $cfdepsUrl -Algorithm MD5).Hash.ToLower())
+    -X $pkg.cfdepsSha256=$((Get-FileHash $cfdepsUrl -Algorithm SHA256).Hash.ToLower())
     -X $pkg.cfdepsSize=$((Get-Item $cfdepsUrl).length)
+    -X $pkg.cfdepsVersion=$cfdepsVersion
+    -X $pkg.catalogIndexUrl=$catalogIndexUrl
 
     -X $pkg.cliVersion=0.0.$date
     -X $pkg.testAnalyticsKey=WFz4dVFXZUxN2Y6MzfUHJNWtlgXuOYV2" `
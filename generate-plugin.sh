@@ -23,18 +23,27 @@ go build \
 cfdepsUrl="$cache_dir/cfdev-deps.tgz"
 pkg="code.cloudfoundry.org/cfdev/config"
 
+# The release of the deps above on the catalog index 'cf dev upgrade' checks.
+cfdepsVersion="${CFDEPS_VERSION:-}"
+catalogIndexUrl="${CFDEV_CATALOG_INDEX:-}"
+
 go build \
   -ldflags \
     "-X $pkg.cfdepsUrl=file://$cfdepsUrl
      -X $pkg.cfdepsMd5=$(md5 $cfdepsUrl | awk '{ print $4 }')
+     -X $pkg.cfdepsSha256=$(shasum -a 256 $cfdepsUrl | awk '{ print $1 }')
      -X $pkg.cfdepsSize=$(wc -c < $cfdepsUrl | tr -d '[:space:]')
+     -X $pkg.cfdepsVersion=$cfdepsVersion
+     -X $pkg.catalogIndexUrl=$catalogIndexUrl
 
      -X $pkg.cfdevdUrl=file://$cfdevd
      -X $pkg.cfdevdMd5=$(md5 "$cfdevd" | awk '{ print $4 }')
+     -X $pkg.cfdevdSha256=$(shasum -a 256 "$cfdevd" | awk '{ print $1 }')
      -X $pkg.cfdevdSize=$(wc -c < "$cfdevd" | tr -d '[:space:]')
 
      -X $pkg.analyticsdUrl=file://$analyticsd
      -X $pkg.analyticsdMd5=$(md5 "$analyticsd" | awk '{ print $4 }')
+     -X $pkg.analyticsdSha256=$(shasum -a 256 "$analyticsd" | awk '{ print $1 }')
      -X $pkg.analyticsdSize=$(wc -c < "$analyticsd" | tr -d '[:space:]')
 
      -X $pkg.cliVersion=0.0.$(date +%Y%m%d-%H%M%S)
@@ -1,7 +1,7 @@
 module code.cloudfoundry.org/cfdev
 
 require (
-	code.cloudfoundry.org/bytefmt v0.0.0-20180108190415-b31f603f5e1e // indirect
+	code.cloudfoundry.org/bytefmt v0.0.0-20180108190415-b31f603f5e1e
 	code.cloudfoundry.org/cli v6.38.0+incompatible
 	code.cloudfoundry.org/clock v0.0.0-20180518195852-02e53af36e6c // indirect
 	code.cloudfoundry.org/gofileutils v0.0.0-20170111115228-4d0c80011a0f // indirect
@@ -20,7 +20,7 @@ require (
 	github.com/cloudfoundry/bosh-cli v5.2.1+incompatible
 	github.com/cloudfoundry/bosh-utils v0.0.0-20180725223622-407dd7546455 // indirect
 	github.com/cloudfoundry/cli-plugin-repo v0.0.0-20181029233042-c6b431855994 // indirect
-	github.com/cloudfoundry/go-socks5 v0.0.0-20180221174514-54f73bdb8a8e // indirect
+	github.com/cloudfoundry/go-socks5 v0.0.0-20180221174514-54f73bdb8a8e
 	github.com/cloudfoundry/gosigar v1.1.0
 	github.com/cloudfoundry/noaa v2.1.0+incompatible // indirect
 	github.com/cloudfoundry/socks5-proxy v0.0.0-20180530211953-3659db090cb2 // indirect
@@ -42,6 +42,7 @@ require (
 	github.com/harlow/kinesis-consumer v0.2.0
 	github.com/inconshreveable/mousetrap v1.0.0 // indirect
 	github.com/jtolds/gls v4.2.1+incompatible // indirect
+	github.com/klauspost/compress v1.18.0
 	github.com/kr/pretty v0.1.0 // indirect
 	github.com/lunixbochs/vtclean v0.0.0-20180621232353-2d01aacdc34a // indirect
 	github.com/mailru/easyjson v0.0.0-20180823135443-60711f1a8329 // indirect
@@ -67,12 +68,13 @@ require (
 	github.com/stretchr/testify v1.2.2 // indirect
 	github.com/tedsuo/ifrit v0.0.0-20180802180643-bea94bb476cc // indirect
 	github.com/tedsuo/rata v1.0.0 // indirect
+	github.com/ulikunitz/xz v0.5.15
 	github.com/vito/go-interact v0.0.0-20171111012221-fa338ed9e9ec // indirect
 	github.com/xtgo/uuid v0.0.0-20140804021211-a0b114877d4c // indirect
 	golang.org/x/crypto v0.0.0-20180830192347-182538f80094
-	golang.org/x/net v0.0.0-20181017193950-04a2e542c03f // indirect
+	golang.org/x/net v0.0.0-20181017193950-04a2e542c03f
 	golang.org/x/oauth2 v0.0.0-20180821212333-d2e6202438be
-	golang.org/x/sys v0.0.0-20181011152604-fa43e7bc11ba // indirect
+	golang.org/x/sys v0.0.0-20181011152604-fa43e7bc11ba
 	google.golang.org/grpc v1.16.0 // indirect
 	gopkg.in/VividCortex/ewma.v1 v1.1.1 // indirect
 	gopkg.in/airbrake/gobrake.v2 v2.0.9 // indirect
@@ -0,0 +1,244 @@
+package health
+
+import (
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"code.cloudfoundry.org/cfdev/config"
+	"code.cloudfoundry.org/cfdev/resource/retry"
+)
+
+type Probe struct {
+	Name     string
+	Optional bool
+	Check    func() error
+}
+
+type Result struct {
+	Name     string
+	Optional bool
+	Err      error
+}
+
+// Verifier probes the endpoints of a deployed CF Dev. Every probe is
+// retried until it succeeds or runs out of attempts.
+type Verifier struct {
+	Config   config.Config
+	Attempts int
+	Interval time.Duration
+	Timeout  time.Duration
+	Client   *http.Client
+	Dial     func(network, address string, timeout time.Duration) (net.Conn, error)
+	// Context stops the retries once it is done.
+	Context context.Context
+}
+
+const TCPRouterPort = 1024
+
+func New(ctx context.Context, cfg config.Config) *Verifier {
+	return &Verifier{
+		Context:  ctx,
+		Config:   cfg,
+		Attempts: 10,
+		Interval: 3 * time.Second,
+		Timeout:  10 * time.Second,
+	}
+}
+
+func (v *Verifier) Verify() []Result {
+	var results []Result
+	for _, probe := range v.Probes() {
+		err := retry.Do(v.Context, retry.Policy{
+			Backoff: retry.Backoff{Initial: v.Interval, MaxAttempts: v.Attempts},
+		}, probe.Check)
+
+		results = append(results, Result{Name: probe.Name, Optional: probe.Optional, Err: err})
+	}
+	return results
+}
+
+func (v *Verifier) Probes() []Probe {
+	domain := v.Config.CFDomain
+	return []Probe{
+		{Name: "Cloud Controller API", Check: v.checkAPI("https://api." + domain + "/v2/info")},
+		{Name: "UAA token endpoint", Check: v.checkToken("https://uaa." + domain + "/oauth/token")},
+		{Name: "Login page", Check: v.checkStatus("https://login."+domain+"/login", http.StatusOK)},
+		{Name: "Gorouter", Check: v.checkRouter("https://login." + domain + "/healthz")},
+		{Name: "TCP router", Optional: true, Check: v.checkTCP(fmt.Sprintf("tcp.%s:%d", domain, TCPRouterPort))},
+	}
+}
+
+// Failed returns an error describing every required probe that failed.
+func Failed(results []Result) error {
+	var failures []string
+	for _, result := range results {
+		if result.Err != nil && !result.Optional {
+			failures = append(failures, fmt.Sprintf("%s: %s", result.Name, result.Err))
+		}
+	}
+
+	if len(failures) == 0 {
+		return nil
+	}
+	return fmt.Errorf("%s", strings.Join(failures, "; "))
+}
+
+// Checklist renders the results one line per probe.
+func Checklist(results []Result) []string {
+	var lines []string
+	for _, result := range results {
+		switch {
+		case result.Err == nil:
+			lines = append(lines, fmt.Sprintf("  [OK]   %s", result.Name))
+		case result.Optional:
+			lines = append(lines, fmt.Sprintf("  [WARN] %s: %s", result.Name, result.Err))
+		default:
+			lines = append(lines, fmt.Sprintf("  [FAIL] %s: %s", result.Name, result.Err))
+		}
+	}
+	return lines
+}
+
+func (v *Verifier) client() *http.Client {
+	if v.Client != nil {
+		return v.Client
+	}
+	return &http.Client{
+		Timeout: v.Timeout,
+		Transport: &http.Transport{
+			Proxy: http.ProxyFromEnvironment,
+			TLSClientConfig: &tls.Config{
+				InsecureSkipVerify: true,
+			},
+		},
+	}
+}
+
+func (v *Verifier) do(req *http.Request, expectedStatus int) ([]byte, *http.Response, error) {
+	resp, err := v.client().Do(req)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer resp.Body.Close()
+
+	contents, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if expectedStatus != 0 && resp.StatusCode != expectedStatus {
+		return nil, nil, fmt.Errorf("%s %s returned %s", req.Method, req.URL, resp.Status)
+	}
+	return contents, resp, nil
+}
+
+func (v *Verifier) checkStatus(url string, status int) func() error {
+	return func() error {
+		req, err := http.NewRequest(http.MethodGet, url, nil)
+		if err != nil {
+			return err
+		}
+		_, _, err = v.do(req, status)
+		return err
+	}
+}
+
+func (v *Verifier) checkAPI(url string) func() error {
+	return func() error {
+		req, err := http.NewRequest(http.MethodGet, url, nil)
+		if err != nil {
+			return err
+		}
+
+		contents, _, err := v.do(req, http.StatusOK)
+		if err != nil {
+			return err
+		}
+
+		var info struct {
+			APIVersion string `json:"api_version"`
+		}
+		if err := json.Unmarshal(contents, &info); err != nil || info.APIVersion == "" {
+			return fmt.Errorf("GET %s did not return cloud controller info", url)
+		}
+		return nil
+	}
+}
+
+func (v *Verifier) checkToken(tokenURL string) func() error {
+	return func() error {
+		form := url.Values{
+			"grant_type": {"password"},
+			"username":   {"admin"},
+			"password":   {"admin"},
+		}
+		req, err := http.NewRequest(http.MethodPost, tokenURL, strings.NewReader(form.Encode()))
+		if err != nil {
+			return err
+		}
+		req.SetBasicAuth("cf", "")
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		req.Header.Set("Accept", "application/json")
+
+		contents, _, err := v.do(req, http.StatusOK)
+		if err != nil {
+			return err
+		}
+
+		var token struct {
+			AccessToken string `json:"access_token"`
+		}
+		if err := json.Unmarshal(contents, &token); err != nil || token.AccessToken == "" {
+			return fmt.Errorf("POST %s did not return an access token", tokenURL)
+		}
+		return nil
+	}
+}
+
+// checkRouter requests a route that the UAA registers. The gorouter
+// tags every response it proxies to a backend with X-Vcap-Request-Id,
+// and its own errors with X-Cf-Routererror.
+func (v *Verifier) checkRouter(url string) func() error {
+	return func() error {
+		req, err := http.NewRequest(http.MethodGet, url, nil)
+		if err != nil {
+			return err
+		}
+
+		_, resp, err := v.do(req, http.StatusOK)
+		if err != nil {
+			return err
+		}
+
+		if routerErr := resp.Header.Get("X-Cf-Routererror"); routerErr != "" {
+			return fmt.Errorf("GET %s failed in the gorouter: %s", url, routerErr)
+		}
+		if resp.Header.Get("X-Vcap-Request-Id") == "" {
+			return fmt.Errorf("GET %s was not routed through the gorouter", url)
+		}
+		return nil
+	}
+}
+
+func (v *Verifier) checkTCP(address string) func() error {
+	return func() error {
+		dial := v.Dial
+		if dial == nil {
+			dial = net.DialTimeout
+		}
+
+		conn, err := dial("tcp", address, v.Timeout)
+		if err != nil {
+			return err
+		}
+		return conn.Close()
+	}
+}
@@ -0,0 +1,135 @@
+package health_test
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"time"
+
+	"code.cloudfoundry.org/cfdev/config"
+	"code.cloudfoundry.org/cfdev/health"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Verifier", func() {
+	var (
+		server     *httptest.Server
+		handlers   map[string]http.HandlerFunc
+		dialed     []string
+		dialErr    error
+		verifier   *health.Verifier
+		apiAttempt int
+	)
+
+	BeforeEach(func() {
+		apiAttempt = 0
+		dialed = nil
+		dialErr = nil
+		handlers = map[string]http.HandlerFunc{
+			"api.dev.cfdev.sh": func(w http.ResponseWriter, r *http.Request) {
+				Expect(r.URL.Path).To(Equal("/v2/info"))
+				w.Write([]byte(`{"api_version": "2.120.0"}`))
+			},
+			"uaa.dev.cfdev.sh": func(w http.ResponseWriter, r *http.Request) {
+				Expect(r.Method).To(Equal(http.MethodPost))
+				Expect(r.URL.Path).To(Equal("/oauth/token"))
+				Expect(r.FormValue("username")).To(Equal("admin"))
+				w.Write([]byte(`{"access_token": "some-token"}`))
+			},
+			"login.dev.cfdev.sh": func(w http.ResponseWriter, r *http.Request) {
+				Expect(r.URL.Path).To(Or(Equal("/login"), Equal("/healthz")))
+				w.Header().Set("X-Vcap-Request-Id", "some-request-id")
+			},
+		}
+
+		server = httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			handlers[strings.Split(r.Host, ":")[0]](w, r)
+		}))
+
+		verifier = &health.Verifier{
+			Config:   config.Config{CFDomain: "dev.cfdev.sh"},
+			Attempts: 3,
+			Timeout:  time.Second,
+			Client: &http.Client{
+				Transport: &http.Transport{
+					TLSClientConfig: &tls.Config{InsecureSkipVerify: true},
+					DialContext: func(ctx context.Context, network, _ string) (net.Conn, error) {
+						return net.Dial(network, server.Listener.Addr().String())
+					},
+				},
+			},
+			Dial: func(network, address string, timeout time.Duration) (net.Conn, error) {
+				dialed = append(dialed, address)
+				if dialErr != nil {
+					return nil, dialErr
+				}
+				return net.Dial(network, server.Listener.Addr().String())
+			},
+		}
+	})
+
+	AfterEach(func() {
+		server.Close()
+	})
+
+	It("reports every endpoint as healthy", func() {
+		results := verifier.Verify()
+		Expect(health.Failed(results)).To(Succeed())
+		Expect(health.Checklist(results)).To(Equal([]string{
+			"  [OK]   Cloud Controller API",
+			"  [OK]   UAA token endpoint",
+			"  [OK]   Login page",
+			"  [OK]   Gorouter",
+			"  [OK]   TCP router",
+		}))
+		Expect(dialed).To(Equal([]string{"tcp.dev.cfdev.sh:1024"}))
+	})
+
+	It("retries probes that are not ready yet", func() {
+		handlers["api.dev.cfdev.sh"] = func(w http.ResponseWriter, r *http.Request) {
+			apiAttempt++
+			if apiAttempt < 3 {
+				w.WriteHeader(http.StatusBadGateway)
+				return
+			}
+			w.Write([]byte(`{"api_version": "2.120.0"}`))
+		}
+
+		Expect(health.Failed(verifier.Verify())).To(Succeed())
+		Expect(apiAttempt).To(Equal(3))
+	})
+
+	It("fails with a precise message when a required endpoint is broken", func() {
+		handlers["login.dev.cfdev.sh"] = func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.Path == "/healthz" {
+				w.Header().Set("X-Cf-Routererror", "unknown_route")
+				w.WriteHeader(http.StatusNotFound)
+			}
+		}
+
+		results := verifier.Verify()
+		Expect(health.Failed(results)).To(MatchError("Gorouter: GET https://login.dev.cfdev.sh/healthz returned 404 Not Found"))
+		Expect(health.Checklist(results)).To(ContainElement(HavePrefix("  [FAIL] Gorouter: ")))
+	})
+
+	It("fails the router check when the route bypasses the gorouter", func() {
+		handlers["login.dev.cfdev.sh"] = func(w http.ResponseWriter, r *http.Request) {}
+
+		results := verifier.Verify()
+		Expect(health.Failed(results)).To(MatchError("Gorouter: GET https://login.dev.cfdev.sh/healthz was not routed through the gorouter"))
+	})
+
+	It("only warns when the tcp router is unreachable", func() {
+		dialErr = errors.New("connection refused")
+
+		results := verifier.Verify()
+		Expect(health.Failed(results)).To(Succeed())
+		Expect(health.Checklist(results)).To(ContainElement("  [WARN] TCP router: connection refused"))
+		Expect(dialed).To(HaveLen(3))
+	})
+})
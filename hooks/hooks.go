@@ -0,0 +1,173 @@
+package hooks
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+
+	"code.cloudfoundry.org/cfdev/bosh"
+	"code.cloudfoundry.org/cfdev/config"
+)
+
+type Event string
+
+const (
+	PreStart     Event = "pre-start"
+	PostBosh     Event = "post-bosh"
+	PostCF       Event = "post-cf"
+	PostServices Event = "post-services"
+	PreStop      Event = "pre-stop"
+)
+
+// Handler lets code embedding cfdev react to lifecycle events in-process.
+// It receives the same environment that hook scripts are run with.
+type Handler interface {
+	Handle(event Event, env []string) error
+}
+
+type HandlerFunc func(event Event, env []string) error
+
+func (f HandlerFunc) Handle(event Event, env []string) error {
+	return f(event, env)
+}
+
+type UI interface {
+	Say(message string, args ...interface{})
+}
+
+type Hooks struct {
+	Config   config.Config
+	UI       UI
+	Handlers []Handler
+	Fatal    bool
+}
+
+func (h *Hooks) Dir() string {
+	return filepath.Join(h.Config.CFDevHome, "hooks")
+}
+
+// Run invokes the registered handlers followed by the executables found at
+// CFDEV_HOME/hooks/<event> (a single file, or a directory whose entries are
+// run in lexical order). Failures are reported and only returned when Fatal.
+func (h *Hooks) Run(event Event) error {
+	env := h.Env(event)
+
+	for _, handler := range h.Handlers {
+		if err := handler.Handle(event, env); err != nil {
+			if err := h.fail(event, "handler", err); err != nil {
+				return err
+			}
+		}
+	}
+
+	scripts, err := h.scripts(event)
+	if err != nil {
+		return h.fail(event, string(event), err)
+	}
+	if len(scripts) == 0 {
+		return nil
+	}
+
+	if err := os.MkdirAll(h.Config.LogDir, 0755); err != nil {
+		return h.fail(event, string(event), err)
+	}
+
+	logFile, err := os.OpenFile(filepath.Join(h.Config.LogDir, "hook-"+string(event)+".log"), os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0600)
+	if err != nil {
+		return h.fail(event, string(event), err)
+	}
+	defer logFile.Close()
+
+	h.UI.Say("Running %s hooks...", event)
+	for _, script := range scripts {
+		fmt.Fprintf(logFile, "==> %s\n", script)
+		if err := h.exec(script, env, logFile); err != nil {
+			if err := h.fail(event, filepath.Base(script), fmt.Errorf("%s (see %s)", err, logFile.Name())); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+func (h *Hooks) Env(event Event) []string {
+	env := os.Environ()
+	env = append(env, bosh.Envs(h.Config)...)
+	return append(env,
+		"CF_API=https://api."+h.Config.CFDomain,
+		"CF_USERNAME=admin",
+		"CF_PASSWORD=admin",
+		"CF_SKIP_SSL_VALIDATION=true",
+		"CFDEV_HOME="+h.Config.CFDevHome,
+		"CFDEV_HOOK="+string(event),
+	)
+}
+
+func (h *Hooks) fail(event Event, name string, err error) error {
+	if h.Fatal {
+		return fmt.Errorf("%s hook '%s' failed: %s", event, name, err)
+	}
+
+	h.UI.Say("WARNING: %s hook '%s' failed: %s", event, name, err)
+	return nil
+}
+
+func (h *Hooks) scripts(event Event) ([]string, error) {
+	path := filepath.Join(h.Dir(), string(event))
+
+	info, err := os.Stat(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	if !info.IsDir() {
+		return []string{path}, nil
+	}
+
+	entries, err := ioutil.ReadDir(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var scripts []string
+	for _, entry := range entries {
+		if entry.Mode().IsRegular() && isExecutable(entry) {
+			scripts = append(scripts, filepath.Join(path, entry.Name()))
+		}
+	}
+	return scripts, nil
+}
+
+func (h *Hooks) exec(script string, env []string, output io.Writer) error {
+	var cmd *exec.Cmd
+	if strings.EqualFold(filepath.Ext(script), ".ps1") {
+		cmd = exec.Command("powershell.exe", "-ExecutionPolicy", "Bypass", "-File", script)
+	} else {
+		cmd = exec.Command(script)
+	}
+
+	cmd.Env = env
+	cmd.Dir = h.Config.CFDevHome
+	cmd.Stdout = output
+	cmd.Stderr = output
+	return cmd.Run()
+}
+
+func isExecutable(info os.FileInfo) bool {
+	if runtime.GOOS == "windows" {
+		switch strings.ToLower(filepath.Ext(info.Name())) {
+		case ".exe", ".bat", ".cmd", ".ps1":
+			return true
+		}
+		return false
+	}
+	return info.Mode().Perm()&0111 != 0
+}
@@ -0,0 +1,149 @@
+package hooks_test
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"runtime"
+
+	"code.cloudfoundry.org/cfdev/config"
+	"code.cloudfoundry.org/cfdev/hooks"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+type fakeUI struct {
+	messages []string
+}
+
+func (f *fakeUI) Say(message string, args ...interface{}) {
+	f.messages = append(f.messages, fmt.Sprintf(message, args...))
+}
+
+var _ = Describe("Hooks", func() {
+	var (
+		tmpDir string
+		ui     *fakeUI
+		h      *hooks.Hooks
+	)
+
+	writeScript := func(path, contents string) {
+		Expect(os.MkdirAll(filepath.Dir(path), 0755)).To(Succeed())
+		Expect(ioutil.WriteFile(path, []byte("#!/bin/sh\n"+contents+"\n"), 0755)).To(Succeed())
+	}
+
+	BeforeEach(func() {
+		var err error
+		tmpDir, err = ioutil.TempDir("", "cfdev-hooks-")
+		Expect(err).NotTo(HaveOccurred())
+
+		ui = &fakeUI{}
+		h = &hooks.Hooks{
+			Config: config.Config{
+				CFDevHome: tmpDir,
+				LogDir:    filepath.Join(tmpDir, "log"),
+				StateBosh: filepath.Join(tmpDir, "state", "bosh"),
+				CFDomain:  "dev.cfdev.sh",
+			},
+			UI: ui,
+		}
+	})
+
+	AfterEach(func() {
+		os.RemoveAll(tmpDir)
+	})
+
+	Context("when there are no hooks", func() {
+		It("does nothing", func() {
+			Expect(h.Run(hooks.PreStart)).To(Succeed())
+			Expect(ui.messages).To(BeEmpty())
+		})
+	})
+
+	Context("when handlers are registered", func() {
+		It("calls them with the event and the hook environment", func() {
+			var (
+				events []hooks.Event
+				env    []string
+			)
+			h.Handlers = []hooks.Handler{
+				hooks.HandlerFunc(func(event hooks.Event, e []string) error {
+					events = append(events, event)
+					env = e
+					return nil
+				}),
+			}
+
+			Expect(h.Run(hooks.PostCF)).To(Succeed())
+			Expect(events).To(Equal([]hooks.Event{hooks.PostCF}))
+			Expect(env).To(ContainElement("CF_API=https://api.dev.cfdev.sh"))
+			Expect(env).To(ContainElement("CFDEV_HOOK=post-cf"))
+		})
+
+		It("reports a failing handler", func() {
+			h.Handlers = []hooks.Handler{
+				hooks.HandlerFunc(func(hooks.Event, []string) error {
+					return errors.New("some-error")
+				}),
+			}
+
+			Expect(h.Run(hooks.PreStop)).To(Succeed())
+			Expect(ui.messages).To(ConsistOf("WARNING: pre-stop hook 'handler' failed: some-error"))
+		})
+	})
+
+	Context("when hook scripts exist", func() {
+		BeforeEach(func() {
+			if runtime.GOOS == "windows" {
+				Skip("shell scripts are not supported on windows")
+			}
+		})
+
+		It("runs every executable in the event directory in order", func() {
+			out := filepath.Join(tmpDir, "out")
+			writeScript(filepath.Join(tmpDir, "hooks", "post-cf", "02-second"), `echo "second $CFDEV_HOOK" >> `+out)
+			writeScript(filepath.Join(tmpDir, "hooks", "post-cf", "01-first"), `echo "first $CF_DOMAIN" >> `+out)
+			Expect(ioutil.WriteFile(filepath.Join(tmpDir, "hooks", "post-cf", "README"), []byte("not a hook"), 0644)).To(Succeed())
+
+			Expect(h.Run(hooks.PostCF)).To(Succeed())
+
+			contents, err := ioutil.ReadFile(out)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(string(contents)).To(Equal("first dev.cfdev.sh\nsecond post-cf\n"))
+			Expect(ui.messages).To(ConsistOf("Running post-cf hooks..."))
+		})
+
+		It("runs a single executable named after the event", func() {
+			out := filepath.Join(tmpDir, "out")
+			writeScript(filepath.Join(tmpDir, "hooks", "pre-start"), `echo "ran" > `+out)
+
+			Expect(h.Run(hooks.PreStart)).To(Succeed())
+
+			contents, err := ioutil.ReadFile(out)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(string(contents)).To(Equal("ran\n"))
+		})
+
+		Context("when a script fails", func() {
+			BeforeEach(func() {
+				writeScript(filepath.Join(tmpDir, "hooks", "post-bosh", "fail"), "echo some-output; exit 3")
+			})
+
+			It("reports the failure and continues", func() {
+				Expect(h.Run(hooks.PostBosh)).To(Succeed())
+				Expect(ui.messages).To(ContainElement(ContainSubstring("WARNING: post-bosh hook 'fail' failed: exit status 3")))
+
+				contents, err := ioutil.ReadFile(filepath.Join(tmpDir, "log", "hook-post-bosh.log"))
+				Expect(err).NotTo(HaveOccurred())
+				Expect(string(contents)).To(ContainSubstring("some-output"))
+			})
+
+			It("returns the error when hooks are fatal", func() {
+				h.Fatal = true
+				Expect(h.Run(hooks.PostBosh)).To(MatchError(ContainSubstring("post-bosh hook 'fail' failed: exit status 3")))
+			})
+		})
+	})
+})
@@ -18,6 +18,7 @@ import (
 	"code.cloudfoundry.org/cfdev/cmd"
 	"code.cloudfoundry.org/cfdev/config"
 	"code.cloudfoundry.org/cfdev/errors"
+	"code.cloudfoundry.org/cfdev/target"
 	"code.cloudfoundry.org/cli/cf/terminal"
 	"code.cloudfoundry.org/cli/cf/trace"
 	"code.cloudfoundry.org/cli/plugin"
@@ -30,12 +31,13 @@ type Command interface {
 }
 
 type Plugin struct {
-	Exit      chan struct{}
-	UI        terminal.UI
-	Config    config.Config
-	Analytics *cfanalytics.Analytics
-	Root      *cobra.Command
-	Version   plugin.VersionType
+	Exit       chan struct{}
+	UI         terminal.UI
+	Config     config.Config
+	Analytics  *cfanalytics.Analytics
+	Root       *cobra.Command
+	Version    plugin.VersionType
+	Connection *target.Connection
 }
 
 const (
@@ -85,12 +87,14 @@ func main() {
 	setWhiteListedProxyVariables()
 
 	v := conf.CliVersion
+	connection := &target.Connection{}
 	cfdev := &Plugin{
-		UI:        ui,
-		Config:    conf,
-		Analytics: analyticsClient,
-		Root:      cmd.NewRoot(exitChan, ui, conf, analyticsClient, analyticsToggle),
-		Version:   plugin.VersionType{Major: v.Major, Minor: v.Minor, Build: v.Build},
+		UI:         ui,
+		Config:     conf,
+		Analytics:  analyticsClient,
+		Root:       cmd.NewRoot(exitChan, ui, conf, analyticsClient, analyticsToggle, connection),
+		Version:    plugin.VersionType{Major: v.Major, Minor: v.Minor, Build: v.Build},
+		Connection: connection,
 	}
 
 	plugin.Start(cfdev)
@@ -138,6 +142,7 @@ func (p *Plugin) Run(connection plugin.CliConnection, args []string) {
 		}
 	}
 
+	p.Connection.CLI = connection
 	p.Root.SetArgs(args)
 	if err := p.Root.Execute(); err != nil {
 		p.UI.Failed(err.Error())
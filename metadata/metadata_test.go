@@ -2,6 +2,11 @@ package metadata_test
 
 import (
 	"code.cloudfoundry.org/cfdev/metadata"
+	"code.cloudfoundry.org/cfdev/semver"
+	"code.cloudfoundry.org/cfdev/signature"
+	"crypto/rand"
+	"encoding/base64"
+	"golang.org/x/crypto/ed25519"
 	"io/ioutil"
 	"path/filepath"
 
@@ -51,5 +56,89 @@ versions:
 			Expect(metadata.Versions[0].Name).To(Equal("some-release"))
 			Expect(metadata.Versions[0].Value).To(Equal("v123-some-version"))
 		})
+
+		Context("when a signing key is embedded", func() {
+			var reader *metadata.Reader
+
+			BeforeEach(func() {
+				publicKey, privateKey, err := ed25519.GenerateKey(rand.Reader)
+				Expect(err).NotTo(HaveOccurred())
+				verifier, err := signature.New(base64.StdEncoding.EncodeToString(publicKey))
+				Expect(err).NotTo(HaveOccurred())
+				reader = &metadata.Reader{Verifier: verifier}
+
+				contents, err := ioutil.ReadFile(metaDataPath)
+				Expect(err).NotTo(HaveOccurred())
+				sig := base64.StdEncoding.EncodeToString(ed25519.Sign(privateKey, contents))
+				Expect(ioutil.WriteFile(metaDataPath+".sig", []byte(sig), 0644)).To(Succeed())
+			})
+
+			It("reads signed metadata", func() {
+				metadata, err := reader.Read(metaDataPath)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(metadata.Version).To(Equal("v29"))
+			})
+
+			It("rejects tampered metadata", func() {
+				Expect(ioutil.WriteFile(metaDataPath, []byte(`compatibility_version: "v1"`), 0644)).To(Succeed())
+				_, err := reader.Read(metaDataPath)
+				Expect(err).To(MatchError(ContainSubstring("signature does not match")))
+			})
+		})
+	})
+
+	Describe("schemas", func() {
+		It("migrates schema 1 metadata", func() {
+			m, err := metadata.Parse([]byte("compatibility_version: v3\ndeployment_name: cf\n"))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(m.SchemaVersion).To(Equal(metadata.SchemaVersion))
+			Expect(m.Version).To(Equal("v3"))
+			Expect(m.DeploymentName).To(Equal("cf"))
+			Expect(m.Check(nil)).To(Succeed())
+		})
+
+		It("reads the current schema", func() {
+			m, err := metadata.Parse([]byte("schema_version: 2\nrequired_plugin: '>=0.0.18 <1'\ncompatibility_version: v3\n"))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(m.SchemaVersion).To(Equal(2))
+			Expect(m.RequiredPlugin).To(Equal(">=0.0.18 <1"))
+			Expect(m.DockerRegistryVars).To(BeFalse())
+		})
+
+		It("reads whether the deps apply the docker registry vars", func() {
+			m, err := metadata.Parse([]byte("schema_version: 2\ncompatibility_version: v3\ndocker_registry_vars: true\n"))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(m.DockerRegistryVars).To(BeTrue())
+		})
+
+		It("reports deps that are too old", func() {
+			m, err := metadata.Parse([]byte("compatibility_version: v2\n"))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(m.Check(nil)).To(MatchError("the deps are too old for this version of the cf dev plugin (compatibility version v2, but at least v3 is needed). Please use newer deps"))
+
+			m, err = metadata.Parse([]byte("deployment_name: cf\n"))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(m.Check(nil)).To(MatchError(ContainSubstring("compatibility version none")))
+		})
+
+		It("reports deps that are too new", func() {
+			m, err := metadata.Parse([]byte("schema_version: 3\ncompatibility_version: v3\n"))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(m.Check(nil)).To(MatchError("the deps are too new for this version of the cf dev plugin (metadata schema 3, but this plugin reads up to 2). Please upgrade the plugin"))
+		})
+
+		It("checks the plugin against the required range", func() {
+			m := metadata.Metadata{SchemaVersion: 2, RequiredPlugin: ">=0.0.18 <1"}
+
+			Expect(m.Check(semver.Must(semver.New("0.0.18")))).To(Succeed())
+			Expect(m.Check(semver.Must(semver.New("1.0.0")))).To(MatchError("the deps need a cf dev plugin version >=0.0.18 <1, but this is 1.0.0"))
+			Expect(m.Check(semver.Must(semver.New("")))).To(Succeed())
+		})
+
+		It("rejects an invalid required range", func() {
+			m := metadata.Metadata{SchemaVersion: 2, RequiredPlugin: ">=a"}
+
+			Expect(m.Check(nil)).To(MatchError(ContainSubstring("invalid required_plugin")))
+		})
 	})
 })
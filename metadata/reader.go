@@ -1,12 +1,38 @@
 package metadata
 
 import (
+	"fmt"
+	"io/ioutil"
+	"strconv"
+	"strings"
+
 	"code.cloudfoundry.org/cfdev/provision"
+	"code.cloudfoundry.org/cfdev/semver"
+	"code.cloudfoundry.org/cfdev/signature"
 	"gopkg.in/yaml.v2"
-	"io/ioutil"
 )
 
-type Reader struct{}
+// Reader checks metadata.yml against metadata.yml.sig when the Verifier
+// has a signing key.
+type Reader struct {
+	Verifier *signature.Verifier
+}
+
+const (
+	// SchemaVersion is the metadata schema that this plugin reads.
+	// Metadata down to MinSchemaVersion is migrated to it.
+	SchemaVersion    = 2
+	MinSchemaVersion = 1
+
+	// CompatibilityVersion is what schema 1 metadata declares. Later
+	// schemas keep it for plugins that predate schema_version.
+	CompatibilityVersion = "v3"
+)
+
+// migrations bring metadata of a schema to the next one.
+var migrations = map[int]func(doc yaml.MapSlice) (yaml.MapSlice, error){
+	1: migrateFrom1,
+}
 
 func New() *Reader {
 	return &Reader{}
@@ -18,6 +44,10 @@ type Version struct {
 }
 
 type Metadata struct {
+	SchemaVersion int `yaml:"schema_version"`
+	// RequiredPlugin is the range of plugin versions that can deploy
+	// the deps, such as ">=0.0.18 <1". Any plugin can when it is empty.
+	RequiredPlugin   string              `yaml:"required_plugin"`
 	Version          string              `yaml:"compatibility_version"`
 	Message          string              `yaml:"splash_message"`
 	DeploymentName   string              `yaml:"deployment_name"`
@@ -25,20 +55,125 @@ type Metadata struct {
 	DefaultMemory    int                 `yaml:"default_memory"`
 	Services         []provision.Service `yaml:"services"`
 	Versions         []Version           `yaml:"versions"`
+	// DockerRegistryVars is set by deps whose deploy-cf applies the vars
+	// file in DOCKER_REGISTRIES_VARS_FILE, which carries the ca certs,
+	// credentials and mirrors of the docker registries.
+	DockerRegistryVars bool `yaml:"docker_registry_vars"`
 }
 
-func (Reader) Read(metaDataPath string) (Metadata, error) {
+func (r Reader) Read(metaDataPath string) (Metadata, error) {
+	if err := r.Verifier.VerifyFile(metaDataPath); err != nil {
+		return Metadata{}, err
+	}
+
 	buf, err := ioutil.ReadFile(metaDataPath)
 	if err != nil {
 		return Metadata{}, err
 	}
+	return Parse(buf)
+}
 
-	var metadata Metadata
+// Parse reads metadata, migrating older schemas to SchemaVersion. Metadata
+// of a schema that cannot be migrated is returned as it is, for Check to
+// report.
+func Parse(contents []byte) (Metadata, error) {
+	var doc yaml.MapSlice
+	if err := yaml.Unmarshal(contents, &doc); err != nil {
+		return Metadata{}, err
+	}
+
+	for schema := schemaOf(doc); schema >= MinSchemaVersion && schema < SchemaVersion; schema = schemaOf(doc) {
+		var err error
+		if doc, err = migrations[schema](doc); err != nil {
+			return Metadata{}, err
+		}
+	}
 
-	err = yaml.Unmarshal(buf, &metadata)
+	var metadata Metadata
+	contents, err := yaml.Marshal(doc)
 	if err != nil {
 		return Metadata{}, err
 	}
-
+	if err := yaml.Unmarshal(contents, &metadata); err != nil {
+		return Metadata{}, err
+	}
 	return metadata, nil
 }
+
+// Check returns why the deps described by m cannot be used with a plugin
+// at version plugin. Development builds, which have no version, are not
+// checked against required_plugin.
+func (m Metadata) Check(plugin *semver.Version) error {
+	switch schema := m.schema(); {
+	case schema == 0 && legacyVersion(m.Version) > legacyVersion(CompatibilityVersion):
+		return fmt.Errorf("the deps are too new for this version of the cf dev plugin (compatibility version %s). Please upgrade the plugin", m.Version)
+	case schema < MinSchemaVersion:
+		return fmt.Errorf("the deps are too old for this version of the cf dev plugin (compatibility version %s, but at least %s is needed). Please use newer deps", describe(m.Version), CompatibilityVersion)
+	case schema > SchemaVersion:
+		return fmt.Errorf("the deps are too new for this version of the cf dev plugin (metadata schema %d, but this plugin reads up to %d). Please upgrade the plugin", schema, SchemaVersion)
+	}
+
+	if m.RequiredPlugin == "" {
+		return nil
+	}
+	required, err := semver.NewConstraint(m.RequiredPlugin)
+	if err != nil {
+		return fmt.Errorf("the deps have an invalid required_plugin: %s", err)
+	}
+	if plugin != nil && plugin.Original != "" && !required.Check(plugin) {
+		return fmt.Errorf("the deps need a cf dev plugin version %s, but this is %s", m.RequiredPlugin, plugin.Original)
+	}
+	return nil
+}
+
+// schema is the schema of metadata that did not go through Parse, such as
+// the metadata of schema 1 that declared only compatibility_version.
+func (m Metadata) schema() int {
+	if m.SchemaVersion != 0 {
+		return m.SchemaVersion
+	} else if m.Version == CompatibilityVersion {
+		return 1
+	}
+	return 0
+}
+
+func schemaOf(doc yaml.MapSlice) int {
+	var m Metadata
+	for _, item := range doc {
+		switch item.Key {
+		case "schema_version":
+			m.SchemaVersion, _ = item.Value.(int)
+		case "compatibility_version":
+			m.Version = fmt.Sprint(item.Value)
+		}
+	}
+	return m.schema()
+}
+
+// migrateFrom1 only adds schema_version, as schema 2 adds fields to
+// schema 1 without changing any.
+func migrateFrom1(doc yaml.MapSlice) (yaml.MapSlice, error) {
+	migrated := yaml.MapSlice{{Key: "schema_version", Value: 2}}
+	for _, item := range doc {
+		if item.Key != "schema_version" {
+			migrated = append(migrated, item)
+		}
+	}
+	return migrated, nil
+}
+
+// legacyVersion is the number of a compatibility_version such as v3.
+func legacyVersion(version string) int {
+	n, err := strconv.Atoi(strings.TrimPrefix(version, "v"))
+	if err != nil {
+		return 0
+	}
+	return n
+}
+
+func describe(version string) string {
+	if version == "" {
+		return "none"
+	}
+	return version
+}
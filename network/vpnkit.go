@@ -6,6 +6,7 @@ import (
 	"code.cloudfoundry.org/cfdev/env"
 	"code.cloudfoundry.org/cfdev/errors"
 	"code.cloudfoundry.org/cfdev/runner"
+	"context"
 	"encoding/json"
 	"io/ioutil"
 	"os"
@@ -23,6 +24,8 @@ type VpnKit struct {
 	EthernetGUID  string
 	PortGUID      string
 	ForwarderGUID string
+	// Context stops the wait for vpnkit to come up once it is done.
+	Context context.Context
 }
 
 type DaemonRunner interface {
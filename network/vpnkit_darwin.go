@@ -6,13 +6,20 @@ import (
 	"time"
 
 	"code.cloudfoundry.org/cfdev/errors"
+	"code.cloudfoundry.org/cfdev/resource/retry"
 
 	"path"
 
 	"code.cloudfoundry.org/cfdev/daemon"
 )
 
-const retries = 5
+// socketBackoff bounds the wait for vpnkit to create its socket.
+var socketBackoff = retry.Backoff{
+	Initial:    100 * time.Millisecond,
+	Max:        time.Second,
+	Multiplier: 2,
+	MaxElapsed: 5 * time.Second,
+}
 
 func (v *VpnKit) Start() error {
 	if err := v.Setup(); err != nil {
@@ -24,19 +31,17 @@ func (v *VpnKit) Start() error {
 	if err := v.DaemonRunner.Start(v.Label); err != nil {
 		return errors.SafeWrap(err, "start vpnkit")
 	}
-	attempt := 0
-	for {
+	err := retry.Do(v.Context, retry.Policy{Backoff: socketBackoff}, func() error {
 		conn, err := net.Dial("unix", filepath.Join(v.Config.VpnKitStateDir, "vpnkit_eth.sock"))
-		if err == nil {
-			conn.Close()
-			return nil
-		} else if attempt >= retries {
-			return errors.SafeWrap(err, "connect to vpnkit")
-		} else {
-			time.Sleep(time.Second)
-			attempt++
+		if err != nil {
+			return err
 		}
+		return conn.Close()
+	})
+	if err != nil {
+		return errors.SafeWrap(err, "connect to vpnkit")
 	}
+	return nil
 }
 
 func (v *VpnKit) Destroy() error {
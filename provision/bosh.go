@@ -20,7 +20,7 @@ func (c *Controller) DeployBosh() error {
 	if err != nil {
 		return err
 	}
-	s := ssh.SSH{}
+	s := ssh.SSH{Context: c.Context, Writer: logFile}
 
 	srcDst := []string{
 		filepath.Join(c.Config.StateBosh, "state.json"),
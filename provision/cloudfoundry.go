@@ -2,7 +2,9 @@ package provision
 
 import (
 	"code.cloudfoundry.org/cfdev/bosh"
+	"code.cloudfoundry.org/cfdev/docker"
 	"fmt"
+	"io/ioutil"
 	"os"
 	"os/exec"
 	"path/filepath"
@@ -11,7 +13,7 @@ import (
 	"time"
 )
 
-func (c *Controller) DeployCloudFoundry(ui UI, dockerRegistries []string) error {
+func (c *Controller) DeployCloudFoundry(ui UI, dockerRegistries docker.Config) error {
 	var cmd *exec.Cmd
 
 	if runtime.GOOS == "windows" {
@@ -24,12 +26,24 @@ func (c *Controller) DeployCloudFoundry(ui UI, dockerRegistries []string) error
 	cmd.Env = append(cmd.Env, bosh.Envs(c.Config)...)
 
 	var arr []string
-	for _, registry := range dockerRegistries {
+	for _, registry := range dockerRegistries.InsecureHosts() {
 		arr = append(arr, fmt.Sprintf(`%q`, registry))
 	}
 
 	cmd.Env = append(cmd.Env, `DOCKER_REGISTRIES=[`+strings.Join(arr, ",")+"]")
 
+	registryVars, err := dockerRegistries.Render()
+	if err != nil {
+		return err
+	}
+
+	registryVarsPath := filepath.Join(c.Config.StateDir, "docker-registries.yml")
+	if err := ioutil.WriteFile(registryVarsPath, registryVars, 0600); err != nil {
+		return err
+	}
+
+	cmd.Env = append(cmd.Env, "DOCKER_REGISTRIES_VARS_FILE="+registryVarsPath)
+
 	logFile, err := os.Create(filepath.Join(c.Config.LogDir, "deploy-cf.log"))
 	if err != nil {
 		return err
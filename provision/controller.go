@@ -14,15 +14,25 @@ type UI interface {
 
 type Controller struct {
 	Config config.Config
+	// Context stops the waits for the VM, ssh and deployments once it is
+	// done.
+	Context context.Context
 }
 
-func NewController(config config.Config) *Controller {
+func NewController(ctx context.Context, config config.Config) *Controller {
 	return &Controller{
-		Config: config,
+		Config:  config,
+		Context: ctx,
 	}
 }
 
 func (c *Controller) Ping() error {
-	ctx := context.Background()
-	return client.Ping(ctx, "127.0.0.1:9999")
+	return client.Ping(c.context(), "127.0.0.1:9999")
+}
+
+func (c *Controller) context() context.Context {
+	if c.Context == nil {
+		return context.Background()
+	}
+	return c.Context
 }
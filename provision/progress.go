@@ -6,30 +6,35 @@ import (
 
 	"code.cloudfoundry.org/cfdev/bosh"
 	"code.cloudfoundry.org/cfdev/errors"
+	"code.cloudfoundry.org/cfdev/resource/progress"
 )
 
 func (c *Controller) report(start time.Time, ui UI, b *bosh.Bosh, service Service, errChan chan error) error {
 	ticker := time.NewTicker(time.Second)
+	defer ticker.Stop()
+	renderer := progress.Detect(ui.Writer(), c.Config.ProgressMode)
 
 	for {
 		select {
 		case err := <-errChan:
 			if err != nil {
+				renderer.End()
 				return errors.SafeWrap(err, fmt.Sprintf("Failed to deploy %s", service.Name))
 			}
 
-			ui.Writer().Write([]byte(fmt.Sprintf("\r\033[K  Done (%s)\n", time.Now().Sub(start).Round(time.Second))))
+			renderer.Update(fmt.Sprintf("  Done (%s)", time.Now().Sub(start).Round(time.Second)))
+			renderer.End()
 			return nil
 		case <-ticker.C:
-			p := b.GetVMProgress(start, service.Deployment, service.IsErrand)
+			p := b.GetVMProgress(c.context(), start, service.Deployment, service.IsErrand)
 
 			switch p.State {
 			case bosh.UploadingReleases:
-				ui.Writer().Write([]byte(fmt.Sprintf("\r\033[K  Uploaded Releases: %d (%s)", p.Releases, p.Duration.Round(time.Second))))
+				renderer.Update(fmt.Sprintf("  Uploaded Releases: %d (%s)", p.Releases, p.Duration.Round(time.Second)))
 			case bosh.Deploying:
-				ui.Writer().Write([]byte(fmt.Sprintf("\r\033[K  Progress: %d of %d (%s)", p.Done, p.Total, p.Duration.Round(time.Second))))
+				renderer.Update(fmt.Sprintf("  Progress: %d of %d (%s)", p.Done, p.Total, p.Duration.Round(time.Second)))
 			case bosh.RunningErrand:
-				ui.Writer().Write([]byte(fmt.Sprintf("\r\033[K  Running errand (%s)", p.Duration.Round(time.Second))))
+				renderer.Update(fmt.Sprintf("  Running errand (%s)", p.Duration.Round(time.Second)))
 			}
 		}
 	}
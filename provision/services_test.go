@@ -3,6 +3,7 @@ package provision_test
 import (
 	"code.cloudfoundry.org/cfdev/config"
 	"code.cloudfoundry.org/cfdev/provision"
+	"context"
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
 )
@@ -14,7 +15,7 @@ var _ = Describe("When progress whitelist is called with", func() {
 	)
 
 	BeforeEach(func() {
-		c = provision.NewController(config.Config{})
+		c = provision.NewController(context.Background(), config.Config{})
 
 		services = []provision.Service{
 			{
@@ -0,0 +1,134 @@
+package resource
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+	"io/ioutil"
+	"os"
+	"strings"
+
+	"github.com/klauspost/compress/gzip"
+	"github.com/klauspost/compress/zstd"
+	"github.com/ulikunitz/xz"
+)
+
+var (
+	gzipMagic = []byte{0x1f, 0x8b}
+	zstdMagic = []byte{0x28, 0xb5, 0x2f, 0xfd}
+	xzMagic   = []byte{0xfd, '7', 'z', 'X', 'Z', 0x00}
+)
+
+// tarStream is the uncompressed tar stream of a gzip, zstd, xz or plain
+// archive. It keeps track of its position so that entries can be located
+// by their offset.
+type tarStream struct {
+	reader io.Reader
+	file   *os.File
+	closer io.Closer
+	pos    int64
+}
+
+func openTarStream(path string) (*tarStream, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+
+	stream := &tarStream{file: file}
+	buffered := bufio.NewReaderSize(file, 64*1024)
+	magic, err := buffered.Peek(len(xzMagic))
+	if err != nil && err != io.EOF {
+		file.Close()
+		return nil, err
+	}
+
+	switch {
+	case bytes.HasPrefix(magic, gzipMagic):
+		gzr, err := gzip.NewReader(buffered)
+		if err != nil {
+			file.Close()
+			return nil, err
+		}
+		stream.reader, stream.closer = gzr, gzr
+	case bytes.HasPrefix(magic, zstdMagic):
+		decoder, err := zstd.NewReader(buffered)
+		if err != nil {
+			file.Close()
+			return nil, err
+		}
+		stream.reader, stream.closer = decoder, decoder.IOReadCloser()
+	case bytes.HasPrefix(magic, xzMagic):
+		xzr, err := xz.NewReader(buffered)
+		if err != nil {
+			file.Close()
+			return nil, err
+		}
+		stream.reader = xzr
+	default:
+		// Plain tarballs are read straight from the file so that skipTo
+		// can seek.
+		if _, err := file.Seek(0, io.SeekStart); err != nil {
+			file.Close()
+			return nil, err
+		}
+		stream.reader = file
+	}
+
+	return stream, nil
+}
+
+func (s *tarStream) Read(p []byte) (int, error) {
+	n, err := s.reader.Read(p)
+	s.pos += int64(n)
+	return n, err
+}
+
+// skipTo moves forward to offset in the uncompressed stream. Only plain
+// tarballs seek; compressed streams have no seek points and are
+// decompressed up to offset.
+func (s *tarStream) skipTo(offset int64) error {
+	if offset < s.pos {
+		return io.ErrUnexpectedEOF
+	}
+
+	if s.reader == io.Reader(s.file) {
+		if _, err := s.file.Seek(offset, io.SeekStart); err != nil {
+			return err
+		}
+		s.pos = offset
+		return nil
+	}
+
+	_, err := io.CopyN(ioutil.Discard, s, offset-s.pos)
+	return err
+}
+
+func (s *tarStream) Close() error {
+	if s.closer != nil {
+		s.closer.Close()
+	}
+	return s.file.Close()
+}
+
+// NewArchiveWriter compresses what is written to it in the format that
+// the extension of name suggests: .tgz or .gz for gzip, .zst for zstd,
+// .xz for xz and anything else, such as .tar, not at all.
+func NewArchiveWriter(w io.Writer, name string) (io.WriteCloser, error) {
+	switch {
+	case strings.HasSuffix(name, ".tgz"), strings.HasSuffix(name, ".gz"):
+		return gzip.NewWriter(w), nil
+	case strings.HasSuffix(name, ".zst"):
+		return zstd.NewWriter(w)
+	case strings.HasSuffix(name, ".xz"):
+		return xz.NewWriter(w)
+	default:
+		return nopWriteCloser{w}, nil
+	}
+}
+
+type nopWriteCloser struct {
+	io.Writer
+}
+
+func (nopWriteCloser) Close() error { return nil }
@@ -0,0 +1,200 @@
+package resource
+
+import (
+	"archive/tar"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"runtime"
+)
+
+const bundleManifestName = "bundle.json"
+
+// bundleManifest is the first entry of a bundle. Its catalog lists the
+// bundled items with their SHA-256, followed by one entry per item.
+type bundleManifest struct {
+	OS      string  `json:"os"`
+	Catalog Catalog `json:"catalog"`
+}
+
+// ExportBundle writes the items of the catalog that are in use, as
+// found in the cache, into a tar archive that ImportBundle accepts on
+// another machine of the same OS.
+func (c *Cache) ExportBundle(w io.Writer, clog Catalog) error {
+	manifest := bundleManifest{OS: runtime.GOOS}
+	for _, item := range clog.Items {
+		if !item.InUse {
+			continue
+		}
+
+		path := filepath.Join(c.Dir, item.Name)
+		if match, err := c.checksumMatches(path, &item); err != nil {
+			return err
+		} else if !match {
+			return fmt.Errorf("%s in %s does not match the catalog", item.Name, c.Dir)
+		}
+
+		if item.SHA256 == "" {
+			sum, err := SHA256(path)
+			if err != nil {
+				return err
+			}
+			item.SHA256 = sum
+		}
+		manifest.Catalog.Items = append(manifest.Catalog.Items, item)
+	}
+
+	contents, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	tw := tar.NewWriter(w)
+	if err := tw.WriteHeader(&tar.Header{Name: bundleManifestName, Mode: 0644, Size: int64(len(contents))}); err != nil {
+		return err
+	}
+	if _, err := tw.Write(contents); err != nil {
+		return err
+	}
+
+	for _, item := range manifest.Catalog.Items {
+		if err := addToBundle(tw, filepath.Join(c.Dir, item.Name), item.Name); err != nil {
+			return err
+		}
+	}
+	return tw.Close()
+}
+
+func addToBundle(tw *tar.Writer, path string, name string) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	fi, err := file.Stat()
+	if err != nil {
+		return err
+	}
+
+	if err := tw.WriteHeader(&tar.Header{Name: name, Mode: 0755, Size: fi.Size(), ModTime: fi.ModTime()}); err != nil {
+		return err
+	}
+	_, err = io.Copy(tw, file)
+	return err
+}
+
+// ImportBundle verifies the items of a bundle and places them into the
+// cache. The bundle has to contain every item of the catalog that is in
+// use, at the same checksums, so that a following Sync has nothing to
+// download. Items are verified against clog, not against the checksums
+// the bundle declares, and items clog does not list are rejected.
+func (c *Cache) ImportBundle(r io.Reader, clog Catalog) ([]Item, error) {
+	tr := tar.NewReader(r)
+	hdr, err := tr.Next()
+	if err != nil || hdr.Name != bundleManifestName {
+		return nil, fmt.Errorf("not a cf dev bundle")
+	}
+
+	var manifest bundleManifest
+	if err := json.NewDecoder(tr).Decode(&manifest); err != nil {
+		return nil, fmt.Errorf("invalid %s: %s", bundleManifestName, err)
+	}
+	if manifest.OS != runtime.GOOS {
+		return nil, fmt.Errorf("bundle was created for %s and cannot be used on %s", manifest.OS, runtime.GOOS)
+	}
+
+	for _, item := range clog.Items {
+		if !item.InUse {
+			continue
+		}
+		bundled := manifest.Catalog.Lookup(item.Name)
+		if bundled == nil {
+			return nil, fmt.Errorf("bundle does not contain %s", item.Name)
+		} else if !sameContent(&item, bundled) {
+			return nil, fmt.Errorf("bundle contains a different version of %s", item.Name)
+		}
+	}
+
+	if err := os.MkdirAll(c.Dir, 0755); err != nil {
+		return nil, err
+	}
+
+	var imported []Item
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			return imported, err
+		}
+
+		item := manifest.Catalog.Lookup(hdr.Name)
+		trusted := clog.Lookup(hdr.Name)
+		if item == nil || trusted == nil || filepath.Base(hdr.Name) != hdr.Name {
+			return imported, fmt.Errorf("unexpected file %s in bundle", hdr.Name)
+		}
+		if err := c.importItem(tr, trusted, item); err != nil {
+			return imported, err
+		}
+		imported = append(imported, *item)
+	}
+
+	if len(imported) != len(manifest.Catalog.Items) {
+		return imported, fmt.Errorf("bundle is incomplete: expected %d items, found %d", len(manifest.Catalog.Items), len(imported))
+	}
+	return imported, nil
+}
+
+// importItem verifies the item with the checksum of the trusted catalog
+// entry. Only items the catalog has no checksum for (development builds)
+// fall back to the SHA-256 that the bundle declares.
+func (c *Cache) importItem(r io.Reader, trusted *Item, bundled *Item) error {
+	algorithm, expected, sum := digest(trusted)
+	if expected == "" {
+		algorithm, expected, sum = digest(&Item{SHA256: bundled.SHA256})
+	}
+
+	tmpPath := filepath.Join(c.Dir, trusted.Name+".tmp.bundle")
+	out, err := os.OpenFile(tmpPath, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0755)
+	if err != nil {
+		return err
+	}
+
+	_, err = io.Copy(out, r)
+	out.Close()
+	if err != nil {
+		os.Remove(tmpPath)
+		return err
+	}
+
+	actual, err := sum(tmpPath)
+	if err != nil {
+		os.Remove(tmpPath)
+		return err
+	}
+	if actual != expected {
+		os.Remove(tmpPath)
+		return fmt.Errorf("%s: %s != %s", trusted.Name, actual, expected)
+	}
+
+	if err := os.Rename(tmpPath, filepath.Join(c.Dir, trusted.Name)); err != nil {
+		return err
+	}
+	return c.record(trusted.Name, algorithm+":"+expected)
+}
+
+// sameContent compares the checksums both items carry. Items without a
+// checksum in the catalog (development builds) match any content.
+func sameContent(item *Item, bundled *Item) bool {
+	switch {
+	case item.SHA256 != "":
+		return item.SHA256 == bundled.SHA256
+	case item.MD5 != "":
+		return item.MD5 == bundled.MD5
+	default:
+		return true
+	}
+}
@@ -0,0 +1,162 @@
+package resource_test
+
+import (
+	"archive/tar"
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"runtime"
+	"time"
+
+	"code.cloudfoundry.org/cfdev/resource"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Bundles", func() {
+	var (
+		srcDir  string
+		dstDir  string
+		catalog resource.Catalog
+		src     *resource.Cache
+		dst     *resource.Cache
+		bundle  bytes.Buffer
+	)
+
+	newCache := func(dir string) *resource.Cache {
+		return &resource.Cache{
+			Dir: dir,
+			HttpDo: func(req *http.Request) (*http.Response, error) {
+				Fail("unexpected download of " + req.URL.String())
+				return nil, nil
+			},
+			Progress:  &MockProgress{},
+			RetryWait: time.Nanosecond,
+		}
+	}
+
+	BeforeEach(func() {
+		var err error
+		srcDir, err = ioutil.TempDir("", "cfdev-bundle-src-")
+		Expect(err).NotTo(HaveOccurred())
+		dstDir, err = ioutil.TempDir("", "cfdev-bundle-dst-")
+		Expect(err).NotTo(HaveOccurred())
+
+		createFile(srcDir, "cfdev-deps.tgz", "content")
+		createFile(srcDir, "cfdevd", "other-content")
+		catalog = resource.Catalog{
+			Items: []resource.Item{
+				{Name: "cfdev-deps.tgz", URL: "some-url", SHA256: "ed7002b439e9ac845f22357d822bac1444730fbdb6016d3ec9432297b9ec9f73", Size: 7, InUse: true},
+				{Name: "cfdevd", URL: "some-other-url", Size: 13, InUse: true},
+				{Name: "unused", URL: "some-unused-url", InUse: false},
+			},
+		}
+		md5, err := resource.MD5(filepath.Join(srcDir, "cfdevd"))
+		Expect(err).NotTo(HaveOccurred())
+		catalog.Items[1].MD5 = md5
+
+		src = newCache(srcDir)
+		dst = newCache(filepath.Join(dstDir, "cache"))
+		bundle.Reset()
+	})
+
+	AfterEach(func() {
+		os.RemoveAll(srcDir)
+		os.RemoveAll(dstDir)
+	})
+
+	It("moves every item in use to another cache", func() {
+		Expect(src.ExportBundle(&bundle, catalog)).To(Succeed())
+
+		items, err := dst.ImportBundle(&bundle, catalog)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(items).To(HaveLen(2))
+
+		Expect(ioutil.ReadFile(filepath.Join(dstDir, "cache", "cfdev-deps.tgz"))).To(Equal([]byte("content")))
+		Expect(ioutil.ReadFile(filepath.Join(dstDir, "cache", "cfdevd"))).To(Equal([]byte("other-content")))
+		Expect(dst.Sync(catalog)).To(Succeed())
+	})
+
+	It("refuses to export items that do not match the catalog", func() {
+		createFile(srcDir, "cfdevd", "corrupted")
+		Expect(src.ExportBundle(&bundle, catalog)).To(MatchError(ContainSubstring("cfdevd in " + srcDir + " does not match the catalog")))
+	})
+
+	It("rejects bundles for a different catalog", func() {
+		Expect(src.ExportBundle(&bundle, catalog)).To(Succeed())
+
+		catalog.Items[0].SHA256 = "some-newer-sha"
+		_, err := dst.ImportBundle(&bundle, catalog)
+		Expect(err).To(MatchError("bundle contains a different version of cfdev-deps.tgz"))
+	})
+
+	Context("when the bundle was tampered with", func() {
+		writeBundle := func(manifest interface{}, files map[string]string) {
+			tw := tar.NewWriter(&bundle)
+			contents, _ := json.Marshal(manifest)
+			tw.WriteHeader(&tar.Header{Name: "bundle.json", Mode: 0644, Size: int64(len(contents))})
+			tw.Write(contents)
+			for name, content := range files {
+				tw.WriteHeader(&tar.Header{Name: name, Mode: 0755, Size: int64(len(content))})
+				tw.Write([]byte(content))
+			}
+			Expect(tw.Close()).To(Succeed())
+		}
+
+		It("rejects items that do not match the bundled checksums", func() {
+			writeBundle(map[string]interface{}{
+				"os":      runtime.GOOS,
+				"catalog": resource.Catalog{Items: catalog.Items[:1]},
+			}, map[string]string{"cfdev-deps.tgz": "tampered"})
+
+			_, err := dst.ImportBundle(&bundle, resource.Catalog{Items: catalog.Items[:1]})
+			Expect(err).To(MatchError(ContainSubstring("cfdev-deps.tgz: ")))
+			Expect(filepath.Join(dstDir, "cache", "cfdev-deps.tgz")).NotTo(BeAnExistingFile())
+		})
+
+		It("verifies items against the catalog rather than the bundled checksums", func() {
+			item := catalog.Items[1]
+			item.SHA256 = "d121be3103007b41edf96f8262925f8c7d61894afe9a041843b631f69445bc57"
+			writeBundle(map[string]interface{}{
+				"os":      runtime.GOOS,
+				"catalog": resource.Catalog{Items: []resource.Item{item}},
+			}, map[string]string{"cfdevd": "tampered"})
+
+			_, err := dst.ImportBundle(&bundle, resource.Catalog{Items: catalog.Items[1:2]})
+			Expect(err).To(MatchError(ContainSubstring("cfdevd: ")))
+			Expect(filepath.Join(dstDir, "cache", "cfdevd")).NotTo(BeAnExistingFile())
+		})
+
+		It("rejects items that are not in the catalog", func() {
+			writeBundle(map[string]interface{}{
+				"os":      runtime.GOOS,
+				"catalog": resource.Catalog{Items: catalog.Items[:1]},
+			}, map[string]string{"cfdev-deps.tgz": "content"})
+
+			_, err := dst.ImportBundle(&bundle, resource.Catalog{})
+			Expect(err).To(MatchError("unexpected file cfdev-deps.tgz in bundle"))
+		})
+
+		It("rejects paths outside of the cache", func() {
+			item := catalog.Items[0]
+			item.Name = "../cfdev-deps.tgz"
+			writeBundle(map[string]interface{}{
+				"os":      runtime.GOOS,
+				"catalog": resource.Catalog{Items: []resource.Item{item}},
+			}, map[string]string{"../cfdev-deps.tgz": "content"})
+
+			_, err := dst.ImportBundle(&bundle, resource.Catalog{})
+			Expect(err).To(MatchError("unexpected file ../cfdev-deps.tgz in bundle"))
+		})
+
+		It("rejects bundles of other operating systems", func() {
+			writeBundle(map[string]interface{}{"os": "plan9"}, nil)
+
+			_, err := dst.ImportBundle(&bundle, catalog)
+			Expect(err).To(MatchError("bundle was created for plan9 and cannot be used on " + runtime.GOOS))
+		})
+	})
+})
@@ -1,7 +1,9 @@
 package resource
 
 import (
+	"context"
 	"crypto/md5"
+	"crypto/sha256"
 	"fmt"
 	"io"
 	"net/http"
@@ -30,6 +32,18 @@ type Cache struct {
 	SkipAssetVerification bool
 	RetryWait             time.Duration
 	Writer                io.Writer
+	// Connections and ChunkSize control how items larger than ChunkSize
+	// are split into byte ranges and fetched concurrently. Setting
+	// Connections to 1 disables parallel downloads.
+	Connections int
+	ChunkSize   uint64
+	// SharedDir is an optional content-addressed cache shared by several
+	// CFDEV_HOMEs. Items found there are linked instead of downloaded.
+	SharedDir string
+	// S3 is used to download s3://bucket/key sources.
+	S3 S3Config
+	// Context stops retrying failed downloads once it is done.
+	Context context.Context
 }
 
 func (c *Cache) Sync(clog Catalog) error {
@@ -60,15 +74,61 @@ func (c *Cache) download(item *Item) error {
 
 	c.Progress.SetLastCompleted()
 
-	if match, err := c.checksumMatches(filepath.Join(c.Dir, item.Name), item.MD5); err != nil {
+	algorithm, expected, _ := digest(item)
+
+	if match, err := c.checksumMatches(filepath.Join(c.Dir, item.Name), item); err != nil {
 		return err
 	} else if match {
+		if !c.SkipAssetVerification {
+			if err := c.record(item.Name, algorithm+":"+expected); err != nil {
+				return err
+			}
+		}
 		c.Progress.Add(item.Size)
-		return os.Chmod(filepath.Join(c.Dir, item.Name), 0755)
+		return c.makeExecutable(item)
 	}
 
-	if strings.HasPrefix(item.URL, "file://") || strings.HasPrefix(item.URL, "C:") {
-		if err := c.copyFile(item); err != nil {
+	if found, err := c.fromShared(item); err != nil {
+		return err
+	} else if found {
+		if err := c.record(item.Name, algorithm+":"+expected); err != nil {
+			return err
+		}
+		c.Progress.Add(item.Size)
+		return nil
+	}
+
+	sources := item.Sources()
+	if len(sources) == 0 {
+		return fmt.Errorf("no url for %s", item.Name)
+	}
+
+	var err error
+	for index, url := range sources {
+		if index > 0 {
+			c.Progress.ResetCurrent()
+			if c.Writer != nil {
+				fmt.Fprintf(c.Writer, "\nFailed to download %s from %s: %s. Trying the next mirror...\n", item.Name, sources[index-1], err)
+			}
+		}
+		if err = c.downloadFrom(item, url); err == nil {
+			if isLocal(url) {
+				// local copies are not checksummed, so they must not
+				// end up in the content-addressed shared cache
+				return nil
+			}
+			return c.toShared(item)
+		}
+	}
+	return err
+}
+
+func (c *Cache) downloadFrom(item *Item, url string) error {
+	if isLocal(url) {
+		if err := c.copyFile(item, url); err != nil {
+			return err
+		}
+		if err := c.record(item.Name, ""); err != nil {
 			return err
 		}
 
@@ -80,21 +140,53 @@ func (c *Cache) download(item *Item) error {
 		return nil
 	}
 
-	tmpPath := filepath.Join(c.Dir, item.Name+".tmp."+item.MD5)
-	downloadFn := func() error { return c.downloadHTTP(item.URL, tmpPath) }
-	if err := retry.Retry(downloadFn, retry.Retryable(10, c.RetryWait, c.Writer)); err != nil {
+	algorithm, expected, sum := digest(item)
+
+	tmpPath := filepath.Join(c.Dir, item.Name+".tmp."+expected)
+	if err := c.fetch(item, url, tmpPath); err != nil {
 		return err
 	}
-	if m, err := MD5(tmpPath); err != nil {
+	if m, err := sum(tmpPath); err != nil {
 		return err
-	} else if m != item.MD5 {
+	} else if m != expected {
 		os.Remove(tmpPath)
-		return errors.SafeWrap(fmt.Errorf("%s: %s != %s", item.Name, m, item.MD5), "md5 did not match")
+		return errors.SafeWrap(fmt.Errorf("%s: %s != %s", item.Name, m, expected), algorithm+" did not match")
 	}
 
 	os.Rename(tmpPath, filepath.Join(c.Dir, item.Name))
+	return c.record(item.Name, algorithm+":"+expected)
+}
 
-	return nil
+func isLocal(url string) bool {
+	return strings.HasPrefix(url, "file://") || strings.HasPrefix(url, "C:")
+}
+
+func (c *Cache) fetch(item *Item, url, tmpPath string) error {
+	if c.parallel(item) {
+		if err := c.downloadChunks(item, url, tmpPath); err != errRangesUnsupported {
+			return err
+		}
+	}
+
+	return retry.Do(c.Context, c.retryPolicy(), func() error {
+		return c.downloadHTTP(url, tmpPath)
+	})
+}
+
+// retryPolicy retries failed connections and busy servers, starting
+// RetryWait apart.
+func (c *Cache) retryPolicy() retry.Policy {
+	return retry.Policy{
+		Backoff: retry.Backoff{
+			Initial:     c.RetryWait,
+			Max:         30 * time.Second,
+			Multiplier:  2,
+			Jitter:      0.2,
+			MaxAttempts: 10,
+		},
+		ShouldRetry: retry.IsRetryable,
+		Notify:      retry.Log(c.Writer),
+	}
 }
 
 func (c *Cache) downloadHTTP(url, tmpPath string) error {
@@ -112,7 +204,7 @@ func (c *Cache) downloadHTTP(url, tmpPath string) error {
 	}
 	defer out.Close()
 
-	resp, err := c.HttpDo(req)
+	resp, err := c.do(req)
 	if err != nil {
 		c.Progress.ResetCurrent()
 		return retry.WrapAsRetryable(err)
@@ -127,31 +219,45 @@ func (c *Cache) downloadHTTP(url, tmpPath string) error {
 	} else if resp.StatusCode == 416 {
 		// Possibly full file already downloaded
 	} else {
-		return errors.SafeWrap(fmt.Errorf(resp.Status), "http status")
+		return retry.FromResponse(resp, errors.SafeWrap(fmt.Errorf(resp.Status), "http status"))
 	}
 	return nil
 }
 
-func (c *Cache) checksumMatches(path, md5 string) (bool, error) {
+func (c *Cache) checksumMatches(path string, item *Item) (bool, error) {
 	if c.SkipAssetVerification {
 		return fileExists(path)
 	}
-	m, err := MD5(path)
+	_, expected, sum := digest(item)
+	m, err := sum(path)
 	if os.IsNotExist(err) {
 		return false, nil
 	} else if err != nil {
 		return false, err
 	}
-	return m == md5, nil
+	return m == expected, nil
+}
+
+// digest picks the strongest checksum the item carries. Catalogs that
+// predate SHA-256 only have an MD5.
+func digest(item *Item) (algorithm string, expected string, sum func(string) (string, error)) {
+	if item.SHA256 != "" {
+		return "sha256", item.SHA256, SHA256
+	}
+	return "md5", item.MD5, MD5
 }
 
-func (c *Cache) copyFile(item *Item) error {
-	source, err := os.Open(strings.Replace(item.URL, "file://", "", 1))
+func (c *Cache) copyFile(item *Item, url string) error {
+	source, err := os.Open(strings.Replace(url, "file://", "", 1))
 	if err != nil {
 		return err
 	}
 	defer source.Close()
-	out, err := os.OpenFile(filepath.Join(c.Dir, item.Name), os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0755)
+	dst := filepath.Join(c.Dir, item.Name)
+	if err := removeExisting(dst); err != nil {
+		return err
+	}
+	out, err := os.OpenFile(dst, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0755)
 	if err != nil {
 		return err
 	}
@@ -160,6 +266,22 @@ func (c *Cache) copyFile(item *Item) error {
 	return err
 }
 
+// makeExecutable marks a cached item executable unless it is linked to
+// the shared cache, where a chmod would change it for every CFDEV_HOME.
+func (c *Cache) makeExecutable(item *Item) error {
+	path := filepath.Join(c.Dir, item.Name)
+	if shared := c.sharedPath(item); shared != "" {
+		fi, err := os.Stat(path)
+		if err != nil {
+			return err
+		}
+		if sfi, err := os.Stat(shared); err == nil && os.SameFile(fi, sfi) {
+			return nil
+		}
+	}
+	return os.Chmod(path, 0755)
+}
+
 func MD5(file string) (string, error) {
 	f, err := os.Open(file)
 	if err != nil {
@@ -175,6 +297,21 @@ func MD5(file string) (string, error) {
 	return fmt.Sprintf("%x", h.Sum(nil)), nil
 }
 
+func SHA256(file string) (string, error) {
+	f, err := os.Open(file)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	h := sha256.New()
+
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
+	}
+
+	return fmt.Sprintf("%x", h.Sum(nil)), nil
+}
+
 func fileExists(file string) (bool, error) {
 	_, err := os.Stat(file)
 	if err != nil {
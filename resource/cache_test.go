@@ -1,11 +1,14 @@
 package resource_test
 
 import (
+	"bytes"
+	"crypto/sha256"
 	"fmt"
 	"io/ioutil"
 	"os"
 	"os/exec"
 	"path/filepath"
+	"sync"
 	"time"
 
 	. "github.com/onsi/ginkgo"
@@ -280,6 +283,41 @@ var _ = Describe("Cache Sync", func() {
 		})
 	})
 
+	Context("when items have mirrors", func() {
+		BeforeEach(func() {
+			catalog.Items = catalog.Items[:1]
+			catalog.Items[0].Mirrors = []string{"first-mirror-url", "second-mirror-url"}
+			cache.HttpDo = func(req *http.Request) (*http.Response, error) {
+				downloads = append(downloads, req.URL.String())
+				if req.URL.String() != "second-mirror-url" {
+					return &http.Response{
+						StatusCode: 404,
+						Status:     "File Not Found",
+						Body:       ioutil.NopCloser(strings.NewReader("")),
+					}, nil
+				}
+				return &http.Response{
+					StatusCode: 200,
+					Body:       ioutil.NopCloser(strings.NewReader("content")),
+				}, nil
+			}
+		})
+
+		It("fails over to the next mirror", func() {
+			Expect(cache.Sync(catalog)).To(Succeed())
+
+			Expect(downloads).To(Equal([]string{"first-resource-url", "first-mirror-url", "second-mirror-url"}))
+			Expect(ioutil.ReadFile(filepath.Join(tmpDir, "first-resource"))).To(Equal([]byte("content")))
+		})
+
+		It("returns the last error when every mirror fails", func() {
+			catalog.Items[0].Mirrors = []string{"first-mirror-url"}
+
+			Expect(cache.Sync(catalog)).To(MatchError("http status: File Not Found"))
+			Expect(downloads).To(Equal([]string{"first-resource-url", "first-mirror-url"}))
+		})
+	})
+
 	Context("downloading fails during transmission", func() {
 		var counter int
 		BeforeEach(func() {
@@ -298,6 +336,37 @@ var _ = Describe("Cache Sync", func() {
 		})
 	})
 
+	Context("when the server is busy", func() {
+		var buffer *bytes.Buffer
+		BeforeEach(func() {
+			buffer = &bytes.Buffer{}
+			cache.Writer = buffer
+			catalog.Items = catalog.Items[:1]
+			cache.HttpDo = func(req *http.Request) (*http.Response, error) {
+				downloads = append(downloads, req.URL.String())
+				if len(downloads) == 1 {
+					return &http.Response{
+						StatusCode: http.StatusServiceUnavailable,
+						Status:     "503 Service Unavailable",
+						Header:     http.Header{"Retry-After": {"0"}},
+						Body:       ioutil.NopCloser(strings.NewReader("")),
+					}, nil
+				}
+				return &http.Response{
+					StatusCode: 200,
+					Body:       ioutil.NopCloser(strings.NewReader("content")),
+				}, nil
+			}
+		})
+
+		It("retries the download", func() {
+			Expect(cache.Sync(catalog)).To(Succeed())
+			Expect(downloads).To(Equal([]string{"first-resource-url", "first-resource-url"}))
+			Expect(buffer.String()).To(ContainSubstring("Failed: Retrying: 1"))
+			Expect(buffer.String()).To(ContainSubstring("http status: 503 Service Unavailable"))
+		})
+	})
+
 	Context("downloaded file contains incorrect checksum", func() {
 		BeforeEach(func() {
 			cache.HttpDo = func(req *http.Request) (*http.Response, error) {
@@ -329,6 +398,145 @@ var _ = Describe("Cache Sync", func() {
 		})
 	})
 
+	Context("when items carry a sha256", func() {
+		BeforeEach(func() {
+			catalog.Items = catalog.Items[:2]
+			for index := range catalog.Items {
+				catalog.Items[index].SHA256 = "ed7002b439e9ac845f22357d822bac1444730fbdb6016d3ec9432297b9ec9f73" // shasum -a 256 content
+			}
+		})
+
+		It("verifies downloads against the sha256", func() {
+			Expect(cache.Sync(catalog)).To(Succeed())
+
+			Expect(downloads).To(ConsistOf("first-resource-url", "second-resource-url"))
+			Expect(ioutil.ReadFile(filepath.Join(tmpDir, "first-resource"))).To(Equal([]byte("content")))
+		})
+
+		It("prefers the sha256 over the md5", func() {
+			catalog.Items[0].MD5 = "some-outdated-md5"
+			Expect(cache.Sync(catalog)).To(Succeed())
+		})
+
+		It("rejects downloads with a different sha256", func() {
+			catalog.Items[0].SHA256 = "0000000000000000000000000000000000000000000000000000000000000000"
+			err := cache.Sync(catalog)
+			Expect(err).To(MatchError(ContainSubstring("first-resource: ed7002b439e9ac845f22357d822bac1444730fbdb6016d3ec9432297b9ec9f73 != 0000")))
+			Expect(filepath.Join(tmpDir, "first-resource")).ToNot(BeAnExistingFile())
+		})
+	})
+
+	Context("when items are larger than the chunk size", func() {
+		var (
+			content string
+			mutex   sync.Mutex
+			ranges  []string
+		)
+
+		serveRanges := func(req *http.Request) (*http.Response, error) {
+			mutex.Lock()
+			ranges = append(ranges, req.Header.Get("Range"))
+			mutex.Unlock()
+
+			var start, end int
+			if _, err := fmt.Sscanf(req.Header.Get("Range"), "bytes=%d-%d", &start, &end); err != nil {
+				return nil, err
+			}
+			return &http.Response{
+				StatusCode: 206,
+				Body:       ioutil.NopCloser(strings.NewReader(content[start : end+1])),
+			}, nil
+		}
+
+		BeforeEach(func() {
+			content = "some-content-split-into-chunks"
+			ranges = nil
+			catalog.Items = []resource.Item{{
+				Name:   "large-resource",
+				URL:    "large-resource-url",
+				SHA256: fmt.Sprintf("%x", sha256.Sum256([]byte(content))),
+				Size:   uint64(len(content)),
+				InUse:  true,
+			}}
+			cache.Connections = 3
+			cache.ChunkSize = 8
+			cache.HttpDo = serveRanges
+		})
+
+		It("downloads the item in byte ranges and reassembles it", func() {
+			Expect(cache.Sync(catalog)).To(Succeed())
+
+			Expect(ioutil.ReadFile(filepath.Join(tmpDir, "large-resource"))).To(Equal([]byte(content)))
+			Expect(ranges).To(ConsistOf("bytes=0-0", "bytes=0-7", "bytes=8-15", "bytes=16-23", "bytes=24-29"))
+			Expect(mockProgress.Current).To(Equal(uint64(len(content))))
+
+			files, err := filepath.Glob(filepath.Join(tmpDir, "large-resource.*"))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(files).To(BeEmpty())
+		})
+
+		It("resumes each chunk independently", func() {
+			tmpPath := filepath.Join(tmpDir, "large-resource.tmp."+catalog.Items[0].SHA256)
+			createFile(tmpDir, filepath.Base(tmpPath)+".part.8-15", content[8:12])
+
+			Expect(cache.Sync(catalog)).To(Succeed())
+
+			Expect(ioutil.ReadFile(filepath.Join(tmpDir, "large-resource"))).To(Equal([]byte(content)))
+			Expect(ranges).To(ContainElement("bytes=12-15"))
+			Expect(ranges).NotTo(ContainElement("bytes=8-15"))
+			Expect(mockProgress.Current).To(Equal(uint64(len(content))))
+		})
+
+		It("retries chunks that fail", func() {
+			failed := false
+			cache.HttpDo = func(req *http.Request) (*http.Response, error) {
+				mutex.Lock()
+				fail := req.Header.Get("Range") == "bytes=16-23" && !failed
+				failed = failed || fail
+				mutex.Unlock()
+				if fail {
+					return &http.Response{
+						StatusCode: 206,
+						Body:       ioutil.NopCloser(strings.NewReader(content[16:19])),
+					}, nil
+				}
+				return serveRanges(req)
+			}
+
+			Expect(cache.Sync(catalog)).To(Succeed())
+			Expect(ioutil.ReadFile(filepath.Join(tmpDir, "large-resource"))).To(Equal([]byte(content)))
+			Expect(ranges).To(ContainElement("bytes=19-23"))
+		})
+
+		It("falls back to a single stream when the server ignores ranges", func() {
+			cache.HttpDo = func(req *http.Request) (*http.Response, error) {
+				downloads = append(downloads, req.Header.Get("Range"))
+				return &http.Response{
+					StatusCode: 200,
+					Body:       ioutil.NopCloser(strings.NewReader(content)),
+				}, nil
+			}
+
+			Expect(cache.Sync(catalog)).To(Succeed())
+			Expect(ioutil.ReadFile(filepath.Join(tmpDir, "large-resource"))).To(Equal([]byte(content)))
+			Expect(downloads).To(Equal([]string{"bytes=0-0", ""}))
+		})
+
+		It("does not split items when parallel downloads are disabled", func() {
+			cache.Connections = 1
+			cache.HttpDo = func(req *http.Request) (*http.Response, error) {
+				downloads = append(downloads, req.Header.Get("Range"))
+				return &http.Response{
+					StatusCode: 200,
+					Body:       ioutil.NopCloser(strings.NewReader(content)),
+				}, nil
+			}
+
+			Expect(cache.Sync(catalog)).To(Succeed())
+			Expect(downloads).To(Equal([]string{""}))
+		})
+	})
+
 	Context("asset verification is turned off", func() {
 		BeforeEach(func() {
 			cache.SkipAssetVerification = true
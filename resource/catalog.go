@@ -5,11 +5,25 @@ type Catalog struct {
 }
 
 type Item struct {
-	URL   string
-	Name  string
-	MD5   string
-	Size  uint64
-	InUse bool
+	URL     string
+	Mirrors []string `json:",omitempty"`
+	Name    string
+	MD5     string
+	SHA256  string
+	Size    uint64
+	InUse   bool
+}
+
+// Sources returns the URL followed by the mirrors, in the order the
+// cache tries them.
+func (i *Item) Sources() []string {
+	var sources []string
+	for _, url := range append([]string{i.URL}, i.Mirrors...) {
+		if url != "" {
+			sources = append(sources, url)
+		}
+	}
+	return sources
 }
 
 func (c *Catalog) Lookup(name string) *Item {
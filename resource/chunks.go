@@ -0,0 +1,254 @@
+package resource
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"code.cloudfoundry.org/cfdev/errors"
+	"code.cloudfoundry.org/cfdev/resource/retry"
+)
+
+const (
+	DefaultConnections        = 4
+	DefaultChunkSize   uint64 = 64 * 1024 * 1024
+)
+
+var errRangesUnsupported = fmt.Errorf("server does not support range requests")
+
+// chunk is a byte range of an item, downloaded to its own part file so
+// that it can be resumed independently of the others.
+type chunk struct {
+	path       string
+	start, end uint64
+}
+
+func (ch chunk) size() uint64 {
+	return ch.end - ch.start + 1
+}
+
+func splitChunks(tmpPath string, size, chunkSize uint64) []chunk {
+	var chunks []chunk
+	for start := uint64(0); start < size; start += chunkSize {
+		end := start + chunkSize - 1
+		if end >= size {
+			end = size - 1
+		}
+		chunks = append(chunks, chunk{
+			path:  fmt.Sprintf("%s.part.%d-%d", tmpPath, start, end),
+			start: start,
+			end:   end,
+		})
+	}
+	return chunks
+}
+
+func (c *Cache) connections() int {
+	if c.Connections == 0 {
+		return DefaultConnections
+	}
+	return c.Connections
+}
+
+func (c *Cache) chunkSize() uint64 {
+	if c.ChunkSize == 0 {
+		return DefaultChunkSize
+	}
+	return c.ChunkSize
+}
+
+func (c *Cache) parallel(item *Item) bool {
+	return c.connections() > 1 && item.Size > c.chunkSize()
+}
+
+// downloadChunks fetches the item over several connections and
+// reassembles it at tmpPath. It returns errRangesUnsupported, before
+// downloading anything, when the server ignores range requests.
+func (c *Cache) downloadChunks(item *Item, url, tmpPath string) error {
+	if exists, err := fileExists(tmpPath); err != nil {
+		return err
+	} else if exists {
+		// Left behind by a single stream download; resume that instead.
+		return errRangesUnsupported
+	}
+
+	chunks := splitChunks(tmpPath, item.Size, c.chunkSize())
+
+	supported, err := c.supportsRanges(url)
+	if err != nil {
+		return err
+	} else if !supported {
+		removeChunks(chunks)
+		return errRangesUnsupported
+	}
+
+	for _, ch := range chunks {
+		if fi, err := os.Stat(ch.path); err == nil {
+			c.Progress.Add(uint64(fi.Size()))
+		}
+	}
+
+	indexes := make(chan int, len(chunks))
+	for index := range chunks {
+		indexes <- index
+	}
+	close(indexes)
+
+	var (
+		wg       sync.WaitGroup
+		errs     = make(chan error, len(chunks))
+		progress = &lockedWriter{writer: c.Progress}
+	)
+	for worker := 0; worker < c.connections() && worker < len(chunks); worker++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for index := range indexes {
+				if err := c.downloadChunk(url, chunks[index], progress); err != nil {
+					errs <- err
+					return
+				}
+			}
+		}()
+	}
+	wg.Wait()
+	close(errs)
+
+	if err := <-errs; err != nil {
+		return err
+	}
+	return assembleChunks(tmpPath, chunks)
+}
+
+func (c *Cache) supportsRanges(url string) (bool, error) {
+	var supported bool
+	probe := func() error {
+		req, err := http.NewRequest("GET", url, nil)
+		if err != nil {
+			return err
+		}
+		req.Header.Add("Range", "bytes=0-0")
+
+		resp, err := c.do(req)
+		if err != nil {
+			return retry.WrapAsRetryable(err)
+		}
+		resp.Body.Close()
+
+		switch {
+		case resp.StatusCode == http.StatusPartialContent:
+			supported = true
+		case resp.StatusCode >= 200 && resp.StatusCode < 300:
+			supported = false
+		default:
+			return retry.FromResponse(resp, errors.SafeWrap(fmt.Errorf("%s", resp.Status), "http status"))
+		}
+		return nil
+	}
+	err := retry.Do(c.Context, c.retryPolicy(), probe)
+	return supported, err
+}
+
+func (c *Cache) downloadChunk(url string, ch chunk, progress io.Writer) error {
+	return retry.Do(c.Context, c.retryPolicy(), func() error {
+		return c.downloadRange(url, ch, progress)
+	})
+}
+
+func (c *Cache) downloadRange(url string, ch chunk, progress io.Writer) error {
+	var offset uint64
+	if fi, err := os.Stat(ch.path); err == nil {
+		offset = uint64(fi.Size())
+	}
+	if offset >= ch.size() {
+		return nil
+	}
+
+	req, err := http.NewRequest("GET", url, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Add("Range", fmt.Sprintf("bytes=%d-%d", ch.start+offset, ch.end))
+
+	resp, err := c.do(req)
+	if err != nil {
+		return retry.WrapAsRetryable(err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusPartialContent {
+		return retry.FromResponse(resp, errors.SafeWrap(fmt.Errorf("%s", resp.Status), "http status"))
+	}
+
+	out, err := os.OpenFile(ch.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return err
+	}
+	defer out.Close()
+
+	// Bytes already written stay in the part file, so progress is only
+	// ever added and never needs to be reset on retries.
+	remaining := int64(ch.size() - offset)
+	written, err := io.Copy(out, io.TeeReader(io.LimitReader(resp.Body, remaining), progress))
+	if err != nil {
+		return retry.WrapAsRetryable(err)
+	} else if written < remaining {
+		return retry.WrapAsRetryable(fmt.Errorf("%s: connection closed after %d of %d bytes", filepath.Base(ch.path), written, remaining))
+	}
+	return nil
+}
+
+func assembleChunks(tmpPath string, chunks []chunk) error {
+	assembling := tmpPath + ".assembling"
+	out, err := os.OpenFile(assembling, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0755)
+	if err != nil {
+		return err
+	}
+
+	for _, ch := range chunks {
+		if err := appendFile(out, ch.path); err != nil {
+			out.Close()
+			os.Remove(assembling)
+			return err
+		}
+	}
+	if err := out.Close(); err != nil {
+		return err
+	}
+
+	if err := os.Rename(assembling, tmpPath); err != nil {
+		return err
+	}
+	removeChunks(chunks)
+	return nil
+}
+
+func appendFile(out io.Writer, path string) error {
+	in, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	_, err = io.Copy(out, in)
+	return err
+}
+
+func removeChunks(chunks []chunk) {
+	for _, ch := range chunks {
+		os.Remove(ch.path)
+	}
+}
+
+type lockedWriter struct {
+	mu     sync.Mutex
+	writer io.Writer
+}
+
+func (w *lockedWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.writer.Write(p)
+}
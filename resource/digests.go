@@ -0,0 +1,60 @@
+package resource
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// ReadDigests parses a manifest in the format written by sha256sum, one
+// "<hex digest>  <file name>" per line.
+func ReadDigests(path string) (map[string]string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	digests := map[string]string{}
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 0 {
+			continue
+		}
+		if len(fields) != 2 {
+			return nil, fmt.Errorf("invalid line in %s: %s", filepath.Base(path), scanner.Text())
+		}
+		digests[filepath.Base(strings.TrimPrefix(fields[1], "*"))] = strings.ToLower(fields[0])
+	}
+	return digests, scanner.Err()
+}
+
+// VerifyDigests checks the named files in dir against the digests.
+// Files that are not present are skipped.
+func VerifyDigests(dir string, digests map[string]string, names ...string) error {
+	for _, name := range names {
+		path := filepath.Join(dir, name)
+		if exists, err := fileExists(path); err != nil {
+			return err
+		} else if !exists {
+			continue
+		}
+
+		expected, ok := digests[name]
+		if !ok {
+			return fmt.Errorf("%s has no digest", name)
+		}
+
+		actual, err := SHA256(path)
+		if err != nil {
+			return err
+		}
+		if actual != expected {
+			return fmt.Errorf("%s: %s != %s", name, actual, expected)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,61 @@
+package resource
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+)
+
+const extractedFile = ".extracted.json"
+
+// extractedFiles records, per destination directory, the digest of every
+// file extracted there, so that files which have not been touched since
+// need not be written again.
+type extractedFiles map[string]map[string]indexEntry
+
+func (x extractedFiles) dir(dir string) map[string]indexEntry {
+	if entries, ok := x[dir]; ok {
+		return entries
+	}
+
+	entries := map[string]indexEntry{}
+	if contents, err := ioutil.ReadFile(filepath.Join(dir, extractedFile)); err == nil {
+		json.Unmarshal(contents, &entries)
+	}
+	x[dir] = entries
+	return entries
+}
+
+func (x extractedFiles) unchanged(target string, digest string) bool {
+	fi, err := os.Stat(target)
+	if err != nil {
+		return false
+	}
+
+	recorded, ok := recordedDigest(x.dir(filepath.Dir(target)), fi)
+	return ok && recorded == digest
+}
+
+func (x extractedFiles) record(target string, digest string) error {
+	fi, err := os.Stat(target)
+	if err != nil {
+		return err
+	}
+
+	x.dir(filepath.Dir(target))[fi.Name()] = indexEntry{Digest: digest, Size: fi.Size(), ModTime: fi.ModTime()}
+	return nil
+}
+
+func (x extractedFiles) save() error {
+	for dir, entries := range x {
+		contents, err := json.MarshalIndent(entries, "", "  ")
+		if err != nil {
+			return err
+		}
+		if err := ioutil.WriteFile(filepath.Join(dir, extractedFile), contents, 0644); err != nil {
+			return err
+		}
+	}
+	return nil
+}
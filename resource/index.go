@@ -0,0 +1,68 @@
+package resource
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+const indexFile = ".index.json"
+
+// indexEntry records the digest of a cached file as of the last time it
+// was checksummed, so that listing the cache does not need to rehash
+// multi-GB files. An empty Digest marks a file that was fetched without
+// verification.
+type indexEntry struct {
+	Digest  string    `json:"digest,omitempty"`
+	Size    int64     `json:"size"`
+	ModTime time.Time `json:"mod_time"`
+}
+
+func (c *Cache) readIndex() map[string]indexEntry {
+	index := map[string]indexEntry{}
+	contents, err := ioutil.ReadFile(filepath.Join(c.Dir, indexFile))
+	if err != nil {
+		return index
+	}
+	json.Unmarshal(contents, &index)
+	return index
+}
+
+func (c *Cache) writeIndex(index map[string]indexEntry) error {
+	contents, err := json.MarshalIndent(index, "", "  ")
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(filepath.Join(c.Dir, indexFile), contents, 0644)
+}
+
+func (c *Cache) record(name string, digest string) error {
+	fi, err := os.Stat(filepath.Join(c.Dir, name))
+	if err != nil {
+		return err
+	}
+
+	index := c.readIndex()
+	index[name] = indexEntry{Digest: digest, Size: fi.Size(), ModTime: fi.ModTime()}
+	return c.writeIndex(index)
+}
+
+func (c *Cache) forget(names ...string) error {
+	index := c.readIndex()
+	for _, name := range names {
+		delete(index, name)
+	}
+	return c.writeIndex(index)
+}
+
+// recordedDigest returns the digest recorded for the file, provided it
+// has not changed since.
+func recordedDigest(index map[string]indexEntry, fi os.FileInfo) (string, bool) {
+	entry, ok := index[fi.Name()]
+	if !ok || entry.Digest == "" || entry.Size != fi.Size() || !entry.ModTime.Equal(fi.ModTime()) {
+		return "", false
+	}
+	return entry.Digest, true
+}
@@ -0,0 +1,168 @@
+package resource
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+type Status string
+
+const (
+	// StatusVerified and StatusMismatch compare a catalog item against
+	// its last recorded checksum.
+	StatusVerified   Status = "verified"
+	StatusMismatch   Status = "mismatch"
+	StatusUnverified Status = "unverified"
+	StatusMissing    Status = "missing"
+	// StatusPartial marks an interrupted download.
+	StatusPartial Status = "partial"
+	// StatusStale marks a download that the catalog no longer refers to,
+	// or any other file that was not extracted from the deps tarball.
+	StatusStale Status = "stale"
+	// StatusExtracted marks files unpacked from the deps tarball, which
+	// later starts reuse for as long as they are unchanged.
+	StatusExtracted Status = "extracted"
+)
+
+type Entry struct {
+	Name       string `json:"name"`
+	Size       int64  `json:"size"`
+	Status     Status `json:"status"`
+	Referenced bool   `json:"referenced"`
+}
+
+type PruneOptions struct {
+	DryRun    bool
+	Extracted bool
+}
+
+// List describes the files in the cache using the recorded checksums.
+func (c *Cache) List(clog Catalog) ([]Entry, error) {
+	return c.inventory(clog, false)
+}
+
+// Verify recomputes the checksums of the catalog items in the cache.
+func (c *Cache) Verify(clog Catalog) ([]Entry, error) {
+	return c.inventory(clog, true)
+}
+
+// Prune removes partial downloads and stale items, and with
+// opts.Extracted also the files extracted from the deps tarball.
+func (c *Cache) Prune(clog Catalog, opts PruneOptions) ([]Entry, error) {
+	entries, err := c.List(clog)
+	if err != nil {
+		return nil, err
+	}
+
+	var pruned []Entry
+	var names []string
+	for _, entry := range entries {
+		switch {
+		case entry.Status == StatusPartial, entry.Status == StatusStale:
+		case entry.Status == StatusExtracted && opts.Extracted:
+		default:
+			continue
+		}
+
+		if !opts.DryRun {
+			if err := os.Remove(filepath.Join(c.Dir, entry.Name)); err != nil && !os.IsNotExist(err) {
+				return pruned, err
+			}
+			names = append(names, entry.Name)
+		}
+		pruned = append(pruned, entry)
+	}
+
+	if len(names) > 0 {
+		return pruned, c.forget(names...)
+	}
+	return pruned, nil
+}
+
+func (c *Cache) inventory(clog Catalog, rehash bool) ([]Entry, error) {
+	files, err := ioutil.ReadDir(c.Dir)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+
+	index := c.readIndex()
+	extracted := extractedFiles{}.dir(c.Dir)
+	seen := map[string]bool{}
+	var entries []Entry
+	for _, fi := range files {
+		if fi.IsDir() || fi.Name() == indexFile || fi.Name() == extractedFile {
+			continue
+		}
+		seen[fi.Name()] = true
+
+		entry := Entry{Name: fi.Name(), Size: fi.Size()}
+		item := clog.Lookup(fi.Name())
+		_, downloaded := index[fi.Name()]
+		_, unpacked := extracted[fi.Name()]
+
+		switch {
+		case isPartial(fi.Name()):
+			entry.Status = StatusPartial
+		case item != nil:
+			entry.Referenced = true
+			if entry.Status, err = c.status(item, fi, index, rehash); err != nil {
+				return nil, err
+			}
+		case unpacked && !downloaded:
+			entry.Status = StatusExtracted
+		default:
+			// Downloads that predate the index, such as the ISOs of
+			// older releases, are in neither record.
+			entry.Status = StatusStale
+		}
+		entries = append(entries, entry)
+	}
+
+	for _, item := range clog.Items {
+		if item.InUse && !seen[item.Name] {
+			entries = append(entries, Entry{Name: item.Name, Status: StatusMissing, Referenced: true})
+		}
+	}
+
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].Name < entries[j].Name
+	})
+	return entries, nil
+}
+
+func (c *Cache) status(item *Item, fi os.FileInfo, index map[string]indexEntry, rehash bool) (Status, error) {
+	algorithm, expected, sum := digest(item)
+	if expected == "" {
+		return StatusUnverified, nil
+	}
+
+	recorded, ok := recordedDigest(index, fi)
+	if rehash {
+		actual, err := sum(filepath.Join(c.Dir, item.Name))
+		if err != nil {
+			return "", err
+		}
+		recorded, ok = algorithm+":"+actual, true
+		if err := c.record(item.Name, recorded); err != nil {
+			return "", err
+		}
+	}
+
+	switch {
+	case !ok || !strings.HasPrefix(recorded, algorithm+":"):
+		return StatusUnverified, nil
+	case recorded == algorithm+":"+expected:
+		return StatusVerified, nil
+	default:
+		return StatusMismatch, nil
+	}
+}
+
+// isPartial matches the temporary files of downloads in progress,
+// including their chunks.
+func isPartial(name string) bool {
+	return strings.Contains(name, ".tmp.")
+}
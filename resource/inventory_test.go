@@ -0,0 +1,250 @@
+package resource_test
+
+import (
+	"archive/tar"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"code.cloudfoundry.org/cfdev/resource"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Cache inventory", func() {
+	var (
+		tmpDir    string
+		catalog   resource.Catalog
+		cache     *resource.Cache
+		downloads int
+	)
+
+	BeforeEach(func() {
+		var err error
+		tmpDir, err = ioutil.TempDir("", "cfdev-inventory-")
+		Expect(err).NotTo(HaveOccurred())
+
+		downloads = 0
+		catalog = resource.Catalog{
+			Items: []resource.Item{
+				{
+					Name:   "cfdev-deps.tgz",
+					URL:    "cfdev-deps-url",
+					SHA256: "ed7002b439e9ac845f22357d822bac1444730fbdb6016d3ec9432297b9ec9f73", // shasum -a 256 content
+					Size:   7,
+					InUse:  true,
+				},
+			},
+		}
+		cache = &resource.Cache{
+			Dir: tmpDir,
+			HttpDo: func(req *http.Request) (*http.Response, error) {
+				downloads++
+				return &http.Response{
+					StatusCode: 200,
+					Body:       ioutil.NopCloser(strings.NewReader("content")),
+				}, nil
+			},
+			Progress:  &MockProgress{},
+			RetryWait: time.Nanosecond,
+		}
+	})
+
+	AfterEach(func() {
+		os.RemoveAll(tmpDir)
+	})
+
+	// extractFile unpacks a file into the cache the way starting does.
+	extractFile := func(name, content string) {
+		src := filepath.Join(tmpDir, "..", filepath.Base(tmpDir)+".tar")
+		f, err := os.Create(src)
+		Expect(err).NotTo(HaveOccurred())
+		defer os.Remove(src)
+		defer os.Remove(src + ".toc")
+
+		tw := tar.NewWriter(f)
+		Expect(tw.WriteHeader(&tar.Header{Name: name, Mode: 0755, Size: int64(len(content)), Typeflag: tar.TypeReg})).To(Succeed())
+		_, err = tw.Write([]byte(content))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(tw.Close()).To(Succeed())
+		Expect(f.Close()).To(Succeed())
+
+		Expect(resource.Untar(src, []resource.TarOpts{{Include: name, Dst: tmpDir}})).To(Succeed())
+	}
+
+	statuses := func(entries []resource.Entry) map[string]resource.Status {
+		result := map[string]resource.Status{}
+		for _, entry := range entries {
+			result[entry.Name] = entry.Status
+		}
+		return result
+	}
+
+	It("reports missing catalog items", func() {
+		entries, err := cache.List(catalog)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(entries).To(Equal([]resource.Entry{
+			{Name: "cfdev-deps.tgz", Status: resource.StatusMissing, Referenced: true},
+		}))
+	})
+
+	It("uses the checksums recorded while downloading", func() {
+		Expect(cache.Sync(catalog)).To(Succeed())
+		extractFile("linuxkit", "some-binary")
+		createFile(tmpDir, "old-deps.tgz.tmp.abc", "part")
+
+		entries, err := cache.List(catalog)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(entries).To(Equal([]resource.Entry{
+			{Name: "cfdev-deps.tgz", Size: 7, Status: resource.StatusVerified, Referenced: true},
+			{Name: "linuxkit", Size: 11, Status: resource.StatusExtracted},
+			{Name: "old-deps.tgz.tmp.abc", Size: 4, Status: resource.StatusPartial},
+		}))
+	})
+
+	It("reports files that were neither downloaded nor extracted as stale", func() {
+		createFile(tmpDir, "cfdev-efi.iso", "some-old-iso")
+
+		entries, err := cache.List(catalog)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(statuses(entries)).To(HaveKeyWithValue("cfdev-efi.iso", resource.StatusStale))
+	})
+
+	It("does not trust recorded checksums of modified files", func() {
+		Expect(cache.Sync(catalog)).To(Succeed())
+		createFile(tmpDir, "cfdev-deps.tgz", "corrupted")
+
+		entries, err := cache.List(catalog)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(statuses(entries)).To(HaveKeyWithValue("cfdev-deps.tgz", resource.StatusUnverified))
+
+		entries, err = cache.Verify(catalog)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(statuses(entries)).To(HaveKeyWithValue("cfdev-deps.tgz", resource.StatusMismatch))
+
+		entries, err = cache.List(catalog)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(statuses(entries)).To(HaveKeyWithValue("cfdev-deps.tgz", resource.StatusMismatch))
+	})
+
+	Describe("Prune", func() {
+		BeforeEach(func() {
+			Expect(cache.Sync(catalog)).To(Succeed())
+			extractFile("linuxkit", "some-binary")
+			createFile(tmpDir, "cfdev-deps.tgz.tmp.abc", "part")
+
+			// a deps tarball from an older catalog
+			catalog.Items[0].Name = "old-deps.tgz"
+			Expect(cache.Sync(catalog)).To(Succeed())
+			catalog.Items[0].Name = "cfdev-deps.tgz"
+		})
+
+		It("deletes partial downloads and stale items", func() {
+			pruned, err := cache.Prune(catalog, resource.PruneOptions{})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(statuses(pruned)).To(Equal(map[string]resource.Status{
+				"cfdev-deps.tgz.tmp.abc": resource.StatusPartial,
+				"old-deps.tgz":           resource.StatusStale,
+			}))
+
+			Expect(filepath.Join(tmpDir, "old-deps.tgz")).NotTo(BeAnExistingFile())
+			Expect(filepath.Join(tmpDir, "cfdev-deps.tgz.tmp.abc")).NotTo(BeAnExistingFile())
+			Expect(filepath.Join(tmpDir, "cfdev-deps.tgz")).To(BeAnExistingFile())
+			Expect(filepath.Join(tmpDir, "linuxkit")).To(BeAnExistingFile())
+		})
+
+		It("deletes nothing on a dry run", func() {
+			pruned, err := cache.Prune(catalog, resource.PruneOptions{DryRun: true})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(pruned).To(HaveLen(2))
+			Expect(filepath.Join(tmpDir, "old-deps.tgz")).To(BeAnExistingFile())
+		})
+
+		It("deletes extracted files when asked to", func() {
+			pruned, err := cache.Prune(catalog, resource.PruneOptions{Extracted: true})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(pruned).To(HaveLen(3))
+			Expect(filepath.Join(tmpDir, "linuxkit")).NotTo(BeAnExistingFile())
+		})
+	})
+
+	Describe("shared cache", func() {
+		var sharedDir string
+
+		BeforeEach(func() {
+			var err error
+			sharedDir, err = ioutil.TempDir("", "cfdev-shared-")
+			Expect(err).NotTo(HaveOccurred())
+			cache.SharedDir = sharedDir
+		})
+
+		AfterEach(func() {
+			os.RemoveAll(sharedDir)
+		})
+
+		It("stores downloads by content", func() {
+			Expect(cache.Sync(catalog)).To(Succeed())
+
+			Expect(ioutil.ReadFile(filepath.Join(sharedDir, "sha256", catalog.Items[0].SHA256))).To(Equal([]byte("content")))
+		})
+
+		It("reuses items downloaded for another CFDEV_HOME", func() {
+			Expect(cache.Sync(catalog)).To(Succeed())
+			Expect(downloads).To(Equal(1))
+
+			otherDir, err := ioutil.TempDir("", "cfdev-inventory-")
+			Expect(err).NotTo(HaveOccurred())
+			defer os.RemoveAll(otherDir)
+			cache.Dir = otherDir
+
+			Expect(cache.Sync(catalog)).To(Succeed())
+			Expect(downloads).To(Equal(1))
+			Expect(ioutil.ReadFile(filepath.Join(otherDir, "cfdev-deps.tgz"))).To(Equal([]byte("content")))
+		})
+
+		It("does not chmod items linked to the shared cache", func() {
+			Expect(cache.Sync(catalog)).To(Succeed())
+			shared := filepath.Join(sharedDir, "sha256", catalog.Items[0].SHA256)
+			Expect(os.Chmod(shared, 0444)).To(Succeed())
+
+			Expect(cache.Sync(catalog)).To(Succeed())
+			fi, err := os.Stat(shared)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(fi.Mode().Perm()).To(Equal(os.FileMode(0444)))
+		})
+
+		It("does not write local copies through links to the shared cache", func() {
+			Expect(cache.Sync(catalog)).To(Succeed())
+			shared := filepath.Join(sharedDir, "sha256", catalog.Items[0].SHA256)
+
+			Expect(ioutil.WriteFile(filepath.Join(tmpDir, "local-deps.tgz"), []byte("local"), 0644)).To(Succeed())
+			catalog.Items[0].URL = "file://" + filepath.Join(tmpDir, "local-deps.tgz")
+			catalog.Items[0].SHA256 = "0000"
+
+			Expect(cache.Sync(catalog)).To(Succeed())
+			Expect(ioutil.ReadFile(filepath.Join(tmpDir, "cfdev-deps.tgz"))).To(Equal([]byte("local")))
+			Expect(ioutil.ReadFile(shared)).To(Equal([]byte("content")))
+		})
+
+		It("does not share unverified local copies", func() {
+			Expect(ioutil.WriteFile(filepath.Join(sharedDir, "local-deps.tgz"), []byte("not the content"), 0644)).To(Succeed())
+			catalog.Items[0].URL = "file://" + filepath.Join(sharedDir, "local-deps.tgz")
+
+			Expect(cache.Sync(catalog)).To(Succeed())
+			Expect(downloads).To(Equal(0))
+			Expect(filepath.Join(sharedDir, "sha256", catalog.Items[0].SHA256)).NotTo(BeAnExistingFile())
+		})
+
+		It("ignores corrupt shared items", func() {
+			Expect(os.MkdirAll(filepath.Join(sharedDir, "sha256"), 0755)).To(Succeed())
+			createFile(filepath.Join(sharedDir, "sha256"), catalog.Items[0].SHA256, "corrupted")
+
+			Expect(cache.Sync(catalog)).To(Succeed())
+			Expect(downloads).To(Equal(1))
+			Expect(ioutil.ReadFile(filepath.Join(tmpDir, "cfdev-deps.tgz"))).To(Equal([]byte("content")))
+		})
+	})
+})
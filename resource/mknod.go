@@ -0,0 +1,25 @@
+// +build !windows
+
+package resource
+
+import (
+	"archive/tar"
+	"os"
+
+	"golang.org/x/sys/unix"
+)
+
+func mknod(target string, entry TOCEntry, mode os.FileMode) error {
+	var kind uint32
+	switch entry.Type {
+	case tar.TypeChar:
+		kind = unix.S_IFCHR
+	case tar.TypeBlock:
+		kind = unix.S_IFBLK
+	default:
+		kind = unix.S_IFIFO
+	}
+
+	dev := unix.Mkdev(uint32(entry.Devmajor), uint32(entry.Devminor))
+	return unix.Mknod(target, kind|uint32(mode.Perm()), int(dev))
+}
@@ -0,0 +1,10 @@
+package resource
+
+import (
+	"fmt"
+	"os"
+)
+
+func mknod(target string, entry TOCEntry, _ os.FileMode) error {
+	return fmt.Errorf("tar entry '%s' is a device or FIFO, which cannot be created on windows", entry.Name)
+}
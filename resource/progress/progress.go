@@ -4,6 +4,9 @@ import (
 	"fmt"
 	"io"
 	"strings"
+	"time"
+
+	"code.cloudfoundry.org/bytefmt"
 )
 
 type Progress struct {
@@ -11,22 +14,32 @@ type Progress struct {
 	currentLastCompleted uint64
 	total                uint64
 	lastPercentage       int
-	writer               io.Writer
+	renderer             Renderer
+	started              time.Time
+	transferred          uint64
 }
 
+// New draws an interactive progress bar on writer.
 func New(writer io.Writer) *Progress {
-	return &Progress{writer: writer}
+	return NewWithRenderer(NewInteractive(writer))
+}
+
+func NewWithRenderer(renderer Renderer) *Progress {
+	return &Progress{renderer: renderer}
 }
 
 func (c *Progress) Start(total uint64) {
 	c.lastPercentage = -1
 	c.current = 0
 	c.total = total
-	fmt.Fprintf(c.writer, "\rProgress: |%-21s| 0%%", ">")
+	c.started = time.Now()
+	c.transferred = 0
+	c.renderer.Update(fmt.Sprintf("Progress: |%-21s| 0%%", ">"))
 }
 
 func (c *Progress) Write(p []byte) (int, error) {
 	c.current += uint64(len(p))
+	c.transferred += uint64(len(p))
 	c.display()
 	return len(p), nil
 }
@@ -46,12 +59,12 @@ func (c *Progress) ResetCurrent() {
 }
 
 func (c *Progress) End() {
-	fmt.Fprintf(c.writer, "\r\n")
+	c.renderer.End()
 }
 
 func (c *Progress) display() {
 	if c.total == 0 {
-		fmt.Fprintf(c.writer, "\rProgress: %d bytes", c.current)
+		c.renderer.Update(fmt.Sprintf("Progress: %d bytes", c.current))
 		return
 	}
 	percentage := int(c.current * 1000 / c.total)
@@ -60,8 +73,27 @@ func (c *Progress) display() {
 	}
 	c.lastPercentage = percentage
 
-	fmt.Fprintf(c.writer,
-		"\rProgress: |%-21s| %.1f%%",
+	c.renderer.Update(fmt.Sprintf(
+		"Progress: |%-21s| %.1f%%%s",
 		strings.Repeat("=", percentage/50)+">",
-		float64(percentage)/10.0)
+		float64(percentage)/10.0,
+		c.rate()))
+}
+
+// rate estimates the speed from the bytes written since Start, leaving
+// out resumed bytes that were only added. It is blank for the first
+// second, before there is enough to go on.
+func (c *Progress) rate() string {
+	elapsed := time.Since(c.started)
+	if elapsed < time.Second || c.transferred == 0 {
+		return ""
+	}
+
+	speed := float64(c.transferred) / elapsed.Seconds()
+	rate := fmt.Sprintf("  %s/s", bytefmt.ByteSize(uint64(speed)))
+	if c.current < c.total {
+		eta := time.Duration(float64(c.total-c.current) / speed * float64(time.Second))
+		rate += fmt.Sprintf("  ETA %s", eta.Round(time.Second))
+	}
+	return rate
 }
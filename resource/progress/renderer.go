@@ -0,0 +1,123 @@
+package progress
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/crypto/ssh/terminal"
+)
+
+const (
+	ModeInteractive = "interactive"
+	ModePlain       = "plain"
+	ModeSilent      = "silent"
+)
+
+// Renderer draws a status line. Update replaces the current status and
+// End completes it, so that the next Update starts a new one.
+type Renderer interface {
+	Update(line string)
+	End()
+}
+
+// Detect returns the renderer for mode, or when mode is empty, an
+// interactive one for terminals and a plain one for logs and pipes.
+func Detect(writer io.Writer, mode string) Renderer {
+	switch mode {
+	case ModeInteractive:
+		return NewInteractive(writer)
+	case ModePlain:
+		return NewPlain(writer)
+	case ModeSilent:
+		return Silent{}
+	}
+
+	if isTerminal(writer) {
+		return NewInteractive(writer)
+	}
+	return NewPlain(writer)
+}
+
+func isTerminal(writer io.Writer) bool {
+	file, ok := writer.(*os.File)
+	if !ok {
+		// The cf CLI wraps stdout in its own writers.
+		file = os.Stdout
+	}
+	return terminal.IsTerminal(int(file.Fd()))
+}
+
+// Interactive redraws the status in place with a carriage return.
+type Interactive struct {
+	writer  io.Writer
+	lastLen int
+}
+
+func NewInteractive(writer io.Writer) *Interactive {
+	return &Interactive{writer: writer}
+}
+
+func (i *Interactive) Update(line string) {
+	padding := ""
+	if i.lastLen > len(line) {
+		padding = strings.Repeat(" ", i.lastLen-len(line))
+	}
+	i.lastLen = len(line)
+	fmt.Fprintf(i.writer, "\r%s%s", line, padding)
+}
+
+func (i *Interactive) End() {
+	i.lastLen = 0
+	fmt.Fprintf(i.writer, "\r\n")
+}
+
+// Plain writes the status as a line of its own at most once per
+// Interval, and always writes the final status.
+type Plain struct {
+	Interval time.Duration
+
+	writer  io.Writer
+	mutex   sync.Mutex
+	pending string
+	written time.Time
+}
+
+func NewPlain(writer io.Writer) *Plain {
+	return &Plain{writer: writer, Interval: 10 * time.Second}
+}
+
+func (p *Plain) Update(line string) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	p.pending = line
+	if time.Since(p.written) >= p.Interval {
+		p.flush()
+	}
+}
+
+func (p *Plain) End() {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	p.flush()
+	p.written = time.Time{}
+}
+
+func (p *Plain) flush() {
+	if p.pending == "" {
+		return
+	}
+	fmt.Fprintln(p.writer, p.pending)
+	p.pending = ""
+	p.written = time.Now()
+}
+
+type Silent struct{}
+
+func (Silent) Update(string) {}
+func (Silent) End()          {}
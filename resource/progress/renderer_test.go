@@ -0,0 +1,82 @@
+package progress_test
+
+import (
+	"bytes"
+	"time"
+
+	"code.cloudfoundry.org/cfdev/resource/progress"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Renderers", func() {
+	var stdout bytes.Buffer
+
+	BeforeEach(func() {
+		stdout = bytes.Buffer{}
+	})
+
+	Describe("Interactive", func() {
+		It("redraws the line in place, blanking what is left of longer lines", func() {
+			renderer := progress.NewInteractive(&stdout)
+			renderer.Update("Progress: 10 of 12")
+			renderer.Update("Done")
+			renderer.End()
+
+			Expect(stdout.String()).To(Equal("\rProgress: 10 of 12\rDone              \r\n"))
+		})
+	})
+
+	Describe("Plain", func() {
+		It("writes a line per interval and the final status", func() {
+			renderer := progress.NewPlain(&stdout)
+			renderer.Update("Progress: 1 of 3")
+			renderer.Update("Progress: 2 of 3")
+			renderer.Update("Done")
+			renderer.End()
+
+			Expect(stdout.String()).To(Equal("Progress: 1 of 3\nDone\n"))
+		})
+
+		It("starts a new status after End", func() {
+			renderer := progress.NewPlain(&stdout)
+			renderer.Update("first")
+			renderer.End()
+			renderer.Update("second")
+
+			Expect(stdout.String()).To(Equal("first\nsecond\n"))
+		})
+
+		It("writes updates once the interval has passed", func() {
+			renderer := progress.NewPlain(&stdout)
+			renderer.Interval = time.Millisecond
+			renderer.Update("first")
+			time.Sleep(2 * time.Millisecond)
+			renderer.Update("second")
+
+			Expect(stdout.String()).To(Equal("first\nsecond\n"))
+		})
+	})
+
+	Describe("Detect", func() {
+		It("honours the configured mode", func() {
+			Expect(progress.Detect(&stdout, progress.ModeInteractive)).To(BeAssignableToTypeOf(&progress.Interactive{}))
+			Expect(progress.Detect(&stdout, progress.ModePlain)).To(BeAssignableToTypeOf(&progress.Plain{}))
+			Expect(progress.Detect(&stdout, progress.ModeSilent)).To(Equal(progress.Silent{}))
+		})
+	})
+
+	Describe("Progress", func() {
+		It("shows the speed and time remaining after the first second", func() {
+			subject := progress.NewWithRenderer(progress.NewInteractive(&stdout))
+			subject.Start(4000)
+			subject.Write(bytes.Repeat([]byte(" "), 1000))
+			Expect(stdout.String()).NotTo(ContainSubstring("/s"))
+
+			time.Sleep(1100 * time.Millisecond)
+			subject.Write(bytes.Repeat([]byte(" "), 1000))
+			Expect(stdout.String()).To(MatchRegexp(`\| 50\.0%  [0-9.]+[KB]*/s  ETA [0-9]+s`))
+		})
+	})
+})
@@ -0,0 +1,37 @@
+package resource
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+)
+
+// IsRemote reports whether path is a URL the cache can download rather
+// than a local file.
+func IsRemote(path string) bool {
+	for _, scheme := range []string{"http://", "https://", "s3://"} {
+		if strings.HasPrefix(path, scheme) {
+			return true
+		}
+	}
+	return false
+}
+
+// ReadURL returns the contents of a small remote file, such as the
+// checksum published next to a deps file.
+func (c *Cache) ReadURL(url string) ([]byte, error) {
+	req, err := http.NewRequest("GET", url, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := c.do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%s: %s", url, resp.Status)
+	}
+	return ioutil.ReadAll(resp.Body)
+}
@@ -0,0 +1,44 @@
+package retry
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// FromResponse marks err as retryable when resp says that the server is
+// busy or rate limiting, honouring its Retry-After header.
+func FromResponse(resp *http.Response, err error) error {
+	if resp.StatusCode != http.StatusTooManyRequests && resp.StatusCode != http.StatusServiceUnavailable {
+		return err
+	}
+
+	after, _ := ParseRetryAfter(resp.Header.Get("Retry-After"), time.Now())
+	return After(err, after)
+}
+
+// ParseRetryAfter reads a Retry-After header, which is either a number of
+// seconds or an HTTP date.
+func ParseRetryAfter(value string, now time.Time) (time.Duration, bool) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return 0, false
+	}
+
+	if seconds, err := strconv.Atoi(value); err == nil {
+		if seconds < 0 {
+			return 0, false
+		}
+		return time.Duration(seconds) * time.Second, true
+	}
+
+	date, err := http.ParseTime(value)
+	if err != nil {
+		return 0, false
+	}
+	if after := date.Sub(now); after > 0 {
+		return after, true
+	}
+	return 0, true
+}
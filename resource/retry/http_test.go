@@ -0,0 +1,80 @@
+package retry_test
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+	"code.cloudfoundry.org/cfdev/resource/retry"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("HTTP", func() {
+	Describe("FromResponse", func() {
+		response := func(status int, retryAfter string) *http.Response {
+			resp := &http.Response{StatusCode: status, Header: http.Header{}}
+			if retryAfter != "" {
+				resp.Header.Set("Retry-After", retryAfter)
+			}
+			return resp
+		}
+
+		It("retries busy servers after the time they ask for", func() {
+			var waits []time.Duration
+			counter := 0
+			policy := retry.Policy{
+				ShouldRetry: retry.IsRetryable,
+				Notify:      func(attempt retry.Attempt) { waits = append(waits, attempt.Wait) },
+			}
+
+			Expect(retry.Do(context.Background(), policy, func() error {
+				counter++
+				switch counter {
+				case 1:
+					return retry.FromResponse(response(http.StatusTooManyRequests, "0"), fmt.Errorf("429"))
+				case 2:
+					return retry.FromResponse(response(http.StatusServiceUnavailable, ""), fmt.Errorf("503"))
+				}
+				return nil
+			})).To(Succeed())
+			Expect(waits).To(Equal([]time.Duration{0, 0}))
+		})
+
+		It("does not retry other statuses", func() {
+			err := fmt.Errorf("404")
+
+			Expect(retry.IsRetryable(retry.FromResponse(response(http.StatusNotFound, "10"), err))).To(BeFalse())
+		})
+	})
+
+	Describe("ParseRetryAfter", func() {
+		now := time.Date(2018, 10, 1, 12, 0, 0, 0, time.UTC)
+
+		It("reads seconds", func() {
+			after, ok := retry.ParseRetryAfter("120", now)
+			Expect(ok).To(BeTrue())
+			Expect(after).To(Equal(2 * time.Minute))
+		})
+
+		It("reads dates", func() {
+			after, ok := retry.ParseRetryAfter("Mon, 01 Oct 2018 12:00:30 GMT", now)
+			Expect(ok).To(BeTrue())
+			Expect(after).To(Equal(30 * time.Second))
+		})
+
+		It("does not wait for dates in the past", func() {
+			after, ok := retry.ParseRetryAfter("Mon, 01 Oct 2018 11:00:00 GMT", now)
+			Expect(ok).To(BeTrue())
+			Expect(after).To(BeZero())
+		})
+
+		It("ignores invalid values", func() {
+			for _, value := range []string{"", "-1", "soon"} {
+				_, ok := retry.ParseRetryAfter(value, now)
+				Expect(ok).To(BeFalse())
+			}
+		})
+	})
+})
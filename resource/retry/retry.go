@@ -1,46 +1,173 @@
 package retry
 
 import (
+	"context"
 	"fmt"
 	"io"
+	"math/rand"
 	"time"
 )
 
-func Retry(fn func() error, shouldRetry func(error) bool) error {
-	for {
+// Backoff describes the waits between attempts. Each wait is the previous
+// one times Multiplier, up to Max, moved by up to Jitter (a fraction of
+// the wait) either way. A Multiplier of 1 or less keeps the wait at
+// Initial.
+type Backoff struct {
+	Initial    time.Duration
+	Max        time.Duration
+	Multiplier float64
+	Jitter     float64
+	// MaxAttempts and MaxElapsed bound the retries. Zero means unbounded.
+	MaxAttempts int
+	MaxElapsed  time.Duration
+}
+
+// Wait returns how long to wait after the given failed attempt, counting
+// from 1.
+func (b Backoff) Wait(attempt int) time.Duration {
+	wait := float64(b.Initial)
+	for i := 1; i < attempt && b.Multiplier > 1; i++ {
+		wait *= b.Multiplier
+		if b.Max > 0 && wait >= float64(b.Max) {
+			break
+		}
+	}
+	if b.Max > 0 && wait > float64(b.Max) {
+		wait = float64(b.Max)
+	}
+	if b.Jitter > 0 {
+		wait += wait * b.Jitter * (2*rand.Float64() - 1)
+	}
+	return time.Duration(wait)
+}
+
+// Attempt describes a failed attempt that is about to be retried.
+type Attempt struct {
+	Number  int
+	Err     error
+	Elapsed time.Duration
+	Wait    time.Duration
+}
+
+type Policy struct {
+	Backoff
+	// ShouldRetry picks the errors worth retrying. All errors are retried
+	// when it is nil.
+	ShouldRetry func(error) bool
+	// Notify is called before waiting for the next attempt.
+	Notify func(Attempt)
+}
+
+// Do calls fn until it succeeds, returns an error that policy does not
+// retry or runs out of attempts or time, returning the last error. It
+// returns the error of ctx once ctx is done. A nil ctx is never done.
+func Do(ctx context.Context, policy Policy, fn func() error) error {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	start := time.Now()
+	for attempt := 1; ; attempt++ {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
 		err := fn()
 		if err == nil {
 			return nil
-		} else if !shouldRetry(err) {
-			return err
+		}
+		if policy.ShouldRetry != nil && !policy.ShouldRetry(err) {
+			return unwrap(err)
+		}
+		if policy.MaxAttempts > 0 && attempt >= policy.MaxAttempts {
+			return unwrap(err)
+		}
+
+		wait := policy.Wait(attempt)
+		if after, ok := retryAfter(err); ok && after > wait {
+			wait = after
+		}
+		elapsed := time.Since(start)
+		if policy.MaxElapsed > 0 {
+			if elapsed >= policy.MaxElapsed {
+				return unwrap(err)
+			} else if elapsed+wait > policy.MaxElapsed {
+				wait = policy.MaxElapsed - elapsed
+			}
+		}
+
+		if policy.Notify != nil {
+			policy.Notify(Attempt{Number: attempt, Err: unwrap(err), Elapsed: elapsed, Wait: wait})
+		}
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
+
+// Until returns a context that is cancelled once exit is closed, such as
+// the Exit channel that commands get closed on Ctrl-C.
+func Until(exit <-chan struct{}) context.Context {
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		<-exit
+		cancel()
+	}()
+	return ctx
+}
+
+// Log writes a line for every retried attempt to writer, if there is one.
+func Log(writer io.Writer) func(Attempt) {
+	return func(attempt Attempt) {
+		if writer != nil {
+			fmt.Fprintf(writer, "\n------- Failed: Retrying: %d in %s: %s -----\n", attempt.Number, attempt.Wait.Round(time.Millisecond), attempt.Err)
 		}
 	}
 }
 
 type retryable struct {
-	err error
+	err   error
+	after time.Duration
 }
 
 func (e *retryable) Error() string {
 	return e.err.Error()
 }
 
+func (e *retryable) Unwrap() error {
+	return e.err
+}
+
 func WrapAsRetryable(err error) error {
-	return &retryable{err}
+	return &retryable{err: err}
 }
 
-func Retryable(retries int, sleep time.Duration, writer io.Writer) func(error) bool {
-	counter := 0
-	return func(e error) bool {
-		counter++
-		_, isRetry := e.(*retryable)
-		if isRetry && counter < retries {
-			if writer != nil {
-				fmt.Fprintf(writer, "\n------- Failed: Retrying: %d -----\n", counter)
-			}
-			time.Sleep(sleep)
-			return true
-		}
-		return false
+// After marks err as retryable, but not before after has passed.
+func After(err error, after time.Duration) error {
+	return &retryable{err: err, after: after}
+}
+
+// IsRetryable reports whether err was marked with WrapAsRetryable or
+// After, for use as a Policy's ShouldRetry.
+func IsRetryable(err error) bool {
+	_, ok := err.(*retryable)
+	return ok
+}
+
+func retryAfter(err error) (time.Duration, bool) {
+	if e, ok := err.(*retryable); ok && e.after > 0 {
+		return e.after, true
+	}
+	return 0, false
+}
+
+func unwrap(err error) error {
+	if e, ok := err.(*retryable); ok {
+		return e.err
 	}
+	return err
 }
@@ -2,6 +2,7 @@ package retry_test
 
 import (
 	"bytes"
+	"context"
 	"fmt"
 	"time"
 
@@ -11,6 +12,19 @@ import (
 )
 
 var _ = Describe("Retry", func() {
+	var (
+		attempts []retry.Attempt
+		policy   retry.Policy
+	)
+
+	BeforeEach(func() {
+		attempts = nil
+		policy = retry.Policy{
+			Backoff: retry.Backoff{Initial: time.Nanosecond},
+			Notify:  func(attempt retry.Attempt) { attempts = append(attempts, attempt) },
+		}
+	})
+
 	It("retries until success", func() {
 		counter := 0
 		fn := func() error {
@@ -20,20 +34,79 @@ var _ = Describe("Retry", func() {
 			}
 			return nil
 		}
-		retryFn := func(error) bool { return true }
-		Expect(retry.Retry(fn, retryFn)).To(Succeed())
+		Expect(retry.Do(context.Background(), policy, fn)).To(Succeed())
 		Expect(counter).To(Equal(6))
+		Expect(attempts).To(HaveLen(5))
+		Expect(attempts[4].Number).To(Equal(5))
+		Expect(attempts[4].Err).To(MatchError("failing"))
+	})
+
+	It("returns the error if ShouldRetry returns false", func() {
+		counter := 0
+		fn := func() error {
+			counter++
+			return fmt.Errorf("failing")
+		}
+		policy.ShouldRetry = func(error) bool { return false }
+
+		Expect(retry.Do(context.Background(), policy, fn)).To(MatchError("failing"))
+		Expect(counter).To(Equal(1))
+		Expect(attempts).To(BeEmpty())
+	})
+
+	It("retries a max number of times", func() {
+		counter := 0
+		fn := func() error {
+			counter++
+			return fmt.Errorf("failing")
+		}
+		policy.MaxAttempts = 10
+
+		Expect(retry.Do(context.Background(), policy, fn)).To(MatchError("failing"))
+		Expect(counter).To(Equal(10))
+	})
+
+	It("gives up once the max elapsed time has passed", func() {
+		policy.Initial = 10 * time.Millisecond
+		policy.MaxElapsed = 50 * time.Millisecond
+
+		start := time.Now()
+		Expect(retry.Do(context.Background(), policy, func() error { return fmt.Errorf("failing") })).To(MatchError("failing"))
+		Expect(time.Since(start)).To(BeNumerically("<", time.Second))
+		Expect(len(attempts)).To(BeNumerically(">=", 4))
+		Expect(attempts[len(attempts)-1].Elapsed + attempts[len(attempts)-1].Wait).To(BeNumerically("<=", 50*time.Millisecond))
+	})
+
+	It("stops when the context is done", func() {
+		ctx, cancel := context.WithCancel(context.Background())
+		policy.Initial = time.Hour
+		policy.Notify = func(retry.Attempt) { cancel() }
+
+		Expect(retry.Do(ctx, policy, func() error { return fmt.Errorf("failing") })).To(Equal(context.Canceled))
 	})
 
-	It("returns error if retryFn returns false", func() {
-		fn := func() error { return fmt.Errorf("failing") }
-		retryFn := func(error) bool { return false }
+	It("does not start when the context is already done", func() {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		called := false
 
-		Expect(retry.Retry(fn, retryFn)).To(MatchError("failing"))
+		Expect(retry.Do(ctx, policy, func() error { called = true; return nil })).To(Equal(context.Canceled))
+		Expect(called).To(BeFalse())
 	})
 
-	Describe("Retryable", func() {
-		var buffer bytes.Buffer
+	It("stops when the exit channel is closed", func() {
+		exit := make(chan struct{})
+		policy.Initial = time.Hour
+		policy.Notify = func(retry.Attempt) { close(exit) }
+
+		Expect(retry.Do(retry.Until(exit), policy, func() error { return fmt.Errorf("failing") })).To(Equal(context.Canceled))
+	})
+
+	Describe("retryables", func() {
+		BeforeEach(func() {
+			policy.ShouldRetry = retry.IsRetryable
+		})
+
 		It("does not retry other errors", func() {
 			counter := 0
 			fn := func() error {
@@ -41,21 +114,71 @@ var _ = Describe("Retry", func() {
 				return fmt.Errorf("failing")
 			}
 
-			Expect(retry.Retry(fn, retry.Retryable(10, time.Nanosecond, &buffer))).To(MatchError("failing"))
+			Expect(retry.Do(context.Background(), policy, fn)).To(MatchError("failing"))
 			Expect(counter).To(Equal(1))
-			Expect(buffer.String()).NotTo(ContainSubstring("Failed: Retrying:"))
 		})
 
-		It("retries retyables a max number of times", func() {
+		It("retries retryables and returns the wrapped error", func() {
+			err := fmt.Errorf("failing")
+			policy.MaxAttempts = 3
+
+			Expect(retry.Do(context.Background(), policy, func() error { return retry.WrapAsRetryable(err) })).To(BeIdenticalTo(err))
+			Expect(attempts).To(HaveLen(2))
+		})
+
+		It("waits at least as long as asked to", func() {
 			counter := 0
 			fn := func() error {
 				counter++
-				return retry.WrapAsRetryable(fmt.Errorf("failing"))
+				if counter == 1 {
+					return retry.After(fmt.Errorf("busy"), 5*time.Millisecond)
+				}
+				return nil
 			}
 
-			Expect(retry.Retry(fn, retry.Retryable(10, time.Nanosecond, &buffer))).To(MatchError("failing"))
-			Expect(counter).To(Equal(10))
-			Expect(buffer.String()).To(ContainSubstring("Failed: Retrying:"))
+			Expect(retry.Do(context.Background(), policy, fn)).To(Succeed())
+			Expect(attempts).To(HaveLen(1))
+			Expect(attempts[0].Wait).To(Equal(5 * time.Millisecond))
+		})
+	})
+
+	Describe("Backoff", func() {
+		It("grows the waits up to the max", func() {
+			backoff := retry.Backoff{Initial: time.Second, Max: 5 * time.Second, Multiplier: 2}
+
+			Expect(backoff.Wait(1)).To(Equal(time.Second))
+			Expect(backoff.Wait(2)).To(Equal(2 * time.Second))
+			Expect(backoff.Wait(3)).To(Equal(4 * time.Second))
+			Expect(backoff.Wait(4)).To(Equal(5 * time.Second))
+			Expect(backoff.Wait(100)).To(Equal(5 * time.Second))
+		})
+
+		It("keeps the wait constant without a multiplier", func() {
+			backoff := retry.Backoff{Initial: time.Second}
+
+			Expect(backoff.Wait(1)).To(Equal(time.Second))
+			Expect(backoff.Wait(10)).To(Equal(time.Second))
+		})
+
+		It("adds jitter", func() {
+			backoff := retry.Backoff{Initial: time.Second, Jitter: 0.5}
+
+			for i := 0; i < 20; i++ {
+				Expect(backoff.Wait(1)).To(BeNumerically("~", time.Second, 500*time.Millisecond))
+			}
+		})
+	})
+
+	Describe("Log", func() {
+		It("writes a line per retried attempt", func() {
+			var buffer bytes.Buffer
+			retry.Log(&buffer)(retry.Attempt{Number: 2, Err: fmt.Errorf("failing"), Wait: 2 * time.Second})
+
+			Expect(buffer.String()).To(Equal("\n------- Failed: Retrying: 2 in 2s: failing -----\n"))
+		})
+
+		It("writes nothing without a writer", func() {
+			retry.Log(nil)(retry.Attempt{Number: 1, Err: fmt.Errorf("failing")})
 		})
 	})
 })
@@ -0,0 +1,78 @@
+package resource
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+
+	"github.com/minio/minio-go"
+)
+
+const DefaultS3Region = "us-east-1"
+
+// S3Config is the endpoint and credentials used for s3://bucket/key URLs.
+type S3Config struct {
+	Endpoint        string
+	Region          string
+	AccessKeyID     string
+	SecretAccessKey string
+	Insecure        bool
+}
+
+func (c *Cache) do(req *http.Request) (*http.Response, error) {
+	if req.URL.Scheme == "s3" {
+		return c.doS3(req)
+	}
+	return c.HttpDo(req)
+}
+
+// doS3 serves a GET for an s3:// URL as if it were http, including
+// Range headers, so that retries, resumes and chunked downloads work
+// the same for both.
+func (c *Cache) doS3(req *http.Request) (*http.Response, error) {
+	if c.S3.Endpoint == "" {
+		return nil, fmt.Errorf("cannot download %s: CFDEV_S3_ENDPOINT is not set", req.URL)
+	}
+	region := c.S3.Region
+	if region == "" {
+		region = DefaultS3Region
+	}
+	client, err := minio.NewWithRegion(c.S3.Endpoint, c.S3.AccessKeyID, c.S3.SecretAccessKey, !c.S3.Insecure, region)
+	if err != nil {
+		return nil, err
+	}
+	client.SetCustomTransport(roundTripper(c.HttpDo))
+
+	opts := minio.GetObjectOptions{}
+	status := http.StatusOK
+	if byteRange := req.Header.Get("Range"); byteRange != "" {
+		opts.Set("Range", byteRange)
+		status = http.StatusPartialContent
+	}
+
+	body, _, err := minio.Core{Client: client}.GetObject(req.URL.Host, strings.TrimPrefix(req.URL.Path, "/"), opts)
+	if resp := minio.ToErrorResponse(err); resp.StatusCode != 0 {
+		return &http.Response{
+			StatusCode: resp.StatusCode,
+			Status:     fmt.Sprintf("%d %s", resp.StatusCode, resp.Message),
+			Body:       ioutil.NopCloser(strings.NewReader("")),
+		}, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	return &http.Response{
+		StatusCode: status,
+		Status:     fmt.Sprintf("%d %s", status, http.StatusText(status)),
+		Body:       body,
+	}, nil
+}
+
+// roundTripper sends s3 requests through HttpDo so that they use the
+// same proxy, CA and rate limit settings as any other download.
+type roundTripper func(req *http.Request) (*http.Response, error)
+
+func (r roundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	return r(req)
+}
@@ -0,0 +1,110 @@
+package resource_test
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"code.cloudfoundry.org/cfdev/resource"
+)
+
+var _ = Describe("S3 sources", func() {
+	var (
+		tmpDir   string
+		server   *httptest.Server
+		requests []*http.Request
+		cache    *resource.Cache
+		catalog  resource.Catalog
+	)
+
+	BeforeEach(func() {
+		var err error
+		tmpDir, err = ioutil.TempDir("", "s3")
+		Expect(err).NotTo(HaveOccurred())
+
+		requests = nil
+		server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			requests = append(requests, r)
+			if r.URL.Path != "/some-bucket/some/key.tgz" {
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+			w.Header().Set("Last-Modified", time.Now().UTC().Format(http.TimeFormat))
+			w.Header().Set("ETag", `"9a0364b9e99bb480dd25e1f0284c8555"`)
+			w.Write([]byte("content"))
+		}))
+
+		cache = &resource.Cache{
+			Dir:       tmpDir,
+			HttpDo:    http.DefaultClient.Do,
+			Progress:  &MockProgress{},
+			RetryWait: time.Nanosecond,
+			S3: resource.S3Config{
+				Endpoint:        strings.TrimPrefix(server.URL, "http://"),
+				AccessKeyID:     "some-access-key",
+				SecretAccessKey: "some-secret-key",
+				Insecure:        true,
+			},
+		}
+		catalog = resource.Catalog{
+			Items: []resource.Item{{
+				Name:  "some-item",
+				URL:   "s3://some-bucket/some/key.tgz",
+				MD5:   "9a0364b9e99bb480dd25e1f0284c8555", // md5 -s content
+				Size:  7,
+				InUse: true,
+			}},
+		}
+	})
+
+	AfterEach(func() {
+		server.Close()
+		os.RemoveAll(tmpDir)
+	})
+
+	It("downloads the object from the configured endpoint with the credentials", func() {
+		Expect(cache.Sync(catalog)).To(Succeed())
+
+		Expect(ioutil.ReadFile(filepath.Join(tmpDir, "some-item"))).To(Equal([]byte("content")))
+		Expect(requests).To(HaveLen(1))
+		Expect(requests[0].Header.Get("Authorization")).To(ContainSubstring("Credential=some-access-key/"))
+	})
+
+	It("returns an error for missing objects", func() {
+		catalog.Items[0].URL = "s3://some-bucket/missing"
+
+		Expect(cache.Sync(catalog)).To(MatchError(ContainSubstring("404")))
+	})
+
+	It("fails over from s3 to a mirror", func() {
+		catalog.Items[0].URL = "s3://some-bucket/missing"
+		catalog.Items[0].Mirrors = []string{server.URL + "/some-bucket/some/key.tgz"}
+
+		Expect(cache.Sync(catalog)).To(Succeed())
+		Expect(ioutil.ReadFile(filepath.Join(tmpDir, "some-item"))).To(Equal([]byte("content")))
+	})
+
+	Context("when no endpoint is configured", func() {
+		It("returns an error", func() {
+			cache.S3 = resource.S3Config{}
+
+			Expect(cache.Sync(catalog)).To(MatchError(ContainSubstring("CFDEV_S3_ENDPOINT is not set")))
+		})
+	})
+})
+
+var _ = Describe("IsRemote", func() {
+	It("recognises urls the cache can download", func() {
+		Expect(resource.IsRemote("https://example.com/cfdev-deps.tgz")).To(BeTrue())
+		Expect(resource.IsRemote("s3://some-bucket/cfdev-deps.tgz")).To(BeTrue())
+		Expect(resource.IsRemote("/some/cfdev-deps.tgz")).To(BeFalse())
+		Expect(resource.IsRemote("C:\\some\\cfdev-deps.tgz")).To(BeFalse())
+	})
+})
@@ -0,0 +1,86 @@
+package resource
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+)
+
+// sharedPath is the location of the item in the shared cache, which is
+// addressed by content so that several CFDEV_HOMEs can use it.
+func (c *Cache) sharedPath(item *Item) string {
+	algorithm, expected, _ := digest(item)
+	if c.SharedDir == "" || expected == "" {
+		return ""
+	}
+	return filepath.Join(c.SharedDir, algorithm, expected)
+}
+
+// fromShared links a verified copy of the item from the shared cache
+// into the cache dir.
+func (c *Cache) fromShared(item *Item) (bool, error) {
+	path := c.sharedPath(item)
+	if path == "" {
+		return false, nil
+	}
+
+	_, expected, sum := digest(item)
+	if actual, err := sum(path); os.IsNotExist(err) {
+		return false, nil
+	} else if err != nil {
+		return false, err
+	} else if actual != expected {
+		return false, nil
+	}
+
+	dst := filepath.Join(c.Dir, item.Name)
+	os.Remove(dst)
+	if err := linkOrCopy(path, dst); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+func (c *Cache) toShared(item *Item) error {
+	path := c.sharedPath(item)
+	if path == "" {
+		return nil
+	}
+	if exists, err := fileExists(path); err != nil || exists {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+
+	tmpPath := path + ".tmp"
+	os.Remove(tmpPath)
+	if err := linkOrCopy(filepath.Join(c.Dir, item.Name), tmpPath); err != nil {
+		return err
+	}
+	return os.Rename(tmpPath, path)
+}
+
+// linkOrCopy hard links src to dst, falling back to a copy when they are
+// on different file systems.
+func linkOrCopy(src, dst string) error {
+	if err := os.Link(src, dst); err == nil {
+		return nil
+	}
+
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	out, err := os.OpenFile(dst, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0755)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
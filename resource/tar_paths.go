@@ -0,0 +1,106 @@
+package resource
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+const (
+	modeSetuid = 04000
+	modeSetgid = 02000
+)
+
+// checkName rejects entries that would be extracted outside of their
+// destination whatever the options.
+func checkName(name string) error {
+	slashed := filepath.ToSlash(name)
+	if strings.HasPrefix(slashed, "/") || filepath.IsAbs(name) || filepath.VolumeName(name) != "" {
+		return fmt.Errorf("tar entry '%s' has an absolute path", name)
+	}
+	if cleaned := filepath.ToSlash(filepath.Clean(name)); cleaned == ".." || strings.HasPrefix(cleaned, "../") {
+		return fmt.Errorf("tar entry '%s' leaves the destination", name)
+	}
+	return nil
+}
+
+// checkSymlink rejects symlinks that lead outside of dst, following the
+// symlinks that already exist along the way.
+func checkSymlink(entry TOCEntry, target, dst string) error {
+	if strings.HasPrefix(filepath.ToSlash(entry.Linkname), "/") || filepath.IsAbs(entry.Linkname) {
+		return fmt.Errorf("tar entry '%s' is a symlink to the absolute path '%s'", entry.Name, entry.Linkname)
+	}
+	if !within(dst, resolveLink(filepath.Dir(target), entry.Linkname)) {
+		return fmt.Errorf("tar entry '%s' is a symlink to '%s', outside of %s", entry.Name, entry.Linkname, dst)
+	}
+	return nil
+}
+
+// resolveLink follows linkname from dir the way the OS would, as far as
+// the path exists. Joining the two would drop a 'link/..' before link
+// is resolved.
+func resolveLink(dir, linkname string) string {
+	path := resolve(dir)
+	for _, part := range strings.Split(filepath.ToSlash(linkname), "/") {
+		switch part {
+		case "", ".":
+		case "..":
+			path = filepath.Dir(path)
+		default:
+			path = resolve(filepath.Join(path, part))
+		}
+	}
+	return path
+}
+
+// resolve evaluates the symlinks in the part of path that exists.
+func resolve(path string) string {
+	if real, err := filepath.EvalSymlinks(path); err == nil {
+		return real
+	}
+	parent := filepath.Dir(path)
+	if parent == path {
+		return path
+	}
+	return filepath.Join(resolve(parent), filepath.Base(path))
+}
+
+func within(dir, path string) bool {
+	rel, err := filepath.Rel(dir, path)
+	return err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
+
+// fileMode derives the permissions of an entry from its header.
+func fileMode(entry TOCEntry, opt TarOpts) (os.FileMode, error) {
+	mode := os.FileMode(entry.Mode).Perm()
+	if entry.Mode&(modeSetuid|modeSetgid) == 0 || !entry.isRegular() {
+		return mode, nil
+	}
+
+	if !opt.AllowSetuid {
+		return 0, fmt.Errorf("tar entry '%s' is setuid or setgid, which is not allowed", entry.Name)
+	}
+	if entry.Mode&modeSetuid != 0 {
+		mode |= os.ModeSetuid
+	}
+	if entry.Mode&modeSetgid != 0 {
+		mode |= os.ModeSetgid
+	}
+	return mode, nil
+}
+
+// removeExisting makes way for an entry, so that it is not written
+// through a link left at its target.
+func removeExisting(target string) error {
+	fi, err := os.Lstat(target)
+	if os.IsNotExist(err) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+	if fi.IsDir() {
+		return fmt.Errorf("%s is a directory", target)
+	}
+	return os.Remove(target)
+}
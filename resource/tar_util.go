@@ -3,6 +3,7 @@ package resource
 import (
 	"archive/tar"
 	"compress/gzip"
+	"crypto/sha256"
 	"fmt"
 	"io"
 	"os"
@@ -16,93 +17,330 @@ type TarOpts struct {
 	Exclude       string
 	FlattenFolder bool
 	Dst           string
+	// AllowSpecialFiles permits device and FIFO entries and AllowSetuid
+	// setuid and setgid bits. Untar refuses them otherwise.
+	AllowSpecialFiles bool
+	AllowSetuid       bool
 }
 
+// Untar extracts the entries of a gzip, zstd, xz or plain tarball that
+// match dstOpts. The first extraction saves a table of contents next to
+// the tarball. Later ones use it to skip files that were extracted before
+// and have not changed since. Only plain tarballs can seek to the entries
+// that are left; compressed ones are still decompressed up to the last.
+//
+// Entries with absolute paths or paths leaving the destination are
+// rejected, as are links pointing outside of it.
 func Untar(src string, dstOpts []TarOpts) error {
-	var foundEverything bool
-
-	f, err := os.Open(src)
+	fi, err := os.Stat(src)
 	if err != nil {
 		return err
 	}
-	defer f.Close()
 
-	gzr, err := gzip.NewReader(f)
+	x := &extraction{opts: dstOpts, extracted: extractedFiles{}}
+	if toc := readTOC(src, fi); toc != nil {
+		err = x.untarWithTOC(src, toc)
+	} else if toc, err = x.untarAndIndex(src, fi); err == nil {
+		// The table of contents only speeds up later extractions, so
+		// a read-only directory is no reason to fail.
+		writeTOC(src, *toc)
+	}
 	if err != nil {
 		return err
 	}
-	defer gzr.Close()
+	if err := x.checkSymlinks(); err != nil {
+		return err
+	}
+	return x.extracted.save()
+}
+
+// ReadTOC returns the table of contents of a tarball. When it has none
+// yet, the entries are listed without hashing them and nothing is saved
+// next to the tarball.
+func ReadTOC(src string) (*TOC, error) {
+	fi, err := os.Stat(src)
+	if err != nil {
+		return nil, err
+	}
+	if toc := readTOC(src, fi); toc != nil {
+		return toc, nil
+	}
+
+	x := &extraction{extracted: extractedFiles{}, listOnly: true}
+	return x.untarAndIndex(src, fi)
+}
+
+// ReadEntry returns the contents of the regular file called name, which
+// toc locates in the tarball.
+func ReadEntry(src string, toc *TOC, name string) ([]byte, error) {
+	for _, entry := range toc.Entries {
+		if entry.Name != name || !entry.isRegular() {
+			continue
+		}
+
+		stream, err := openTarStream(src)
+		if err != nil {
+			return nil, err
+		}
+		defer stream.Close()
+
+		if err := stream.skipTo(entry.Offset); err != nil {
+			return nil, err
+		}
+		contents := make([]byte, entry.Size)
+		if _, err := io.ReadFull(stream, contents); err != nil {
+			return nil, err
+		}
+		if entry.SHA256 != "" && fmt.Sprintf("%x", sha256.Sum256(contents)) != entry.SHA256 {
+			return nil, fmt.Errorf("%s in %s does not match its table of contents", name, src)
+		}
+		return contents, nil
+	}
+	return nil, fmt.Errorf("%s has no file %s", src, name)
+}
 
-	tr := tar.NewReader(gzr)
+type extraction struct {
+	opts      []TarOpts
+	extracted extractedFiles
+	// symlinks are checked again once everything is extracted, as a
+	// later entry can change where an earlier link leads.
+	symlinks []pendingEntry
+	// listOnly records the entries without hashing or extracting them.
+	listOnly bool
+}
+
+type pendingEntry struct {
+	entry  TOCEntry
+	target string
+	opt    TarOpts
+}
 
+func (x *extraction) untarAndIndex(src string, fi os.FileInfo) (*TOC, error) {
+	stream, err := openTarStream(src)
+	if err != nil {
+		return nil, err
+	}
+	defer stream.Close()
+
+	toc := TOC{Size: fi.Size(), ModTime: fi.ModTime()}
+	tr := tar.NewReader(stream)
 	for {
 		header, err := tr.Next()
 
 		switch {
 		case err == io.EOF:
-			return nil
+			return &toc, nil
 		case err != nil:
-			return err
-		case header == nil:
+			return nil, err
+		case header == nil || header.Typeflag == tar.TypeDir:
 			continue
 		}
 
-		foundEverything, err = copyCurrentFileIfMatch(tr, header, dstOpts)
-		if err != nil {
-			return err
+		entry := TOCEntry{
+			Name:     header.Name,
+			Type:     header.Typeflag,
+			Mode:     header.Mode,
+			Size:     header.Size,
+			Offset:   stream.pos,
+			Linkname: header.Linkname,
+			Devmajor: header.Devmajor,
+			Devminor: header.Devminor,
 		}
-		if foundEverything {
-			return nil
+		if err := checkName(entry.Name); err != nil {
+			return nil, err
 		}
+		if x.listOnly {
+			toc.Entries = append(toc.Entries, entry)
+			continue
+		}
+
+		target, opt := match(entry.Name, x.opts)
+		hash := sha256.New()
+		if target == "" {
+			if _, err := io.Copy(hash, tr); err != nil {
+				return nil, err
+			}
+		} else if err := x.extract(pendingEntry{entry, target, opt}, io.TeeReader(tr, hash)); err != nil {
+			return nil, err
+		}
+
+		if entry.isRegular() {
+			entry.SHA256 = fmt.Sprintf("%x", hash.Sum(nil))
+			if target != "" {
+				if err := x.extracted.record(target, "sha256:"+entry.SHA256); err != nil {
+					return nil, err
+				}
+			}
+		}
+		toc.Entries = append(toc.Entries, entry)
 	}
 }
 
-func copyCurrentFileIfMatch(tr *tar.Reader, header *tar.Header, opts []TarOpts) (bool, error) {
-	for _, opt := range opts {
-		if header.Typeflag == tar.TypeDir {
-			break
+func (x *extraction) untarWithTOC(src string, toc *TOC) error {
+	var pending []pendingEntry
+	for _, entry := range toc.Entries {
+		if err := checkName(entry.Name); err != nil {
+			return err
 		}
-		target := ""
 
-		dir, filename := filepath.Split(header.Name)
-		if opt.IncludeFolder != "" && !strings.Contains(dir, opt.IncludeFolder) {
+		target, opt := match(entry.Name, x.opts)
+		if target == "" || (entry.isRegular() && x.extracted.unchanged(target, "sha256:"+entry.SHA256)) {
 			continue
-		} else if opt.IncludeFolder != "" && strings.Contains(dir, opt.IncludeFolder) {
-			if !opt.FlattenFolder {
-				if err := os.MkdirAll(filepath.Join(opt.Dst, dir), 0755); err != nil {
-					return false, err
-				}
+		}
+		pending = append(pending, pendingEntry{entry, target, opt})
+	}
 
-				target = filepath.Join(opt.Dst, header.Name)
-			} else {
-				target = filepath.Join(opt.Dst, filename)
-			}
-		} else if opt.Include == filepath.Base(header.Name) {
-			if !opt.FlattenFolder {
-				target = filepath.Join(opt.Dst, header.Name)
-			} else {
-				target = filepath.Join(opt.Dst, filepath.Base(header.Name))
-			}
+	var stream *tarStream
+	defer func() {
+		if stream != nil {
+			stream.Close()
 		}
+	}()
 
-		if target != "" {
-			f, err := os.OpenFile(target, os.O_TRUNC|os.O_CREATE|os.O_RDWR, os.FileMode(header.Mode))
-			if err != nil {
-				return false, err
+	for _, p := range pending {
+		if !p.entry.isRegular() {
+			if err := x.extract(p, nil); err != nil {
+				return err
 			}
-			if _, err := io.Copy(f, tr); err != nil {
-				return false, err
-			}
-			f.Close()
+			continue
+		}
 
-			if len(opts) == 1 && opt.Include != "" {
-				return true, nil
+		if stream == nil {
+			var err error
+			if stream, err = openTarStream(src); err != nil {
+				return err
 			}
+		}
+		if err := stream.skipTo(p.entry.Offset); err != nil {
+			return err
+		}
 
-			break
+		hash := sha256.New()
+		if err := x.extract(p, io.TeeReader(io.LimitReader(stream, p.entry.Size), hash)); err != nil {
+			return err
+		}
+		if actual := fmt.Sprintf("%x", hash.Sum(nil)); actual != p.entry.SHA256 {
+			os.Remove(tocPath(src))
+			return fmt.Errorf("%s in %s does not match its table of contents", p.entry.Name, src)
 		}
+		if err := x.extracted.record(p.target, "sha256:"+p.entry.SHA256); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// extract creates the entry at its target. contents are only read for
+// regular files.
+func (x *extraction) extract(p pendingEntry, contents io.Reader) error {
+	entry := p.entry
+	dst, err := filepath.EvalSymlinks(p.opt.Dst)
+	if err != nil {
+		return err
+	}
+	if !within(dst, resolve(filepath.Dir(p.target))) {
+		return fmt.Errorf("tar entry '%s' would be written outside of %s", entry.Name, p.opt.Dst)
+	}
+	if err := os.MkdirAll(filepath.Dir(p.target), 0755); err != nil {
+		return err
+	}
+
+	mode, err := fileMode(entry, p.opt)
+	if err != nil {
+		return err
+	}
+
+	switch entry.Type {
+	case tar.TypeReg, tar.TypeRegA:
+		return writeEntry(p.target, mode, contents)
+	case tar.TypeSymlink:
+		if err := checkSymlink(entry, p.target, dst); err != nil {
+			return err
+		}
+		if err := removeExisting(p.target); err != nil {
+			return err
+		}
+		x.symlinks = append(x.symlinks, p)
+		return os.Symlink(entry.Linkname, p.target)
+	case tar.TypeLink:
+		if err := checkName(entry.Linkname); err != nil {
+			return err
+		}
+		linked, _ := match(entry.Linkname, x.opts)
+		if linked == "" {
+			return fmt.Errorf("tar entry '%s' is a hardlink to '%s', which is not extracted", entry.Name, entry.Linkname)
+		}
+		if !within(dst, resolve(linked)) {
+			return fmt.Errorf("tar entry '%s' is a hardlink to '%s', outside of %s", entry.Name, entry.Linkname, p.opt.Dst)
+		}
+		if err := removeExisting(p.target); err != nil {
+			return err
+		}
+		return os.Link(linked, p.target)
+	case tar.TypeChar, tar.TypeBlock, tar.TypeFifo:
+		if !p.opt.AllowSpecialFiles {
+			return fmt.Errorf("tar entry '%s' is a device or FIFO, which is not allowed", entry.Name)
+		}
+		if err := removeExisting(p.target); err != nil {
+			return err
+		}
+		return mknod(p.target, entry, mode)
+	default:
+		return fmt.Errorf("tar entry '%s' has the unsupported type '%c'", entry.Name, entry.Type)
+	}
+}
+
+func (x *extraction) checkSymlinks() error {
+	for _, p := range x.symlinks {
+		dst, err := filepath.EvalSymlinks(p.opt.Dst)
+		if err != nil {
+			return err
+		}
+		if err := checkSymlink(p.entry, p.target, dst); err != nil {
+			os.Remove(p.target)
+			return err
+		}
+	}
+	return nil
+}
+
+// match returns where the entry called name is extracted to, or an empty
+// string when opts do not include it.
+func match(name string, opts []TarOpts) (string, TarOpts) {
+	dir, filename := filepath.Split(name)
+	for _, opt := range opts {
+		if opt.IncludeFolder != "" && !strings.Contains(dir, opt.IncludeFolder) {
+			continue
+		} else if opt.IncludeFolder != "" || opt.Include == filename {
+			if opt.FlattenFolder {
+				return filepath.Join(opt.Dst, filename), opt
+			}
+			return filepath.Join(opt.Dst, name), opt
+		}
+	}
+	return "", TarOpts{}
+}
+
+func writeEntry(target string, mode os.FileMode, contents io.Reader) error {
+	if err := removeExisting(target); err != nil {
+		return err
+	}
+
+	f, err := os.OpenFile(target, os.O_TRUNC|os.O_CREATE|os.O_WRONLY, mode)
+	if err != nil {
+		return err
+	}
+
+	if _, err := io.Copy(f, contents); err != nil {
+		f.Close()
+		return err
+	}
+	// The mode of an existing file, and the umask, would apply otherwise.
+	if err := f.Chmod(mode); err != nil {
+		f.Close()
+		return err
 	}
-	return false, nil
+	return f.Close()
 }
 
 func Tar(src string, writers ...io.Writer) error {
@@ -0,0 +1,299 @@
+package resource_test
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"code.cloudfoundry.org/cfdev/resource"
+	"github.com/klauspost/compress/zstd"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/ulikunitz/xz"
+)
+
+var _ = Describe("Untar", func() {
+	var (
+		dir  string
+		src  string
+		dst  string
+		opts []resource.TarOpts
+	)
+
+	files := map[string]string{
+		"metadata.yml":          "versions: []",
+		"binaries/linuxkit":     "some-linuxkit",
+		"binaries/vpnkit":       "some-vpnkit",
+		"services/service.file": "some-service",
+	}
+
+	writeTar := func(compress func(io.Writer) io.WriteCloser) {
+		var archive bytes.Buffer
+		tw := tar.NewWriter(&archive)
+		for _, name := range []string{"metadata.yml", "binaries/linuxkit", "binaries/vpnkit", "services/service.file"} {
+			Expect(tw.WriteHeader(&tar.Header{Name: name, Mode: 0644, Size: int64(len(files[name])), Typeflag: tar.TypeReg})).To(Succeed())
+			_, err := tw.Write([]byte(files[name]))
+			Expect(err).NotTo(HaveOccurred())
+		}
+		Expect(tw.Close()).To(Succeed())
+
+		f, err := os.Create(src)
+		Expect(err).NotTo(HaveOccurred())
+		defer f.Close()
+		w := compress(f)
+		_, err = io.Copy(w, &archive)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(w.Close()).To(Succeed())
+	}
+
+	gzipped := func(w io.Writer) io.WriteCloser { return gzip.NewWriter(w) }
+
+	// replaceArchive swaps the tarball for garbage that looks unchanged.
+	replaceArchive := func() {
+		fi, err := os.Stat(src)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(ioutil.WriteFile(src, bytes.Repeat([]byte{0}, int(fi.Size())), 0644)).To(Succeed())
+		Expect(os.Chtimes(src, fi.ModTime(), fi.ModTime())).To(Succeed())
+	}
+
+	BeforeEach(func() {
+		var err error
+		dir, err = ioutil.TempDir("", "cfdev-untar-")
+		Expect(err).NotTo(HaveOccurred())
+		src = filepath.Join(dir, "deps.tgz")
+		dst = filepath.Join(dir, "dst")
+		Expect(os.Mkdir(dst, 0755)).To(Succeed())
+
+		opts = []resource.TarOpts{
+			{Include: "metadata.yml", Dst: dst},
+			{IncludeFolder: "binaries", FlattenFolder: true, Dst: dst},
+		}
+	})
+
+	AfterEach(func() {
+		os.RemoveAll(dir)
+	})
+
+	formats := map[string]func(io.Writer) io.WriteCloser{
+		"gzip": gzipped,
+		"zstd": func(w io.Writer) io.WriteCloser {
+			encoder, err := zstd.NewWriter(w)
+			Expect(err).NotTo(HaveOccurred())
+			return encoder
+		},
+		"xz": func(w io.Writer) io.WriteCloser {
+			xzw, err := xz.NewWriter(w)
+			Expect(err).NotTo(HaveOccurred())
+			return xzw
+		},
+		"no": func(w io.Writer) io.WriteCloser {
+			return nopWriteCloser{w}
+		},
+	}
+
+	for format, compress := range formats {
+		compress := compress
+
+		It("extracts the included entries of tarballs with "+format+" compression", func() {
+			writeTar(compress)
+
+			Expect(resource.Untar(src, opts)).To(Succeed())
+
+			Expect(ioutil.ReadFile(filepath.Join(dst, "metadata.yml"))).To(Equal([]byte("versions: []")))
+			Expect(ioutil.ReadFile(filepath.Join(dst, "linuxkit"))).To(Equal([]byte("some-linuxkit")))
+			Expect(ioutil.ReadFile(filepath.Join(dst, "vpnkit"))).To(Equal([]byte("some-vpnkit")))
+			Expect(filepath.Join(dst, "service.file")).NotTo(BeAnExistingFile())
+			Expect(src + ".toc").To(BeAnExistingFile())
+		})
+	}
+
+	It("lists and reads entries without saving a table of contents", func() {
+		writeTar(gzipped)
+
+		toc, err := resource.ReadTOC(src)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(toc.Entries).To(HaveLen(4))
+		Expect(resource.ReadEntry(src, toc, "binaries/vpnkit")).To(Equal([]byte("some-vpnkit")))
+		Expect(src + ".toc").NotTo(BeAnExistingFile())
+	})
+
+	Context("when the tarball has a table of contents", func() {
+		BeforeEach(func() {
+			writeTar(gzipped)
+			Expect(resource.Untar(src, opts)).To(Succeed())
+		})
+
+		It("extracts single entries using it", func() {
+			other := filepath.Join(dir, "other")
+			Expect(os.Mkdir(other, 0755)).To(Succeed())
+
+			Expect(resource.Untar(src, []resource.TarOpts{{Include: "vpnkit", Dst: other, FlattenFolder: true}})).To(Succeed())
+			Expect(ioutil.ReadFile(filepath.Join(other, "vpnkit"))).To(Equal([]byte("some-vpnkit")))
+		})
+
+		It("does not read the tarball when the extracted files are unchanged", func() {
+			replaceArchive()
+
+			Expect(resource.Untar(src, opts)).To(Succeed())
+		})
+
+		It("extracts files again once they have changed", func() {
+			Expect(ioutil.WriteFile(filepath.Join(dst, "linuxkit"), []byte("tampered"), 0644)).To(Succeed())
+			Expect(os.Remove(filepath.Join(dst, "vpnkit"))).To(Succeed())
+
+			Expect(resource.Untar(src, opts)).To(Succeed())
+			Expect(ioutil.ReadFile(filepath.Join(dst, "linuxkit"))).To(Equal([]byte("some-linuxkit")))
+			Expect(ioutil.ReadFile(filepath.Join(dst, "vpnkit"))).To(Equal([]byte("some-vpnkit")))
+		})
+
+		It("rebuilds it when the tarball changes", func() {
+			files["binaries/linuxkit"] = "new-linuxkit"
+			defer func() { files["binaries/linuxkit"] = "some-linuxkit" }()
+			writeTar(gzipped)
+
+			Expect(resource.Untar(src, opts)).To(Succeed())
+			Expect(ioutil.ReadFile(filepath.Join(dst, "linuxkit"))).To(Equal([]byte("new-linuxkit")))
+		})
+
+		It("fails when the tarball no longer matches it", func() {
+			Expect(os.Remove(filepath.Join(dst, "linuxkit"))).To(Succeed())
+			replaceArchive()
+
+			Expect(resource.Untar(src, opts)).NotTo(Succeed())
+		})
+	})
+
+	Describe("unsafe entries", func() {
+		writeEntries := func(headers ...*tar.Header) {
+			f, err := os.Create(src)
+			Expect(err).NotTo(HaveOccurred())
+			defer f.Close()
+
+			tw := tar.NewWriter(f)
+			for _, header := range headers {
+				if header.Typeflag == tar.TypeReg {
+					header.Size = int64(len(header.Name))
+				}
+				Expect(tw.WriteHeader(header)).To(Succeed())
+				if header.Typeflag == tar.TypeReg {
+					_, err := tw.Write([]byte(header.Name))
+					Expect(err).NotTo(HaveOccurred())
+				}
+			}
+			Expect(tw.Close()).To(Succeed())
+		}
+
+		BeforeEach(func() {
+			opts = []resource.TarOpts{{IncludeFolder: "binaries", Dst: dst}}
+		})
+
+		It("rejects absolute paths", func() {
+			writeEntries(&tar.Header{Name: "/binaries/linuxkit", Mode: 0644, Typeflag: tar.TypeReg})
+
+			Expect(resource.Untar(src, opts)).To(MatchError("tar entry '/binaries/linuxkit' has an absolute path"))
+		})
+
+		It("rejects paths leaving the destination", func() {
+			writeEntries(&tar.Header{Name: "binaries/../../linuxkit", Mode: 0644, Typeflag: tar.TypeReg})
+
+			Expect(resource.Untar(src, opts)).To(MatchError("tar entry 'binaries/../../linuxkit' leaves the destination"))
+			Expect(filepath.Join(dir, "linuxkit")).NotTo(BeAnExistingFile())
+		})
+
+		It("extracts symlinks within the destination", func() {
+			writeEntries(
+				&tar.Header{Name: "binaries/lib/linuxkit", Mode: 0755, Typeflag: tar.TypeReg},
+				&tar.Header{Name: "binaries/linuxkit", Linkname: "lib/linuxkit", Typeflag: tar.TypeSymlink},
+				&tar.Header{Name: "binaries/hardlink", Linkname: "binaries/lib/linuxkit", Typeflag: tar.TypeLink},
+			)
+
+			Expect(resource.Untar(src, opts)).To(Succeed())
+			Expect(ioutil.ReadFile(filepath.Join(dst, "binaries", "linuxkit"))).To(Equal([]byte("binaries/lib/linuxkit")))
+			Expect(ioutil.ReadFile(filepath.Join(dst, "binaries", "hardlink"))).To(Equal([]byte("binaries/lib/linuxkit")))
+		})
+
+		It("rejects symlinks leading outside of the destination", func() {
+			writeEntries(&tar.Header{Name: "binaries/linuxkit", Linkname: "../../linuxkit", Typeflag: tar.TypeSymlink})
+
+			Expect(resource.Untar(src, opts)).To(MatchError(ContainSubstring("tar entry 'binaries/linuxkit' is a symlink to '../../linuxkit', outside of")))
+		})
+
+		It("rejects symlinks to absolute paths", func() {
+			writeEntries(&tar.Header{Name: "binaries/linuxkit", Linkname: "/usr/bin/linuxkit", Typeflag: tar.TypeSymlink})
+
+			Expect(resource.Untar(src, opts)).To(MatchError("tar entry 'binaries/linuxkit' is a symlink to the absolute path '/usr/bin/linuxkit'"))
+		})
+
+		It("rejects symlinks that a later symlink makes lead outside of the destination", func() {
+			writeEntries(
+				&tar.Header{Name: "binaries/escape", Linkname: "dot/../..", Typeflag: tar.TypeSymlink},
+				&tar.Header{Name: "binaries/dot", Linkname: ".", Typeflag: tar.TypeSymlink},
+			)
+
+			Expect(resource.Untar(src, opts)).To(MatchError(ContainSubstring("tar entry 'binaries/escape' is a symlink to 'dot/../..', outside of")))
+			Expect(filepath.Join(dst, "binaries", "escape")).NotTo(BeAnExistingFile())
+		})
+
+		It("does not write through symlinks leading outside of the destination", func() {
+			writeEntries(
+				&tar.Header{Name: "binaries/escape", Linkname: "dot/../..", Typeflag: tar.TypeSymlink},
+				&tar.Header{Name: "binaries/dot", Linkname: ".", Typeflag: tar.TypeSymlink},
+				&tar.Header{Name: "binaries/escape/linuxkit", Mode: 0644, Typeflag: tar.TypeReg},
+			)
+
+			Expect(resource.Untar(src, opts)).To(MatchError(ContainSubstring("tar entry 'binaries/escape/linuxkit' would be written outside of")))
+			Expect(filepath.Join(dir, "linuxkit")).NotTo(BeAnExistingFile())
+		})
+
+		It("rejects hardlinks to files outside of the destination", func() {
+			writeEntries(&tar.Header{Name: "binaries/linuxkit", Linkname: "etc/passwd", Typeflag: tar.TypeLink})
+
+			Expect(resource.Untar(src, opts)).To(MatchError("tar entry 'binaries/linuxkit' is a hardlink to 'etc/passwd', which is not extracted"))
+		})
+
+		It("rejects devices and FIFOs unless they are allowed", func() {
+			writeEntries(&tar.Header{Name: "binaries/fifo", Mode: 0644, Typeflag: tar.TypeFifo})
+
+			Expect(resource.Untar(src, opts)).To(MatchError("tar entry 'binaries/fifo' is a device or FIFO, which is not allowed"))
+		})
+
+		It("rejects setuid files unless they are allowed", func() {
+			writeEntries(&tar.Header{Name: "binaries/linuxkit", Mode: 04755, Typeflag: tar.TypeReg})
+
+			Expect(resource.Untar(src, opts)).To(MatchError("tar entry 'binaries/linuxkit' is setuid or setgid, which is not allowed"))
+
+			opts[0].AllowSetuid = true
+			Expect(resource.Untar(src, opts)).To(Succeed())
+			fi, err := os.Stat(filepath.Join(dst, "binaries", "linuxkit"))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(fi.Mode() & os.ModeSetuid).NotTo(BeZero())
+		})
+
+		It("creates files with the mode of their header", func() {
+			writeEntries(
+				&tar.Header{Name: "binaries/linuxkit", Mode: 0700, Typeflag: tar.TypeReg},
+				&tar.Header{Name: "binaries/config", Mode: 0640, Typeflag: tar.TypeReg},
+			)
+			Expect(os.MkdirAll(filepath.Join(dst, "binaries"), 0755)).To(Succeed())
+			Expect(ioutil.WriteFile(filepath.Join(dst, "binaries", "config"), nil, 0777)).To(Succeed())
+
+			Expect(resource.Untar(src, opts)).To(Succeed())
+			fi, err := os.Stat(filepath.Join(dst, "binaries", "linuxkit"))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(fi.Mode().Perm()).To(Equal(os.FileMode(0700)))
+			fi, err = os.Stat(filepath.Join(dst, "binaries", "config"))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(fi.Mode().Perm()).To(Equal(os.FileMode(0640)))
+		})
+	})
+})
+
+type nopWriteCloser struct {
+	io.Writer
+}
+
+func (nopWriteCloser) Close() error { return nil }
@@ -0,0 +1,71 @@
+package resource
+
+import (
+	"archive/tar"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"time"
+)
+
+// TOC is the table of contents of a tarball. It is saved next to the
+// tarball as <tarball>.toc the first time the tarball is extracted, and
+// lets later extractions go straight to the entries they need.
+type TOC struct {
+	Size    int64      `json:"size"`
+	ModTime time.Time  `json:"mod_time"`
+	Entries []TOCEntry `json:"entries"`
+}
+
+// TOCEntry describes an entry of the tarball other than a directory.
+// Offset is the position of its contents in the uncompressed tar stream.
+type TOCEntry struct {
+	Name     string `json:"name"`
+	Type     byte   `json:"type,omitempty"`
+	Mode     int64  `json:"mode"`
+	Size     int64  `json:"size"`
+	Offset   int64  `json:"offset"`
+	SHA256   string `json:"sha256,omitempty"`
+	Linkname string `json:"linkname,omitempty"`
+	Devmajor int64  `json:"devmajor,omitempty"`
+	Devminor int64  `json:"devminor,omitempty"`
+}
+
+func (e TOCEntry) isRegular() bool {
+	return e.Type == tar.TypeReg || e.Type == tar.TypeRegA
+}
+
+func tocPath(src string) string {
+	return src + ".toc"
+}
+
+// readTOC returns nil when the tarball has no table of contents or has
+// changed since it was written.
+func readTOC(src string, fi os.FileInfo) *TOC {
+	contents, err := ioutil.ReadFile(tocPath(src))
+	if err != nil {
+		return nil
+	}
+
+	var toc TOC
+	if err := json.Unmarshal(contents, &toc); err != nil {
+		return nil
+	}
+	if toc.Size != fi.Size() || !toc.ModTime.Equal(fi.ModTime()) {
+		return nil
+	}
+	return &toc
+}
+
+func writeTOC(src string, toc TOC) error {
+	contents, err := json.Marshal(toc)
+	if err != nil {
+		return err
+	}
+
+	tmpPath := tocPath(src) + ".tmp"
+	if err := ioutil.WriteFile(tmpPath, contents, 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmpPath, tocPath(src))
+}
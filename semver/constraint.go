@@ -0,0 +1,260 @@
+package semver
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Constraint is a range of versions in the syntax of npm and Cargo:
+// comparisons such as ">=1.2.0 <2" (a comma works as well as a space),
+// "~1.2.3", "^1.2", wildcards such as "1.x" and hyphen ranges such as
+// "1.2 - 1.4", with "||" between alternatives.
+//
+// A pre-release only satisfies a range that names a pre-release of the
+// same major, minor and patch numbers, so that ">=1.0.0" does not pick
+// up 2.0.0-alpha.
+type Constraint struct {
+	sets     [][]comparator
+	original string
+}
+
+type comparator struct {
+	op      string
+	version *Version
+}
+
+var operators = []string{">=", "<=", "!=", ">", "<", "=", "~", "^"}
+
+func NewConstraint(c string) (*Constraint, error) {
+	constraint := &Constraint{original: c}
+	for _, alternative := range strings.Split(c, "||") {
+		set, err := parseSet(alternative)
+		if err != nil {
+			return nil, fmt.Errorf("invalid version range '%s': %s", c, err)
+		}
+		constraint.sets = append(constraint.sets, set)
+	}
+	return constraint, nil
+}
+
+// Check reports whether v is within the range.
+func (c *Constraint) Check(v *Version) bool {
+	for _, set := range c.sets {
+		if matches(set, v) {
+			return true
+		}
+	}
+	return false
+}
+
+func (c *Constraint) String() string {
+	return c.original
+}
+
+func matches(set []comparator, v *Version) bool {
+	for _, cmp := range set {
+		if !cmp.matches(v) {
+			return false
+		}
+	}
+	if len(v.PreRelease) == 0 {
+		return true
+	}
+
+	for _, cmp := range set {
+		other := cmp.version
+		if len(other.PreRelease) > 0 && other.Major == v.Major && other.Minor == v.Minor && other.Build == v.Build {
+			return true
+		}
+	}
+	return false
+}
+
+func (c comparator) matches(v *Version) bool {
+	result := v.Compare(c.version)
+	switch c.op {
+	case "=":
+		return result == 0
+	case "!=":
+		return result != 0
+	case ">":
+		return result > 0
+	case ">=":
+		return result >= 0
+	case "<":
+		return result < 0
+	case "<=":
+		return result <= 0
+	}
+	return false
+}
+
+func parseSet(s string) ([]comparator, error) {
+	terms := strings.Fields(strings.Replace(s, ",", " ", -1))
+	set := []comparator{}
+	for i := 0; i < len(terms); i++ {
+		term := terms[i]
+		// Allow a space between an operator and its version.
+		if isOperator(term) && i+1 < len(terms) {
+			i++
+			term += terms[i]
+		}
+
+		if i+2 < len(terms) && terms[i+1] == "-" {
+			comparators, err := hyphenRange(term, terms[i+2])
+			if err != nil {
+				return nil, err
+			}
+			set = append(set, comparators...)
+			i += 2
+			continue
+		}
+
+		comparators, err := parseTerm(term)
+		if err != nil {
+			return nil, err
+		}
+		set = append(set, comparators...)
+	}
+	return set, nil
+}
+
+func isOperator(s string) bool {
+	for _, op := range operators {
+		if s == op {
+			return true
+		}
+	}
+	return false
+}
+
+// partial is a version whose trailing numbers may be missing or wildcards.
+// parts counts the numbers that are given.
+type partial struct {
+	version *Version
+	parts   int
+}
+
+func parsePartial(s string) (partial, error) {
+	s = strings.TrimPrefix(s, "v")
+	if s == "" || s == "*" || s == "x" || s == "X" {
+		return partial{version: &Version{}}, nil
+	}
+
+	core := s
+	if i := strings.IndexAny(core, "-+"); i >= 0 {
+		core = core[:i]
+	}
+	numbers := strings.Split(core, ".")
+	parts := len(numbers)
+	for i, number := range numbers {
+		if number == "*" || number == "x" || number == "X" {
+			parts = i
+			break
+		}
+	}
+	if parts < len(numbers) && core != s {
+		return partial{}, fmt.Errorf("'%s' has a wildcard and a pre-release", s)
+	}
+
+	version, err := New(strings.Join(numbers[:parts], ".") + s[len(core):])
+	if err != nil {
+		return partial{}, err
+	}
+	return partial{version: version, parts: parts}, nil
+}
+
+// next returns the first version after every version that p matches.
+func (p partial) next() *Version {
+	switch p.parts {
+	case 1:
+		return &Version{Major: p.version.Major + 1}
+	case 2:
+		return &Version{Major: p.version.Major, Minor: p.version.Minor + 1}
+	}
+	return &Version{Major: p.version.Major, Minor: p.version.Minor, Build: p.version.Build + 1}
+}
+
+func parseTerm(term string) ([]comparator, error) {
+	op := ""
+	for _, candidate := range operators {
+		if strings.HasPrefix(term, candidate) {
+			op = candidate
+			break
+		}
+	}
+	if op != "" && term == op {
+		return nil, fmt.Errorf("'%s' has no version", term)
+	}
+	p, err := parsePartial(term[len(op):])
+	if err != nil {
+		return nil, err
+	}
+	v := p.version
+
+	if p.parts == 0 {
+		switch op {
+		case ">", "<", "!=":
+			return nil, fmt.Errorf("'%s' matches no version", term)
+		}
+		return nil, nil
+	}
+
+	switch op {
+	case "", "=":
+		if p.parts == 3 {
+			return []comparator{{"=", v}}, nil
+		}
+		return []comparator{{">=", v}, {"<", p.next()}}, nil
+	case "!=":
+		return []comparator{{"!=", v}}, nil
+	case ">":
+		if p.parts == 3 {
+			return []comparator{{">", v}}, nil
+		}
+		return []comparator{{">=", p.next()}}, nil
+	case ">=":
+		return []comparator{{">=", v}}, nil
+	case "<":
+		return []comparator{{"<", v}}, nil
+	case "<=":
+		if p.parts == 3 {
+			return []comparator{{"<=", v}}, nil
+		}
+		return []comparator{{"<", p.next()}}, nil
+	case "~":
+		if p.parts == 1 {
+			return []comparator{{">=", v}, {"<", p.next()}}, nil
+		}
+		return []comparator{{">=", v}, {"<", &Version{Major: v.Major, Minor: v.Minor + 1}}}, nil
+	case "^":
+		switch {
+		case v.Major > 0 || p.parts == 1:
+			return []comparator{{">=", v}, {"<", &Version{Major: v.Major + 1}}}, nil
+		case v.Minor > 0 || p.parts == 2:
+			return []comparator{{">=", v}, {"<", &Version{Minor: v.Minor + 1}}}, nil
+		}
+		return []comparator{{">=", v}, {"<", &Version{Build: v.Build + 1}}}, nil
+	}
+	return nil, fmt.Errorf("'%s' has an unknown operator", term)
+}
+
+func hyphenRange(from, to string) ([]comparator, error) {
+	lower, err := parsePartial(from)
+	if err != nil {
+		return nil, err
+	}
+	upper, err := parsePartial(to)
+	if err != nil {
+		return nil, err
+	}
+
+	set := []comparator{{">=", lower.version}}
+	switch upper.parts {
+	case 0:
+		return set, nil
+	case 3:
+		return append(set, comparator{"<=", upper.version}), nil
+	}
+	return append(set, comparator{"<", upper.next()}), nil
+}
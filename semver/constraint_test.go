@@ -0,0 +1,62 @@
+package semver_test
+
+import (
+	"code.cloudfoundry.org/cfdev/semver"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Constraint", func() {
+	check := func(constraint string, matching []string, other []string) {
+		c, err := semver.NewConstraint(constraint)
+		Expect(err).NotTo(HaveOccurred())
+		for _, v := range matching {
+			Expect(c.Check(semver.Must(semver.New(v)))).To(BeTrue(), v+" should be within "+constraint)
+		}
+		for _, v := range other {
+			Expect(c.Check(semver.Must(semver.New(v)))).To(BeFalse(), v+" should not be within "+constraint)
+		}
+	}
+
+	It("checks comparisons", func() {
+		check(">=1.2.0 <2", []string{"1.2.0", "1.9.9"}, []string{"1.1.9", "2.0.0"})
+		check(">= 1.2.0, < 2.0.0", []string{"1.2.0", "1.9.9"}, []string{"1.1.9", "2.0.0"})
+		check("=1.2.3", []string{"1.2.3", "1.2.3+build"}, []string{"1.2.4"})
+		check("!=1.2.3", []string{"1.2.4"}, []string{"1.2.3"})
+		check(">1.2", []string{"1.3.0"}, []string{"1.2.9"})
+		check("<=1.2", []string{"1.2.9"}, []string{"1.3.0"})
+	})
+
+	It("checks tilde and caret ranges", func() {
+		check("~1.2.3", []string{"1.2.3", "1.2.9"}, []string{"1.2.2", "1.3.0"})
+		check("~1", []string{"1.0.0", "1.9.0"}, []string{"2.0.0"})
+		check("^1.2.3", []string{"1.2.3", "1.9.0"}, []string{"1.2.2", "2.0.0"})
+		check("^0.2.3", []string{"0.2.3", "0.2.9"}, []string{"0.3.0"})
+		check("^0.0.3", []string{"0.0.3"}, []string{"0.0.4"})
+	})
+
+	It("checks wildcards and hyphen ranges", func() {
+		check("1.x", []string{"1.0.0", "1.9.9"}, []string{"0.9.9", "2.0.0"})
+		check("1.2.*", []string{"1.2.0", "1.2.9"}, []string{"1.3.0"})
+		check("*", []string{"0.0.1", "9.9.9"}, nil)
+		check("", []string{"0.0.1"}, nil)
+		check("1.2 - 1.4", []string{"1.2.0", "1.4.9"}, []string{"1.1.9", "1.5.0"})
+		check("1.2.3 - 1.4.5", []string{"1.2.3", "1.4.5"}, []string{"1.4.6"})
+	})
+
+	It("checks alternatives", func() {
+		check("1.x || >=3.1", []string{"1.5.0", "3.1.0"}, []string{"2.0.0", "3.0.0"})
+	})
+
+	It("only matches pre-releases that the range names", func() {
+		check(">=1.0.0", []string{"1.0.0"}, []string{"2.0.0-alpha"})
+		check(">=1.2.3-beta <2", []string{"1.2.3-beta", "1.2.3-rc.1", "1.5.0"}, []string{"1.2.3-alpha", "1.5.0-beta"})
+	})
+
+	It("rejects invalid ranges", func() {
+		for _, c := range []string{">=a", "1.x-beta", ">*", ">=", "1.2.3.4"} {
+			_, err := semver.NewConstraint(c)
+			Expect(err).To(HaveOccurred(), c)
+		}
+	})
+})
@@ -1,39 +1,69 @@
 package semver
 
 import (
+	"fmt"
 	"strconv"
 	"strings"
 )
 
+// Version is a semantic version. Build is the patch number; build
+// metadata, which plays no part in comparisons, is kept in Metadata.
 type Version struct {
-	Major    int
-	Minor    int
-	Build    int
-	Original string
+	Major      int
+	Minor      int
+	Build      int
+	PreRelease []string
+	Metadata   string
+	Original   string
 }
 
+// New parses a semantic version such as 1.2.3-beta.1+sha.5114f85. A
+// leading v is ignored, as are missing minor and patch numbers, so that
+// "v1.2" is 1.2.0. The empty string is 0.0.0.
 func New(v string) (*Version, error) {
+	return parse(v, true)
+}
+
+// NewLenient parses v as New does, but allows numeric pre-release
+// identifiers with leading zeros, such as the 0.0.20181019-090501 that
+// development builds of the plugin are versioned with.
+func NewLenient(v string) (*Version, error) {
+	return parse(v, false)
+}
+
+func parse(v string, strict bool) (*Version, error) {
 	s := &Version{Original: v}
-	arr := strings.SplitN(v, ".", 3)
-	var err error
-	if len(arr) >= 1 && v != "" {
-		s.Major, err = strconv.Atoi(arr[0])
-		if err != nil {
-			return nil, err
+	if v == "" {
+		return s, nil
+	}
+
+	rest := strings.TrimPrefix(v, "v")
+	if i := strings.Index(rest, "+"); i >= 0 {
+		s.Metadata = rest[i+1:]
+		rest = rest[:i]
+		if err := checkIdentifiers(s.Metadata, false); err != nil {
+			return nil, fmt.Errorf("invalid version '%s': build metadata %s", v, err)
 		}
 	}
-	if len(arr) >= 2 {
-		s.Minor, err = strconv.Atoi(arr[1])
-		if err != nil {
-			return nil, err
+	if i := strings.Index(rest, "-"); i >= 0 {
+		pre := rest[i+1:]
+		rest = rest[:i]
+		if err := checkIdentifiers(pre, strict); err != nil {
+			return nil, fmt.Errorf("invalid version '%s': pre-release %s", v, err)
 		}
+		s.PreRelease = strings.Split(pre, ".")
 	}
-	if len(arr) >= 3 {
-		parts := strings.SplitN(arr[2], "-", 2)
-		s.Build, err = strconv.Atoi(parts[0])
+
+	numbers := strings.Split(rest, ".")
+	if len(numbers) > 3 {
+		return nil, fmt.Errorf("invalid version '%s': more than three numbers", v)
+	}
+	for i, field := range []*int{&s.Major, &s.Minor, &s.Build}[:len(numbers)] {
+		n, err := parseNumber(numbers[i])
 		if err != nil {
-			return nil, err
+			return nil, fmt.Errorf("invalid version '%s': %s", v, err)
 		}
+		*field = n
 	}
 	return s, nil
 }
@@ -44,3 +74,115 @@ func Must(v *Version, err error) *Version {
 	}
 	return v
 }
+
+// Compare returns -1, 0 or 1 when v is older than, the same as or newer
+// than other. Pre-releases come before their release and build metadata
+// is ignored.
+func (v *Version) Compare(other *Version) int {
+	for _, pair := range [][2]int{
+		{v.Major, other.Major},
+		{v.Minor, other.Minor},
+		{v.Build, other.Build},
+	} {
+		if pair[0] < pair[1] {
+			return -1
+		} else if pair[0] > pair[1] {
+			return 1
+		}
+	}
+	return comparePreRelease(v.PreRelease, other.PreRelease)
+}
+
+func (v *Version) String() string {
+	s := fmt.Sprintf("%d.%d.%d", v.Major, v.Minor, v.Build)
+	if len(v.PreRelease) > 0 {
+		s += "-" + strings.Join(v.PreRelease, ".")
+	}
+	if v.Metadata != "" {
+		s += "+" + v.Metadata
+	}
+	return s
+}
+
+func comparePreRelease(a, b []string) int {
+	switch {
+	case len(a) == 0 && len(b) == 0:
+		return 0
+	case len(a) == 0:
+		return 1
+	case len(b) == 0:
+		return -1
+	}
+
+	for i := 0; i < len(a) && i < len(b); i++ {
+		if c := compareIdentifier(a[i], b[i]); c != 0 {
+			return c
+		}
+	}
+	switch {
+	case len(a) < len(b):
+		return -1
+	case len(a) > len(b):
+		return 1
+	}
+	return 0
+}
+
+// compareIdentifier orders numeric identifiers numerically and before
+// alphanumeric ones, which are ordered by their ASCII values.
+func compareIdentifier(a, b string) int {
+	an, aErr := strconv.Atoi(a)
+	bn, bErr := strconv.Atoi(b)
+	switch {
+	case aErr == nil && bErr == nil:
+		switch {
+		case an < bn:
+			return -1
+		case an > bn:
+			return 1
+		}
+		return 0
+	case aErr == nil:
+		return -1
+	case bErr == nil:
+		return 1
+	}
+	return strings.Compare(a, b)
+}
+
+func parseNumber(s string) (int, error) {
+	if s == "" {
+		return 0, fmt.Errorf("empty number")
+	}
+	for _, c := range s {
+		if c < '0' || c > '9' {
+			return 0, fmt.Errorf("'%s' is not a number", s)
+		}
+	}
+	if len(s) > 1 && s[0] == '0' {
+		return 0, fmt.Errorf("'%s' has a leading zero", s)
+	}
+	return strconv.Atoi(s)
+}
+
+func checkIdentifiers(s string, numeric bool) error {
+	for _, id := range strings.Split(s, ".") {
+		if id == "" {
+			return fmt.Errorf("'%s' has an empty identifier", s)
+		}
+		digits := true
+		for _, c := range id {
+			switch {
+			case c >= '0' && c <= '9':
+			case c >= 'a' && c <= 'z', c >= 'A' && c <= 'Z', c == '-':
+				digits = false
+			default:
+				return fmt.Errorf("'%s' has an invalid character '%c'", s, c)
+			}
+		}
+		if numeric && digits && len(id) > 1 && id[0] == '0' {
+			return fmt.Errorf("'%s' has a leading zero", s)
+		}
+	}
+	return nil
+}
@@ -33,4 +33,62 @@ var _ = Describe("Semver", func() {
 		Expect(s.Build).To(Equal(0))
 		Expect(s.Original).To(Equal(""))
 	})
+
+	It("compares versions", func() {
+		Expect(semver.Must(semver.New("1.2.3")).Compare(semver.Must(semver.New("1.10.0")))).To(Equal(-1))
+		Expect(semver.Must(semver.New("2.0.0")).Compare(semver.Must(semver.New("1.10.0")))).To(Equal(1))
+		Expect(semver.Must(semver.New("1.2.3-patch-1")).Compare(semver.Must(semver.New("1.2.3")))).To(Equal(-1))
+		Expect(semver.Must(semver.New("1.2.3+build.5")).Compare(semver.Must(semver.New("1.2.3")))).To(Equal(0))
+	})
+
+	It("parses pre-releases and build metadata", func() {
+		s, err := semver.New("v1.2.3-rc.1+sha.5114f85")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(s.PreRelease).To(Equal([]string{"rc", "1"}))
+		Expect(s.Metadata).To(Equal("sha.5114f85"))
+		Expect(s.String()).To(Equal("1.2.3-rc.1+sha.5114f85"))
+	})
+
+	It("fills in missing numbers", func() {
+		Expect(semver.Must(semver.New("v1.2")).String()).To(Equal("1.2.0"))
+	})
+
+	It("rejects invalid versions", func() {
+		for _, v := range []string{"1.2.3.4", "1.a.3", "01.2.3", "1.2.3-", "1.2.3-rc..1", "1.2.3-01", "1.2.3+b_1"} {
+			_, err := semver.New(v)
+			Expect(err).To(HaveOccurred(), v)
+		}
+	})
+
+	It("leniently parses the versions of development builds", func() {
+		// generate-plugin.sh versions builds as 0.0.$(date +%Y%m%d-%H%M%S)
+		_, err := semver.New("0.0.20181019-090501")
+		Expect(err).To(HaveOccurred())
+
+		morning := semver.Must(semver.NewLenient("0.0.20181019-090501"))
+		Expect(morning.Build).To(Equal(20181019))
+		Expect(morning.Original).To(Equal("0.0.20181019-090501"))
+		Expect(morning.Compare(semver.Must(semver.NewLenient("0.0.20181019-100000")))).To(Equal(-1))
+
+		_, err = semver.NewLenient("1.2.3-rc..1")
+		Expect(err).To(HaveOccurred())
+	})
+
+	It("orders pre-releases as SemVer does", func() {
+		ordered := []string{
+			"1.0.0-alpha",
+			"1.0.0-alpha.1",
+			"1.0.0-alpha.beta",
+			"1.0.0-beta",
+			"1.0.0-beta.2",
+			"1.0.0-beta.11",
+			"1.0.0-rc.1",
+			"1.0.0",
+		}
+		for i := 0; i < len(ordered)-1; i++ {
+			older, newer := semver.Must(semver.New(ordered[i])), semver.Must(semver.New(ordered[i+1]))
+			Expect(older.Compare(newer)).To(Equal(-1), ordered[i]+" < "+ordered[i+1])
+			Expect(newer.Compare(older)).To(Equal(1), ordered[i+1]+" > "+ordered[i])
+		}
+	})
 })
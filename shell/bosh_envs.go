@@ -1,61 +1,43 @@
 package shell
 
 import (
-	"fmt"
-	"os"
-	"strings"
-
 	"code.cloudfoundry.org/cfdev/bosh"
-
-	"bytes"
-
-	"runtime"
 )
 
 type Environment struct {
+	Format Format
 }
 
 func (e *Environment) Prepare(config bosh.Config) (string, error) {
-	order := []string{
-		"BOSH_ENVIRONMENT",
-		"BOSH_CLIENT",
-		"BOSH_CLIENT_SECRET",
-		"BOSH_CA_CERT",
-		"BOSH_GW_HOST",
-		"BOSH_GW_PRIVATE_KEY",
-		"BOSH_GW_USER",
-	}
-
-	values := map[string]string{
-		"BOSH_ENVIRONMENT":    config.DirectorAddress,
-		"BOSH_CLIENT":         config.AdminUsername,
-		"BOSH_CLIENT_SECRET":  config.AdminPassword,
-		"BOSH_CA_CERT":        config.CACertificate,
-		"BOSH_GW_HOST":        config.GatewayHost,
-		"BOSH_GW_PRIVATE_KEY": config.GatewayPrivateKey,
-		"BOSH_GW_USER":        config.GatewayUsername,
+	vars := []Var{
+		{"BOSH_ENVIRONMENT", config.DirectorAddress},
+		{"BOSH_CLIENT", config.AdminUsername},
+		{"BOSH_CLIENT_SECRET", config.AdminPassword},
+		{"BOSH_CA_CERT", config.CACertificate},
+		{"BOSH_GW_HOST", config.GatewayHost},
+		{"BOSH_GW_PRIVATE_KEY", config.GatewayPrivateKey},
+		{"BOSH_GW_USER", config.GatewayUsername},
 	}
-
-	var output bytes.Buffer
-
-	for _, envvar := range os.Environ() {
-		if strings.HasPrefix(envvar, "BOSH_") {
-			envvar = strings.Split(envvar, "=")[0]
-			if runtime.GOOS != "windows" {
-				fmt.Fprintf(&output, "unset %s;\n", envvar)
-			} else {
-				fmt.Fprintf(&output, "Remove-Item Env:%s;\n", envvar)
-			}
-		}
+	if config.AllProxy != "" {
+		vars = append(vars, Var{"BOSH_ALL_PROXY", config.AllProxy})
 	}
+	return e.Script("BOSH_", vars)
+}
 
-	for _, name := range order {
-		if runtime.GOOS != "windows" {
-			fmt.Fprintf(&output, "export %s=\"%s\";\n", name, values[name])
-		} else {
-			fmt.Fprintf(&output, "$env:%s=\"%s\";\n", name, values[name])
-		}
-	}
+func (e *Environment) PrepareCredhub(config bosh.CredhubConfig) (string, error) {
+	return e.Script("CREDHUB_", []Var{
+		{"CREDHUB_SERVER", config.Server},
+		{"CREDHUB_CLIENT", config.Client},
+		{"CREDHUB_SECRET", config.Secret},
+		{"CREDHUB_CA_CERT", config.CACertificate},
+	})
+}
 
-	return strings.TrimSpace(output.String()), nil
+func (e *Environment) PrepareUAA(config bosh.UAAConfig) (string, error) {
+	return e.Script("UAA_", []Var{
+		{"UAA_URL", config.URL},
+		{"UAA_CLIENT", config.Client},
+		{"UAA_CLIENT_SECRET", config.Secret},
+		{"UAA_CA_CERT", config.CACertificate},
+	})
 }
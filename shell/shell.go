@@ -0,0 +1,132 @@
+package shell
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"runtime"
+	"strings"
+)
+
+type Format string
+
+const (
+	Bash       Format = "bash"
+	Fish       Format = "fish"
+	Cmd        Format = "cmd"
+	PowerShell Format = "powershell"
+	Dotenv     Format = "dotenv"
+	JSON       Format = "json"
+)
+
+var Formats = []Format{Bash, Fish, Cmd, PowerShell, Dotenv, JSON}
+
+func DefaultFormat() Format {
+	if runtime.GOOS == "windows" {
+		return PowerShell
+	}
+	return Bash
+}
+
+func ParseFormat(name string) (Format, error) {
+	switch strings.ToLower(name) {
+	case "":
+		return DefaultFormat(), nil
+	case "bash", "zsh", "sh":
+		return Bash, nil
+	case "fish":
+		return Fish, nil
+	case "cmd", "cmd.exe":
+		return Cmd, nil
+	case "powershell", "pwsh":
+		return PowerShell, nil
+	case "dotenv", ".env":
+		return Dotenv, nil
+	case "json":
+		return JSON, nil
+	}
+
+	var names []string
+	for _, f := range Formats {
+		names = append(names, string(f))
+	}
+	return "", fmt.Errorf("unknown shell '%s', expected one of: %s", name, strings.Join(names, ", "))
+}
+
+type Var struct {
+	Name  string
+	Value string
+}
+
+// Script renders vars in the environment's format. Variables currently
+// set whose names start with prefix are unset first, so nothing from a
+// previously targeted environment leaks into the new one.
+func (e *Environment) Script(prefix string, vars []Var) (string, error) {
+	format := e.Format
+	if format == "" {
+		format = DefaultFormat()
+	}
+
+	if format == JSON {
+		values := map[string]string{}
+		for _, v := range vars {
+			values[v.Name] = v.Value
+		}
+		contents, err := json.MarshalIndent(values, "", "  ")
+		return string(contents), err
+	}
+
+	var output bytes.Buffer
+
+	if format != Dotenv {
+		for _, envvar := range os.Environ() {
+			if strings.HasPrefix(envvar, prefix) {
+				fmt.Fprintln(&output, unset(format, strings.Split(envvar, "=")[0]))
+			}
+		}
+	}
+
+	for _, v := range vars {
+		fmt.Fprintln(&output, export(format, v.Name, v.Value))
+	}
+
+	return strings.TrimSpace(output.String()), nil
+}
+
+func unset(format Format, name string) string {
+	switch format {
+	case Fish:
+		return fmt.Sprintf("set -e %s;", name)
+	case Cmd:
+		return fmt.Sprintf("SET %s=", name)
+	case PowerShell:
+		return fmt.Sprintf("Remove-Item Env:%s;", name)
+	default:
+		return fmt.Sprintf("unset %s;", name)
+	}
+}
+
+func export(format Format, name string, value string) string {
+	switch format {
+	case Fish:
+		return fmt.Sprintf(`set -gx %s "%s";`, name, escape(value, `\`, `\`, `"`, `$`))
+	case Cmd:
+		return fmt.Sprintf("SET %s=%s", name, value)
+	case PowerShell:
+		return fmt.Sprintf(`$env:%s="%s";`, name, escape(value, "`", "`", `"`, `$`))
+	case Dotenv:
+		return fmt.Sprintf(`%s="%s"`, name, strings.Replace(escape(value, `\`, `\`, `"`), "\n", `\n`, -1))
+	default:
+		return fmt.Sprintf(`export %s="%s";`, name, escape(value, `\`, `\`, `"`, `$`, "`"))
+	}
+}
+
+// escape prefixes every occurrence of the special characters with the
+// escape character. The escape character itself must be listed first.
+func escape(value string, escapeChar string, special ...string) string {
+	for _, s := range special {
+		value = strings.Replace(value, s, escapeChar+s, -1)
+	}
+	return value
+}
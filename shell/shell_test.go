@@ -0,0 +1,82 @@
+package shell_test
+
+import (
+	"os"
+
+	"code.cloudfoundry.org/cfdev/shell"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Script", func() {
+	var vars []shell.Var
+
+	BeforeEach(func() {
+		os.Setenv("SOME_PREFIX_OLD", "old-value")
+		vars = []shell.Var{
+			{Name: "SOME_PREFIX_NAME", Value: "some-value"},
+			{Name: "SOME_PREFIX_QUOTED", Value: `a "quoted" $value`},
+		}
+	})
+
+	AfterEach(func() {
+		os.Unsetenv("SOME_PREFIX_OLD")
+	})
+
+	render := func(name string) string {
+		format, err := shell.ParseFormat(name)
+		Expect(err).NotTo(HaveOccurred())
+
+		env := shell.Environment{Format: format}
+		script, err := env.Script("SOME_PREFIX_", vars)
+		Expect(err).NotTo(HaveOccurred())
+		return script
+	}
+
+	It("renders bash", func() {
+		Expect(render("bash")).To(Equal(`unset SOME_PREFIX_OLD;
+export SOME_PREFIX_NAME="some-value";
+export SOME_PREFIX_QUOTED="a \"quoted\" \$value";`))
+	})
+
+	It("renders zsh", func() {
+		Expect(render("zsh")).To(Equal(`unset SOME_PREFIX_OLD;
+export SOME_PREFIX_NAME="some-value";
+export SOME_PREFIX_QUOTED="a \"quoted\" \$value";`))
+	})
+
+	It("renders fish", func() {
+		Expect(render("fish")).To(Equal(`set -e SOME_PREFIX_OLD;
+set -gx SOME_PREFIX_NAME "some-value";
+set -gx SOME_PREFIX_QUOTED "a \"quoted\" \$value";`))
+	})
+
+	It("renders cmd", func() {
+		Expect(render("cmd")).To(Equal(`SET SOME_PREFIX_OLD=
+SET SOME_PREFIX_NAME=some-value
+SET SOME_PREFIX_QUOTED=a "quoted" $value`))
+	})
+
+	It("renders powershell", func() {
+		Expect(render("powershell")).To(Equal("Remove-Item Env:SOME_PREFIX_OLD;\n" +
+			"$env:SOME_PREFIX_NAME=\"some-value\";\n" +
+			"$env:SOME_PREFIX_QUOTED=\"a `\"quoted`\" `$value\";"))
+	})
+
+	It("renders dotenv", func() {
+		Expect(render("dotenv")).To(Equal(`SOME_PREFIX_NAME="some-value"
+SOME_PREFIX_QUOTED="a \"quoted\" $value"`))
+	})
+
+	It("renders json", func() {
+		Expect(render("json")).To(Equal(`{
+  "SOME_PREFIX_NAME": "some-value",
+  "SOME_PREFIX_QUOTED": "a \"quoted\" $value"
+}`))
+	})
+
+	It("rejects unknown formats", func() {
+		_, err := shell.ParseFormat("tcsh")
+		Expect(err).To(MatchError("unknown shell 'tcsh', expected one of: bash, fish, cmd, powershell, dotenv, json"))
+	})
+})
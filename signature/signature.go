@@ -0,0 +1,82 @@
+package signature
+
+import (
+	"encoding/base64"
+	"fmt"
+	"io/ioutil"
+	"strings"
+
+	"golang.org/x/crypto/ed25519"
+)
+
+// Verifier checks detached ed25519 signatures against the public key
+// embedded at build time. Builds without a key (local development) skip
+// verification.
+type Verifier struct {
+	key ed25519.PublicKey
+}
+
+func New(encodedKey string) (*Verifier, error) {
+	if encodedKey == "" {
+		return &Verifier{}, nil
+	}
+
+	key, err := base64.StdEncoding.DecodeString(encodedKey)
+	if err != nil {
+		return nil, fmt.Errorf("invalid signing key: %s", err)
+	}
+	if len(key) != ed25519.PublicKeySize {
+		return nil, fmt.Errorf("invalid signing key: expected %d bytes, got %d", ed25519.PublicKeySize, len(key))
+	}
+	return &Verifier{key: ed25519.PublicKey(key)}, nil
+}
+
+func Must(v *Verifier, err error) *Verifier {
+	if err != nil {
+		panic(err)
+	}
+	return v
+}
+
+func (v *Verifier) Enabled() bool {
+	return v != nil && len(v.key) > 0
+}
+
+// Verify checks a base64 encoded signature of message.
+func (v *Verifier) Verify(message []byte, encodedSignature string) error {
+	if !v.Enabled() {
+		return nil
+	}
+
+	sig, err := base64.StdEncoding.DecodeString(strings.TrimSpace(encodedSignature))
+	if err != nil {
+		return fmt.Errorf("invalid signature: %s", err)
+	}
+	if !ed25519.Verify(v.key, message, sig) {
+		return fmt.Errorf("signature does not match")
+	}
+	return nil
+}
+
+// VerifyFile checks path against the signature stored next to it in
+// path + ".sig".
+func (v *Verifier) VerifyFile(path string) error {
+	if !v.Enabled() {
+		return nil
+	}
+
+	message, err := ioutil.ReadFile(path)
+	if err != nil {
+		return err
+	}
+
+	sig, err := ioutil.ReadFile(path + ".sig")
+	if err != nil {
+		return fmt.Errorf("missing signature for %s: %s", path, err)
+	}
+
+	if err := v.Verify(message, string(sig)); err != nil {
+		return fmt.Errorf("%s: %s", path, err)
+	}
+	return nil
+}
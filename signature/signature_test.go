@@ -0,0 +1,88 @@
+package signature_test
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"code.cloudfoundry.org/cfdev/signature"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"golang.org/x/crypto/ed25519"
+)
+
+var _ = Describe("Verifier", func() {
+	var (
+		publicKey  ed25519.PublicKey
+		privateKey ed25519.PrivateKey
+		verifier   *signature.Verifier
+	)
+
+	sign := func(message []byte) string {
+		return base64.StdEncoding.EncodeToString(ed25519.Sign(privateKey, message))
+	}
+
+	BeforeEach(func() {
+		var err error
+		publicKey, privateKey, err = ed25519.GenerateKey(rand.Reader)
+		Expect(err).NotTo(HaveOccurred())
+
+		verifier, err = signature.New(base64.StdEncoding.EncodeToString(publicKey))
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	It("accepts valid signatures", func() {
+		Expect(verifier.Enabled()).To(BeTrue())
+		Expect(verifier.Verify([]byte("some-message"), sign([]byte("some-message")))).To(Succeed())
+	})
+
+	It("rejects signatures of other messages", func() {
+		err := verifier.Verify([]byte("some-message"), sign([]byte("some-other-message")))
+		Expect(err).To(MatchError("signature does not match"))
+	})
+
+	It("rejects malformed keys", func() {
+		_, err := signature.New(base64.StdEncoding.EncodeToString([]byte("short")))
+		Expect(err).To(MatchError("invalid signing key: expected 32 bytes, got 5"))
+	})
+
+	Context("when no key is embedded", func() {
+		It("skips verification", func() {
+			verifier, err := signature.New("")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(verifier.Enabled()).To(BeFalse())
+			Expect(verifier.Verify([]byte("some-message"), "garbage")).To(Succeed())
+		})
+	})
+
+	Describe("VerifyFile", func() {
+		var (
+			tmpDir string
+			path   string
+		)
+
+		BeforeEach(func() {
+			var err error
+			tmpDir, err = ioutil.TempDir("", "cfdev-signature-")
+			Expect(err).NotTo(HaveOccurred())
+
+			path = filepath.Join(tmpDir, "metadata.yml")
+			Expect(ioutil.WriteFile(path, []byte("some-content"), 0644)).To(Succeed())
+		})
+
+		AfterEach(func() {
+			os.RemoveAll(tmpDir)
+		})
+
+		It("reads the detached signature next to the file", func() {
+			Expect(ioutil.WriteFile(path+".sig", []byte(sign([]byte("some-content"))+"\n"), 0644)).To(Succeed())
+			Expect(verifier.VerifyFile(path)).To(Succeed())
+		})
+
+		It("fails when the signature is missing", func() {
+			Expect(verifier.VerifyFile(path)).To(MatchError(ContainSubstring("missing signature for " + path)))
+		})
+	})
+})
@@ -0,0 +1,458 @@
+package snapshot
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"sort"
+	"strings"
+	"time"
+
+	"code.cloudfoundry.org/cfdev/config"
+	"code.cloudfoundry.org/cfdev/errors"
+)
+
+const (
+	manifestFile  = "snapshot.json"
+	argsFile      = "start-args.json"
+	archivePrefix = "cfdev-snapshot"
+	restoreSuffix = ".restore"
+)
+
+var validName = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._-]*$`)
+
+type Snapshot struct {
+	Name          string    `json:"name"`
+	CreatedAt     time.Time `json:"created_at"`
+	OS            string    `json:"os"`
+	PluginVersion string    `json:"plugin_version"`
+	Size          int64     `json:"-"`
+	Dir           string    `json:"-"`
+}
+
+type Manager struct {
+	Config config.Config
+}
+
+func New(cfg config.Config) *Manager {
+	return &Manager{Config: cfg}
+}
+
+func (m *Manager) Dir() string {
+	return filepath.Join(m.Config.CFDevHome, "snapshots")
+}
+
+func (m *Manager) Save(name string) (Snapshot, error) {
+	if err := validateName(name); err != nil {
+		return Snapshot{}, err
+	}
+
+	dir := filepath.Join(m.Dir(), name)
+	if exists(dir) {
+		return Snapshot{}, errors.SafeWrap(nil, fmt.Sprintf("snapshot '%s' already exists", name))
+	}
+
+	if !exists(m.diskPath()) {
+		return Snapshot{}, errors.SafeWrap(nil, "no VM disk found. Please execute 'cf dev start' first")
+	}
+
+	tmpDir := dir + ".tmp"
+	os.RemoveAll(tmpDir)
+	defer os.RemoveAll(tmpDir)
+
+	copies := []struct{ src, dst string }{
+		{m.diskPath(), filepath.Join(tmpDir, "linuxkit", filepath.Base(m.diskPath()))},
+		{m.Config.StateBosh, filepath.Join(tmpDir, "bosh")},
+		{filepath.Join(m.Config.CacheDir, "metadata.yml"), filepath.Join(tmpDir, "metadata.yml")},
+		{filepath.Join(m.Config.CacheDir, "metadata.yml.sig"), filepath.Join(tmpDir, "metadata.yml.sig")},
+		{filepath.Join(m.Config.StateDir, argsFile), filepath.Join(tmpDir, argsFile)},
+	}
+
+	for _, c := range copies {
+		if !exists(c.src) {
+			continue
+		}
+		if err := copyPath(c.src, c.dst); err != nil {
+			return Snapshot{}, errors.SafeWrap(err, "failed to copy "+filepath.Base(c.src))
+		}
+	}
+
+	snapshot := Snapshot{
+		Name:          name,
+		CreatedAt:     time.Now().UTC(),
+		OS:            runtime.GOOS,
+		PluginVersion: m.pluginVersion(),
+	}
+	if err := writeManifest(tmpDir, snapshot); err != nil {
+		return Snapshot{}, err
+	}
+
+	if err := os.Rename(tmpDir, dir); err != nil {
+		return Snapshot{}, errors.SafeWrap(err, "failed to store snapshot")
+	}
+
+	return m.Get(name)
+}
+
+func (m *Manager) List() ([]Snapshot, error) {
+	entries, err := ioutil.ReadDir(m.Dir())
+	if os.IsNotExist(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	var snapshots []Snapshot
+	for _, entry := range entries {
+		if !entry.IsDir() || strings.HasSuffix(entry.Name(), ".tmp") {
+			continue
+		}
+		snapshot, err := m.Get(entry.Name())
+		if err != nil {
+			continue
+		}
+		snapshots = append(snapshots, snapshot)
+	}
+
+	sort.Slice(snapshots, func(i, j int) bool {
+		return snapshots[i].CreatedAt.Before(snapshots[j].CreatedAt)
+	})
+	return snapshots, nil
+}
+
+func (m *Manager) Get(name string) (Snapshot, error) {
+	if err := validateName(name); err != nil {
+		return Snapshot{}, err
+	}
+
+	dir := filepath.Join(m.Dir(), name)
+	contents, err := ioutil.ReadFile(filepath.Join(dir, manifestFile))
+	if os.IsNotExist(err) {
+		return Snapshot{}, errors.SafeWrap(nil, fmt.Sprintf("snapshot '%s' does not exist", name))
+	} else if err != nil {
+		return Snapshot{}, err
+	}
+
+	var snapshot Snapshot
+	if err := json.Unmarshal(contents, &snapshot); err != nil {
+		return Snapshot{}, errors.SafeWrap(err, "failed to parse snapshot manifest")
+	}
+
+	snapshot.Dir = dir
+	snapshot.Size, _ = dirSize(dir)
+	return snapshot, nil
+}
+
+func (m *Manager) ArgsPath(name string) string {
+	return filepath.Join(m.Dir(), name, argsFile)
+}
+
+func (m *Manager) Restore(name string) error {
+	snapshot, err := m.Get(name)
+	if err != nil {
+		return err
+	}
+
+	if snapshot.OS != runtime.GOOS {
+		return errors.SafeWrap(nil, fmt.Sprintf("snapshot '%s' was taken on %s and cannot be restored on %s", name, snapshot.OS, runtime.GOOS))
+	}
+
+	copies := []struct{ src, dst string }{
+		{filepath.Join(snapshot.Dir, "linuxkit", filepath.Base(m.diskPath())), m.diskPath()},
+		{filepath.Join(snapshot.Dir, "bosh"), m.Config.StateBosh},
+		{filepath.Join(snapshot.Dir, "metadata.yml"), filepath.Join(m.Config.CacheDir, "metadata.yml")},
+		{filepath.Join(snapshot.Dir, "metadata.yml.sig"), filepath.Join(m.Config.CacheDir, "metadata.yml.sig")},
+		{filepath.Join(snapshot.Dir, argsFile), filepath.Join(m.Config.StateDir, argsFile)},
+	}
+
+	// Everything is copied next to its target first, so that a failed
+	// copy leaves the current state as it was.
+	var restored []string
+	defer func() {
+		for _, dst := range restored {
+			os.RemoveAll(dst + restoreSuffix)
+		}
+	}()
+	for _, c := range copies {
+		if !exists(c.src) {
+			continue
+		}
+		restored = append(restored, c.dst)
+		os.RemoveAll(c.dst + restoreSuffix)
+		if err := copyPath(c.src, c.dst+restoreSuffix); err != nil {
+			return errors.SafeWrap(err, "failed to restore "+filepath.Base(c.src))
+		}
+	}
+
+	for _, dst := range restored {
+		if err := replace(dst+restoreSuffix, dst); err != nil {
+			return errors.SafeWrap(err, "failed to restore "+filepath.Base(dst))
+		}
+	}
+	return nil
+}
+
+// replace renames src to dst, moving a dst directory out of the way
+// first, as renames cannot replace directories.
+func replace(src, dst string) error {
+	old := dst + ".old"
+	if err := os.RemoveAll(old); err != nil {
+		return err
+	}
+	if err := os.Rename(dst, old); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	if err := os.Rename(src, dst); err != nil {
+		os.Rename(old, dst)
+		return err
+	}
+	return os.RemoveAll(old)
+}
+
+func (m *Manager) Delete(name string) error {
+	snapshot, err := m.Get(name)
+	if err != nil {
+		return err
+	}
+	return os.RemoveAll(snapshot.Dir)
+}
+
+func (m *Manager) Export(name string, w io.Writer) error {
+	snapshot, err := m.Get(name)
+	if err != nil {
+		return err
+	}
+
+	gzw := gzip.NewWriter(w)
+	tw := tar.NewWriter(gzw)
+
+	err = filepath.Walk(snapshot.Dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+
+		rel, err := filepath.Rel(snapshot.Dir, path)
+		if err != nil {
+			return err
+		}
+
+		header, err := tar.FileInfoHeader(info, "")
+		if err != nil {
+			return err
+		}
+		header.Name = filepath.ToSlash(filepath.Join(archivePrefix, rel))
+
+		if err := tw.WriteHeader(header); err != nil {
+			return err
+		}
+
+		if !info.Mode().IsRegular() {
+			return nil
+		}
+
+		f, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+
+		_, err = io.Copy(tw, f)
+		return err
+	})
+	if err != nil {
+		return errors.SafeWrap(err, "failed to export snapshot")
+	}
+
+	if err := tw.Close(); err != nil {
+		return err
+	}
+	return gzw.Close()
+}
+
+func (m *Manager) Import(r io.Reader, name string) (Snapshot, error) {
+	tmpDir, err := ioutil.TempDir(m.ensureDir(), "import-")
+	if err != nil {
+		return Snapshot{}, err
+	}
+	defer os.RemoveAll(tmpDir)
+
+	gzr, err := gzip.NewReader(r)
+	if err != nil {
+		return Snapshot{}, errors.SafeWrap(err, "snapshot archive is not a valid gzip file")
+	}
+	defer gzr.Close()
+
+	tr := tar.NewReader(gzr)
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			return Snapshot{}, errors.SafeWrap(err, "failed to read snapshot archive")
+		}
+
+		entryName := path.Clean(header.Name)
+		rel := strings.TrimPrefix(entryName, archivePrefix)
+		if rel == entryName || (rel != "" && !strings.HasPrefix(rel, "/")) || strings.Contains(rel, "..") {
+			return Snapshot{}, errors.SafeWrap(nil, fmt.Sprintf("snapshot archive contains unexpected entry '%s'", header.Name))
+		}
+		target := filepath.Join(tmpDir, filepath.FromSlash(rel))
+
+		switch header.Typeflag {
+		case tar.TypeDir:
+			if err := os.MkdirAll(target, 0755); err != nil {
+				return Snapshot{}, err
+			}
+		case tar.TypeReg:
+			if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+				return Snapshot{}, err
+			}
+			if err := writeFile(target, tr, os.FileMode(header.Mode).Perm()); err != nil {
+				return Snapshot{}, err
+			}
+		default:
+			return Snapshot{}, errors.SafeWrap(nil, fmt.Sprintf("snapshot archive contains unsupported entry '%s'", header.Name))
+		}
+	}
+
+	contents, err := ioutil.ReadFile(filepath.Join(tmpDir, manifestFile))
+	if err != nil {
+		return Snapshot{}, errors.SafeWrap(err, "snapshot archive is missing its manifest")
+	}
+
+	var snapshot Snapshot
+	if err := json.Unmarshal(contents, &snapshot); err != nil {
+		return Snapshot{}, errors.SafeWrap(err, "failed to parse snapshot manifest")
+	}
+
+	if snapshot.OS != runtime.GOOS {
+		return Snapshot{}, errors.SafeWrap(nil, fmt.Sprintf("snapshot was taken on %s and cannot be imported on %s", snapshot.OS, runtime.GOOS))
+	}
+
+	if name != "" {
+		snapshot.Name = name
+	}
+	if err := validateName(snapshot.Name); err != nil {
+		return Snapshot{}, err
+	}
+
+	dir := filepath.Join(m.Dir(), snapshot.Name)
+	if exists(dir) {
+		return Snapshot{}, errors.SafeWrap(nil, fmt.Sprintf("snapshot '%s' already exists", snapshot.Name))
+	}
+
+	if err := writeManifest(tmpDir, snapshot); err != nil {
+		return Snapshot{}, err
+	}
+
+	if err := os.Rename(tmpDir, dir); err != nil {
+		return Snapshot{}, errors.SafeWrap(err, "failed to store snapshot")
+	}
+
+	return m.Get(snapshot.Name)
+}
+
+func (m *Manager) diskPath() string {
+	if runtime.GOOS == "windows" {
+		return filepath.Join(m.Config.StateLinuxkit, "disk.vhdx")
+	}
+	return filepath.Join(m.Config.StateLinuxkit, "disk.qcow2")
+}
+
+func (m *Manager) ensureDir() string {
+	os.MkdirAll(m.Dir(), 0755)
+	return m.Dir()
+}
+
+func (m *Manager) pluginVersion() string {
+	if m.Config.CliVersion == nil {
+		return ""
+	}
+	return m.Config.CliVersion.Original
+}
+
+func validateName(name string) error {
+	if !validName.MatchString(name) {
+		return errors.SafeWrap(nil, fmt.Sprintf("invalid snapshot name '%s': use letters, digits, '.', '_' and '-'", name))
+	}
+	return nil
+}
+
+func writeManifest(dir string, snapshot Snapshot) error {
+	contents, err := json.MarshalIndent(snapshot, "", "  ")
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(filepath.Join(dir, manifestFile), contents, 0644)
+}
+
+func copyPath(src, dst string) error {
+	return filepath.Walk(src, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+
+		rel, err := filepath.Rel(src, path)
+		if err != nil {
+			return err
+		}
+		target := filepath.Join(dst, rel)
+
+		if info.IsDir() {
+			return os.MkdirAll(target, info.Mode().Perm())
+		}
+		if !info.Mode().IsRegular() {
+			return nil
+		}
+
+		if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+			return err
+		}
+
+		f, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+
+		return writeFile(target, f, info.Mode().Perm())
+	})
+}
+
+func writeFile(path string, r io.Reader, mode os.FileMode) error {
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, mode)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	_, err = io.Copy(f, r)
+	return err
+}
+
+func dirSize(dir string) (int64, error) {
+	var size int64
+	err := filepath.Walk(dir, func(_ string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.Mode().IsRegular() {
+			size += info.Size()
+		}
+		return nil
+	})
+	return size, err
+}
+
+func exists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
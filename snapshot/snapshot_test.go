@@ -0,0 +1,218 @@
+package snapshot_test
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"runtime"
+
+	"code.cloudfoundry.org/cfdev/config"
+	"code.cloudfoundry.org/cfdev/semver"
+	"code.cloudfoundry.org/cfdev/snapshot"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Snapshot Manager", func() {
+	var (
+		tmpDir   string
+		cfg      config.Config
+		manager  *snapshot.Manager
+		diskName string
+	)
+
+	BeforeEach(func() {
+		var err error
+		tmpDir, err = ioutil.TempDir("", "cfdev-snapshot-")
+		Expect(err).NotTo(HaveOccurred())
+
+		cfg = config.Config{
+			CFDevHome:     tmpDir,
+			StateDir:      filepath.Join(tmpDir, "state"),
+			StateBosh:     filepath.Join(tmpDir, "state", "bosh"),
+			StateLinuxkit: filepath.Join(tmpDir, "state", "linuxkit"),
+			CacheDir:      filepath.Join(tmpDir, "cache"),
+			CliVersion:    &semver.Version{Original: "1.2.3"},
+		}
+		manager = snapshot.New(cfg)
+
+		diskName = "disk.qcow2"
+		if runtime.GOOS == "windows" {
+			diskName = "disk.vhdx"
+		}
+
+		for _, dir := range []string{cfg.StateBosh, cfg.StateLinuxkit, cfg.CacheDir} {
+			Expect(os.MkdirAll(dir, 0755)).To(Succeed())
+		}
+		Expect(ioutil.WriteFile(filepath.Join(cfg.StateLinuxkit, diskName), []byte("some-disk"), 0644)).To(Succeed())
+		Expect(ioutil.WriteFile(filepath.Join(cfg.StateBosh, "state.json"), []byte("some-state"), 0644)).To(Succeed())
+		Expect(ioutil.WriteFile(filepath.Join(cfg.StateBosh, "creds.yml"), []byte("some-creds"), 0600)).To(Succeed())
+		Expect(ioutil.WriteFile(filepath.Join(cfg.CacheDir, "metadata.yml"), []byte("some-metadata"), 0644)).To(Succeed())
+		Expect(ioutil.WriteFile(filepath.Join(cfg.StateDir, "start-args.json"), []byte(`{"Cpus":6}`), 0644)).To(Succeed())
+	})
+
+	AfterEach(func() {
+		os.RemoveAll(tmpDir)
+	})
+
+	expectFile := func(path, contents string) {
+		actual, err := ioutil.ReadFile(path)
+		ExpectWithOffset(1, err).NotTo(HaveOccurred())
+		ExpectWithOffset(1, string(actual)).To(Equal(contents))
+	}
+
+	Describe("Save", func() {
+		It("captures the disk, bosh state, metadata and start arguments", func() {
+			snap, err := manager.Save("some-snapshot")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(snap.Name).To(Equal("some-snapshot"))
+			Expect(snap.OS).To(Equal(runtime.GOOS))
+			Expect(snap.PluginVersion).To(Equal("1.2.3"))
+			Expect(snap.Size).To(BeNumerically(">", 0))
+
+			dir := filepath.Join(tmpDir, "snapshots", "some-snapshot")
+			expectFile(filepath.Join(dir, "linuxkit", diskName), "some-disk")
+			expectFile(filepath.Join(dir, "bosh", "state.json"), "some-state")
+			expectFile(filepath.Join(dir, "bosh", "creds.yml"), "some-creds")
+			expectFile(filepath.Join(dir, "metadata.yml"), "some-metadata")
+			expectFile(manager.ArgsPath("some-snapshot"), `{"Cpus":6}`)
+		})
+
+		It("refuses to overwrite an existing snapshot", func() {
+			_, err := manager.Save("some-snapshot")
+			Expect(err).NotTo(HaveOccurred())
+
+			_, err = manager.Save("some-snapshot")
+			Expect(err).To(MatchError(ContainSubstring("already exists")))
+		})
+
+		It("rejects invalid names", func() {
+			_, err := manager.Save("../escape")
+			Expect(err).To(MatchError(ContainSubstring("invalid snapshot name")))
+		})
+
+		Context("when there is no VM disk", func() {
+			BeforeEach(func() {
+				os.Remove(filepath.Join(cfg.StateLinuxkit, diskName))
+			})
+
+			It("returns an error", func() {
+				_, err := manager.Save("some-snapshot")
+				Expect(err).To(MatchError(ContainSubstring("no VM disk found")))
+			})
+		})
+	})
+
+	Describe("List and Delete", func() {
+		It("lists the saved snapshots and removes them", func() {
+			_, err := manager.Save("first")
+			Expect(err).NotTo(HaveOccurred())
+			_, err = manager.Save("second")
+			Expect(err).NotTo(HaveOccurred())
+
+			snapshots, err := manager.List()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(snapshots).To(HaveLen(2))
+			Expect(snapshots[0].Name).To(Equal("first"))
+			Expect(snapshots[1].Name).To(Equal("second"))
+
+			Expect(manager.Delete("first")).To(Succeed())
+
+			snapshots, err = manager.List()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(snapshots).To(HaveLen(1))
+			Expect(snapshots[0].Name).To(Equal("second"))
+		})
+
+		It("returns an error when deleting an unknown snapshot", func() {
+			Expect(manager.Delete("unknown")).To(MatchError(ContainSubstring("does not exist")))
+		})
+	})
+
+	Describe("Restore", func() {
+		It("puts the captured files back into place", func() {
+			_, err := manager.Save("some-snapshot")
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(os.RemoveAll(cfg.StateDir)).To(Succeed())
+			Expect(os.MkdirAll(cfg.StateLinuxkit, 0755)).To(Succeed())
+			Expect(ioutil.WriteFile(filepath.Join(cfg.CacheDir, "metadata.yml"), []byte("other-metadata"), 0644)).To(Succeed())
+
+			Expect(manager.Restore("some-snapshot")).To(Succeed())
+
+			expectFile(filepath.Join(cfg.StateLinuxkit, diskName), "some-disk")
+			expectFile(filepath.Join(cfg.StateBosh, "state.json"), "some-state")
+			expectFile(filepath.Join(cfg.CacheDir, "metadata.yml"), "some-metadata")
+			expectFile(filepath.Join(cfg.StateDir, "start-args.json"), `{"Cpus":6}`)
+		})
+
+		It("replaces the bosh state instead of merging into it", func() {
+			_, err := manager.Save("some-snapshot")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(ioutil.WriteFile(filepath.Join(cfg.StateBosh, "newer.json"), []byte("newer"), 0644)).To(Succeed())
+
+			Expect(manager.Restore("some-snapshot")).To(Succeed())
+
+			Expect(filepath.Join(cfg.StateBosh, "newer.json")).NotTo(BeAnExistingFile())
+			expectFile(filepath.Join(cfg.StateBosh, "state.json"), "some-state")
+			Expect(cfg.StateBosh + ".restore").NotTo(BeAnExistingFile())
+			Expect(cfg.StateBosh + ".old").NotTo(BeAnExistingFile())
+		})
+
+		It("leaves the current state alone when a copy fails", func() {
+			_, err := manager.Save("some-snapshot")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(ioutil.WriteFile(filepath.Join(cfg.StateBosh, "state.json"), []byte("current-state"), 0644)).To(Succeed())
+			Expect(ioutil.WriteFile(filepath.Join(cfg.StateLinuxkit, diskName), []byte("current-disk"), 0644)).To(Succeed())
+			Expect(os.RemoveAll(cfg.CacheDir)).To(Succeed())
+			Expect(ioutil.WriteFile(cfg.CacheDir, []byte("not a dir"), 0644)).To(Succeed())
+
+			Expect(manager.Restore("some-snapshot")).To(MatchError(ContainSubstring("failed to restore metadata.yml")))
+
+			expectFile(filepath.Join(cfg.StateBosh, "state.json"), "current-state")
+			expectFile(filepath.Join(cfg.StateLinuxkit, diskName), "current-disk")
+			Expect(cfg.StateBosh + ".restore").NotTo(BeAnExistingFile())
+			Expect(filepath.Join(cfg.StateLinuxkit, diskName+".restore")).NotTo(BeAnExistingFile())
+		})
+	})
+
+	Describe("Export and Import", func() {
+		It("round trips a snapshot through a single archive", func() {
+			_, err := manager.Save("some-snapshot")
+			Expect(err).NotTo(HaveOccurred())
+
+			var archive bytes.Buffer
+			Expect(manager.Export("some-snapshot", &archive)).To(Succeed())
+
+			snap, err := manager.Import(bytes.NewReader(archive.Bytes()), "imported")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(snap.Name).To(Equal("imported"))
+			Expect(snap.PluginVersion).To(Equal("1.2.3"))
+
+			dir := filepath.Join(tmpDir, "snapshots", "imported")
+			expectFile(filepath.Join(dir, "linuxkit", diskName), "some-disk")
+			expectFile(filepath.Join(dir, "bosh", "creds.yml"), "some-creds")
+
+			info, err := os.Stat(filepath.Join(dir, "bosh", "creds.yml"))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(info.Mode().Perm()).To(Equal(os.FileMode(0600)))
+		})
+
+		It("refuses to import over an existing snapshot", func() {
+			_, err := manager.Save("some-snapshot")
+			Expect(err).NotTo(HaveOccurred())
+
+			var archive bytes.Buffer
+			Expect(manager.Export("some-snapshot", &archive)).To(Succeed())
+
+			_, err = manager.Import(&archive, "")
+			Expect(err).To(MatchError(ContainSubstring("already exists")))
+		})
+
+		It("rejects archives that are not snapshots", func() {
+			_, err := manager.Import(bytes.NewBufferString("not-a-gzip"), "")
+			Expect(err).To(MatchError(ContainSubstring("not a valid gzip file")))
+		})
+	})
+})
@@ -0,0 +1,340 @@
+package ssh
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"code.cloudfoundry.org/cfdev/resource"
+)
+
+const (
+	scpOK      = 0
+	scpWarning = 1
+	scpFatal   = 2
+)
+
+type noopProgress struct{}
+
+func (noopProgress) Write(p []byte) (int, error) { return len(p), nil }
+func (noopProgress) Start(total uint64)          {}
+func (noopProgress) Add(add uint64)              {}
+func (noopProgress) End()                        {}
+func (noopProgress) SetLastCompleted()           {}
+func (noopProgress) ResetCurrent()               {}
+
+// SCPSender speaks the source side of the scp protocol, i.e. it feeds
+// files to a remote 'scp -t'.
+type SCPSender struct {
+	w        io.Writer
+	r        *bufio.Reader
+	progress resource.Progress
+}
+
+func NewSCPSender(w io.Writer, r io.Reader, progress resource.Progress) *SCPSender {
+	if progress == nil {
+		progress = noopProgress{}
+	}
+	return &SCPSender{w: w, r: bufio.NewReader(r), progress: progress}
+}
+
+func (s *SCPSender) Send(localPath string) error {
+	info, err := os.Stat(localPath)
+	if err != nil {
+		return err
+	}
+
+	if err := readAck(s.r); err != nil {
+		return err
+	}
+
+	if info.IsDir() {
+		return s.sendDir(localPath, info)
+	}
+	return s.sendFile(localPath, info)
+}
+
+func (s *SCPSender) sendDir(path string, info os.FileInfo) error {
+	if err := s.sendTimes(info); err != nil {
+		return err
+	}
+
+	fmt.Fprintf(s.w, "D%04o 0 %s\n", info.Mode().Perm(), info.Name())
+	if err := readAck(s.r); err != nil {
+		return err
+	}
+
+	entries, err := ioutil.ReadDir(path)
+	if err != nil {
+		return err
+	}
+
+	for _, entry := range entries {
+		entryPath := filepath.Join(path, entry.Name())
+		switch {
+		case entry.IsDir():
+			err = s.sendDir(entryPath, entry)
+		case entry.Mode().IsRegular():
+			err = s.sendFile(entryPath, entry)
+		default:
+			continue
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	fmt.Fprint(s.w, "E\n")
+	return readAck(s.r)
+}
+
+func (s *SCPSender) sendFile(path string, info os.FileInfo) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	if err := s.sendTimes(info); err != nil {
+		return err
+	}
+
+	fmt.Fprintf(s.w, "C%04o %d %s\n", info.Mode().Perm(), info.Size(), info.Name())
+	if err := readAck(s.r); err != nil {
+		return err
+	}
+
+	if _, err := io.Copy(s.w, io.TeeReader(f, s.progress)); err != nil {
+		return err
+	}
+
+	if _, err := s.w.Write([]byte{scpOK}); err != nil {
+		return err
+	}
+	return readAck(s.r)
+}
+
+func (s *SCPSender) sendTimes(info os.FileInfo) error {
+	mtime := info.ModTime().Unix()
+	fmt.Fprintf(s.w, "T%d 0 %d 0\n", mtime, mtime)
+	return readAck(s.r)
+}
+
+// SCPReceiver speaks the sink side of the scp protocol, i.e. it reads
+// files produced by a remote 'scp -f'.
+type SCPReceiver struct {
+	w        io.Writer
+	r        *bufio.Reader
+	progress resource.Progress
+}
+
+func NewSCPReceiver(w io.Writer, r io.Reader, progress resource.Progress) *SCPReceiver {
+	if progress == nil {
+		progress = noopProgress{}
+	}
+	return &SCPReceiver{w: w, r: bufio.NewReader(r), progress: progress}
+}
+
+func (s *SCPReceiver) Receive(localPath string) error {
+	var (
+		dirs     []string
+		mtime    *time.Time
+		rootName = ""
+	)
+
+	if info, err := os.Stat(localPath); err == nil && info.IsDir() {
+		dirs = []string{localPath}
+	} else {
+		dirs = []string{filepath.Dir(localPath)}
+		rootName = filepath.Base(localPath)
+	}
+
+	if err := s.ack(); err != nil {
+		return err
+	}
+
+	for {
+		kind, err := s.r.ReadByte()
+		if err == io.EOF {
+			if len(dirs) != 1 {
+				return fmt.Errorf("scp: unexpected end of directory stream")
+			}
+			return nil
+		} else if err != nil {
+			return err
+		}
+
+		switch kind {
+		case scpWarning, scpFatal:
+			line, _ := s.r.ReadString('\n')
+			return errors.New(strings.TrimSpace(line))
+		case 'T':
+			line, err := s.r.ReadString('\n')
+			if err != nil {
+				return err
+			}
+			t, err := parseTimes(line)
+			if err != nil {
+				return err
+			}
+			mtime = &t
+			if err := s.ack(); err != nil {
+				return err
+			}
+		case 'C', 'D':
+			line, err := s.r.ReadString('\n')
+			if err != nil {
+				return err
+			}
+			mode, size, name, err := parseEntry(line)
+			if err != nil {
+				return err
+			}
+			if rootName != "" {
+				name, rootName = rootName, ""
+			}
+			if strings.Contains(name, "/") || name == ".." || name == "." {
+				return fmt.Errorf("scp: refusing unexpected filename %q", name)
+			}
+
+			target := filepath.Join(dirs[len(dirs)-1], name)
+			if kind == 'D' {
+				if err := os.MkdirAll(target, mode); err != nil {
+					return err
+				}
+				if err := os.Chmod(target, mode); err != nil {
+					return err
+				}
+				dirs = append(dirs, target)
+				if err := s.ack(); err != nil {
+					return err
+				}
+				mtime = nil
+				continue
+			}
+
+			if err := s.receiveFile(target, mode, size); err != nil {
+				return err
+			}
+			if mtime != nil {
+				os.Chtimes(target, *mtime, *mtime)
+				mtime = nil
+			}
+		case 'E':
+			if _, err := s.r.ReadString('\n'); err != nil {
+				return err
+			}
+			if len(dirs) == 1 {
+				return fmt.Errorf("scp: unbalanced end of directory")
+			}
+			dirs = dirs[:len(dirs)-1]
+			if err := s.ack(); err != nil {
+				return err
+			}
+		default:
+			return fmt.Errorf("scp: unexpected protocol message %q", kind)
+		}
+	}
+}
+
+func (s *SCPReceiver) receiveFile(target string, mode os.FileMode, size int64) error {
+	f, err := os.OpenFile(target, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, mode)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	if err := s.ack(); err != nil {
+		return err
+	}
+
+	if _, err := io.CopyN(f, io.TeeReader(s.r, s.progress), size); err != nil {
+		return err
+	}
+
+	if err := readAck(s.r); err != nil {
+		return err
+	}
+
+	if err := f.Chmod(mode); err != nil {
+		return err
+	}
+
+	return s.ack()
+}
+
+func (s *SCPReceiver) ack() error {
+	_, err := s.w.Write([]byte{scpOK})
+	return err
+}
+
+func readAck(r *bufio.Reader) error {
+	code, err := r.ReadByte()
+	if err != nil {
+		return fmt.Errorf("scp: failed to read acknowledgement: %s", err)
+	}
+
+	switch code {
+	case scpOK:
+		return nil
+	case scpWarning, scpFatal:
+		message, _ := r.ReadString('\n')
+		return errors.New(strings.TrimSpace(message))
+	default:
+		return fmt.Errorf("scp: unexpected acknowledgement %d", code)
+	}
+}
+
+func parseEntry(line string) (os.FileMode, int64, string, error) {
+	parts := strings.SplitN(strings.TrimSuffix(line, "\n"), " ", 3)
+	if len(parts) != 3 {
+		return 0, 0, "", fmt.Errorf("scp: malformed entry %q", line)
+	}
+
+	mode, err := strconv.ParseUint(parts[0], 8, 32)
+	if err != nil {
+		return 0, 0, "", fmt.Errorf("scp: malformed mode in %q", line)
+	}
+
+	size, err := strconv.ParseInt(parts[1], 10, 64)
+	if err != nil {
+		return 0, 0, "", fmt.Errorf("scp: malformed size in %q", line)
+	}
+
+	return os.FileMode(mode).Perm(), size, parts[2], nil
+}
+
+func parseTimes(line string) (time.Time, error) {
+	parts := strings.Fields(line)
+	if len(parts) != 4 {
+		return time.Time{}, fmt.Errorf("scp: malformed times %q", line)
+	}
+
+	mtime, err := strconv.ParseInt(parts[0], 10, 64)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("scp: malformed times %q", line)
+	}
+
+	return time.Unix(mtime, 0), nil
+}
+
+func totalSize(path string) (uint64, error) {
+	var total uint64
+	err := filepath.Walk(path, func(_ string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.Mode().IsRegular() {
+			total += uint64(info.Size())
+		}
+		return nil
+	})
+	return total, err
+}
@@ -0,0 +1,150 @@
+package ssh_test
+
+import (
+	"bytes"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"code.cloudfoundry.org/cfdev/ssh"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+type MockProgress struct {
+	Current uint64
+}
+
+func (m *MockProgress) Write(b []byte) (int, error) { m.Current += uint64(len(b)); return len(b), nil }
+func (m *MockProgress) Start(total uint64)          {}
+func (m *MockProgress) Add(add uint64)              { m.Current += add }
+func (m *MockProgress) End()                        {}
+func (m *MockProgress) SetLastCompleted()           {}
+func (m *MockProgress) ResetCurrent()               {}
+
+var _ = Describe("SCP", func() {
+	var (
+		srcDir   string
+		dstDir   string
+		progress *MockProgress
+	)
+
+	transfer := func(src, dst string) (error, error) {
+		toReceiver, fromSender := io.Pipe()
+		toSender, fromReceiver := io.Pipe()
+
+		sendErr := make(chan error, 1)
+		go func() {
+			err := ssh.NewSCPSender(fromSender, toSender, progress).Send(src)
+			fromSender.Close()
+			sendErr <- err
+		}()
+
+		receiveErr := ssh.NewSCPReceiver(fromReceiver, toReceiver, nil).Receive(dst)
+		toReceiver.Close()
+		fromReceiver.Close()
+		return <-sendErr, receiveErr
+	}
+
+	BeforeEach(func() {
+		var err error
+		srcDir, err = ioutil.TempDir("", "cfdev-scp-src-")
+		Expect(err).NotTo(HaveOccurred())
+		dstDir, err = ioutil.TempDir("", "cfdev-scp-dst-")
+		Expect(err).NotTo(HaveOccurred())
+		progress = &MockProgress{}
+	})
+
+	AfterEach(func() {
+		os.RemoveAll(srcDir)
+		os.RemoveAll(dstDir)
+	})
+
+	Context("when copying a single file", func() {
+		BeforeEach(func() {
+			Expect(ioutil.WriteFile(filepath.Join(srcDir, "some-file"), []byte("some-content"), 0640)).To(Succeed())
+		})
+
+		It("copies the file into an existing directory", func() {
+			sendErr, receiveErr := transfer(filepath.Join(srcDir, "some-file"), dstDir)
+			Expect(sendErr).NotTo(HaveOccurred())
+			Expect(receiveErr).NotTo(HaveOccurred())
+
+			contents, err := ioutil.ReadFile(filepath.Join(dstDir, "some-file"))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(string(contents)).To(Equal("some-content"))
+
+			info, err := os.Stat(filepath.Join(dstDir, "some-file"))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(info.Mode().Perm()).To(Equal(os.FileMode(0640)))
+			Expect(progress.Current).To(Equal(uint64(len("some-content"))))
+		})
+
+		It("copies the file to a new name", func() {
+			sendErr, receiveErr := transfer(filepath.Join(srcDir, "some-file"), filepath.Join(dstDir, "other-file"))
+			Expect(sendErr).NotTo(HaveOccurred())
+			Expect(receiveErr).NotTo(HaveOccurred())
+
+			contents, err := ioutil.ReadFile(filepath.Join(dstDir, "other-file"))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(string(contents)).To(Equal("some-content"))
+		})
+	})
+
+	Context("when copying a directory", func() {
+		BeforeEach(func() {
+			Expect(os.MkdirAll(filepath.Join(srcDir, "some-dir", "nested"), 0755)).To(Succeed())
+			Expect(ioutil.WriteFile(filepath.Join(srcDir, "some-dir", "a"), []byte("aaa"), 0600)).To(Succeed())
+			Expect(ioutil.WriteFile(filepath.Join(srcDir, "some-dir", "nested", "b"), []byte("bb"), 0755)).To(Succeed())
+		})
+
+		It("copies the directory recursively and preserves modes", func() {
+			sendErr, receiveErr := transfer(filepath.Join(srcDir, "some-dir"), dstDir)
+			Expect(sendErr).NotTo(HaveOccurred())
+			Expect(receiveErr).NotTo(HaveOccurred())
+
+			contents, err := ioutil.ReadFile(filepath.Join(dstDir, "some-dir", "a"))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(string(contents)).To(Equal("aaa"))
+
+			contents, err = ioutil.ReadFile(filepath.Join(dstDir, "some-dir", "nested", "b"))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(string(contents)).To(Equal("bb"))
+
+			info, err := os.Stat(filepath.Join(dstDir, "some-dir", "a"))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(info.Mode().Perm()).To(Equal(os.FileMode(0600)))
+
+			info, err = os.Stat(filepath.Join(dstDir, "some-dir", "nested", "b"))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(info.Mode().Perm()).To(Equal(os.FileMode(0755)))
+
+			Expect(progress.Current).To(Equal(uint64(5)))
+		})
+	})
+
+	Context("when the remote side reports an error", func() {
+		It("returns the error from the acknowledgement", func() {
+			Expect(ioutil.WriteFile(filepath.Join(srcDir, "some-file"), []byte("some-content"), 0644)).To(Succeed())
+
+			remote := bytes.NewBufferString("\x00\x02scp: /some/path: Permission denied\n")
+			err := ssh.NewSCPSender(ioutil.Discard, remote, nil).Send(filepath.Join(srcDir, "some-file"))
+			Expect(err).To(MatchError("scp: /some/path: Permission denied"))
+		})
+
+		It("returns the error while receiving", func() {
+			remote := bytes.NewBufferString("\x01scp: /some/path: No such file or directory\n")
+			err := ssh.NewSCPReceiver(ioutil.Discard, remote, nil).Receive(dstDir)
+			Expect(err).To(MatchError("scp: /some/path: No such file or directory"))
+		})
+	})
+
+	Context("when the remote sends a path instead of a filename", func() {
+		It("refuses to write outside of the destination", func() {
+			remote := bytes.NewBufferString("C0644 3 ../escape\nabc\x00")
+			err := ssh.NewSCPReceiver(ioutil.Discard, remote, nil).Receive(dstDir)
+			Expect(err).To(MatchError(ContainSubstring("refusing unexpected filename")))
+		})
+	})
+})
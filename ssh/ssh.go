@@ -2,18 +2,23 @@ package ssh
 
 import (
 	"bytes"
+	"context"
 	"fmt"
 	"io"
-	"io/ioutil"
 	"net"
-	"os"
-	"path/filepath"
+	"strings"
 	"time"
 
+	"code.cloudfoundry.org/cfdev/resource"
+	"code.cloudfoundry.org/cfdev/resource/retry"
 	"golang.org/x/crypto/ssh"
 )
 
 type SSH struct {
+	// Context stops the wait for an ssh connection once it is done.
+	Context context.Context
+	// Writer, if set, gets a line for every failed connection attempt.
+	Writer io.Writer
 }
 
 type SSHAddress struct {
@@ -29,34 +34,41 @@ func (s *SSH) CopyFile(filePath string, remoteFilePath string, address SSHAddres
 	defer client.Close()
 	defer session.Close()
 
-	l, err := os.Open(filePath)
-	defer l.Close()
+	session.Stderr = stderr
+	return s.upload(session, filePath, remoteFilePath, nil)
+}
+
+func (s *SSH) RetrieveFile(filePath string, remoteFilePath string, address SSHAddress, privateKey []byte, timeout time.Duration) error {
+	return s.Download(remoteFilePath, filePath, address, privateKey, timeout, nil)
+}
 
-	command := fmt.Sprintf("/usr/bin/scp -qt %s", filepath.Dir(remoteFilePath))
-	contentsBytes, _ := ioutil.ReadAll(l)
-	bytesReader := bytes.NewReader(contentsBytes)
+func (s *SSH) Upload(localPath string, remotePath string, address SSHAddress, privateKey []byte, timeout time.Duration, progress resource.Progress) error {
+	client, session, err := s.newSession(address, privateKey, timeout)
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+	defer session.Close()
 
-	go func() {
-		w, _ := session.StdinPipe()
+	var stderr bytes.Buffer
+	session.Stderr = &stderr
 
-		fmt.Fprintln(w, "C0755", int64(len(contentsBytes)), remoteFilePath)
-		_, err = io.Copy(w, bytesReader)
+	if progress != nil {
+		total, err := totalSize(localPath)
 		if err != nil {
-			fmt.Print(err)
+			return err
 		}
+		progress.Start(total)
+		defer progress.End()
+	}
 
-		fmt.Fprintln(w, "\x00")
-
-		defer w.Close()
-	}()
-
-	session.Stdout = stdout
-	session.Stderr = stderr
-
-	return session.Run(command)
+	if err := s.upload(session, localPath, remotePath, progress); err != nil {
+		return withStderr(err, &stderr)
+	}
+	return nil
 }
 
-func (s *SSH) RetrieveFile(filePath string, remoteFilePath string, address SSHAddress, privateKey []byte, timeout time.Duration) error {
+func (s *SSH) Download(remotePath string, localPath string, address SSHAddress, privateKey []byte, timeout time.Duration, progress resource.Progress) error {
 	client, session, err := s.newSession(address, privateKey, timeout)
 	if err != nil {
 		return err
@@ -64,14 +76,73 @@ func (s *SSH) RetrieveFile(filePath string, remoteFilePath string, address SSHAd
 	defer client.Close()
 	defer session.Close()
 
-	f, err := os.Create(filePath)
+	var stderr bytes.Buffer
+	session.Stderr = &stderr
+
+	w, err := session.StdinPipe()
 	if err != nil {
 		return err
 	}
-	defer f.Close()
+	defer w.Close()
 
-	session.Stdout = f
-	return session.Run("cat " + remoteFilePath)
+	r, err := session.StdoutPipe()
+	if err != nil {
+		return err
+	}
+
+	if err := session.Start(fmt.Sprintf("/usr/bin/scp -rpqf %s", shellQuote(remotePath))); err != nil {
+		return err
+	}
+
+	if progress != nil {
+		progress.Start(0)
+		defer progress.End()
+	}
+
+	if err := NewSCPReceiver(w, r, progress).Receive(localPath); err != nil {
+		return withStderr(err, &stderr)
+	}
+	w.Close()
+
+	if err := session.Wait(); err != nil {
+		return withStderr(err, &stderr)
+	}
+	return nil
+}
+
+func (s *SSH) upload(session *ssh.Session, localPath string, remotePath string, progress resource.Progress) error {
+	w, err := session.StdinPipe()
+	if err != nil {
+		return err
+	}
+	defer w.Close()
+
+	r, err := session.StdoutPipe()
+	if err != nil {
+		return err
+	}
+
+	if err := session.Start(fmt.Sprintf("/usr/bin/scp -rpqt %s", shellQuote(remotePath))); err != nil {
+		return err
+	}
+
+	if err := NewSCPSender(w, r, progress).Send(localPath); err != nil {
+		return err
+	}
+	w.Close()
+
+	return session.Wait()
+}
+
+func withStderr(err error, stderr *bytes.Buffer) error {
+	if msg := strings.TrimSpace(stderr.String()); msg != "" {
+		return fmt.Errorf("%s: %s", err, msg)
+	}
+	return err
+}
+
+func shellQuote(s string) string {
+	return "'" + strings.Replace(s, "'", `'\''`, -1) + "'"
 }
 
 func (s *SSH) RunSSHCommand(command string, addresses SSHAddress, privateKey []byte, timeout time.Duration, stdout io.Writer, stderr io.Writer) (err error) {
@@ -89,7 +160,7 @@ func (s *SSH) RunSSHCommand(command string, addresses SSHAddress, privateKey []b
 }
 
 func (s *SSH) WaitForSSH(addresses SSHAddress, privateKey []byte, timeout time.Duration) error {
-	client, err := s.waitForSSH(addresses, privateKey, timeout)
+	client, err := s.waitForSSH(addresses, "root", privateKey, timeout)
 	if err == nil {
 		client.Close()
 	}
@@ -97,7 +168,7 @@ func (s *SSH) WaitForSSH(addresses SSHAddress, privateKey []byte, timeout time.D
 }
 
 func (s *SSH) newSession(addresses SSHAddress, privateKey []byte, timeout time.Duration) (*ssh.Client, *ssh.Session, error) {
-	client, err := s.waitForSSH(addresses, privateKey, timeout)
+	client, err := s.waitForSSH(addresses, "root", privateKey, timeout)
 	if err != nil {
 		return nil, nil, err
 	}
@@ -111,14 +182,20 @@ func (s *SSH) newSession(addresses SSHAddress, privateKey []byte, timeout time.D
 	return client, session, nil
 }
 
-func (*SSH) waitForSSH(address SSHAddress, privateKey []byte, timeout time.Duration) (*ssh.Client, error) {
+func (s *SSH) waitForSSH(address SSHAddress, user string, privateKey []byte, timeout time.Duration) (*ssh.Client, error) {
+	return s.dialSSH(nil, address, user, privateKey, timeout)
+}
+
+// dialSSH connects to address, through the via connection when it is
+// not nil.
+func (s *SSH) dialSSH(via *ssh.Client, address SSHAddress, user string, privateKey []byte, timeout time.Duration) (*ssh.Client, error) {
 	signer, err := ssh.ParsePrivateKey(privateKey)
 	if err != nil {
 		return nil, fmt.Errorf("could not parse private key: %s", err)
 	}
 
 	config := &ssh.ClientConfig{
-		User: "root",
+		User: user,
 		Auth: []ssh.AuthMethod{
 			ssh.PublicKeys(signer),
 		},
@@ -128,35 +205,40 @@ func (*SSH) waitForSSH(address SSHAddress, privateKey []byte, timeout time.Durat
 		Timeout: 10 * time.Second,
 	}
 
-	clientChan := make(chan *ssh.Client, 1)
-	errorChan := make(chan error, 1)
-	doneChan := make(chan bool)
-
-	go func(ip string, port string) {
-		var client *ssh.Client
-		var dialErr error
-		timeoutChan := time.After(timeout)
-		for {
-			select {
-			case <-timeoutChan:
-				clientChan <- nil
-				errorChan <- fmt.Errorf("ssh connection timed out: %s", dialErr)
-				return
-			case <-doneChan:
-				return
-			default:
-				if client, dialErr = ssh.Dial("tcp", ip+":"+port, config); dialErr == nil {
-					clientChan <- client
-					errorChan <- nil
-					return
-				}
-				time.Sleep(time.Second)
-			}
-		}
-	}(address.IP, address.Port)
+	var client *ssh.Client
+	err = retry.Do(s.Context, retry.Policy{
+		Backoff: retry.Backoff{
+			Initial:    250 * time.Millisecond,
+			Max:        5 * time.Second,
+			Multiplier: 2,
+			Jitter:     0.2,
+			MaxElapsed: timeout,
+		},
+		Notify: retry.Log(s.Writer),
+	}, func() error {
+		var err error
+		client, err = dial(via, address.IP+":"+address.Port, config)
+		return err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("ssh connection timed out: %s", err)
+	}
+	return client, nil
+}
+
+func dial(via *ssh.Client, address string, config *ssh.ClientConfig) (*ssh.Client, error) {
+	if via == nil {
+		return ssh.Dial("tcp", address, config)
+	}
 
-	client := <-clientChan
-	err = <-errorChan
-	close(doneChan)
-	return client, err
+	conn, err := via.Dial("tcp", address)
+	if err != nil {
+		return nil, err
+	}
+	c, chans, reqs, err := ssh.NewClientConn(conn, address, config)
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+	return ssh.NewClient(c, chans, reqs), nil
 }
@@ -0,0 +1,140 @@
+package ssh
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"log"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/cloudfoundry/go-socks5"
+	"golang.org/x/crypto/ssh"
+)
+
+// Forward is an ssh -L style port forward.
+type Forward struct {
+	LocalAddress  string
+	RemoteAddress string
+}
+
+// ParseForward parses [bind_address:]port:host:hostport. The bind
+// address defaults to 127.0.0.1.
+func ParseForward(spec string) (Forward, error) {
+	parts := strings.Split(spec, ":")
+	if len(parts) == 3 {
+		parts = append([]string{"127.0.0.1"}, parts...)
+	}
+	if len(parts) != 4 {
+		return Forward{}, fmt.Errorf("invalid forward '%s': expected [bind_address:]port:host:hostport", spec)
+	}
+
+	for _, port := range []string{parts[1], parts[3]} {
+		if n, err := strconv.Atoi(port); err != nil || n < 0 || n > 65535 {
+			return Forward{}, fmt.Errorf("invalid forward '%s': '%s' is not a port", spec, port)
+		}
+	}
+
+	return Forward{
+		LocalAddress:  net.JoinHostPort(parts[0], parts[1]),
+		RemoteAddress: net.JoinHostPort(parts[2], parts[3]),
+	}, nil
+}
+
+// Hop is an ssh server that a Tunnel goes through.
+type Hop struct {
+	Address    SSHAddress
+	User       string
+	PrivateKey []byte
+}
+
+// Tunnel relays local connections over a chain of ssh connections, each
+// made through the one before, so that addresses only routable from the
+// last host can be reached.
+type Tunnel struct {
+	clients []*ssh.Client
+}
+
+func (s *SSH) Tunnel(hops []Hop, timeout time.Duration) (*Tunnel, error) {
+	tunnel := &Tunnel{}
+	for _, hop := range hops {
+		var via *ssh.Client
+		if len(tunnel.clients) > 0 {
+			via = tunnel.clients[len(tunnel.clients)-1]
+		}
+		client, err := s.dialSSH(via, hop.Address, hop.User, hop.PrivateKey, timeout)
+		if err != nil {
+			tunnel.Close()
+			return nil, fmt.Errorf("%s:%s: %s", hop.Address.IP, hop.Address.Port, err)
+		}
+		tunnel.clients = append(tunnel.clients, client)
+	}
+	return tunnel, nil
+}
+
+func (t *Tunnel) Dial(network string, address string) (net.Conn, error) {
+	return t.clients[len(t.clients)-1].Dial(network, address)
+}
+
+// ServeSOCKS5 runs a SOCKS5 proxy on the listener. Host names are
+// resolved on the remote end so that internal DNS names work.
+func (t *Tunnel) ServeSOCKS5(listener net.Listener) error {
+	server, err := socks5.New(&socks5.Config{
+		Resolver: remoteResolver{},
+		Logger:   log.New(ioutil.Discard, "", 0),
+		Dial: func(ctx context.Context, network string, address string) (net.Conn, error) {
+			return t.Dial(network, address)
+		},
+	})
+	if err != nil {
+		return err
+	}
+	return server.Serve(listener)
+}
+
+// Forward relays every connection accepted on the listener to the
+// remote address.
+func (t *Tunnel) Forward(listener net.Listener, remoteAddress string) error {
+	for {
+		conn, err := listener.Accept()
+		if err != nil {
+			return err
+		}
+
+		go func() {
+			defer conn.Close()
+
+			remote, err := t.Dial("tcp", remoteAddress)
+			if err != nil {
+				return
+			}
+			defer remote.Close()
+
+			done := make(chan struct{}, 2)
+			go func() { io.Copy(remote, conn); done <- struct{}{} }()
+			go func() { io.Copy(conn, remote); done <- struct{}{} }()
+			<-done
+		}()
+	}
+}
+
+func (t *Tunnel) Close() error {
+	var err error
+	for i := len(t.clients) - 1; i >= 0; i-- {
+		if closeErr := t.clients[i].Close(); err == nil {
+			err = closeErr
+		}
+	}
+	return err
+}
+
+type remoteResolver struct{}
+
+// Resolve leaves the name unresolved; go-socks5 then dials the name
+// itself, which the ssh server resolves.
+func (remoteResolver) Resolve(ctx context.Context, name string) (context.Context, net.IP, error) {
+	return ctx, nil, nil
+}
@@ -0,0 +1,214 @@
+package ssh_test
+
+import (
+	"bufio"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+	"io"
+	"net"
+	"strings"
+	"time"
+
+	"code.cloudfoundry.org/cfdev/ssh"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	gossh "golang.org/x/crypto/ssh"
+	"golang.org/x/net/proxy"
+)
+
+// startJumpbox runs an ssh server that only supports direct-tcpip
+// channels. Connections to internalHost are sent to internalAddress,
+// standing in for names that only resolve on the remote end.
+func startJumpbox(internalHost string, internalAddress string) (net.Listener, []byte) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	Expect(err).NotTo(HaveOccurred())
+	signer, err := gossh.NewSignerFromKey(key)
+	Expect(err).NotTo(HaveOccurred())
+
+	config := &gossh.ServerConfig{
+		PublicKeyCallback: func(conn gossh.ConnMetadata, key gossh.PublicKey) (*gossh.Permissions, error) {
+			if conn.User() != "jumpbox" {
+				return nil, fmt.Errorf("unknown user %s", conn.User())
+			}
+			return nil, nil
+		},
+	}
+	config.AddHostKey(signer)
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	Expect(err).NotTo(HaveOccurred())
+
+	go func() {
+		defer GinkgoRecover()
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			_, chans, reqs, err := gossh.NewServerConn(conn, config)
+			if err != nil {
+				continue
+			}
+			go gossh.DiscardRequests(reqs)
+			go func() {
+				for newChannel := range chans {
+					var target struct {
+						Host     string
+						Port     uint32
+						OrigHost string
+						OrigPort uint32
+					}
+					gossh.Unmarshal(newChannel.ExtraData(), &target)
+
+					address := net.JoinHostPort(target.Host, fmt.Sprint(target.Port))
+					if target.Host == internalHost {
+						address = internalAddress
+					}
+
+					remote, err := net.Dial("tcp", address)
+					if err != nil {
+						newChannel.Reject(gossh.ConnectionFailed, err.Error())
+						continue
+					}
+					channel, reqs, _ := newChannel.Accept()
+					go gossh.DiscardRequests(reqs)
+					go func() { io.Copy(channel, remote); channel.Close() }()
+					go func() { io.Copy(remote, channel); remote.Close() }()
+				}
+			}()
+		}
+	}()
+
+	privateKey := pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)})
+	return listener, privateKey
+}
+
+func startEcho() net.Listener {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	Expect(err).NotTo(HaveOccurred())
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer conn.Close()
+				io.Copy(conn, conn)
+			}()
+		}
+	}()
+	return listener
+}
+
+var _ = Describe("Tunnel", func() {
+	var (
+		jumpbox    net.Listener
+		echo       net.Listener
+		local      net.Listener
+		privateKey []byte
+		address    ssh.SSHAddress
+	)
+
+	echoThrough := func(conn net.Conn) string {
+		defer conn.Close()
+		fmt.Fprintln(conn, "some-message")
+		line, err := bufio.NewReader(conn).ReadString('\n')
+		Expect(err).NotTo(HaveOccurred())
+		return strings.TrimSpace(line)
+	}
+
+	BeforeEach(func() {
+		echo = startEcho()
+		jumpbox, privateKey = startJumpbox("some-internal-host", echo.Addr().String())
+
+		host, port, _ := net.SplitHostPort(jumpbox.Addr().String())
+		address = ssh.SSHAddress{IP: host, Port: port}
+
+		var err error
+		local, err = net.Listen("tcp", "127.0.0.1:0")
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	AfterEach(func() {
+		local.Close()
+		jumpbox.Close()
+		echo.Close()
+	})
+
+	It("serves a SOCKS5 proxy that resolves names remotely", func() {
+		tunnel, err := (&ssh.SSH{}).Tunnel([]ssh.Hop{{Address: address, User: "jumpbox", PrivateKey: privateKey}}, 5*time.Second)
+		Expect(err).NotTo(HaveOccurred())
+		defer tunnel.Close()
+		go tunnel.ServeSOCKS5(local)
+
+		dialer, err := proxy.SOCKS5("tcp", local.Addr().String(), nil, proxy.Direct)
+		Expect(err).NotTo(HaveOccurred())
+
+		_, port, _ := net.SplitHostPort(echo.Addr().String())
+		conn, err := dialer.Dial("tcp", net.JoinHostPort("some-internal-host", port))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(echoThrough(conn)).To(Equal("some-message"))
+	})
+
+	It("forwards a local port to a remote address", func() {
+		tunnel, err := (&ssh.SSH{}).Tunnel([]ssh.Hop{{Address: address, User: "jumpbox", PrivateKey: privateKey}}, 5*time.Second)
+		Expect(err).NotTo(HaveOccurred())
+		defer tunnel.Close()
+		go tunnel.Forward(local, "some-internal-host:1234")
+
+		conn, err := net.Dial("tcp", local.Addr().String())
+		Expect(err).NotTo(HaveOccurred())
+		Expect(echoThrough(conn)).To(Equal("some-message"))
+	})
+
+	It("goes through every hop", func() {
+		vm, vmKey := startJumpbox("some-jumpbox-host", jumpbox.Addr().String())
+		defer vm.Close()
+		host, port, _ := net.SplitHostPort(vm.Addr().String())
+
+		tunnel, err := (&ssh.SSH{}).Tunnel([]ssh.Hop{
+			{Address: ssh.SSHAddress{IP: host, Port: port}, User: "jumpbox", PrivateKey: vmKey},
+			{Address: ssh.SSHAddress{IP: "some-jumpbox-host", Port: "22"}, User: "jumpbox", PrivateKey: privateKey},
+		}, 5*time.Second)
+		Expect(err).NotTo(HaveOccurred())
+		defer tunnel.Close()
+		go tunnel.Forward(local, "some-internal-host:1234")
+
+		conn, err := net.Dial("tcp", local.Addr().String())
+		Expect(err).NotTo(HaveOccurred())
+		Expect(echoThrough(conn)).To(Equal("some-message"))
+	})
+
+	It("fails when the jumpbox rejects the user", func() {
+		_, err := (&ssh.SSH{}).Tunnel([]ssh.Hop{{Address: address, User: "root", PrivateKey: privateKey}}, time.Second)
+		Expect(err).To(HaveOccurred())
+	})
+})
+
+var _ = Describe("ParseForward", func() {
+	It("defaults the bind address to localhost", func() {
+		Expect(ssh.ParseForward("8080:10.144.0.4:25555")).To(Equal(ssh.Forward{
+			LocalAddress:  "127.0.0.1:8080",
+			RemoteAddress: "10.144.0.4:25555",
+		}))
+	})
+
+	It("accepts a bind address", func() {
+		Expect(ssh.ParseForward("0.0.0.0:8080:some-host:80")).To(Equal(ssh.Forward{
+			LocalAddress:  "0.0.0.0:8080",
+			RemoteAddress: "some-host:80",
+		}))
+	})
+
+	It("rejects malformed specs", func() {
+		_, err := ssh.ParseForward("8080:some-host")
+		Expect(err).To(MatchError("invalid forward '8080:some-host': expected [bind_address:]port:host:hostport"))
+
+		_, err = ssh.ParseForward("http:some-host:80")
+		Expect(err).To(MatchError("invalid forward 'http:some-host:80': 'http' is not a port"))
+	})
+})
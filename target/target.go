@@ -0,0 +1,134 @@
+package target
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+
+	"code.cloudfoundry.org/cfdev/config"
+)
+
+const (
+	DefaultOrg   = "cfdev-org"
+	DefaultSpace = "cfdev-space"
+)
+
+// CLI runs cf commands; it is satisfied by plugin.CliConnection.
+type CLI interface {
+	CliCommandWithoutTerminalOutput(args ...string) ([]string, error)
+}
+
+// Connection is the cf CLI that launched the plugin. It is only known
+// once the plugin runs, which is after the commands have been built.
+type Connection struct {
+	CLI
+}
+
+type Options struct {
+	User   string
+	Org    string
+	Space  string
+	Create bool
+	CFHome string
+}
+
+type Target struct {
+	Config     config.Config
+	Connection *Connection
+}
+
+var users = map[string]string{
+	"admin": "admin",
+	"user":  "pass",
+}
+
+// Password returns the password of one of the users CF Dev creates.
+func Password(user string) (string, error) {
+	password, ok := users[user]
+	if !ok {
+		return "", fmt.Errorf("unknown user '%s', expected admin or user", user)
+	}
+	return password, nil
+}
+
+func (t *Target) Login(opts Options) error {
+	password, err := Password(opts.User)
+	if err != nil {
+		return err
+	}
+
+	cli, err := t.cli(opts.CFHome)
+	if err != nil {
+		return err
+	}
+
+	run := func(args ...string) error {
+		if _, err := cli.CliCommandWithoutTerminalOutput(args...); err != nil {
+			return fmt.Errorf("cf %s failed: %s", args[0], err)
+		}
+		return nil
+	}
+
+	if err := run("api", "https://api."+t.Config.CFDomain, "--skip-ssl-validation"); err != nil {
+		return err
+	}
+
+	if err := run("auth", opts.User, password); err != nil {
+		return err
+	}
+
+	if opts.Org == "" {
+		return nil
+	}
+
+	if opts.Create {
+		if err := run("create-org", opts.Org); err != nil {
+			return err
+		}
+		if opts.Space != "" {
+			if err := run("create-space", opts.Space, "-o", opts.Org); err != nil {
+				return err
+			}
+		}
+	}
+
+	if opts.Space == "" {
+		return run("target", "-o", opts.Org)
+	}
+	return run("target", "-o", opts.Org, "-s", opts.Space)
+}
+
+func (t *Target) cli(cfHome string) (CLI, error) {
+	if cfHome != "" {
+		if err := os.MkdirAll(cfHome, 0755); err != nil {
+			return nil, err
+		}
+		return &commandLine{CFHome: cfHome}, nil
+	}
+
+	if t.Connection != nil && t.Connection.CLI != nil {
+		return t.Connection.CLI, nil
+	}
+	return &commandLine{}, nil
+}
+
+// commandLine runs the cf binary on the PATH, which allows using a
+// CF_HOME other than the one of the cf CLI that launched the plugin.
+type commandLine struct {
+	CFHome string
+}
+
+func (c *commandLine) CliCommandWithoutTerminalOutput(args ...string) ([]string, error) {
+	cmd := exec.Command("cf", args...)
+	cmd.Env = os.Environ()
+	if c.CFHome != "" {
+		cmd.Env = append(cmd.Env, "CF_HOME="+c.CFHome)
+	}
+
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		return nil, fmt.Errorf("%s: %s", err, strings.TrimSpace(string(output)))
+	}
+	return strings.Split(strings.TrimSpace(string(output)), "\n"), nil
+}
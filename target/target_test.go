@@ -0,0 +1,121 @@
+package target_test
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+
+	"code.cloudfoundry.org/cfdev/config"
+	"code.cloudfoundry.org/cfdev/target"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+type fakeCLI struct {
+	commands [][]string
+	fail     string
+}
+
+func (f *fakeCLI) CliCommandWithoutTerminalOutput(args ...string) ([]string, error) {
+	f.commands = append(f.commands, args)
+	if args[0] == f.fail {
+		return nil, errors.New("some-error")
+	}
+	return nil, nil
+}
+
+var _ = Describe("Target", func() {
+	var (
+		cli     *fakeCLI
+		subject *target.Target
+	)
+
+	BeforeEach(func() {
+		cli = &fakeCLI{}
+		subject = &target.Target{
+			Config:     config.Config{CFDomain: "dev.cfdev.sh"},
+			Connection: &target.Connection{CLI: cli},
+		}
+	})
+
+	It("sets the api and authenticates", func() {
+		Expect(subject.Login(target.Options{User: "user"})).To(Succeed())
+		Expect(cli.commands).To(Equal([][]string{
+			{"api", "https://api.dev.cfdev.sh", "--skip-ssl-validation"},
+			{"auth", "user", "pass"},
+		}))
+	})
+
+	It("targets the org and space", func() {
+		Expect(subject.Login(target.Options{User: "admin", Org: "some-org", Space: "some-space"})).To(Succeed())
+		Expect(cli.commands[2:]).To(Equal([][]string{
+			{"target", "-o", "some-org", "-s", "some-space"},
+		}))
+	})
+
+	It("creates the org and space when asked to", func() {
+		Expect(subject.Login(target.Options{User: "admin", Org: "some-org", Space: "some-space", Create: true})).To(Succeed())
+		Expect(cli.commands[2:]).To(Equal([][]string{
+			{"create-org", "some-org"},
+			{"create-space", "some-space", "-o", "some-org"},
+			{"target", "-o", "some-org", "-s", "some-space"},
+		}))
+	})
+
+	It("rejects unknown users", func() {
+		Expect(subject.Login(target.Options{User: "root"})).To(MatchError("unknown user 'root', expected admin or user"))
+		Expect(cli.commands).To(BeEmpty())
+	})
+
+	It("does not leak the password when authentication fails", func() {
+		cli.fail = "auth"
+		err := subject.Login(target.Options{User: "admin"})
+		Expect(err).To(MatchError("cf auth failed: some-error"))
+	})
+
+	Context("when a CF_HOME is given", func() {
+		var (
+			tmpDir string
+			path   string
+		)
+
+		BeforeEach(func() {
+			if runtime.GOOS == "windows" {
+				Skip("uses a shell script in place of cf")
+			}
+
+			var err error
+			tmpDir, err = ioutil.TempDir("", "cfdev-target-")
+			Expect(err).NotTo(HaveOccurred())
+
+			script := "#!/bin/sh\necho \"$CF_HOME $*\" >> " + filepath.Join(tmpDir, "cf.log") + "\n"
+			Expect(ioutil.WriteFile(filepath.Join(tmpDir, "cf"), []byte(script), 0755)).To(Succeed())
+
+			path = os.Getenv("PATH")
+			os.Setenv("PATH", tmpDir+string(os.PathListSeparator)+path)
+		})
+
+		AfterEach(func() {
+			os.Setenv("PATH", path)
+			os.RemoveAll(tmpDir)
+		})
+
+		It("runs the cf binary with that CF_HOME instead of the plugin connection", func() {
+			cfHome := filepath.Join(tmpDir, "some-cf-home")
+			Expect(subject.Login(target.Options{User: "admin", CFHome: cfHome})).To(Succeed())
+
+			Expect(cli.commands).To(BeEmpty())
+			Expect(cfHome).To(BeADirectory())
+
+			contents, err := ioutil.ReadFile(filepath.Join(tmpDir, "cf.log"))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(strings.Split(strings.TrimSpace(string(contents)), "\n")).To(Equal([]string{
+				cfHome + " api https://api.dev.cfdev.sh --skip-ssl-validation",
+				cfHome + " auth admin admin",
+			}))
+		})
+	})
+})